@@ -8,6 +8,10 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,9 +20,10 @@ import (
 
 // BridgeBlockEvent represents the JSON payload emitted by the warehouse event bridge
 // for each newly stored block. It mirrors the structure produced in
-// pkg/warehouse/event_bridge.go.
+// pkg/warehouse/event_bridge.go. Delete events only carry Type, Seq, ID and Datasource.
 type BridgeBlockEvent struct {
 	Type       string                 `json:"type"`
+	Seq        uint64                 `json:"seq"`
 	ID         string                 `json:"id"`
 	Datasource string                 `json:"datasource"`
 	DSType     string                 `json:"ds_type"` // Added to allow renderer selection without DB lookup
@@ -27,13 +32,39 @@ type BridgeBlockEvent struct {
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// BridgeGapEvent is sent by the event bridge when events a consumer asked to
+// resume from are no longer in its log.
+type BridgeGapEvent struct {
+	Type    string `json:"type"`
+	FromSeq uint64 `json:"from_seq"`
+	ToSeq   uint64 `json:"to_seq"`
+}
+
 // Removed local InternalEvent (now using realtime.InternalEvent)
 
-// BridgeConsumer connects to the Unix domain socket exposed by the warehouse
-// and streams block events into an internal channel for downstream consumers
-// (e.g., a WebSocket hub).
+// bridgeConsumerStateFile is where 'ergs web' saves the last event it got
+// from the bridge, relative to the storage directory
+const bridgeConsumerStateFile = "internal/bridge-consumer.seq"
+
+// bridgeStateSaveInterval is how often the last sequence number is saved to
+// the state file while events arrive
+const bridgeStateSaveInterval = time.Second
+
+// BridgeConsumer connects to the Unix domain socket (or TCP listener) exposed by the warehouse
+// and streams block and delete events into an internal channel for downstream consumers
+// (e.g., a WebSocket hub). On every connection it asks the bridge to resume
+// after the last event it got, so events published while it was disconnected
+// are replayed. With a state file, this also holds across restarts.
 type BridgeConsumer struct {
-	socketPath string
+	socketPath  string
+	dialOptions BridgeDialOptions
+
+	// resume state
+	statePath string
+	seqMu     sync.Mutex
+	lastSeq   uint64
+	savedSeq  uint64
+	savedAt   time.Time
 
 	// outCh delivers parsed block events (non-blocking best-effort).
 	outCh chan realtime.InternalEvent
@@ -53,7 +84,7 @@ type BridgeConsumer struct {
 
 // NewBridgeConsumer constructs a new consumer.
 //
-// socketPath: path to the Unix domain socket (empty disables the consumer)
+// socketPath: path to the Unix domain socket, or a tcp:// or tls:// URL (empty disables the consumer)
 // buffer: size of the outbound channel buffer (recommend >= number of connected websocket listeners * average burst size)
 func NewBridgeConsumer(socketPath string, buffer int) *BridgeConsumer {
 	if buffer <= 0 {
@@ -74,6 +105,26 @@ func NewBridgeConsumer(socketPath string, buffer int) *BridgeConsumer {
 	}.BridgeConsumer
 }
 
+// SetDialOptions sets the token and TLS CA used to connect to the bridge over
+// TCP. Must be called before Start.
+func (c *BridgeConsumer) SetDialOptions(opts BridgeDialOptions) {
+	c.dialOptions = opts
+}
+
+// SetStatePath sets the file the sequence number of the last event is saved
+// to, so the consumer resumes where it left off after a restart. Must be
+// called before Start.
+func (c *BridgeConsumer) SetStatePath(path string) {
+	c.statePath = path
+}
+
+// LastSeq returns the sequence number of the last event received.
+func (c *BridgeConsumer) LastSeq() uint64 {
+	c.seqMu.Lock()
+	defer c.seqMu.Unlock()
+	return c.lastSeq
+}
+
 // Start launches the background reader and reconnect loop.
 // Safe to call multiple times; subsequent calls are ignored.
 func (c *BridgeConsumer) Start() {
@@ -82,6 +133,15 @@ func (c *BridgeConsumer) Start() {
 			log.Printf("bridge consumer: disabled (no socket path configured)")
 			return
 		}
+		if c.statePath != "" {
+			seq, err := readBridgeSeq(c.statePath)
+			if err != nil {
+				log.Printf("bridge consumer: %v", err)
+			}
+			c.seqMu.Lock()
+			c.lastSeq, c.savedSeq = seq, seq
+			c.seqMu.Unlock()
+		}
 		go c.run()
 	})
 }
@@ -91,6 +151,7 @@ func (c *BridgeConsumer) Start() {
 func (c *BridgeConsumer) Stop() {
 	c.stopOnce.Do(func() {
 		c.cancel()
+		c.saveSeq(true)
 		// Give run loop a moment to exit cleanly before closing channel.
 		go func() {
 			// Small delay to ensure all in-flight sends complete.
@@ -116,7 +177,7 @@ func (c *BridgeConsumer) run() {
 		default:
 		}
 
-		conn, err := net.Dial("unix", c.socketPath)
+		conn, err := dialBridge(c.ctx, c.socketPath, c.dialOptions)
 		if err != nil {
 			if !errors.Is(err, context.Canceled) {
 				log.Printf("bridge consumer: connect failed (%v), retrying in %s", err, backoff)
@@ -137,13 +198,34 @@ func (c *BridgeConsumer) run() {
 
 		log.Printf("bridge consumer: connected to %s", c.socketPath)
 		backoff = c.initialBackoff // reset on success
-		c.readLoop(conn)
+		if seq := c.LastSeq(); seq > 0 {
+			if err := writeResumeRequest(conn, seq, realtime.Filter{}); err != nil {
+				log.Printf("bridge consumer: resume request failed: %v", err)
+			}
+		}
+		done := make(chan struct{})
+		go func() {
+			// Unblock the read loop on Stop
+			select {
+			case <-c.ctx.Done():
+				_ = conn.Close()
+			case <-done:
+			}
+		}()
+		rejected := c.readLoop(conn)
+		close(done)
 
 		_ = conn.Close()
+		c.saveSeq(true)
 		log.Printf("bridge consumer: disconnected")
-		// brief pause before immediate reconnect attempt
+		// brief pause before immediate reconnect attempt, a long one if the
+		// bridge rejected the connection (e.g. wrong token)
+		pause := 250 * time.Millisecond
+		if rejected {
+			pause = c.maxBackoff
+		}
 		select {
-		case <-time.After(250 * time.Millisecond):
+		case <-time.After(pause):
 		case <-c.ctx.Done():
 			return
 		}
@@ -151,7 +233,8 @@ func (c *BridgeConsumer) run() {
 }
 
 // readLoop processes newline-delimited JSON frames until EOF or error.
-func (c *BridgeConsumer) readLoop(conn net.Conn) {
+// It returns true if the bridge sent an error event.
+func (c *BridgeConsumer) readLoop(conn net.Conn) (rejected bool) {
 	sc := bufio.NewScanner(conn)
 
 	// Increase buffer in case of large metadata payloads.
@@ -177,8 +260,25 @@ func (c *BridgeConsumer) readLoop(conn net.Conn) {
 			continue
 		}
 
-		if t != "block" {
-			// We could handle heartbeat/info/error later if needed.
+		if t == "gap" {
+			var gap BridgeGapEvent
+			if err := json.Unmarshal(line, &gap); err == nil {
+				log.Printf("bridge consumer: events %d to %d are no longer in the bridge log and were lost", gap.FromSeq, gap.ToSeq)
+			}
+			continue
+		}
+		if t == "error" {
+			var msg struct {
+				Message string `json:"message"`
+			}
+			if err := json.Unmarshal(line, &msg); err == nil {
+				log.Printf("bridge consumer: bridge error: %s", msg.Message)
+			}
+			rejected = true
+			continue
+		}
+		if t != "block" && t != "delete" {
+			// We could handle heartbeat/info later if needed.
 			continue
 		}
 
@@ -190,6 +290,7 @@ func (c *BridgeConsumer) readLoop(conn net.Conn) {
 
 		// Convert bridge event into realtime.BlockEvent + realtime.InternalEvent
 		be := realtime.BlockEvent{
+			Seq:        evt.Seq,
 			ID:         evt.ID,
 			Datasource: evt.Datasource,
 			DSType:     evt.DSType,
@@ -197,18 +298,21 @@ func (c *BridgeConsumer) readLoop(conn net.Conn) {
 			Text:       evt.Text,
 			Metadata:   evt.Metadata,
 		}
-		re := realtime.InternalEvent{Type: "block", Block: be}
+		re := realtime.InternalEvent{Type: t, Block: be}
+		// Wait for downstream rather than dropping, as a dropped event would
+		// be skipped when resuming too. The hub itself never blocks.
 		select {
 		case c.outCh <- re:
-		default:
-			// Drop if downstream is saturated (best-effort semantics).
+		case <-c.ctx.Done():
+			return rejected
 		}
 
-		select {
-		case <-c.ctx.Done():
-			return
-		default:
+		c.seqMu.Lock()
+		if evt.Seq > 0 {
+			c.lastSeq = evt.Seq
 		}
+		c.seqMu.Unlock()
+		c.saveSeq(false)
 	}
 
 	// If scanning ended with error (other than EOF), we can optionally log.
@@ -218,6 +322,68 @@ func (c *BridgeConsumer) readLoop(conn net.Conn) {
 			log.Printf("bridge consumer: read error: %v", err)
 		}
 	}
+	return rejected
+}
+
+// saveSeq saves the last sequence number to the state file when it changed,
+// at most once every bridgeStateSaveInterval unless force is set.
+func (c *BridgeConsumer) saveSeq(force bool) {
+	if c.statePath == "" {
+		return
+	}
+	c.seqMu.Lock()
+	defer c.seqMu.Unlock()
+	if c.lastSeq == c.savedSeq || (!force && time.Since(c.savedAt) < bridgeStateSaveInterval) {
+		return
+	}
+	if err := writeBridgeSeq(c.statePath, c.lastSeq); err != nil {
+		log.Printf("bridge consumer: %v", err)
+		return
+	}
+	c.savedSeq = c.lastSeq
+	c.savedAt = time.Now()
+}
+
+// writeResumeRequest asks the event bridge to replay the events after seq,
+// and to only send the events matching filter
+func writeResumeRequest(conn net.Conn, seq uint64, filter realtime.Filter) error {
+	return writeBridgeLine(conn, struct {
+		Type       string `json:"type"`
+		ResumeFrom uint64 `json:"resume_from"`
+		realtime.Filter
+	}{Type: "resume", ResumeFrom: seq, Filter: filter})
+}
+
+// readBridgeSeq reads a sequence number saved by writeBridgeSeq, 0 if the
+// file doesn't exist.
+func readBridgeSeq(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading bridge state: %w", err)
+	}
+	seq, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid bridge state in %s: %w", path, err)
+	}
+	return seq, nil
+}
+
+// writeBridgeSeq saves a sequence number, replacing the file atomically
+func writeBridgeSeq(path string, seq uint64) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("saving bridge state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatUint(seq, 10)+"\n"), 0644); err != nil {
+		return fmt.Errorf("saving bridge state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("saving bridge state: %w", err)
+	}
+	return nil
 }
 
 // isNetClosed attempts to identify benign network closure errors.
@@ -259,8 +425,9 @@ func AttachConsumer(ctx context.Context, consumer *BridgeConsumer, hub *realtime
 // /api/firehose) to obtain the most recent historical slice, then rely on hub
 // events for live updates.
 //
-// Error handling strategy: best-effort fire-and-forget. If guaranteed delivery
-// is required in the future, a persistent queue / sequence table can be added.
+// Delivery: events missed while disconnected are replayed by the bridge from
+// its event log (see SetStatePath to resume across restarts). Events older
+// than the log are reported as gaps and have to be backfilled over REST.
 func (c *BridgeConsumer) String() string {
 	return fmt.Sprintf("BridgeConsumer(socketPath=%s)", c.socketPath)
 }
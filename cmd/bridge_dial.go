@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/config"
+)
+
+// bridgeDialTimeout bounds connecting to the event bridge, TLS handshake included
+const bridgeDialTimeout = 10 * time.Second
+
+// BridgeDialOptions configures connections to an event bridge over TCP.
+type BridgeDialOptions struct {
+	Token string // Shared token sent to authenticate; required over TCP
+	TLSCA string // CA certificate file to verify the bridge with; system roots if empty
+}
+
+// bridgeDialOptions returns the dial options in the config
+func bridgeDialOptions(cfg *config.Config) BridgeDialOptions {
+	if cfg.EventBridge == nil {
+		return BridgeDialOptions{}
+	}
+	return BridgeDialOptions{Token: cfg.EventBridge.Token, TLSCA: cfg.EventBridge.TLSCA}
+}
+
+// bridgeSocketPath returns the Unix socket path the warehouse listens on, or
+// "" when event_socket_path is the TCP address of a remote warehouse.
+func bridgeSocketPath(cfg *config.Config) string {
+	network, address, _, err := parseBridgeAddress(cfg.EventSocketPath)
+	if err != nil || network != "unix" {
+		return ""
+	}
+	return address
+}
+
+// parseBridgeAddress parses an event bridge address: a Unix socket path, with
+// or without a unix:// prefix, or a tcp:// or tls:// URL with a host and port.
+func parseBridgeAddress(addr string) (network, address string, useTLS bool, err error) {
+	scheme, rest, found := strings.Cut(addr, "://")
+	if !found {
+		return "unix", addr, false, nil
+	}
+	switch scheme {
+	case "unix":
+		return "unix", rest, false, nil
+	case "tcp", "tls":
+		if _, _, err := net.SplitHostPort(rest); err != nil {
+			return "", "", false, fmt.Errorf("invalid event bridge address %q: %w", addr, err)
+		}
+		return "tcp", rest, scheme == "tls", nil
+	default:
+		return "", "", false, fmt.Errorf("invalid event bridge address %q: unknown scheme %s", addr, scheme)
+	}
+}
+
+// dialBridge connects to the event bridge at addr, authenticating with the
+// token over TCP.
+func dialBridge(ctx context.Context, addr string, opts BridgeDialOptions) (net.Conn, error) {
+	network, address, useTLS, err := parseBridgeAddress(addr)
+	if err != nil {
+		return nil, err
+	}
+	if network == "tcp" && opts.Token == "" {
+		return nil, errors.New("a token is required to connect to the event bridge over TCP")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, bridgeDialTimeout)
+	defer cancel()
+
+	var conn net.Conn
+	if useTLS {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if opts.TLSCA != "" {
+			pem, err := os.ReadFile(opts.TLSCA)
+			if err != nil {
+				return nil, fmt.Errorf("reading TLS CA certificate: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", opts.TLSCA)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, network, address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, network, address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if network == "tcp" {
+		if err := writeBridgeLine(conn, map[string]any{"type": "auth", "token": opts.Token}); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("authenticating: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// writeBridgeLine writes v as a line of JSON to the event bridge
+func writeBridgeLine(conn net.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetWriteDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Write(append(data, '\n'))
+	_ = conn.SetWriteDeadline(time.Time{})
+	return err
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import "testing"
+
+func TestParseBridgeAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		network string
+		address string
+		useTLS  bool
+		wantErr bool
+	}{
+		{addr: "/run/ergs/bridge.sock", network: "unix", address: "/run/ergs/bridge.sock"},
+		{addr: "unix:///run/ergs/bridge.sock", network: "unix", address: "/run/ergs/bridge.sock"},
+		{addr: "tcp://127.0.0.1:7777", network: "tcp", address: "127.0.0.1:7777"},
+		{addr: "tls://warehouse.lan:7777", network: "tcp", address: "warehouse.lan:7777", useTLS: true},
+		{addr: "tls://warehouse.lan", wantErr: true},
+		{addr: "http://warehouse.lan:7777", wantErr: true},
+	}
+	for _, tt := range tests {
+		network, address, useTLS, err := parseBridgeAddress(tt.addr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseBridgeAddress(%q) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+			continue
+		}
+		if network != tt.network || address != tt.address || useTLS != tt.useTLS {
+			t.Errorf("parseBridgeAddress(%q) = %s, %s, %v", tt.addr, network, address, useTLS)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/admin"
+	"github.com/rubiojr/ergs/pkg/config"
+	"github.com/urfave/cli/v3"
+)
+
+// CtlCommand creates the ctl command, controlling a running 'ergs serve'
+// through its admin API
+func CtlCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "ctl",
+		Usage: "Control a running 'ergs serve'",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "socket",
+				Usage: "Admin API socket (default: internal/admin.sock in the storage directory)",
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name:  "status",
+				Usage: "List the datasources with their schedule",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					client, err := ctlClient(c)
+					if err != nil {
+						return err
+					}
+					datasources, err := client.Datasources(ctx)
+					if err != nil {
+						return err
+					}
+					if len(datasources) == 0 {
+						fmt.Println("No datasources configured")
+					}
+					for _, ds := range datasources {
+						printCtlDatasource(ds)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "fetch",
+				Usage:     "Fetch datasources now, even if paused",
+				ArgsUsage: "<datasource>...",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return ctlDatasourceAction(ctx, c, "Fetching", (*admin.Client).Fetch)
+				},
+			},
+			{
+				Name:      "pause",
+				Usage:     "Pause the scheduled fetches of datasources",
+				ArgsUsage: "<datasource>...",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return ctlDatasourceAction(ctx, c, "Paused", (*admin.Client).Pause)
+				},
+			},
+			{
+				Name:      "resume",
+				Usage:     "Resume the scheduled fetches of paused datasources",
+				ArgsUsage: "<datasource>...",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return ctlDatasourceAction(ctx, c, "Resumed", (*admin.Client).Resume)
+				},
+			},
+			{
+				Name:      "log",
+				Usage:     "Show the log levels, or set them to debug or info globally or for some services",
+				ArgsUsage: "[debug|info [service...]]",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					client, err := ctlClient(c)
+					if err != nil {
+						return err
+					}
+					var levels admin.LogLevels
+					if c.Args().Len() == 0 {
+						levels, err = client.LogLevels(ctx)
+					} else {
+						levels, err = client.SetLogLevel(ctx, c.Args().First(), c.Args().Tail()...)
+					}
+					if err != nil {
+						return err
+					}
+					global := "info"
+					if levels.Debug {
+						global = "debug"
+					}
+					fmt.Printf("Global level: %s\n", global)
+					if len(levels.DebugServices) > 0 {
+						fmt.Printf("Debug enabled for: %s\n", strings.Join(levels.DebugServices, ", "))
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// ctlClient returns the admin API client of the 'ergs serve' using the
+// configured storage directory, or listening on --socket
+func ctlClient(c *cli.Command) (*admin.Client, error) {
+	socketPath := c.String("socket")
+	if socketPath == "" {
+		cfg, err := config.LoadConfig(c.String("config"))
+		if err != nil {
+			return nil, fmt.Errorf("loading config: %w", err)
+		}
+		socketPath = adminSocketPath(cfg)
+	}
+	return admin.NewClient(socketPath), nil
+}
+
+// ctlDatasourceAction calls action for every datasource in the arguments
+func ctlDatasourceAction(ctx context.Context, c *cli.Command, done string, action func(*admin.Client, context.Context, string) (admin.Datasource, error)) error {
+	if c.Args().Len() == 0 {
+		return fmt.Errorf("no datasources given")
+	}
+	client, err := ctlClient(c)
+	if err != nil {
+		return err
+	}
+	for _, name := range c.Args().Slice() {
+		if _, err := action(client, ctx, name); err != nil {
+			return err
+		}
+		fmt.Printf("%s %s\n", done, name)
+	}
+	return nil
+}
+
+func printCtlDatasource(ds admin.Datasource) {
+	schedule := "every " + ds.Interval
+	if ds.Interval == "0s" {
+		schedule = "not scheduled"
+	}
+	var state []string
+	if ds.Paused {
+		state = append(state, "paused")
+	}
+	if ds.Fetching {
+		state = append(state, "fetching")
+	}
+	fmt.Printf("%s (%s)  %s", ds.Name, ds.Type, schedule)
+	if len(state) > 0 {
+		fmt.Printf("  [%s]", strings.Join(state, ", "))
+	}
+	fmt.Println()
+
+	var times []string
+	if ds.NextRun != nil {
+		times = append(times, "Next run: "+formatUntil(*ds.NextRun))
+	}
+	if ds.LastRun != nil {
+		times = append(times, "Last run: "+formatTime(*ds.LastRun))
+	}
+	if len(times) > 0 {
+		fmt.Printf("   %s\n", strings.Join(times, "  "))
+	}
+	if ds.LastError != "" {
+		fmt.Printf("   Error: %s\n", ds.LastError)
+	}
+}
+
+// formatUntil formats a time in the near future relative to now
+func formatUntil(t time.Time) string {
+	d := time.Until(t).Round(time.Second)
+	if d <= 0 {
+		return "due"
+	}
+	return "in " + d.String()
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"github.com/rubiojr/ergs/pkg/config"
+	"github.com/rubiojr/ergs/pkg/core"
+	"github.com/rubiojr/ergs/pkg/storage"
 	"github.com/urfave/cli/v3"
 )
 
@@ -35,6 +37,23 @@ func DatasourceCommand() *cli.Command {
 					return removeDatasource(c.String("config"), c.String("name"))
 				},
 			},
+			{
+				Name:  "apply-exclusions",
+				Usage: "Delete stored blocks matching the datasource exclusion rules",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "datasource",
+						Usage: "Only apply the exclusion rules of this datasource",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Only count the blocks that would be deleted",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return applyExclusions(c.String("config"), c.String("datasource"), c.Bool("dry-run"))
+				},
+			},
 		},
 	}
 }
@@ -82,3 +101,95 @@ func removeDatasource(configPath, name string) error {
 	fmt.Printf("Removed datasource '%s'\n", name)
 	return nil
 }
+
+// applyExclusions deletes the stored blocks matching the exclusion rules of
+// every configured datasource, or only of datasourceName.
+func applyExclusions(configPath, datasourceName string, dryRun bool) error {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	names := cfg.ListDatasources()
+	if datasourceName != "" {
+		if _, exists := cfg.Datasources[datasourceName]; !exists {
+			return fmt.Errorf("datasource '%s' not found", datasourceName)
+		}
+		names = []string{datasourceName}
+	}
+
+	// Compile the rules first so a bad pattern fails before anything is deleted
+	rules := make(map[string]*storage.ExclusionRules)
+	for _, name := range names {
+		r, err := datasourceExclusions(cfg, name)
+		if err != nil {
+			return err
+		}
+		if !r.IsZero() {
+			rules[name] = r
+		}
+	}
+	if len(rules) == 0 {
+		fmt.Println("No exclusion rules configured")
+		return nil
+	}
+
+	registry := core.GetGlobalRegistry()
+	if err := createDatasourcesFromConfig(registry, cfg); err != nil {
+		return fmt.Errorf("creating datasources: %w", err)
+	}
+	defer func() {
+		if err := registry.Close(); err != nil {
+			fmt.Printf("Warning: failed to close registry: %v\n", err)
+		}
+	}()
+
+	storageManager, err := storage.NewManager(cfg.StorageDir, cfg.ListDatasources()...)
+	if err != nil {
+		return fmt.Errorf("creating storage manager: %w", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			fmt.Printf("Warning: failed to close storage manager: %v\n", err)
+		}
+	}()
+
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
+		return fmt.Errorf("initializing storage: %w", err)
+	}
+
+	if err := openGlobalIndex(cfg, storageManager); err != nil {
+		return err
+	}
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	total := 0
+	for _, name := range names {
+		r, ok := rules[name]
+		if !ok {
+			continue
+		}
+		s, err := storageManager.GetStorage(name)
+		if err != nil {
+			return fmt.Errorf("getting storage for %s: %w", name, err)
+		}
+		ids, err := s.DeleteExcluded(r, dryRun)
+		if err != nil {
+			return fmt.Errorf("applying exclusions to %s: %w", name, err)
+		}
+		if !dryRun && len(ids) > 0 {
+			if index := storageManager.GlobalIndex(); index != nil {
+				if err := index.RemoveBlocks(name, ids); err != nil {
+					return fmt.Errorf("removing excluded blocks of %s from the global index: %w", name, err)
+				}
+			}
+		}
+		fmt.Printf("%s %d blocks from %s\n", verb, len(ids), name)
+		total += len(ids)
+	}
+	fmt.Printf("Total: %d blocks\n", total)
+	return nil
+}
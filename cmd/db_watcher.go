@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/realtime"
+	"github.com/rubiojr/ergs/pkg/storage"
+)
+
+// dbWatchInterval is how often the datasource databases are checked for new
+// blocks
+const dbWatchInterval = time.Second
+
+// dbWatchBatch is the most blocks published per datasource and check
+const dbWatchBatch = 500
+
+// DBWatcher publishes the blocks stored in the datasource databases into the
+// firehose hub, for 'ergs web' running without the event bridge. It sees the
+// blocks stored by any process sharing the storage directory, at most
+// dbWatchInterval after they are committed. Deletions are not reported.
+type DBWatcher struct {
+	watcher *storage.BlockWatcher
+	hub     *realtime.FirehoseHub
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// NewDBWatcher watches the databases of the given datasources, publishing the
+// blocks stored from now on into hub.
+func NewDBWatcher(manager *storage.Manager, datasources []string, hub *realtime.FirehoseHub) (*DBWatcher, error) {
+	watcher, err := manager.NewBlockWatcher(datasources)
+	if err != nil {
+		return nil, err
+	}
+	return &DBWatcher{
+		watcher: watcher,
+		hub:     hub,
+		stopCh:  make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}, nil
+}
+
+// Start launches the background watch loop, stopped by Stop or when ctx is
+// done.
+func (w *DBWatcher) Start(ctx context.Context) {
+	go w.run(ctx)
+}
+
+// Stop terminates the watch loop and closes the database connections.
+// Safe to call multiple times.
+func (w *DBWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+		<-w.doneCh
+		if err := w.watcher.Close(); err != nil {
+			log.Printf("db watcher: %v", err)
+		}
+	})
+}
+
+func (w *DBWatcher) run(ctx context.Context) {
+	defer close(w.doneCh)
+	ticker := time.NewTicker(dbWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.stopCh:
+			return
+		case <-ticker.C:
+			w.publishNew()
+		}
+	}
+}
+
+// publishNew broadcasts the blocks stored since the previous check
+func (w *DBWatcher) publishNew() {
+	found, err := w.watcher.Poll(dbWatchBatch)
+	if err != nil {
+		log.Printf("db watcher: %v", err)
+	}
+	for _, wb := range found {
+		b := wb.Block
+		w.hub.Broadcast(realtime.WrapBlock(realtime.NewBlockEvent(b.ID(), wb.Datasource, b.Type(), b.CreatedAt(), b.Text(), b.Metadata())))
+	}
+}
@@ -26,15 +26,20 @@ func FetchCommand() *cli.Command {
 				Name:  "datasource",
 				Usage: "Specific datasource to fetch from",
 			},
+			&cli.BoolFlag{
+				Name:  "reset-cursor",
+				Usage: "Discard saved incremental fetch cursors and fetch everything again",
+				Value: false,
+			},
 		},
 		Action: func(ctx context.Context, c *cli.Command) error {
-			return fetchData(ctx, c.String("config"), c.Bool("stream"), c.String("datasource"))
+			return fetchData(ctx, c.String("config"), c.Bool("stream"), c.String("datasource"), c.Bool("reset-cursor"))
 		},
 	}
 }
 
 // fetchData fetches data from configured datasources
-func fetchData(ctx context.Context, configPath string, stream bool, datasourceName string) error {
+func fetchData(ctx context.Context, configPath string, stream bool, datasourceName string, resetCursor bool) error {
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
@@ -62,8 +67,17 @@ func fetchData(ctx context.Context, configPath string, stream bool, datasourceNa
 		}
 	}()
 
+	if err := openGlobalIndex(cfg, storageManager); err != nil {
+		return err
+	}
+
+	if err := setDatasourceTokenizers(cfg, storageManager); err != nil {
+		return err
+	}
+
 	warehouseConfig := warehouse.Config{
 		OptimizeInterval: 0, // No optimization for one-time fetch
+		WebhookLogPath:   webhookLogPath(cfg),
 	}
 	wh := warehouse.NewWarehouse(warehouseConfig, storageManager)
 	defer func() {
@@ -88,6 +102,27 @@ func fetchData(ctx context.Context, configPath string, stream bool, datasourceNa
 		if err := wh.AddDatasourceWithInterval(name, ds, interval); err != nil {
 			return fmt.Errorf("adding datasource to warehouse: %w", err)
 		}
+		if err := configureWarehouseDatasource(wh, cfg, name); err != nil {
+			return err
+		}
+	}
+	if err := configureSavedSearches(wh, cfg); err != nil {
+		return err
+	}
+	if err := configureWebhooks(wh, cfg); err != nil {
+		return err
+	}
+
+	if resetCursor {
+		for name := range datasources {
+			removed, err := wh.ResetCursors(name)
+			if err != nil {
+				return fmt.Errorf("resetting cursors for datasource %s: %w", name, err)
+			}
+			if removed > 0 {
+				fmt.Printf("Reset %d fetch cursor(s) for datasource '%s'\n", removed, name)
+			}
+		}
 	}
 
 	if stream {
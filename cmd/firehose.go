@@ -12,24 +12,30 @@ import (
 	"time"
 
 	"github.com/rubiojr/ergs/pkg/config"
+	"github.com/rubiojr/ergs/pkg/realtime"
 	"github.com/urfave/cli/v3"
 )
 
 // FirehoseCommand creates a CLI command that tails the warehouse event bridge
-// Unix domain socket and writes NDJSON block events to stdout.
+// Unix domain socket (or TCP listener) and writes NDJSON block events to stdout.
 //
 // Typical usage:
 //
 //	ergs firehose --socket /run/ergs/bridge.sock
+//	ergs firehose --socket tls://warehouse.lan:7777 --token <token>
 //	ergs firehose                       (uses event_socket_path from config)
 //	ergs firehose | jq -r 'select(.type=="block") | .text'
 //
-// By default it filters to only "block" events and reprints them as-is (single line JSON).
+// By default it filters to only "block" and "delete" events and reprints them as-is (single line JSON).
+// --datasource and --type make the bridge only send the events of those datasources.
 // You can choose to include heartbeats/info/error frames with --all.
 // You can request pretty formatting (multi-line) with --pretty (mainly for manual inspection).
 //
 // The command auto-reconnects with exponential backoff if the socket is not
-// yet available or the connection drops. It never exits unless:
+// yet available or the connection drops, and resumes after the last event it
+// printed, so nothing is lost while reconnecting. --resume-from and
+// --state-file resume across runs too; gaps the bridge can no longer replay
+// are reported on stderr. It never exits unless:
 //   - Context is cancelled (Ctrl+C / signal)
 //   - A non-recoverable error occurs opening the socket AND --no-retry is set.
 func FirehoseCommand() *cli.Command {
@@ -39,13 +45,29 @@ func FirehoseCommand() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "socket",
-				Usage: "Path to Unix domain socket (overrides config event_socket_path)",
+				Usage: "Path to Unix domain socket, or tcp://host:port or tls://host:port URL (overrides config event_socket_path)",
+			},
+			&cli.StringFlag{
+				Name:  "token",
+				Usage: "Token to authenticate with over TCP (overrides config event_bridge.token)",
+			},
+			&cli.StringFlag{
+				Name:  "tls-ca",
+				Usage: "CA certificate to verify the bridge with over TLS (overrides config event_bridge.tls_ca)",
 			},
 			&cli.BoolFlag{
 				Name:  "all",
-				Usage: "Print all event types (block, heartbeat, info, error) instead of only blocks",
+				Usage: "Print all event types (block, delete, heartbeat, info, error) instead of only block and delete events",
 				Value: false,
 			},
+			&cli.StringSliceFlag{
+				Name:  "datasource",
+				Usage: "Only stream events of this datasource. Can be used multiple times",
+			},
+			&cli.StringSliceFlag{
+				Name:  "type",
+				Usage: "Only stream events of datasources of this type. Can be used multiple times",
+			},
 			&cli.BoolFlag{
 				Name:  "pretty",
 				Usage: "Pretty-print JSON instead of raw single-line",
@@ -56,6 +78,14 @@ func FirehoseCommand() *cli.Command {
 				Usage: "Do not retry on failures; exit on first connection error",
 				Value: false,
 			},
+			&cli.Uint64Flag{
+				Name:  "resume-from",
+				Usage: "Replay the events after this sequence number before streaming live events",
+			},
+			&cli.StringFlag{
+				Name:  "state-file",
+				Usage: "File keeping the last sequence number printed, to resume from it on the next run",
+			},
 			&cli.DurationFlag{
 				Name:  "initial-backoff",
 				Usage: "Initial reconnect backoff",
@@ -70,26 +100,44 @@ func FirehoseCommand() *cli.Command {
 		Action: func(ctx context.Context, c *cli.Command) error {
 			cfgPath := c.String("config")
 			socketPath := c.String("socket")
-			if socketPath == "" {
-				// Load config to get default event socket path
-				cfg, err := config.LoadConfig(cfgPath)
-				if err != nil {
+			var dialOptions BridgeDialOptions
+			// Load config to get the default event socket path and dial options
+			cfg, err := config.LoadConfig(cfgPath)
+			if err != nil {
+				if socketPath == "" {
 					return fmt.Errorf("load config: %w", err)
 				}
-				socketPath = cfg.EventSocketPath
+			} else {
+				if socketPath == "" {
+					socketPath = cfg.EventSocketPath
+				}
+				dialOptions = bridgeDialOptions(cfg)
+			}
+			if c.String("token") != "" {
+				dialOptions.Token = c.String("token")
+			}
+			if c.String("tls-ca") != "" {
+				dialOptions.TLSCA = c.String("tls-ca")
 			}
 
 			if socketPath == "" {
 				return errors.New("no socket path provided (flag --socket or config event_socket_path required)")
 			}
+			if _, _, _, err := parseBridgeAddress(socketPath); err != nil {
+				return err
+			}
 
 			opts := firehoseTailOptions{
 				socketPath:     socketPath,
+				dialOptions:    dialOptions,
+				filter:         realtime.Filter{Datasources: c.StringSlice("datasource"), Types: c.StringSlice("type")},
 				includeAll:     c.Bool("all"),
 				pretty:         c.Bool("pretty"),
 				noRetry:        c.Bool("no-retry"),
 				initialBackoff: c.Duration("initial-backoff"),
 				maxBackoff:     c.Duration("max-backoff"),
+				resumeFrom:     c.Uint64("resume-from"),
+				stateFile:      c.String("state-file"),
 				stdout:         os.Stdout,
 				stderr:         os.Stderr,
 			}
@@ -98,17 +146,59 @@ func FirehoseCommand() *cli.Command {
 	}
 }
 
+// errBridgeRejected is returned when the event bridge sends an error event
+var errBridgeRejected = errors.New("event bridge error")
+
 type firehoseTailOptions struct {
 	socketPath     string
+	dialOptions    BridgeDialOptions
+	filter         realtime.Filter
 	includeAll     bool
 	pretty         bool
 	noRetry        bool
 	initialBackoff time.Duration
 	maxBackoff     time.Duration
+	resumeFrom     uint64
+	stateFile      string
 	stdout         *os.File
 	stderr         *os.File
 }
 
+// firehoseCursor tracks the sequence number of the last event printed and
+// saves it to the state file, if any.
+type firehoseCursor struct {
+	seq       uint64
+	savedSeq  uint64
+	savedAt   time.Time
+	stateFile string
+	stderr    *os.File
+}
+
+// advance records seq as printed, saving it at most once every
+// bridgeStateSaveInterval.
+func (c *firehoseCursor) advance(seq uint64) {
+	if seq == 0 {
+		return
+	}
+	c.seq = seq
+	if time.Since(c.savedAt) >= bridgeStateSaveInterval {
+		c.save()
+	}
+}
+
+// save writes the sequence number to the state file when it changed
+func (c *firehoseCursor) save() {
+	if c.stateFile == "" || c.seq == c.savedSeq {
+		return
+	}
+	if err := writeBridgeSeq(c.stateFile, c.seq); err != nil {
+		_, _ = fmt.Fprintf(c.stderr, "Firehose: %v\n", err)
+		return
+	}
+	c.savedSeq = c.seq
+	c.savedAt = time.Now()
+}
+
 func tailFirehose(ctx context.Context, opts firehoseTailOptions) error {
 	if opts.initialBackoff <= 0 {
 		opts.initialBackoff = time.Second
@@ -117,11 +207,22 @@ func tailFirehose(ctx context.Context, opts firehoseTailOptions) error {
 		opts.maxBackoff = 30 * time.Second
 	}
 
+	cursor := &firehoseCursor{seq: opts.resumeFrom, stateFile: opts.stateFile, stderr: opts.stderr}
+	if cursor.seq == 0 && opts.stateFile != "" {
+		seq, err := readBridgeSeq(opts.stateFile)
+		if err != nil {
+			return err
+		}
+		cursor.seq = seq
+	}
+	cursor.savedSeq = cursor.seq
+	defer cursor.save()
+
 	_, _ = fmt.Fprintf(opts.stderr, "Firehose: connecting to %s\n", opts.socketPath)
 	backoff := opts.initialBackoff
 
 	for {
-		conn, err := net.Dial("unix", opts.socketPath)
+		conn, err := dialBridge(ctx, opts.socketPath, opts.dialOptions)
 		if err != nil {
 			if opts.noRetry {
 				return fmt.Errorf("dial: %w", err)
@@ -141,10 +242,20 @@ func tailFirehose(ctx context.Context, opts firehoseTailOptions) error {
 
 		_, _ = fmt.Fprintf(opts.stderr, "Firehose: connected (backoff reset)\n")
 		backoff = opts.initialBackoff
+		if cursor.seq > 0 || len(opts.filter.Datasources) > 0 || len(opts.filter.Types) > 0 {
+			if cursor.seq > 0 {
+				_, _ = fmt.Fprintf(opts.stderr, "Firehose: resuming after event %d\n", cursor.seq)
+			}
+			if err := writeResumeRequest(conn, cursor.seq, opts.filter); err != nil {
+				_, _ = fmt.Fprintf(opts.stderr, "Firehose: resume request failed (%v)\n", err)
+			}
+		}
 
-		if err := streamEvents(ctx, conn, opts); err != nil {
+		err = streamEvents(ctx, conn, opts, cursor)
+		cursor.save()
+		if err != nil {
 			_ = conn.Close()
-			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errBridgeRejected) {
 				return err
 			}
 			// Log and attempt reconnect unless no-retry
@@ -169,9 +280,20 @@ func tailFirehose(ctx context.Context, opts firehoseTailOptions) error {
 	}
 }
 
-func streamEvents(ctx context.Context, conn net.Conn, opts firehoseTailOptions) error {
+func streamEvents(ctx context.Context, conn net.Conn, opts firehoseTailOptions, cursor *firehoseCursor) error {
 	defer func() { _ = conn.Close() }()
 
+	// Unblock the scanner when the context is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
 	// Increase scanner buffer (metadata could be large)
 	sc := bufio.NewScanner(conn)
 	buf := make([]byte, 64*1024)
@@ -190,51 +312,57 @@ func streamEvents(ctx context.Context, conn net.Conn, opts firehoseTailOptions)
 			continue
 		}
 
-		// Optimistic fast-path: if user wants raw all events or just blocks?
-		// We parse minimally to filter if necessary.
-		if !opts.includeAll || opts.pretty {
-			var raw map[string]json.RawMessage
-			if err := json.Unmarshal(line, &raw); err != nil {
-				// If malformed, just show raw line when includeAll (else skip)
-				if opts.includeAll {
-					_, _ = fmt.Fprintln(opts.stdout, trimmed)
-				}
-				continue
+		// Parse the envelope to track sequence numbers and filter if necessary.
+		var envelope struct {
+			Type    string `json:"type"`
+			Seq     uint64 `json:"seq"`
+			FromSeq uint64 `json:"from_seq"`
+			ToSeq   uint64 `json:"to_seq"`
+		}
+		if err := json.Unmarshal(line, &envelope); err != nil {
+			// If malformed, just show raw line when includeAll (else skip)
+			if opts.includeAll {
+				_, _ = fmt.Fprintln(opts.stdout, trimmed)
 			}
+			continue
+		}
+		isEvent := envelope.Type == "block" || envelope.Type == "delete"
+		if envelope.Type == "error" {
+			// The bridge only sends errors before closing the connection, and
+			// reconnecting won't help (e.g. wrong token)
+			return fmt.Errorf("%w: %s", errBridgeRejected, trimmed)
+		}
+		if envelope.Type == "gap" {
+			_, _ = fmt.Fprintf(opts.stderr, "Firehose: events %d to %d are no longer in the bridge log and were lost\n", envelope.FromSeq, envelope.ToSeq)
+		}
 
-			// Filter if only blocks
-			if !opts.includeAll {
-				tt, ok := raw["type"]
-				if !ok {
-					continue
-				}
-				var t string
-				if err := json.Unmarshal(tt, &t); err != nil || t != "block" {
-					continue
-				}
-			}
+		// Filter if only block and delete events
+		if !opts.includeAll && !isEvent {
+			continue
+		}
 
-			if opts.pretty {
-				var anyJSON any
-				if err := json.Unmarshal(line, &anyJSON); err != nil {
-					// Fallback: raw
-					_, _ = fmt.Fprintln(opts.stdout, trimmed)
-					continue
-				}
-				b, err := json.MarshalIndent(anyJSON, "", "  ")
-				if err != nil {
-					_, _ = fmt.Fprintln(opts.stdout, trimmed)
-					continue
-				}
+		if opts.pretty {
+			var anyJSON any
+			if err := json.Unmarshal(line, &anyJSON); err != nil {
+				// Fallback: raw
+				_, _ = fmt.Fprintln(opts.stdout, trimmed)
+			} else if b, err := json.MarshalIndent(anyJSON, "", "  "); err != nil {
+				_, _ = fmt.Fprintln(opts.stdout, trimmed)
+			} else {
 				_, _ = fmt.Fprintln(opts.stdout, string(b))
-				continue
 			}
+		} else {
+			// Default pass-through (already filtered if needed)
+			_, _ = fmt.Fprintln(opts.stdout, trimmed)
+		}
+		if isEvent {
+			cursor.advance(envelope.Seq)
 		}
-
-		// Default pass-through (already filtered if needed)
-		_, _ = fmt.Fprintln(opts.stdout, trimmed)
 	}
 
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	if err := sc.Err(); err != nil {
 		return fmt.Errorf("read error: %w", err)
 	}
@@ -130,5 +130,13 @@ func formatStats(stats map[string]interface{}) {
 				fmt.Printf("   Span:   %s\n", formatDuration(duration))
 			}
 		}
+
+		if prunedBlocks, ok := dsStats["pruned_blocks"].(int); ok {
+			fmt.Printf("   Pruned: %s", formatNumber(prunedBlocks))
+			if lastPruned, ok := dsStats["last_pruned"].(time.Time); ok && !lastPruned.IsZero() {
+				fmt.Printf(" (last: %s)", formatTime(lastPruned))
+			}
+			fmt.Printf("\n")
+		}
 	}
 }
@@ -51,6 +51,11 @@ type ImporterServer struct {
 	db         *sql.DB
 	storageDir string
 	apiToken   string
+
+	// store stores imported blocks right away when the importer runs in the
+	// warehouse process; blocks are staged in db for the importer datasource
+	// when nil
+	store func(core.Block) error
 }
 
 type ImportBlocksRequest struct {
@@ -81,28 +86,71 @@ func startImporterServer(ctx context.Context, configPath, host, port string) err
 		return fmt.Errorf("cannot start importer; %w", err)
 	}
 
-	// Use config values if flags are default and config has values
+	host, port = importerAddress(cfg, host, port)
+	server, err := newImporterServer(cfg, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := server.Close(); err != nil {
+			log.Printf("Warning: failed to close database: %v", err)
+		}
+	}()
+
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf("%s:%s", host, port),
+		Handler: server.Handler(),
+	}
+
+	// Start server in goroutine
+	go func() {
+		server.logEndpoints(host, port)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	// Wait for interrupt signal
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	log.Println("Shutting down importer server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return httpServer.Shutdown(shutdownCtx)
+}
+
+// importerAddress returns the host and port the importer listens on: the
+// flag values, or the config ones when the flags have their defaults.
+func importerAddress(cfg *config.Config, host, port string) (string, string) {
 	if cfg.Importer != nil {
-		// Use config host if flag is default
 		if host == "localhost" && cfg.Importer.Host != "" {
 			host = cfg.Importer.Host
 		}
-		// Use config port if flag is default
 		if port == "9090" && cfg.Importer.Port != "" {
 			port = cfg.Importer.Port
 		}
 	}
+	return host, port
+}
 
-	// Get or generate API token
+// newImporterServer opens the staging database in the storage directory and
+// returns the importer API server, with a random API key if the config has
+// none. With a store function, imported blocks are stored right away through
+// it instead of being staged for the importer datasource.
+func newImporterServer(cfg *config.Config, store func(core.Block) error) (*ImporterServer, error) {
 	var apiToken string
 	if cfg.Importer != nil {
 		apiToken = cfg.Importer.APIKey
 	}
 	if apiToken == "" {
 		// Generate a random API token
+		var err error
 		apiToken, err = generateAPIToken()
 		if err != nil {
-			return fmt.Errorf("generating API token: %w", err)
+			return nil, fmt.Errorf("generating API token: %w", err)
 		}
 		log.Printf("⚠️  No API key configured. Generated random key for this session.")
 		log.Printf("⚠️  Add this to your config.toml to persist it:")
@@ -113,76 +161,64 @@ func startImporterServer(ctx context.Context, configPath, host, port string) err
 	// Ensure internal directory exists
 	internalDir := filepath.Join(cfg.StorageDir, "internal")
 	if err := os.MkdirAll(internalDir, 0755); err != nil {
-		return fmt.Errorf("creating internal directory: %w", err)
+		return nil, fmt.Errorf("creating internal directory: %w", err)
 	}
 
 	// Initialize database
 	dbPath := filepath.Join(internalDir, "importer.db")
 	db, err := initImporterDB(dbPath)
 	if err != nil {
-		return fmt.Errorf("initializing database: %w", err)
+		return nil, fmt.Errorf("initializing database: %w", err)
 	}
-	defer func() {
-		if err := db.Close(); err != nil {
-			log.Printf("Warning: failed to close database: %v", err)
-		}
-	}()
 
-	server := &ImporterServer{
+	return &ImporterServer{
 		db:         db,
 		storageDir: cfg.StorageDir,
 		apiToken:   apiToken,
-	}
+		store:      store,
+	}, nil
+}
 
+// Close closes the staging database.
+func (s *ImporterServer) Close() error {
+	return s.db.Close()
+}
+
+// Handler returns the HTTP handler serving the importer API.
+func (s *ImporterServer) Handler() http.Handler {
 	mux := http.NewServeMux()
-	mux.HandleFunc("POST /api/import/blocks", server.handleImportBlocks)
-	mux.HandleFunc("GET /api/blocks/export", server.handleExportBlocks)
-	mux.HandleFunc("GET /health", server.handleHealth)
-	mux.HandleFunc("GET /api/stats", server.handleStats)
+	mux.HandleFunc("POST /api/import/blocks", s.handleImportBlocks)
+	mux.HandleFunc("GET /api/blocks/export", s.handleExportBlocks)
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("GET /api/stats", s.handleStats)
 
 	// Add CORS and auth middleware
-	handler := corsMiddleware(server.authMiddleware(mux))
-
-	httpServer := &http.Server{
-		Addr:    fmt.Sprintf("%s:%s", host, port),
-		Handler: handler,
-	}
+	return corsMiddleware(s.authMiddleware(mux))
+}
 
-	// Start server in goroutine
-	go func() {
-		log.Printf("Starting importer API server on http://%s:%s", host, port)
-		log.Printf("")
-		log.Printf("🔑 API Key: %s", apiToken)
-		log.Printf("")
-		log.Printf("Available endpoints:")
+// logEndpoints logs the address, API key and endpoints of the importer
+func (s *ImporterServer) logEndpoints(host, port string) {
+	log.Printf("Starting importer API server on http://%s:%s", host, port)
+	log.Printf("")
+	log.Printf("🔑 API Key: %s", s.apiToken)
+	log.Printf("")
+	log.Printf("Available endpoints:")
+	if s.store != nil {
+		log.Printf("  POST /api/import/blocks - Import blocks, stored right away (datasource specified in block data)")
+	} else {
 		log.Printf("  POST /api/import/blocks - Import blocks (datasource specified in block data)")
-		log.Printf("  GET  /api/blocks/export - Export and delete all pending blocks")
-		log.Printf("  GET  /health - Health check (no auth required)")
-		log.Printf("  GET  /api/stats - Get import statistics")
-		log.Printf("")
-		log.Printf("Example usage:")
-		log.Printf("  curl -X POST http://%s:%s/api/import/blocks \\", host, port)
-		log.Printf("    -H 'Content-Type: application/json' \\")
-		log.Printf("    -H 'Authorization: Bearer %s' \\", apiToken)
-		log.Printf("    -d '{\"blocks\": [{\"id\": \"test-1\", \"text\": \"Test block\", \"created_at\": \"%s\", \"type\": \"github\", \"datasource\": \"github-main\", \"metadata\": {}}]}'",
-			time.Now().Format(time.RFC3339))
-		log.Printf("")
-
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
-		}
-	}()
-
-	// Wait for interrupt signal
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	<-sigCh
-
-	log.Println("Shutting down importer server...")
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	return httpServer.Shutdown(shutdownCtx)
+	}
+	log.Printf("  GET  /api/blocks/export - Export and delete all pending blocks")
+	log.Printf("  GET  /health - Health check (no auth required)")
+	log.Printf("  GET  /api/stats - Get import statistics")
+	log.Printf("")
+	log.Printf("Example usage:")
+	log.Printf("  curl -X POST http://%s:%s/api/import/blocks \\", host, port)
+	log.Printf("    -H 'Content-Type: application/json' \\")
+	log.Printf("    -H 'Authorization: Bearer %s' \\", s.apiToken)
+	log.Printf("    -d '{\"blocks\": [{\"id\": \"test-1\", \"text\": \"Test block\", \"created_at\": \"%s\", \"type\": \"github\", \"datasource\": \"github-main\", \"metadata\": {}}]}'",
+		time.Now().Format(time.RFC3339))
+	log.Printf("")
 }
 
 func initImporterDB(dbPath string) (*sql.DB, error) {
@@ -287,6 +323,11 @@ func (s *ImporterServer) handleImportBlocks(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if s.store != nil {
+		s.storeBlocks(w, req.Blocks)
+		return
+	}
+
 	// Validate and store blocks
 	var errors []string
 	accepted := 0
@@ -322,29 +363,8 @@ func (s *ImporterServer) handleImportBlocks(w http.ResponseWriter, r *http.Reque
 	}()
 
 	for i, block := range req.Blocks {
-		// Validate block (matches core.Block interface requirements)
-		if block.ID() == "" {
-			errors = append(errors, fmt.Sprintf("Block %d: missing ID", i))
-			rejected++
-			continue
-		}
-		if block.Text() == "" {
-			errors = append(errors, fmt.Sprintf("Block %d (%s): missing text", i, block.ID()))
-			rejected++
-			continue
-		}
-		if block.CreatedAt().IsZero() {
-			errors = append(errors, fmt.Sprintf("Block %d (%s): missing or invalid created_at", i, block.ID()))
-			rejected++
-			continue
-		}
-		if block.Type() == "" {
-			errors = append(errors, fmt.Sprintf("Block %d (%s): missing type", i, block.ID()))
-			rejected++
-			continue
-		}
-		if block.Source() == "" {
-			errors = append(errors, fmt.Sprintf("Block %d (%s): missing datasource", i, block.ID()))
+		if msg := validateImportBlock(i, &block); msg != "" {
+			errors = append(errors, msg)
 			rejected++
 			continue
 		}
@@ -388,6 +408,53 @@ func (s *ImporterServer) handleImportBlocks(w http.ResponseWriter, r *http.Reque
 	s.writeJSON(w, http.StatusOK, response)
 }
 
+// storeBlocks stores imported blocks right away, responding like the staging
+// path does
+func (s *ImporterServer) storeBlocks(w http.ResponseWriter, blocks []core.GenericBlock) {
+	var errors []string
+	accepted := 0
+	rejected := 0
+	for i, block := range blocks {
+		if msg := validateImportBlock(i, &block); msg != "" {
+			errors = append(errors, msg)
+			rejected++
+			continue
+		}
+		if err := s.store(&block); err != nil {
+			errors = append(errors, fmt.Sprintf("Block %d (%s): failed to store: %v", i, block.ID(), err))
+			rejected++
+			continue
+		}
+		accepted++
+	}
+
+	log.Printf("Imported and stored %d blocks (rejected: %d)", accepted, rejected)
+
+	s.writeJSON(w, http.StatusOK, ImportBlocksResponse{
+		Accepted: accepted,
+		Rejected: rejected,
+		Errors:   errors,
+	})
+}
+
+// validateImportBlock returns why the i-th imported block is rejected, or ""
+// if it is valid (matches core.Block interface requirements)
+func validateImportBlock(i int, block *core.GenericBlock) string {
+	switch {
+	case block.ID() == "":
+		return fmt.Sprintf("Block %d: missing ID", i)
+	case block.Text() == "":
+		return fmt.Sprintf("Block %d (%s): missing text", i, block.ID())
+	case block.CreatedAt().IsZero():
+		return fmt.Sprintf("Block %d (%s): missing or invalid created_at", i, block.ID())
+	case block.Type() == "":
+		return fmt.Sprintf("Block %d (%s): missing type", i, block.ID())
+	case block.Source() == "":
+		return fmt.Sprintf("Block %d (%s): missing datasource", i, block.ID())
+	}
+	return ""
+}
+
 func (s *ImporterServer) handleExportBlocks(w http.ResponseWriter, r *http.Request) {
 	// Query all blocks
 	rows, err := s.db.Query(`
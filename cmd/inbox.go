@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rubiojr/ergs/pkg/storage"
+	"github.com/urfave/cli/v3"
+)
+
+// InboxCommand creates the inbox command with subcommands
+func InboxCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "inbox",
+		Usage: "List the blocks ingested since they were last looked at",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "datasource",
+				Usage: "Only list blocks from this datasource",
+			},
+			&cli.StringFlag{
+				Name:  "state",
+				Usage: "List unread, read or archived blocks",
+				Value: string(storage.InboxUnread),
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "Maximum number of blocks",
+				Value: 20,
+			},
+			&cli.IntFlag{
+				Name:  "page",
+				Usage: "Page of blocks to list",
+				Value: 1,
+			},
+			&cli.BoolFlag{
+				Name:  "mark-read",
+				Usage: "Mark the listed unread blocks read",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			state, err := storage.ParseInboxState(c.String("state"))
+			if err != nil {
+				return err
+			}
+			return listInbox(c.String("config"), c.String("datasource"), state, int(c.Int("page")), int(c.Int("limit")), c.Bool("mark-read"))
+		},
+		Commands: []*cli.Command{
+			inboxStateCommand("read", "Mark blocks read", storage.InboxRead),
+			inboxStateCommand("unread", "Move blocks back to the inbox", storage.InboxUnread),
+			inboxStateCommand("archive", "Archive blocks", storage.InboxArchived),
+			{
+				Name:  "mark-all-read",
+				Usage: "Mark every unread block read",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "datasource",
+						Usage: "Only mark blocks from this datasource",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return withBlockStorage(c.String("config"), c.String("datasource"), func(m *storage.Manager, _ *storage.GenericStorage) error {
+						var datasources []string
+						if name := c.String("datasource"); name != "" {
+							datasources = []string{name}
+						}
+						marked, err := m.MarkAllRead(datasources)
+						if err != nil {
+							return err
+						}
+						total := 0
+						for _, n := range marked {
+							total += n
+						}
+						fmt.Printf("Marked %d blocks read\n", total)
+						return nil
+					})
+				},
+			},
+		},
+	}
+}
+
+// inboxStateCommand creates a subcommand moving blocks to state
+func inboxStateCommand(name, usage string, state storage.InboxState) *cli.Command {
+	return &cli.Command{
+		Name:      name,
+		Usage:     usage,
+		ArgsUsage: "<id>...",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "datasource",
+				Usage:    "Datasource of the blocks",
+				Required: true,
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			if c.Args().Len() == 0 {
+				return fmt.Errorf("no block IDs given")
+			}
+			return withBlockStorage(c.String("config"), c.String("datasource"), func(_ *storage.Manager, st *storage.GenericStorage) error {
+				n, err := st.SetInboxState(c.Args().Slice(), state)
+				if err != nil {
+					return err
+				}
+				if n < c.Args().Len() {
+					fmt.Printf("%d of the blocks were not found in datasource '%s'\n", c.Args().Len()-n, c.String("datasource"))
+				}
+				fmt.Printf("Marked %d blocks %s\n", n, state)
+				return nil
+			})
+		},
+	}
+}
+
+// listInbox prints a page of the inbox, optionally marking it read
+func listInbox(configPath, datasourceName string, state storage.InboxState, page, limit int, markRead bool) error {
+	return withBlockStorage(configPath, datasourceName, func(m *storage.Manager, _ *storage.GenericStorage) error {
+		params := storage.InboxParams{State: state, Page: page, Limit: limit}
+		if datasourceName != "" {
+			params.Datasources = []string{datasourceName}
+		}
+		result, err := m.Inbox(params)
+		if err != nil {
+			return err
+		}
+		if len(result.Items) == 0 {
+			fmt.Printf("No %s blocks\n", state)
+			return nil
+		}
+
+		start := (result.Page - 1) * result.Limit
+		ids := make(map[string][]string)
+		for i, item := range result.Items {
+			fmt.Printf("%d. %s\n", start+i+1, item.Block.PrettyText())
+			fmt.Printf("   Datasource: %s  ID: %s  Ingested: %s\n\n", item.Datasource, item.Block.ID(), formatTime(item.IngestedAt))
+			ids[item.Datasource] = append(ids[item.Datasource], item.Block.ID())
+		}
+		fmt.Printf("Showing %d-%d of %d %s blocks\n", start+1, start+len(result.Items), result.TotalCount, state)
+
+		if !markRead || state != storage.InboxUnread {
+			return nil
+		}
+		datasources := make([]string, 0, len(ids))
+		for name := range ids {
+			datasources = append(datasources, name)
+		}
+		sort.Strings(datasources)
+		for _, name := range datasources {
+			st, err := m.GetStorage(name)
+			if err != nil {
+				return fmt.Errorf("getting storage for %s: %w", name, err)
+			}
+			if _, err := st.SetInboxState(ids[name], storage.InboxRead); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Marked %d blocks read\n", len(result.Items))
+		return nil
+	})
+}
@@ -62,7 +62,7 @@ func listBlocks(configPath, datasourceName string, limit int) error {
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
@@ -73,7 +73,7 @@ func RunMigrations(configPath string, statusOnly bool, datasourceName string) er
 	}
 
 	// Run migrations for each datasource database
-	for name := range datasources {
+	for name, ds := range datasources {
 		// Skip importer datasource (it has no dedicated persistent database / schema)
 		if name == "importer" {
 			continue
@@ -102,14 +102,29 @@ func RunMigrations(configPath string, statusOnly bool, datasourceName string) er
 
 		migrationManager := db.NewMigrationManager(dbConn)
 
+		tokenizer := cfg.GetDatasourceTokenizer(name)
 		if statusOnly {
 			if err := showMigrationStatus(migrationManager, name); err != nil {
 				return fmt.Errorf("showing migration status for %s: %w", name, err)
 			}
+			// Schema changes need the numbered migrations applied first
+			if status, err := migrationManager.GetMigrationStatus(); err == nil && len(status.Pending) == 0 {
+				if err := showSchemaChanges(genericStorage, ds.Schema(), tokenizer); err != nil {
+					return fmt.Errorf("showing schema changes for %s: %w", name, err)
+				}
+			}
 		} else {
 			if err := migrationManager.ApplyPendingMigrations(); err != nil {
 				return fmt.Errorf("applying migrations for %s: %w", name, err)
 			}
+			// Bring the schema columns in line with the datasource's Schema()
+			// and rebuild the FTS index if the configured tokenizer changed
+			if err := genericStorage.InitializeSchema(ds.Schema()); err != nil {
+				return fmt.Errorf("applying schema for %s: %w", name, err)
+			}
+			if err := genericStorage.ApplyTokenizer(tokenizer); err != nil {
+				return fmt.Errorf("applying tokenizer for %s: %w", name, err)
+			}
 		}
 	}
 
@@ -150,6 +165,21 @@ func showMigrationStatus(manager *db.MigrationManager, datasourceName string) er
 	return nil
 }
 
+// showSchemaChanges displays the schema and tokenizer changes 'ergs migrate'
+// would apply
+func showSchemaChanges(genericStorage *storage.GenericStorage, schema map[string]any, tokenizer string) error {
+	changes, err := genericStorage.PendingSchemaChanges(schema, tokenizer)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pending schema changes: %d\n", len(changes))
+	for _, change := range changes {
+		fmt.Printf("  • %s\n", change)
+	}
+	return nil
+}
+
 // getDBConnection extracts the database connection from GenericStorage
 func getDBConnection(genericStorage *storage.GenericStorage) (*sql.DB, error) {
 	return genericStorage.GetDB(), nil
@@ -91,6 +91,13 @@ func OptimizeCommand() *cli.Command {
 					return checkpointDatabase(c.String("config"), c.String("datasource"))
 				},
 			},
+			{
+				Name:  "index",
+				Usage: "Rebuild the global search index from all datasource databases",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return rebuildGlobalIndex(c.String("config"))
+				},
+			},
 			{
 				Name:  "all",
 				Usage: "Run all optimization operations (analyze, checkpoint, optimize)",
@@ -137,7 +144,7 @@ func optimizeAll(configPath string, datasourceName string) error {
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
@@ -232,7 +239,7 @@ func analyzeDatabase(configPath string, datasourceName string) error {
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
@@ -294,7 +301,7 @@ func vacuumDatabase(configPath string, datasourceName string) error {
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
@@ -372,7 +379,7 @@ func checkpointDatabase(configPath string, datasourceName string) error {
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
@@ -434,7 +441,7 @@ func checkDatabases(configPath string, datasourceName string, deepFTS bool) erro
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
@@ -530,7 +537,7 @@ func rebuildFTS(configPath string, datasourceName string, force bool) error {
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
@@ -622,6 +629,67 @@ func rebuildFTS(configPath string, datasourceName string, force bool) error {
 }
 
 // getDatasourcesToProcess returns a list of datasources to process based on the datasourceName filter.
+// rebuildGlobalIndex rebuilds the global search index from every datasource
+// database. It works whether or not global_index is enabled in the config.
+func rebuildGlobalIndex(configPath string) error {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	registry := core.GetGlobalRegistry()
+
+	if err := createDatasourcesFromConfig(registry, cfg); err != nil {
+		return fmt.Errorf("creating datasources: %w", err)
+	}
+	defer func() {
+		if err := registry.Close(); err != nil {
+			fmt.Printf("Warning: failed to close registry: %v\n", err)
+		}
+	}()
+
+	configuredDatasources := cfg.ListDatasources()
+	storageManager, err := storage.NewManager(cfg.StorageDir, configuredDatasources...)
+	if err != nil {
+		return fmt.Errorf("creating storage manager: %w", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			fmt.Printf("Warning: failed to close storage manager: %v\n", err)
+		}
+	}()
+
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
+		return fmt.Errorf("initializing storage: %w", err)
+	}
+
+	if err := storageManager.OpenGlobalIndex(); err != nil {
+		return fmt.Errorf("opening global index: %w", err)
+	}
+
+	fmt.Println("Rebuilding global search index...")
+	fmt.Println()
+
+	datasources := storageManager.GetDatasourceNames()
+	if len(datasources) == 0 {
+		return fmt.Errorf("no datasources found")
+	}
+
+	err = storageManager.GlobalIndex().Rebuild(storageManager, datasources, func(name string) {
+		fmt.Printf("Indexing %s...\n", name)
+	})
+	if err != nil {
+		return fmt.Errorf("rebuilding global index: %w", err)
+	}
+
+	fmt.Println()
+	fmt.Println("✓ Global search index rebuilt successfully")
+	if !cfg.GlobalIndex {
+		fmt.Println("Set global_index = true in the config to search and maintain it")
+	}
+	return nil
+}
+
 // If datasourceName is empty, returns all datasources. Otherwise, returns a single-element slice with the specified datasource.
 func getDatasourcesToProcess(storageManager *storage.Manager, datasourceName string) []string {
 	allDatasources := storageManager.GetDatasourceNames()
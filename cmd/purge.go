@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rubiojr/ergs/pkg/config"
+	"github.com/rubiojr/ergs/pkg/core"
+	"github.com/rubiojr/ergs/pkg/storage"
+	"github.com/urfave/cli/v3"
+)
+
+// PurgeCommand creates the purge command
+func PurgeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "purge",
+		Usage: "Delete the blocks matching a search",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "query",
+				Usage: "Search query selecting the blocks to delete (same syntax as search)",
+			},
+			&cli.StringFlag{
+				Name:  "datasource",
+				Usage: "Only delete blocks from this datasource",
+			},
+			&cli.StringFlag{
+				Name:  "before",
+				Usage: "Only delete blocks created before this date (YYYY-MM-DD or relative, e.g. 30d)",
+			},
+			&cli.StringFlag{
+				Name:  "after",
+				Usage: "Only delete blocks created after this date (YYYY-MM-DD or relative, e.g. 30d)",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Only count the blocks that would be deleted",
+			},
+		},
+		Action: func(ctx context.Context, c *cli.Command) error {
+			return purgeData(c.String("config"), c.String("query"), c.String("datasource"), c.String("before"), c.String("after"), c.Bool("dry-run"))
+		},
+	}
+}
+
+// purgeData deletes the blocks matching the query and filters
+func purgeData(configPath, query, datasourceName, before, after string, dryRun bool) error {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	registry := core.GetGlobalRegistry()
+
+	if err := createDatasourcesFromConfig(registry, cfg); err != nil {
+		return fmt.Errorf("creating datasources: %w", err)
+	}
+	defer func() {
+		if err := registry.Close(); err != nil {
+			fmt.Printf("Warning: failed to close registry: %v\n", err)
+		}
+	}()
+
+	configuredDatasources := cfg.ListDatasources()
+	storageManager, err := storage.NewManager(cfg.StorageDir, configuredDatasources...)
+	if err != nil {
+		return fmt.Errorf("creating storage manager: %w", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			fmt.Printf("Warning: failed to close storage manager: %v\n", err)
+		}
+	}()
+
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
+		return fmt.Errorf("initializing storage: %w", err)
+	}
+
+	if err := openGlobalIndex(cfg, storageManager); err != nil {
+		return err
+	}
+
+	if _, exists := registry.GetAllDatasources()[datasourceName]; datasourceName != "" && !exists {
+		return fmt.Errorf("datasource '%s' not found", datasourceName)
+	}
+
+	// --before and --after are the before: and after: query operators
+	terms := []string{query}
+	if before != "" {
+		terms = append(terms, "before:"+before)
+	}
+	if after != "" {
+		terms = append(terms, "after:"+after)
+	}
+	params := storage.SearchParams{Query: strings.TrimSpace(strings.Join(terms, " "))}
+	if datasourceName != "" {
+		params.DatasourceFilters = []string{datasourceName}
+	}
+
+	// Purge resolves the operators, keeping them within --datasource
+	result, err := storageManager.GetSearchService().Purge(params, dryRun)
+	if err != nil {
+		return fmt.Errorf("purging blocks: %w", err)
+	}
+
+	if result.Total == 0 {
+		fmt.Println("No matching blocks")
+		return nil
+	}
+
+	names := make([]string, 0, len(result.Datasources))
+	for name := range result.Datasources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	for _, name := range names {
+		fmt.Printf("%s %d blocks from %s\n", verb, result.Datasources[name], name)
+	}
+	fmt.Printf("Total: %d blocks across %d datasources\n", result.Total, len(names))
+	return nil
+}
@@ -3,7 +3,9 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/rubiojr/ergs/pkg/config"
 	"github.com/rubiojr/ergs/pkg/core"
 	"github.com/rubiojr/ergs/pkg/storage"
@@ -18,7 +20,7 @@ func SearchCommand() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "query",
-				Usage: "Search query",
+				Usage: "Search query (supports after:7d, before:2025-01-01, on:yesterday, source:<name>, type:<type> and tag:<tag>)",
 			},
 			&cli.StringFlag{
 				Name:  "datasource",
@@ -65,18 +67,23 @@ func searchData(configPath, query, datasourceName string, limit int) error {
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
+	if err := openGlobalIndex(cfg, storageManager); err != nil {
+		return err
+	}
+
 	// Use search service for consistent behavior
 	searchService := storageManager.GetSearchService()
 
 	// Build search parameters
 	params := storage.SearchParams{
-		Query: query,
-		Page:  1,
-		Limit: limit,
+		Query:    query,
+		Page:     1,
+		Limit:    limit,
+		Snippets: true,
 	}
 
 	// Add datasource filter if specified
@@ -84,6 +91,11 @@ func searchData(configPath, query, datasourceName string, limit int) error {
 		params.DatasourceFilters = []string{datasourceName}
 	}
 
+	// Map date, source and type operators in the query onto the search parameters
+	if err := storage.ApplyQueryOperators(&params); err != nil {
+		return err
+	}
+
 	results, err := searchService.Search(params)
 	if err != nil {
 		return fmt.Errorf("executing search: %w", err)
@@ -106,6 +118,9 @@ func searchData(configPath, query, datasourceName string, limit int) error {
 
 		for i, block := range blocks {
 			fmt.Printf("%d. %s\n", i+1, block.PrettyText())
+			if snippet := results.Snippet(datasource, block.ID()); snippet != "" {
+				fmt.Printf("   Match: %s\n", highlightTerminalSnippet(snippet))
+			}
 			if i < len(blocks)-1 {
 				fmt.Println()
 			}
@@ -118,3 +133,22 @@ func searchData(configPath, query, datasourceName string, limit int) error {
 
 	return nil
 }
+
+// snippetMatchStyle highlights matched terms in search snippets. lipgloss drops
+// the styling when stdout isn't a terminal.
+var snippetMatchStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("214"))
+
+// highlightTerminalSnippet styles the matched terms of a search snippet for terminal output.
+func highlightTerminalSnippet(snippet string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(snippet, storage.SnippetMatchStart) {
+		match, rest, found := strings.Cut(part, storage.SnippetMatchEnd)
+		if !found {
+			b.WriteString(part)
+			continue
+		}
+		b.WriteString(snippetMatchStyle.Render(match))
+		b.WriteString(rest)
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
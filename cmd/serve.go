@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
@@ -13,8 +15,10 @@ import (
 	"github.com/rubiojr/ergs/pkg/log"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/rubiojr/ergs/pkg/admin"
 	"github.com/rubiojr/ergs/pkg/config"
 	"github.com/rubiojr/ergs/pkg/core"
+	"github.com/rubiojr/ergs/pkg/realtime"
 	"github.com/rubiojr/ergs/pkg/storage"
 	"github.com/rubiojr/ergs/pkg/warehouse"
 	"github.com/urfave/cli/v3"
@@ -28,15 +32,38 @@ func ServeCommand() *cli.Command {
 		Flags: []cli.Flag{
 			&cli.BoolFlag{Name: "debug", Usage: "Enable global debug logging"},
 			&cli.StringFlag{Name: "debug-services", Usage: "Comma-separated list of services/datasources to enable debug for (e.g. github,warehouse,serve)"},
+			&cli.BoolFlag{Name: "web", Usage: "Also serve the web UI and API from this process"},
+			&cli.StringFlag{Name: "web-host", Usage: "Host the web server binds to", Value: "localhost"},
+			&cli.StringFlag{Name: "web-port", Usage: "Port the web server listens on", Value: "8080"},
+			&cli.BoolFlag{Name: "importer", Usage: "Also serve the importer API from this process, storing imported blocks right away"},
+			&cli.StringFlag{Name: "importer-host", Usage: "Host the importer API binds to", Value: "localhost"},
+			&cli.StringFlag{Name: "importer-port", Usage: "Port the importer API listens on", Value: "9090"},
 		},
 		Action: func(ctx context.Context, c *cli.Command) error {
-			return serve(ctx, c.String("config"), c.Bool("debug"), c.String("debug-services"))
+			return serve(ctx, c.String("config"), c.Bool("debug"), c.String("debug-services"), serveOptions{
+				web:          c.Bool("web"),
+				webHost:      c.String("web-host"),
+				webPort:      c.String("web-port"),
+				importer:     c.Bool("importer"),
+				importerHost: c.String("importer-host"),
+				importerPort: c.String("importer-port"),
+			})
 		},
 	}
 }
 
+// serveOptions select the servers 'ergs serve' runs in the warehouse process,
+// sharing its registry and storage
+type serveOptions struct {
+	web              bool
+	webHost, webPort string
+
+	importer                   bool
+	importerHost, importerPort string
+}
+
 // serve starts the scheduler daemon to continuously fetch data
-func serve(ctx context.Context, configPath string, debug bool, debugServices string) error {
+func serve(ctx context.Context, configPath string, debug bool, debugServices string, opts serveOptions) error {
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
@@ -81,9 +108,26 @@ func serve(ctx context.Context, configPath string, debug bool, debugServices str
 		}
 	}()
 
+	if err := openGlobalIndex(cfg, storageManager); err != nil {
+		return err
+	}
+
+	if err := setDatasourceTokenizers(cfg, storageManager); err != nil {
+		return err
+	}
+
 	warehouseConfig := warehouse.Config{
 		OptimizeInterval: time.Hour, // Optimize every hour
-		EventSocketPath:  cfg.EventSocketPath,
+		EventSocketPath:  bridgeSocketPath(cfg),
+		EventLogPath:     eventLogPath(cfg),
+		EventLogSize:     cfg.EventLogSize,
+		WebhookLogPath:   webhookLogPath(cfg),
+	}
+	if eb := cfg.EventBridge; eb != nil {
+		warehouseConfig.EventListenAddr = eb.Listen
+		warehouseConfig.EventTLSCert = eb.TLSCert
+		warehouseConfig.EventTLSKey = eb.TLSKey
+		warehouseConfig.EventToken = eb.Token
 	}
 	wh := warehouse.NewWarehouse(warehouseConfig, storageManager)
 	defer func() {
@@ -100,6 +144,61 @@ func serve(ctx context.Context, configPath string, debug bool, debugServices str
 		if err := wh.AddDatasourceWithInterval(name, ds, interval); err != nil {
 			return fmt.Errorf("adding datasource to warehouse: %w", err)
 		}
+		if err := configureWarehouseDatasource(wh, cfg, name); err != nil {
+			return err
+		}
+	}
+	if err := configureSavedSearches(wh, cfg); err != nil {
+		return err
+	}
+	if err := configureWebhooks(wh, cfg); err != nil {
+		return err
+	}
+
+	// The web server gets the warehouse events in-process, and the importer
+	// stores blocks through the warehouse, so neither needs the event
+	// bridge or the importer datasource
+	var (
+		servers        []*http.Server
+		importerServer *ImporterServer
+	)
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		for _, server := range servers {
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				srvLogger.Warnf("failed to shut down server on %s: %v", server.Addr, err)
+			}
+		}
+		if importerServer != nil {
+			if err := importerServer.Close(); err != nil {
+				srvLogger.Warnf("failed to close importer database: %v", err)
+			}
+		}
+	}()
+	if opts.web {
+		hub := realtime.NewFirehoseHub(64)
+		wh.SetFirehoseHub(hub)
+		webServer := newWebServer(cfg, registry, storageManager, hub)
+		server, err := startServer(opts.webHost, opts.webPort, webServer.Handler())
+		if err != nil {
+			return fmt.Errorf("starting web server: %w", err)
+		}
+		servers = append(servers, server)
+		logWebEndpoints(opts.webHost, opts.webPort)
+	}
+	if opts.importer {
+		importerServer, err = newImporterServer(cfg, wh.StoreBlock)
+		if err != nil {
+			return err
+		}
+		host, port := importerAddress(cfg, opts.importerHost, opts.importerPort)
+		server, err := startServer(host, port, importerServer.Handler())
+		if err != nil {
+			return fmt.Errorf("starting importer server: %w", err)
+		}
+		servers = append(servers, server)
+		importerServer.logEndpoints(host, port)
 	}
 
 	// Create a cancellable context for the warehouse
@@ -117,6 +216,18 @@ func serve(ctx context.Context, configPath string, debug bool, debugServices str
 		return fmt.Errorf("starting warehouse: %w", err)
 	}
 
+	// Local control API for 'ergs ctl'
+	adminServer := admin.NewServer(wh)
+	if err := adminServer.Listen(adminSocketPath(cfg)); err != nil {
+		srvLogger.Warnf("admin API disabled: %v", err)
+	} else {
+		defer func() {
+			if err := adminServer.Close(); err != nil {
+				srvLogger.Warnf("failed to close admin API: %v", err)
+			}
+		}()
+	}
+
 	fmt.Println("Warehouse started. Press Ctrl+C to stop, send SIGHUP to reload, or modify config file for automatic reload.")
 
 	// Configuration reload state
@@ -149,7 +260,7 @@ func serve(ctx context.Context, configPath string, debug bool, debugServices str
 			switch sig {
 			case syscall.SIGHUP:
 				srvLogger.Debugf("Received SIGHUP, reloading configuration...")
-				if err := reloadConfiguration(configPath, registry, wh, &cfgMutex, &currentConfig); err != nil {
+				if err := reloadConfiguration(configPath, registry, storageManager, wh, &cfgMutex, &currentConfig); err != nil {
 					srvLogger.Warnf("Failed to reload configuration: %v", err)
 				} else {
 					srvLogger.Debugf("Configuration reloaded successfully")
@@ -188,7 +299,7 @@ func serve(ctx context.Context, configPath string, debug bool, debugServices str
 					time.Sleep(100 * time.Millisecond)
 				}
 
-				if err := reloadConfiguration(configPath, registry, wh, &cfgMutex, &currentConfig); err != nil {
+				if err := reloadConfiguration(configPath, registry, storageManager, wh, &cfgMutex, &currentConfig); err != nil {
 					srvLogger.Warnf("Failed to reload configuration after file change: %v", err)
 				} else {
 					srvLogger.Debugf("Configuration reloaded successfully after file change")
@@ -203,8 +314,31 @@ func serve(ctx context.Context, configPath string, debug bool, debugServices str
 	}
 }
 
+// startServer serves handler on host:port in the background. Shutting the
+// server down cancels the context of its requests, ending firehose streams.
+func startServer(host, port string, handler http.Handler) (*http.Server, error) {
+	baseCtx, cancel := context.WithCancel(context.Background())
+	server := &http.Server{
+		Addr:        fmt.Sprintf("%s:%s", host, port),
+		Handler:     handler,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+	server.RegisterOnShutdown(cancel)
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.ForService("serve").Warnf("server on %s failed: %v", server.Addr, err)
+		}
+	}()
+	return server, nil
+}
+
 // reloadConfiguration handles the configuration reload process
-func reloadConfiguration(configPath string, registry *core.Registry, wh *warehouse.Warehouse, cfgMutex *sync.RWMutex, currentConfig **config.Config) error {
+func reloadConfiguration(configPath string, registry *core.Registry, storageManager *storage.Manager, wh *warehouse.Warehouse, cfgMutex *sync.RWMutex, currentConfig **config.Config) error {
 	cfgMutex.Lock()
 	defer cfgMutex.Unlock()
 
@@ -214,6 +348,21 @@ func reloadConfiguration(configPath string, registry *core.Registry, wh *warehou
 		return fmt.Errorf("loading new config: %w", err)
 	}
 
+	// Tokenizers are applied as the datasources are added back
+	if err := setDatasourceTokenizers(newCfg, storageManager); err != nil {
+		return err
+	}
+
+	// Checked before touching the running datasources
+	searches, err := savedSearches(newCfg)
+	if err != nil {
+		return err
+	}
+	hooks, err := configWebhooks(newCfg)
+	if err != nil {
+		return err
+	}
+
 	oldCfg := *currentConfig
 
 	// Remove all existing datasources
@@ -234,6 +383,11 @@ func reloadConfiguration(configPath string, registry *core.Registry, wh *warehou
 		}
 	}
 
+	wh.SetSavedSearches(searches)
+	if err := wh.SetWebhooks(hooks); err != nil {
+		return err
+	}
+
 	// Update current config
 	*currentConfig = newCfg
 
@@ -292,6 +446,9 @@ func addDatasourceToWarehouse(wh *warehouse.Warehouse, registry *core.Registry,
 	if err := wh.AddDatasourceWithInterval(name, ds, interval); err != nil {
 		return fmt.Errorf("adding datasource %s to warehouse: %w", name, err)
 	}
+	if err := configureWarehouseDatasource(wh, cfg, name); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -50,7 +50,7 @@ func showStats(configPath string) error {
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rubiojr/ergs/pkg/config"
+	"github.com/rubiojr/ergs/pkg/core"
+	"github.com/rubiojr/ergs/pkg/storage"
+	"github.com/urfave/cli/v3"
+)
+
+// blockFlags select the block to annotate
+func blockFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "datasource",
+			Usage:    "Datasource of the block",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "id",
+			Usage:    "Block ID",
+			Required: true,
+		},
+	}
+}
+
+// TagCommand creates the tag command with subcommands
+func TagCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "tag",
+		Usage: "Tag blocks (search them with tag:<tag>)",
+		Commands: []*cli.Command{
+			{
+				Name:      "add",
+				Usage:     "Add tags to a block",
+				ArgsUsage: "<tag>...",
+				Flags:     blockFlags(),
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if c.Args().Len() == 0 {
+						return fmt.Errorf("no tags given")
+					}
+					return withBlockStorage(c.String("config"), c.String("datasource"), func(_ *storage.Manager, st *storage.GenericStorage) error {
+						if err := st.AddTags(c.String("id"), c.Args().Slice()); err != nil {
+							return annotationError(err, c.String("datasource"), c.String("id"))
+						}
+						return printAnnotations(st, c.String("id"))
+					})
+				},
+			},
+			{
+				Name:      "remove",
+				Usage:     "Remove tags from a block",
+				ArgsUsage: "<tag>...",
+				Flags:     blockFlags(),
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if c.Args().Len() == 0 {
+						return fmt.Errorf("no tags given")
+					}
+					return withBlockStorage(c.String("config"), c.String("datasource"), func(_ *storage.Manager, st *storage.GenericStorage) error {
+						if _, err := st.RemoveTags(c.String("id"), c.Args().Slice()); err != nil {
+							return err
+						}
+						return printAnnotations(st, c.String("id"))
+					})
+				},
+			},
+			{
+				Name:  "show",
+				Usage: "Show the tags and note of a block",
+				Flags: blockFlags(),
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return withBlockStorage(c.String("config"), c.String("datasource"), func(_ *storage.Manager, st *storage.GenericStorage) error {
+						return printAnnotations(st, c.String("id"))
+					})
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "List the tags in use and how many blocks have each",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return withBlockStorage(c.String("config"), "", func(m *storage.Manager, _ *storage.GenericStorage) error {
+						tags, err := m.ListTags()
+						if err != nil {
+							return err
+						}
+						if len(tags) == 0 {
+							fmt.Println("No tags")
+							return nil
+						}
+						for _, tc := range tags {
+							fmt.Printf("%-30s %d\n", tc.Tag, tc.Count)
+						}
+						return nil
+					})
+				},
+			},
+		},
+	}
+}
+
+// NoteCommand creates the note command
+func NoteCommand() *cli.Command {
+	flags := append(blockFlags(), &cli.BoolFlag{
+		Name:  "clear",
+		Usage: "Remove the note",
+	})
+	return &cli.Command{
+		Name:      "note",
+		Usage:     "Set the note of a block",
+		ArgsUsage: "<note>",
+		Flags:     flags,
+		Action: func(ctx context.Context, c *cli.Command) error {
+			note := strings.Join(c.Args().Slice(), " ")
+			if note == "" && !c.Bool("clear") {
+				return fmt.Errorf("no note given, use --clear to remove it")
+			}
+			return withBlockStorage(c.String("config"), c.String("datasource"), func(_ *storage.Manager, st *storage.GenericStorage) error {
+				if err := st.SetNote(c.String("id"), note); err != nil {
+					return annotationError(err, c.String("datasource"), c.String("id"))
+				}
+				return printAnnotations(st, c.String("id"))
+			})
+		},
+	}
+}
+
+// withBlockStorage opens the storage and calls fn with the storage of
+// datasourceName (nil when datasourceName is empty).
+func withBlockStorage(configPath, datasourceName string, fn func(*storage.Manager, *storage.GenericStorage) error) error {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	registry := core.GetGlobalRegistry()
+
+	if err := createDatasourcesFromConfig(registry, cfg); err != nil {
+		return fmt.Errorf("creating datasources: %w", err)
+	}
+	defer func() {
+		if err := registry.Close(); err != nil {
+			fmt.Printf("Warning: failed to close registry: %v\n", err)
+		}
+	}()
+
+	configuredDatasources := cfg.ListDatasources()
+	storageManager, err := storage.NewManager(cfg.StorageDir, configuredDatasources...)
+	if err != nil {
+		return fmt.Errorf("creating storage manager: %w", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			fmt.Printf("Warning: failed to close storage manager: %v\n", err)
+		}
+	}()
+
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
+		return fmt.Errorf("initializing storage: %w", err)
+	}
+
+	var st *storage.GenericStorage
+	if datasourceName != "" {
+		if _, exists := registry.GetAllDatasources()[datasourceName]; !exists {
+			return fmt.Errorf("datasource '%s' not found", datasourceName)
+		}
+		if st, err = storageManager.GetStorage(datasourceName); err != nil {
+			return fmt.Errorf("getting storage for %s: %w", datasourceName, err)
+		}
+	}
+	return fn(storageManager, st)
+}
+
+// annotationError explains annotation errors for missing blocks
+func annotationError(err error, datasourceName, id string) error {
+	if errors.Is(err, storage.ErrBlockNotFound) {
+		return fmt.Errorf("block '%s' not found in datasource '%s'", id, datasourceName)
+	}
+	return err
+}
+
+// printAnnotations prints the tags and note of a block
+func printAnnotations(st *storage.GenericStorage, id string) error {
+	annotations, err := st.GetAnnotations([]string{id})
+	if err != nil {
+		return err
+	}
+	a := annotations[id]
+	if a.IsZero() {
+		fmt.Println("No tags or note")
+		return nil
+	}
+	if len(a.Tags) > 0 {
+		fmt.Printf("Tags: %s\n", strings.Join(a.Tags, ", "))
+	}
+	if a.Note != "" {
+		fmt.Printf("Note: %s\n", a.Note)
+	}
+	return nil
+}
@@ -116,7 +116,7 @@ func showTodayBlocks(configPath string, limit int, noPager bool, datasourceFilte
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
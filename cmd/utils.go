@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
+	"path/filepath"
 
 	"github.com/pelletier/go-toml/v2"
+	"github.com/rubiojr/ergs/pkg/admin"
+	"github.com/rubiojr/ergs/pkg/alerts"
 	"github.com/rubiojr/ergs/pkg/config"
 	"github.com/rubiojr/ergs/pkg/core"
 	"github.com/rubiojr/ergs/pkg/storage"
+	"github.com/rubiojr/ergs/pkg/warehouse"
+	"github.com/rubiojr/ergs/pkg/webhooks"
 )
 
 // createDatasourcesFromConfig creates and configures datasources from the config
@@ -67,12 +73,18 @@ func convertRawConfigToType(ds core.Datasource, rawConfig interface{}) (interfac
 	return configType, nil
 }
 
-// initializeDatasourceStorage initializes storage for all registered datasources
-func initializeDatasourceStorage(registry *core.Registry, storageManager *storage.Manager) error {
+// initializeDatasourceStorage opens the storage of all registered datasources
+// for commands not running the warehouse. Schema and tokenizer changes are not
+// applied, they need 'ergs migrate' (see storage.Manager.OpenDatasourceStorage).
+func initializeDatasourceStorage(cfg *config.Config, registry *core.Registry, storageManager *storage.Manager) error {
+	if err := setDatasourceTokenizers(cfg, storageManager); err != nil {
+		return err
+	}
+
 	datasources := registry.GetAllDatasources()
 	for name, ds := range datasources {
 		schema := ds.Schema()
-		if err := storageManager.InitializeDatasourceStorage(name, schema); err != nil {
+		if err := storageManager.OpenDatasourceStorage(name, schema); err != nil {
 			return fmt.Errorf("initializing storage for %s: %w", name, err)
 		}
 
@@ -82,3 +94,208 @@ func initializeDatasourceStorage(registry *core.Registry, storageManager *storag
 
 	return nil
 }
+
+// setDatasourceTokenizers configures the FTS tokenizer of every datasource in the
+// config. They are applied when the warehouse initializes the datasource
+// storage, or by 'ergs migrate'.
+func setDatasourceTokenizers(cfg *config.Config, storageManager *storage.Manager) error {
+	for _, name := range cfg.ListDatasources() {
+		if err := storageManager.SetTokenizer(name, cfg.GetDatasourceTokenizer(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// datasourceRetention returns the retention policy of a datasource in the config.
+func datasourceRetention(cfg *config.Config, name string) storage.RetentionPolicy {
+	var policy storage.RetentionPolicy
+	retention := cfg.GetDatasourceRetention(name)
+	if retention == nil {
+		return policy
+	}
+	if retention.MaxAge != nil {
+		policy.MaxAge = retention.MaxAge.Duration
+	}
+	if retention.DownsampleAfter != nil {
+		policy.DownsampleAfter = retention.DownsampleAfter.Duration
+	}
+	policy.MaxBlocks = retention.MaxBlocks
+	return policy
+}
+
+// configureWarehouseDatasource applies the retention policy, exclusion rules
+// and processors of a datasource in the config to the warehouse.
+func configureWarehouseDatasource(wh *warehouse.Warehouse, cfg *config.Config, name string) error {
+	if err := wh.SetRetentionPolicy(name, datasourceRetention(cfg, name)); err != nil {
+		return err
+	}
+	exclusions, err := datasourceExclusions(cfg, name)
+	if err != nil {
+		return err
+	}
+	wh.SetExclusionRules(name, exclusions)
+	processors, err := datasourceProcessors(cfg, name)
+	if err != nil {
+		return err
+	}
+	wh.SetProcessors(name, processors)
+	return nil
+}
+
+// datasourceExclusions returns the compiled exclusion rules of a datasource in
+// the config (nil when it has none).
+func datasourceExclusions(cfg *config.Config, name string) (*storage.ExclusionRules, error) {
+	exclude := cfg.GetDatasourceExclusions(name)
+	if exclude == nil {
+		return nil, nil
+	}
+	rules, err := storage.NewExclusionRules(exclude.Domains, exclude.URLPatterns, exclude.Keywords)
+	if err != nil {
+		return nil, fmt.Errorf("exclusion rules for datasource %s: %w", name, err)
+	}
+	return rules, nil
+}
+
+// datasourceProcessors builds the processor pipeline of a datasource in the config
+func datasourceProcessors(cfg *config.Config, name string) (core.Pipeline, error) {
+	var pipeline core.Pipeline
+	for i, table := range cfg.GetDatasourceProcessors(name) {
+		processorType, _ := table["type"].(string)
+		options := make(map[string]interface{}, len(table))
+		for key, value := range table {
+			if key != "type" {
+				options[key] = value
+			}
+		}
+		processor, err := core.NewProcessor(processorType, options)
+		if err != nil {
+			return nil, fmt.Errorf("processor %d (%s) of datasource %s: %w", i+1, processorType, name, err)
+		}
+		pipeline = append(pipeline, processor)
+	}
+	return pipeline, nil
+}
+
+// configureSavedSearches sets the saved searches in the config on the warehouse
+func configureSavedSearches(wh *warehouse.Warehouse, cfg *config.Config) error {
+	searches, err := savedSearches(cfg)
+	if err != nil {
+		return err
+	}
+	wh.SetSavedSearches(searches)
+	return nil
+}
+
+// savedSearches builds the saved searches in the config and their sinks
+func savedSearches(cfg *config.Config) ([]alerts.SavedSearch, error) {
+	var searches []alerts.SavedSearch
+	names := make(map[string]bool)
+	for i, sc := range cfg.SavedSearches {
+		if sc.Name == "" {
+			return nil, fmt.Errorf("saved search %d has no name", i+1)
+		}
+		if names[sc.Name] {
+			return nil, fmt.Errorf("duplicate saved search %s", sc.Name)
+		}
+		names[sc.Name] = true
+		if err := storage.ValidateQuery(sc.Query); err != nil {
+			return nil, fmt.Errorf("saved search %s: %w", sc.Name, err)
+		}
+		if len(sc.Sinks) == 0 {
+			return nil, fmt.Errorf("saved search %s has no sinks", sc.Name)
+		}
+
+		search := alerts.SavedSearch{Name: sc.Name, Query: sc.Query}
+		for j, sink := range sc.Sinks {
+			var s alerts.Sink
+			switch sink.Type {
+			case "webhook":
+				if sink.URL == "" {
+					return nil, fmt.Errorf("sink %d of saved search %s: webhook needs a url", j+1, sc.Name)
+				}
+				s = &alerts.WebhookSink{URL: sink.URL}
+			case "exec":
+				if len(sink.Command) == 0 {
+					return nil, fmt.Errorf("sink %d of saved search %s: exec needs a command", j+1, sc.Name)
+				}
+				s = &alerts.ExecSink{Command: sink.Command}
+			case "file":
+				if sink.Path == "" {
+					return nil, fmt.Errorf("sink %d of saved search %s: file needs a path", j+1, sc.Name)
+				}
+				s = &alerts.FileSink{Path: sink.Path}
+			default:
+				return nil, fmt.Errorf("sink %d of saved search %s: unknown type %q (use webhook, exec or file)", j+1, sc.Name, sink.Type)
+			}
+			search.Sinks = append(search.Sinks, s)
+		}
+		searches = append(searches, search)
+	}
+	return searches, nil
+}
+
+// configureWebhooks sets the webhooks in the config on the warehouse
+func configureWebhooks(wh *warehouse.Warehouse, cfg *config.Config) error {
+	hooks, err := configWebhooks(cfg)
+	if err != nil {
+		return err
+	}
+	return wh.SetWebhooks(hooks)
+}
+
+// configWebhooks builds the webhooks in the config
+func configWebhooks(cfg *config.Config) ([]webhooks.Webhook, error) {
+	var hooks []webhooks.Webhook
+	names := make(map[string]bool)
+	for i, wc := range cfg.Webhooks {
+		if wc.Name == "" {
+			return nil, fmt.Errorf("webhook %d has no name", i+1)
+		}
+		if names[wc.Name] {
+			return nil, fmt.Errorf("duplicate webhook %s", wc.Name)
+		}
+		names[wc.Name] = true
+		if u, err := url.Parse(wc.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhook %s: invalid url %q", wc.Name, wc.URL)
+		}
+		if wc.MaxAttempts < 0 {
+			return nil, fmt.Errorf("webhook %s: max_attempts can't be negative", wc.Name)
+		}
+		hooks = append(hooks, webhooks.Webhook{
+			Name:        wc.Name,
+			URL:         wc.URL,
+			Secret:      wc.Secret,
+			Datasources: wc.Datasources,
+			Types:       wc.Types,
+			MaxAttempts: wc.MaxAttempts,
+		})
+	}
+	return hooks, nil
+}
+
+// webhookLogPath returns the path of the webhook delivery log
+func webhookLogPath(cfg *config.Config) string {
+	return filepath.Join(cfg.StorageDir, webhooks.LogFile)
+}
+
+// adminSocketPath returns the path of the admin API socket of 'ergs serve'
+func adminSocketPath(cfg *config.Config) string {
+	return filepath.Join(cfg.StorageDir, admin.SocketFile)
+}
+
+// eventLogPath returns the path of the log of recent event bridge events
+func eventLogPath(cfg *config.Config) string {
+	return filepath.Join(cfg.StorageDir, warehouse.EventLogFile)
+}
+
+// openGlobalIndex opens the global search index when it is enabled in the config
+func openGlobalIndex(cfg *config.Config, storageManager *storage.Manager) error {
+	if !cfg.GlobalIndex {
+		return nil
+	}
+	if err := storageManager.OpenGlobalIndex(); err != nil {
+		return fmt.Errorf("opening global index: %w", err)
+	}
+	return nil
+}
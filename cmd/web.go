@@ -3,13 +3,16 @@ package cmd
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"sort"
 
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
@@ -64,9 +67,12 @@ type WebServer struct {
 	pipelineService  *render.Service
 	apiServer        *api.Server
 
-	// Realtime firehose integration (optional; active when EventSocketPath configured)
+	// Realtime firehose integration, fed by the warehouse directly when
+	// running inside 'ergs serve --web', by the event bridge when
+	// EventSocketPath is configured and by the database watcher otherwise
 	firehoseHub    *realtime.FirehoseHub
 	bridgeConsumer *BridgeConsumer
+	dbWatcher      *DBWatcher
 }
 
 // startWebServer starts the web server with both API and UI
@@ -98,86 +104,55 @@ func startWebServer(ctx context.Context, configPath, host, port string) error {
 		}
 	}()
 
-	if err := initializeDatasourceStorage(registry, storageManager); err != nil {
+	if err := initializeDatasourceStorage(cfg, registry, storageManager); err != nil {
 		return fmt.Errorf("initializing storage: %w", err)
 	}
 
-	// Initialize renderer registry with auto-registered renderers
-	rendererRegistry := render.GetGlobalRegistry()
-	pipelineService := render.NewService(rendererRegistry)
-
-	apiServer := api.NewServer(registry, storageManager)
-	apiServer.SetRendererService(pipelineService)
+	if err := openGlobalIndex(cfg, storageManager); err != nil {
+		return err
+	}
 
 	// Initialize realtime firehose hub & bridge consumer (if configured)
 	var (
 		firehoseHub    *realtime.FirehoseHub
 		bridgeConsumer *BridgeConsumer
+		dbWatcher      *DBWatcher
 	)
 	if cfg.EventSocketPath != "" {
 		firehoseHub = realtime.NewFirehoseHub(64)
 		bridgeConsumer = NewBridgeConsumer(cfg.EventSocketPath, 256)
+		// Resume after the last event seen, also across restarts
+		bridgeConsumer.SetStatePath(filepath.Join(cfg.StorageDir, bridgeConsumerStateFile))
+		bridgeConsumer.SetDialOptions(bridgeDialOptions(cfg))
 		bridgeConsumer.Start()
 		AttachConsumer(ctx, bridgeConsumer, firehoseHub)
 		log.Printf("Realtime firehose: enabled (socket=%s)", cfg.EventSocketPath)
-		// Inject hub into API server so future WS endpoint can use it
-		apiServer.SetFirehoseHub(firehoseHub)
 	} else {
-		log.Printf("Realtime firehose: disabled (event_socket_path not set in config)")
-	}
-
-	webServer := &WebServer{
-		registry:         registry,
-		storageManager:   storageManager,
-		config:           cfg,
-		rendererRegistry: rendererRegistry,
-		pipelineService:  pipelineService,
-		apiServer:        apiServer,
-		firehoseHub:      firehoseHub,
-		bridgeConsumer:   bridgeConsumer,
+		// Without the bridge, follow the blocks the warehouse stores in the
+		// shared storage directory
+		firehoseHub = realtime.NewFirehoseHub(64)
+		dbWatcher, err = NewDBWatcher(storageManager, configuredDatasources, firehoseHub)
+		if err != nil {
+			log.Printf("Realtime firehose: disabled (watching databases: %v)", err)
+			firehoseHub = nil
+		} else {
+			dbWatcher.Start(ctx)
+			log.Printf("Realtime firehose: enabled (watching databases in %s)", cfg.StorageDir)
+		}
 	}
 
-	mux := http.NewServeMux()
-
-	// API routes
-	webServer.apiServer.RegisterRoutes(mux)
-
-	// Web UI routes
-	mux.HandleFunc("/", webServer.handleHome)
-	mux.HandleFunc("/search", webServer.handleSearch)
-	mux.HandleFunc("/firehose", webServer.handleFirehose)
-	mux.HandleFunc("/datasources", webServer.handleDatasources)
-	mux.HandleFunc("/datasource/", webServer.handleDatasource)
-
-	// Static assets
-	mux.HandleFunc("/static/", webServer.handleStatic)
-
-	// Add CORS middleware
-	handler := api.CorsMiddleware(mux)
+	webServer := newWebServer(cfg, registry, storageManager, firehoseHub)
+	webServer.bridgeConsumer = bridgeConsumer
+	webServer.dbWatcher = dbWatcher
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf("%s:%s", host, port),
-		Handler: handler,
+		Handler: webServer.Handler(),
 	}
 
 	// Start server in goroutine
 	go func() {
-		log.Printf("Starting web server on http://%s:%s", host, port)
-		log.Printf("Available endpoints:")
-		log.Printf("  Web UI:")
-		log.Printf("    GET / - Home page with datasource overview")
-		log.Printf("    GET /search - Search across all datasources")
-		log.Printf("    GET /firehose - Latest blocks across all datasources")
-		log.Printf("    GET /datasources - List all datasources")
-		log.Printf("    GET /datasource/{name} - Browse specific datasource")
-		log.Printf("  API:")
-		log.Printf("    GET /api/datasources - List all datasources")
-		log.Printf("    GET /api/datasources/{name} - List blocks from a datasource")
-		log.Printf("    GET /api/search - Search across all datasources")
-		log.Printf("    GET /api/firehose - Latest blocks across all datasources")
-		log.Printf("    GET /api/stats - Get storage statistics")
-		log.Printf("    GET /health - Health check")
-
+		logWebEndpoints(host, port)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed to start: %v", err)
 		}
@@ -189,12 +164,90 @@ func startWebServer(ctx context.Context, configPath, host, port string) error {
 	<-sigCh
 
 	log.Println("Shutting down web server...")
+	if bridgeConsumer != nil {
+		bridgeConsumer.Stop()
+	}
+	if dbWatcher != nil {
+		dbWatcher.Stop()
+	}
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	return server.Shutdown(shutdownCtx)
 }
 
+// newWebServer returns the web UI and API server of the datasources in
+// registry and storageManager. Live firehose updates are streamed from hub,
+// polled from storage if it is nil.
+func newWebServer(cfg *config.Config, registry *core.Registry, storageManager *storage.Manager, hub *realtime.FirehoseHub) *WebServer {
+	// Initialize renderer registry with auto-registered renderers
+	rendererRegistry := render.GetGlobalRegistry()
+	pipelineService := render.NewService(rendererRegistry)
+
+	apiServer := api.NewServer(registry, storageManager)
+	apiServer.SetRendererService(pipelineService)
+	if cfg.API != nil {
+		apiServer.SetAPIKey(cfg.API.APIKey)
+	}
+	if hub != nil {
+		apiServer.SetFirehoseHub(hub)
+	}
+
+	return &WebServer{
+		registry:         registry,
+		storageManager:   storageManager,
+		config:           cfg,
+		rendererRegistry: rendererRegistry,
+		pipelineService:  pipelineService,
+		apiServer:        apiServer,
+		firehoseHub:      hub,
+	}
+}
+
+// Handler returns the HTTP handler serving the web UI and the API.
+func (s *WebServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	// API routes
+	s.apiServer.RegisterRoutes(mux)
+
+	// Web UI routes
+	mux.HandleFunc("/", s.handleHome)
+	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/firehose", s.handleFirehose)
+	mux.HandleFunc("/inbox", s.handleInbox)
+	mux.HandleFunc("/datasources", s.handleDatasources)
+	mux.HandleFunc("/datasource/", s.handleDatasource)
+
+	// Static assets
+	mux.HandleFunc("/static/", s.handleStatic)
+
+	// Add CORS middleware
+	return api.CorsMiddleware(mux)
+}
+
+// logWebEndpoints logs the address and endpoints of the web server
+func logWebEndpoints(host, port string) {
+	log.Printf("Starting web server on http://%s:%s", host, port)
+	log.Printf("Available endpoints:")
+	log.Printf("  Web UI:")
+	log.Printf("    GET / - Home page with datasource overview")
+	log.Printf("    GET /search - Search across all datasources")
+	log.Printf("    GET /firehose - Latest blocks across all datasources")
+	log.Printf("    GET /inbox - Blocks ingested since they were last looked at")
+	log.Printf("    GET /datasources - List all datasources")
+	log.Printf("    GET /datasource/{name} - Browse specific datasource")
+	log.Printf("  API:")
+	log.Printf("    GET /api/datasources - List all datasources")
+	log.Printf("    GET /api/datasources/{name} - List blocks from a datasource")
+	log.Printf("    GET /api/search - Search across all datasources")
+	log.Printf("    GET /api/firehose - Latest blocks across all datasources")
+	log.Printf("    GET /api/firehose/sse - Stream new blocks as Server-Sent Events")
+	log.Printf("    GET /api/stats - Get storage statistics")
+	log.Printf("    GET /api/tags - List block tags")
+	log.Printf("    GET /health - Health check")
+}
+
 // Web UI Handlers
 
 // handleHome serves the main page
@@ -299,8 +352,10 @@ func (s *WebServer) handleHome(w http.ResponseWriter, r *http.Request) {
 // handleSearch handles search requests with distributed results
 func (s *WebServer) handleSearch(w http.ResponseWriter, r *http.Request) {
 	// Parse search parameters using search service
+	// Invalid query operators are reported on the search page
 	params, err := storage.ParseSearchParams(r.URL.Query())
-	if err != nil {
+	var queryErr *storage.QueryError
+	if err != nil && !errors.As(err, &queryErr) {
 		http.Error(w, fmt.Sprintf("Invalid parameters: %v", err), http.StatusBadRequest)
 		return
 	}
@@ -310,20 +365,31 @@ func (s *WebServer) handleSearch(w http.ResponseWriter, r *http.Request) {
 		params.Limit = 100
 	}
 
+	// The form keeps showing the query as typed, operators included; the date
+	// inputs and datasource picker only reflect their own form fields.
+	formValues := r.URL.Query()
+	query := formValues.Get("q")
+	formValues.Del("q")
+	formParams, _ := storage.ParseSearchParams(formValues)
+
 	data := types.PageData{
 		Title:               "Search - Ergs",
-		Query:               params.Query,
-		SelectedDatasources: params.DatasourceFilters,
+		Query:               query,
+		SelectedDatasources: formParams.DatasourceFilters,
 		Datasources:         shared.GetDatasourceList(s.registry, s.storageManager),
 		CurrentPage:         params.Page,
 		PageSize:            params.Limit,
-		StartDate:           params.StartDate,
-		EndDate:             params.EndDate,
+		StartDate:           formParams.StartDate,
+		EndDate:             formParams.EndDate,
 		Version:             version.APIVersion(),
 	}
 
 	// Web allows empty queries (shows search page)
-	if params.Query != "" {
+	params.Snippets = true
+	params.Facets = true
+	if queryErr != nil {
+		data.Error = formatSearchError(queryErr)
+	} else if query != "" {
 		// Perform search using search service
 		searchService := s.storageManager.GetSearchService()
 		results, err := searchService.Search(params)
@@ -333,9 +399,15 @@ func (s *WebServer) handleSearch(w http.ResponseWriter, r *http.Request) {
 			// Still render the search page with the error message
 		} else {
 			data.Results = s.convertBlocksToWebBlocks(results.Results)
+			for datasource, blocks := range data.Results {
+				for i := range blocks {
+					blocks[i].Snippet = storage.SnippetHTML(results.Snippet(datasource, blocks[i].ID))
+				}
+			}
 			data.TotalCount = results.TotalCount
 			data.HasNextPage = results.HasMore
 			data.TotalPages = results.TotalPages
+			data.Facets = buildWebFacets(results.Facets, r.URL.Query())
 		}
 	}
 
@@ -504,6 +576,119 @@ func (s *WebServer) handleFirehose(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleInbox renders the blocks ingested since they were last looked at.
+// Blocks are marked read or archived through the API (see script.js).
+func (s *WebServer) handleInbox(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	state, err := storage.ParseInboxState(query.Get("state"))
+	if err != nil {
+		state = storage.InboxUnread
+	}
+	page := 1
+	if parsed, err := strconv.Atoi(query.Get("page")); err == nil && parsed > 0 {
+		page = parsed
+	}
+	datasourceName := query.Get("datasource")
+	if _, exists := s.registry.GetAllDatasources()[datasourceName]; !exists {
+		datasourceName = ""
+	}
+
+	data := types.PageData{
+		Title:       "Inbox - Ergs",
+		Datasource:  datasourceName,
+		InboxState:  string(state),
+		CurrentPage: page,
+		PageSize:    30,
+		Version:     version.APIVersion(),
+	}
+
+	params := storage.InboxParams{State: state, Page: page, Limit: data.PageSize}
+	if datasourceName != "" {
+		params.Datasources = []string{datasourceName}
+	}
+	result, err := s.storageManager.Inbox(params)
+	if err != nil {
+		data.Error = fmt.Sprintf("Failed to load inbox: %v", err)
+	} else {
+		for _, item := range result.Items {
+			data.InboxItems = append(data.InboxItems, types.InboxItem{
+				Block:      s.convertBlockToWebBlock(item.Block),
+				Datasource: item.Datasource,
+				IngestedAt: item.IngestedAt,
+			})
+		}
+		data.TotalCount = result.TotalCount
+		data.HasNextPage = result.HasMore
+		data.Facets = s.inboxFacets(state, datasourceName, result)
+	}
+
+	if err := components.Inbox(data).Render(r.Context(), w); err != nil {
+		http.Error(w, fmt.Sprintf("Template error: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// inboxFacets builds the inbox state and datasource filters, rendered like
+// the search facets.
+func (s *WebServer) inboxFacets(state storage.InboxState, datasourceName string, result *storage.InboxResult) *types.SearchFacets {
+	inboxURL := func(state storage.InboxState, datasourceName string) string {
+		params := url.Values{}
+		params.Set("state", string(state))
+		if datasourceName != "" {
+			params.Set("datasource", datasourceName)
+		}
+		return "/inbox?" + params.Encode()
+	}
+
+	var datasources []string
+	if datasourceName != "" {
+		datasources = []string{datasourceName}
+	}
+	states := types.FacetGroup{Title: "State"}
+	for _, st := range []storage.InboxState{storage.InboxUnread, storage.InboxRead, storage.InboxArchived} {
+		count := result.TotalCount
+		if st != state {
+			var err error
+			if count, err = s.storageManager.CountInbox(st, datasources); err != nil {
+				log.Printf("Error counting %s inbox blocks: %v", st, err)
+			}
+		}
+		states.Values = append(states.Values, types.FacetValue{
+			Label:  string(st),
+			Count:  count,
+			URL:    inboxURL(st, datasourceName),
+			Active: st == state,
+		})
+	}
+
+	total := 0
+	names := make([]string, 0, len(result.Counts))
+	for name, count := range result.Counts {
+		total += count
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sources := types.FacetGroup{Title: "Datasource"}
+	sources.Values = append(sources.Values, types.FacetValue{
+		Label:  "all",
+		Count:  total,
+		URL:    inboxURL(state, ""),
+		Active: datasourceName == "",
+	})
+	for _, name := range names {
+		sources.Values = append(sources.Values, types.FacetValue{
+			Label:  name,
+			Count:  result.Counts[name],
+			URL:    inboxURL(state, name),
+			Active: name == datasourceName,
+		})
+	}
+
+	return &types.SearchFacets{
+		Total:  result.TotalCount,
+		Groups: []types.FacetGroup{states, sources},
+	}
+}
+
 // handleStatic serves static assets from embedded files
 func (s *WebServer) handleStatic(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
@@ -588,9 +773,127 @@ func (s *WebServer) convertBlockToWebBlock(block core.Block) types.WebBlock {
 		FormattedText: renderedHTML,
 	}
 
+	// Tags and note are stored apart from the block
+	if st, err := s.storageManager.GetStorage(block.Source()); err == nil {
+		if annotations, err := st.GetAnnotations([]string{block.ID()}); err == nil {
+			if a := annotations[block.ID()]; a != nil {
+				webBlock.Tags = a.Tags
+				webBlock.Note = a.Note
+			}
+		} else {
+			log.Printf("Error reading annotations of block %s: %v", block.ID(), err)
+		}
+	}
+
 	return webBlock
 }
 
+// buildWebFacets converts search facets into links narrowing the current search
+func buildWebFacets(facets *storage.SearchFacets, current url.Values) *types.SearchFacets {
+	if facets == nil {
+		return nil
+	}
+
+	// narrow returns the current search URL, back on its first page, with change applied
+	narrow := func(change func(url.Values)) string {
+		values := url.Values{}
+		for key, vals := range current {
+			values[key] = append([]string(nil), vals...)
+		}
+		values.Del("page")
+		values.Del("cursor")
+		change(values)
+		return "/search?" + values.Encode()
+	}
+	addToQuery := func(term string) func(url.Values) {
+		return func(values url.Values) {
+			values.Set("q", strings.TrimSpace(values.Get("q")+" "+term))
+		}
+	}
+
+	webFacets := &types.SearchFacets{Total: facets.Total}
+	selected := current["datasource"]
+
+	datasources := types.FacetGroup{Title: "Datasources"}
+	for _, facet := range facets.Datasources {
+		name := facet.Value
+		datasources.Values = append(datasources.Values, types.FacetValue{
+			Label:  name,
+			Count:  facet.Count,
+			URL:    narrow(func(values url.Values) { values.Set("datasource", name) }),
+			Active: len(selected) == 1 && selected[0] == name,
+		})
+	}
+
+	dsTypes := types.FacetGroup{Title: "Types"}
+	for _, facet := range facets.Types {
+		dsTypes.Values = append(dsTypes.Values, types.FacetValue{
+			Label: facet.Value,
+			Count: facet.Count,
+			URL:   narrow(addToQuery("type:" + facet.Value)),
+		})
+	}
+
+	hostnames := types.FacetGroup{Title: "Hosts"}
+	for _, facet := range facets.Hostnames {
+		if facet.Value == "" {
+			continue
+		}
+		hostnames.Values = append(hostnames.Values, types.FacetValue{
+			Label: facet.Value,
+			Count: facet.Count,
+			URL:   narrow(addToQuery(`hostname:"` + facet.Value + `"`)),
+		})
+	}
+
+	for _, group := range []types.FacetGroup{datasources, dsTypes, hostnames} {
+		if len(group.Values) > 0 {
+			webFacets.Groups = append(webFacets.Groups, group)
+		}
+	}
+
+	for _, interval := range []storage.FacetInterval{storage.FacetIntervalDay, storage.FacetIntervalWeek, storage.FacetIntervalMonth} {
+		webFacets.Intervals = append(webFacets.Intervals, types.FacetValue{
+			Label:  string(interval),
+			URL:    narrow(func(values url.Values) { values.Set("interval", string(interval)) }),
+			Active: facets.Interval == interval,
+		})
+	}
+
+	maxCount := 0
+	for _, bucket := range facets.Histogram {
+		maxCount = max(maxCount, bucket.Count)
+	}
+	for _, bucket := range facets.Histogram {
+		// Clicking a bar narrows the date range to the bucket (end_date is inclusive)
+		start := bucket.Start
+		var end time.Time
+		var label string
+		switch facets.Interval {
+		case storage.FacetIntervalDay:
+			end = start
+			label = start.Format("Jan 2, 2006")
+		case storage.FacetIntervalWeek:
+			end = start.AddDate(0, 0, 6)
+			label = "Week of " + start.Format("Jan 2, 2006")
+		default:
+			end = start.AddDate(0, 1, -1)
+			label = start.Format("January 2006")
+		}
+		webFacets.Histogram = append(webFacets.Histogram, types.HistogramBar{
+			Label:   label,
+			Count:   bucket.Count,
+			Percent: bucket.Count * 100 / maxCount,
+			URL: narrow(func(values url.Values) {
+				values.Set("start_date", start.Format("2006-01-02"))
+				values.Set("end_date", end.Format("2006-01-02"))
+			}),
+		})
+	}
+
+	return webFacets
+}
+
 // extractLinks extracts HTTP/HTTPS URLs from text
 func extractLinks(text string) []string {
 	var links []string
@@ -609,6 +912,12 @@ func extractLinks(text string) []string {
 
 // formatSearchError converts search errors into user-friendly messages
 func formatSearchError(err error) string {
+	// Invalid field filters (unknown field, bad value) carry a clear message
+	var queryErr *storage.QueryError
+	if errors.As(err, &queryErr) {
+		return "Invalid search query: " + queryErr.Msg + "."
+	}
+
 	errStr := err.Error()
 
 	// Handle FTS5 syntax errors
@@ -526,121 +526,408 @@ func searchResultsFirehoseStyle(data types.PageData) templ.Component {
 				return templ_7745c5c3_Err
 			}
 		} else {
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "<div class=\"results-summary\"><p>Found ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 35, "<div class=\"results-summary\"><p>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var16 string
-			templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(data.TotalCount))
+			if data.Facets != nil {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, "Found ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var16 string
+				templ_7745c5c3_Var16, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(data.Facets.Total))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 193, Col: 44}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, " results for \"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var17 string
+				templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(data.Query)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 193, Col: 72}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "\" (Page ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var18 string
+				templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(data.CurrentPage))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 193, Col: 114}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, ")")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			} else {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "Found ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var19 string
+				templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(data.TotalCount))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 195, Col: 42}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, " results for \"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var20 string
+				templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(data.Query)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 195, Col: 70}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "\" (Page ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var21 string
+				templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(data.CurrentPage))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 195, Col: 112}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, ")")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "</p></div>")
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 192, Col: 41}
+				return templ_7745c5c3_Err
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var16))
+			if data.Facets != nil {
+				templ_7745c5c3_Err = searchFacets(data.Facets).Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 45, " <div class=\"results-section\"><div class=\"firehose-blocks\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 36, " results for \"")
+			for datasource, blocks := range data.Results {
+				for _, block := range blocks {
+					templ_7745c5c3_Err = UnifiedBlock(block, datasource).Render(ctx, templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 46, "</div></div><div class=\"pagination\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var17 string
-			templ_7745c5c3_Var17, templ_7745c5c3_Err = templ.JoinStringErrs(data.Query)
+			if data.CurrentPage > 1 {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, "<a href=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var22 templ.SafeURL
+				templ_7745c5c3_Var22, templ_7745c5c3_Err = templ.JoinURLErrs(templ.URL("/search?q=" + data.Query + "&" + buildDatasourceParams(data.SelectedDatasources) + "&" + buildDateParams(data) + "&limit=" + strconv.Itoa(data.PageSize) + "&page=" + strconv.Itoa(data.CurrentPage-1)))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 214, Col: 222}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var22))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 48, "\" class=\"pagination-btn\">← Previous</a> ")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 49, "<span class=\"page-info\">Page ")
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 192, Col: 69}
+				return templ_7745c5c3_Err
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var17))
+			var templ_7745c5c3_Var23 string
+			templ_7745c5c3_Var23, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(data.CurrentPage))
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 219, Col: 41}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var23))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "\" (Page ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 50, "</span> ")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var18 string
-			templ_7745c5c3_Var18, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(data.CurrentPage))
+			if data.HasNextPage {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 51, "<a href=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var24 templ.SafeURL
+				templ_7745c5c3_Var24, templ_7745c5c3_Err = templ.JoinURLErrs(templ.URL("/search?q=" + data.Query + "&" + buildDatasourceParams(data.SelectedDatasources) + "&" + buildDateParams(data) + "&limit=" + strconv.Itoa(data.PageSize) + "&page=" + strconv.Itoa(data.CurrentPage+1)))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 223, Col: 222}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var24))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 52, "\" class=\"pagination-btn\">Next →</a>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 53, "</div>")
 			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 192, Col: 111}
+				return templ_7745c5c3_Err
 			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var18))
+		}
+		return nil
+	})
+}
+
+// searchFacets renders match counts and the date histogram; each entry links to the narrowed search
+func searchFacets(facets *types.SearchFacets) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var25 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var25 == nil {
+			templ_7745c5c3_Var25 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 54, "<div class=\"search-facets\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		for _, group := range facets.Groups {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 55, "<div class=\"facet-group\"><h4>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, ")</p></div><div class=\"results-section\"><div class=\"firehose-blocks\">")
+			var templ_7745c5c3_Var26 string
+			templ_7745c5c3_Var26, templ_7745c5c3_Err = templ.JoinStringErrs(group.Title)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 236, Col: 21}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var26))
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			for datasource, blocks := range data.Results {
-				for _, block := range blocks {
-					templ_7745c5c3_Err = UnifiedBlock(block, datasource).Render(ctx, templ_7745c5c3_Buffer)
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 56, "</h4><ul>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			for _, value := range group.Values {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 57, "<li><a href=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var27 templ.SafeURL
+				templ_7745c5c3_Var27, templ_7745c5c3_Err = templ.JoinURLErrs(templ.SafeURL(value.URL))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 241, Col: 39}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var27))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 58, "\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				if value.Active {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 59, " class=\"facet-link active\"")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				} else {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 60, " class=\"facet-link\"")
 					if templ_7745c5c3_Err != nil {
 						return templ_7745c5c3_Err
 					}
 				}
-			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, "</div></div><div class=\"pagination\">")
-			if templ_7745c5c3_Err != nil {
-				return templ_7745c5c3_Err
-			}
-			if data.CurrentPage > 1 {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "<a href=\"")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 61, "><span class=\"facet-label\">")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var19 templ.SafeURL
-				templ_7745c5c3_Var19, templ_7745c5c3_Err = templ.JoinURLErrs(templ.URL("/search?q=" + data.Query + "&" + buildDatasourceParams(data.SelectedDatasources) + "&" + buildDateParams(data) + "&limit=" + strconv.Itoa(data.PageSize) + "&page=" + strconv.Itoa(data.CurrentPage-1)))
+				var templ_7745c5c3_Var28 string
+				templ_7745c5c3_Var28, templ_7745c5c3_Err = templ.JoinStringErrs(value.Label)
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 207, Col: 222}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 248, Col: 47}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var19))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var28))
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "\" class=\"pagination-btn\">← Previous</a> ")
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 62, "</span> <span class=\"facet-count\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var29 string
+				templ_7745c5c3_Var29, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(value.Count))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 249, Col: 61}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var29))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 63, "</span></a></li>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "<span class=\"page-info\">Page ")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 64, "</ul></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			var templ_7745c5c3_Var20 string
-			templ_7745c5c3_Var20, templ_7745c5c3_Err = templ.JoinStringErrs(strconv.Itoa(data.CurrentPage))
-			if templ_7745c5c3_Err != nil {
-				return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 212, Col: 41}
-			}
-			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var20))
+		}
+		if len(facets.Histogram) > 0 {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 65, "<div class=\"facet-group facet-histogram-group\"><h4>Timeline <span class=\"facet-intervals\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, "</span> ")
+			for _, interval := range facets.Intervals {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 66, "<a href=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var30 templ.SafeURL
+				templ_7745c5c3_Var30, templ_7745c5c3_Err = templ.JoinURLErrs(templ.SafeURL(interval.URL))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 263, Col: 42}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var30))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 67, "\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				if interval.Active {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 68, " class=\"facet-interval active\"")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				} else {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 69, " class=\"facet-interval\"")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 70, ">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var31 string
+				templ_7745c5c3_Var31, templ_7745c5c3_Err = templ.JoinStringErrs(interval.Label)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 269, Col: 24}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var31))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 71, "</a>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 72, "</span></h4><div class=\"facet-histogram\">")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
-			if data.HasNextPage {
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "<a href=\"")
+			for _, bar := range facets.Histogram {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 73, "<a href=\"")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				var templ_7745c5c3_Var21 templ.SafeURL
-				templ_7745c5c3_Var21, templ_7745c5c3_Err = templ.JoinURLErrs(templ.URL("/search?q=" + data.Query + "&" + buildDatasourceParams(data.SelectedDatasources) + "&" + buildDateParams(data) + "&limit=" + strconv.Itoa(data.PageSize) + "&page=" + strconv.Itoa(data.CurrentPage+1)))
+				var templ_7745c5c3_Var32 templ.SafeURL
+				templ_7745c5c3_Var32, templ_7745c5c3_Err = templ.JoinURLErrs(templ.SafeURL(bar.URL))
 				if templ_7745c5c3_Err != nil {
-					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 216, Col: 222}
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 276, Col: 36}
 				}
-				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var21))
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var32))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 74, "\" class=\"histogram-bar\" title=\"")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
-				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 45, "\" class=\"pagination-btn\">Next →</a>")
+				var templ_7745c5c3_Var33 string
+				templ_7745c5c3_Var33, templ_7745c5c3_Err = templ.JoinStringErrs(bar.Label + ": " + strconv.Itoa(bar.Count))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 278, Col: 57}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var33))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 75, "\"><span style=\"")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var34 string
+				templ_7745c5c3_Var34, templ_7745c5c3_Err = templruntime.SanitizeStyleAttributeValues("height: " + strconv.Itoa(max(bar.Percent, 2)) + "%")
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 280, Col: 73}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var34))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 76, "\"></span></a>")
 				if templ_7745c5c3_Err != nil {
 					return templ_7745c5c3_Err
 				}
 			}
-			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 46, "</div>")
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 77, "</div></div>")
 			if templ_7745c5c3_Err != nil {
 				return templ_7745c5c3_Err
 			}
 		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 78, "</div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
 		return nil
 	})
 }
@@ -662,25 +949,25 @@ func noResults(query string) templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var22 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var22 == nil {
-			templ_7745c5c3_Var22 = templ.NopComponent
+		templ_7745c5c3_Var35 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var35 == nil {
+			templ_7745c5c3_Var35 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 47, "<div class=\"no-results\"><h3>No results found</h3><p>No results found for \"")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 79, "<div class=\"no-results\"><h3>No results found</h3><p>No results found for \"")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		var templ_7745c5c3_Var23 string
-		templ_7745c5c3_Var23, templ_7745c5c3_Err = templ.JoinStringErrs(query)
+		var templ_7745c5c3_Var36 string
+		templ_7745c5c3_Var36, templ_7745c5c3_Err = templ.JoinStringErrs(query)
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 228, Col: 34}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `cmd/web/components/search.templ`, Line: 293, Col: 34}
 		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var23))
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var36))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 48, "\". Try different search terms.</p></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 80, "\". Try different search terms.</p></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -705,12 +992,12 @@ func searchHelp() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var24 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var24 == nil {
-			templ_7745c5c3_Var24 = templ.NopComponent
+		templ_7745c5c3_Var37 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var37 == nil {
+			templ_7745c5c3_Var37 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 49, "<div class=\"no-results\"><h3>Search Your Data</h3><p>Enter a search query above to find content across all your datasources.</p><p><a href=\"/datasources\">Browse datasources individually</a> or use the search above to find specific content.</p></div>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 81, "<div class=\"no-results\"><h3>Search Your Data</h3><p>Enter a search query above to find content across all your datasources.</p><p><a href=\"/datasources\">Browse datasources individually</a> or use the search above to find specific content.</p></div>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -735,12 +1022,12 @@ func searchScript() templ.Component {
 			}()
 		}
 		ctx = templ.InitializeContext(ctx)
-		templ_7745c5c3_Var25 := templ.GetChildren(ctx)
-		if templ_7745c5c3_Var25 == nil {
-			templ_7745c5c3_Var25 = templ.NopComponent
+		templ_7745c5c3_Var38 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var38 == nil {
+			templ_7745c5c3_Var38 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 50, "<script>\n\t\tdocument.addEventListener('DOMContentLoaded', function() {\n\t\t\tinitializeChoices();\n\t\t\taddKeyboardShortcuts();\n\t\t});\n\n\t\tfunction initializeChoices() {\n\t\t\tconst datasourceSelect = document.getElementById('datasource-select');\n\t\t\tif (datasourceSelect) {\n\t\t\t\tconst choices = new Choices(datasourceSelect, {\n\t\t\t\t\tremoveItemButton: true,\n\t\t\t\t\tsearchEnabled: true,\n\t\t\t\t\tsearchPlaceholderValue: 'Search datasources...',\n\t\t\t\t\tplaceholder: false,\n\t\t\t\t\tnoResultsText: 'No datasources found',\n\t\t\t\t\tnoChoicesText: 'No datasources available',\n\t\t\t\t\titemSelectText: 'Press to select'\n\t\t\t\t});\n\n\t\t\t\tsetupCustomPlaceholder(choices);\n\t\t\t}\n\n\t\t\t// Initialize limit select\n\t\t\tconst limitSelect = document.getElementById('limit-select');\n\t\t\tif (limitSelect) {\n\t\t\t\tnew Choices(limitSelect, {\n\t\t\t\t\tsearchEnabled: false,\n\t\t\t\t\titemSelectText: '',\n\t\t\t\t\tshouldSort: false\n\t\t\t\t});\n\t\t\t}\n\t\t}\n\n\t\tfunction setupCustomPlaceholder(choices) {\n\t\t\tfunction updatePlaceholder() {\n\t\t\t\tconst choicesInner = document.querySelector('.choices__inner');\n\t\t\t\tconst selectedItems = choices.getValue();\n\t\t\t\tlet placeholder = choicesInner.querySelector('.custom-placeholder');\n\n\t\t\t\tif (selectedItems.length === 0) {\n\t\t\t\t\tif (!placeholder) {\n\t\t\t\t\t\tplaceholder = document.createElement('div');\n\t\t\t\t\t\tplaceholder.className = 'custom-placeholder';\n\t\t\t\t\t\tplaceholder.textContent = 'All datasources (leave empty to search all)';\n\t\t\t\t\t\tplaceholder.style.cssText = 'color: var(--text-dim); pointer-events: none; position: absolute; left: 12px; top: 50%; transform: translateY(-50%); font-size: 0.8rem;';\n\t\t\t\t\t\tchoicesInner.style.position = 'relative';\n\t\t\t\t\t\tchoicesInner.appendChild(placeholder);\n\t\t\t\t\t}\n\t\t\t\t\t// Show placeholder only if no pills AND input empty\n\t\t\t\t\tconst clonedInput = choicesInner.querySelector('.choices__input--cloned');\n\t\t\t\t\tconst hasValue = clonedInput && clonedInput.value.trim().length > 0;\n\t\t\t\t\tconst hasItems = selectedItems.length > 0;\n\t\t\t\t\tplaceholder.style.display = (!hasValue && !hasItems) ? 'block' : 'none';\n\t\t\t\t} else if (placeholder) {\n\t\t\t\t\tconst clonedInput = choicesInner.querySelector('.choices__input--cloned');\n\t\t\t\t\tconst hasValue = clonedInput && clonedInput.value.trim().length > 0;\n\t\t\t\t\tconst hasItems = selectedItems.length > 0;\n\t\t\t\t\tplaceholder.style.display = (!hasValue && !hasItems) ? 'block' : 'none';\n\t\t\t\t}\n\t\t\t}\n\n\t\t\t// Listen for changes and update placeholder\n\t\t\tconst select = document.getElementById('datasource-select');\n\t\t\tselect.addEventListener('addItem', updatePlaceholder);\n\t\t\tselect.addEventListener('removeItem', updatePlaceholder);\n\t\t\t// Also watch input changes to hide placeholder while typing before an item is created\n\t\t\tconst clonedInput = document.querySelector('.choices__input--cloned');\n\t\t\tif (clonedInput) {\n\t\t\t\tclonedInput.addEventListener('input', updatePlaceholder);\n\t\t\t\tclonedInput.addEventListener('focus', updatePlaceholder);\n\t\t\t\tclonedInput.addEventListener('blur', updatePlaceholder);\n\t\t\t}\n\n\t\t\t// Initial update\n\t\t\tsetTimeout(() => {\n\t\t\t\tupdatePlaceholder();\n\t\t\t\t// Re-bind if Choices re-renders the cloned input\n\t\t\t\tconst observer = new MutationObserver(() => {\n\t\t\t\t\tconst ci = document.querySelector('.choices__input--cloned');\n\t\t\t\t\tif (ci && !ci.dataset._phBound) {\n\t\t\t\t\t\tci.addEventListener('input', updatePlaceholder);\n\t\t\t\t\t\tci.addEventListener('focus', updatePlaceholder);\n\t\t\t\t\t\tci.addEventListener('blur', updatePlaceholder);\n\t\t\t\t\t\tci.dataset._phBound = '1';\n\t\t\t\t\t\tupdatePlaceholder();\n\t\t\t\t\t}\n\t\t\t\t});\n\t\t\t\tconst inner = document.querySelector('.choices__inner');\n\t\t\t\tif (inner) observer.observe(inner, { subtree: true, childList: true });\n\t\t\t}, 120);\n\t\t}\n\n\t\tfunction addKeyboardShortcuts() {\n\t\t\tdocument.addEventListener('keydown', function(e) {\n\t\t\t\t// Ctrl/Cmd + D to focus datasource select\n\t\t\t\tif ((e.ctrlKey || e.metaKey) && e.key === 'd') {\n\t\t\t\t\te.preventDefault();\n\t\t\t\t\tconst choicesInput = document.querySelector('.choices__input--cloned');\n\t\t\t\t\tif (choicesInput) {\n\t\t\t\t\t\tchoicesInput.focus();\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t});\n\t\t}\n\n\t\tfunction initializeAdvancedSearch() {\n\t\t\tconst toggleBtn = document.getElementById('advanced-search-btn');\n\t\t\tconst advancedSection = document.getElementById('advanced-search-section');\n\t\t\tconst chevronIcon = toggleBtn.querySelector('.chevron-icon');\n\n\t\t\tif (!toggleBtn || !advancedSection) return;\n\n\t\t\t// Get stored preference or check if advanced options are set\n\t\t\tconst storageKey = 'ergs-advanced-search-expanded';\n\t\t\tlet shouldExpand = false;\n\n\t\t\t// Check if user has manually set the preference\n\t\t\tconst storedPreference = localStorage.getItem(storageKey);\n\t\t\tif (storedPreference !== null) {\n\t\t\t\tshouldExpand = storedPreference === 'true';\n\t\t\t} else {\n\t\t\t\t// Auto-expand only if advanced options are set and no preference stored\n\t\t\t\tconst hasAdvancedOptions = document.querySelector('#datasource-select').value ||\n\t\t\t\t\t\t\t\t\t\t document.querySelector('#start_date').value ||\n\t\t\t\t\t\t\t\t\t\t document.querySelector('#end_date').value ||\n\t\t\t\t\t\t\t\t\t\t (document.querySelector('#limit-select').value && document.querySelector('#limit-select').value !== '30');\n\t\t\t\tshouldExpand = hasAdvancedOptions;\n\t\t\t}\n\n\t\t\ttoggleBtn.addEventListener('click', function() {\n\t\t\t\tconst isHidden = advancedSection.style.display === 'none';\n\n\t\t\t\tif (isHidden) {\n\t\t\t\t\tadvancedSection.style.display = 'block';\n\t\t\t\t\ttoggleBtn.classList.add('expanded');\n\t\t\t\t\tchevronIcon.style.transform = 'rotate(180deg)';\n\t\t\t\t\tlocalStorage.setItem(storageKey, 'true');\n\t\t\t\t} else {\n\t\t\t\t\tadvancedSection.style.display = 'none';\n\t\t\t\t\ttoggleBtn.classList.remove('expanded');\n\t\t\t\t\tchevronIcon.style.transform = 'rotate(0deg)';\n\t\t\t\t\tlocalStorage.setItem(storageKey, 'false');\n\t\t\t\t}\n\t\t\t});\n\n\t\t\t// Apply initial state\n\t\t\tif (shouldExpand) {\n\t\t\t\tadvancedSection.style.display = 'block';\n\t\t\t\ttoggleBtn.classList.add('expanded');\n\t\t\t\tchevronIcon.style.transform = 'rotate(180deg)';\n\t\t\t}\n\t\t}\n\n\t\t// Initialize advanced search after DOM is loaded\n\t\tinitializeAdvancedSearch();\n\t</script>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 82, "<script>\n\t\tdocument.addEventListener('DOMContentLoaded', function() {\n\t\t\tinitializeChoices();\n\t\t\taddKeyboardShortcuts();\n\t\t});\n\n\t\tfunction initializeChoices() {\n\t\t\tconst datasourceSelect = document.getElementById('datasource-select');\n\t\t\tif (datasourceSelect) {\n\t\t\t\tconst choices = new Choices(datasourceSelect, {\n\t\t\t\t\tremoveItemButton: true,\n\t\t\t\t\tsearchEnabled: true,\n\t\t\t\t\tsearchPlaceholderValue: 'Search datasources...',\n\t\t\t\t\tplaceholder: false,\n\t\t\t\t\tnoResultsText: 'No datasources found',\n\t\t\t\t\tnoChoicesText: 'No datasources available',\n\t\t\t\t\titemSelectText: 'Press to select'\n\t\t\t\t});\n\n\t\t\t\tsetupCustomPlaceholder(choices);\n\t\t\t}\n\n\t\t\t// Initialize limit select\n\t\t\tconst limitSelect = document.getElementById('limit-select');\n\t\t\tif (limitSelect) {\n\t\t\t\tnew Choices(limitSelect, {\n\t\t\t\t\tsearchEnabled: false,\n\t\t\t\t\titemSelectText: '',\n\t\t\t\t\tshouldSort: false\n\t\t\t\t});\n\t\t\t}\n\t\t}\n\n\t\tfunction setupCustomPlaceholder(choices) {\n\t\t\tfunction updatePlaceholder() {\n\t\t\t\tconst choicesInner = document.querySelector('.choices__inner');\n\t\t\t\tconst selectedItems = choices.getValue();\n\t\t\t\tlet placeholder = choicesInner.querySelector('.custom-placeholder');\n\n\t\t\t\tif (selectedItems.length === 0) {\n\t\t\t\t\tif (!placeholder) {\n\t\t\t\t\t\tplaceholder = document.createElement('div');\n\t\t\t\t\t\tplaceholder.className = 'custom-placeholder';\n\t\t\t\t\t\tplaceholder.textContent = 'All datasources (leave empty to search all)';\n\t\t\t\t\t\tplaceholder.style.cssText = 'color: var(--text-dim); pointer-events: none; position: absolute; left: 12px; top: 50%; transform: translateY(-50%); font-size: 0.8rem;';\n\t\t\t\t\t\tchoicesInner.style.position = 'relative';\n\t\t\t\t\t\tchoicesInner.appendChild(placeholder);\n\t\t\t\t\t}\n\t\t\t\t\t// Show placeholder only if no pills AND input empty\n\t\t\t\t\tconst clonedInput = choicesInner.querySelector('.choices__input--cloned');\n\t\t\t\t\tconst hasValue = clonedInput && clonedInput.value.trim().length > 0;\n\t\t\t\t\tconst hasItems = selectedItems.length > 0;\n\t\t\t\t\tplaceholder.style.display = (!hasValue && !hasItems) ? 'block' : 'none';\n\t\t\t\t} else if (placeholder) {\n\t\t\t\t\tconst clonedInput = choicesInner.querySelector('.choices__input--cloned');\n\t\t\t\t\tconst hasValue = clonedInput && clonedInput.value.trim().length > 0;\n\t\t\t\t\tconst hasItems = selectedItems.length > 0;\n\t\t\t\t\tplaceholder.style.display = (!hasValue && !hasItems) ? 'block' : 'none';\n\t\t\t\t}\n\t\t\t}\n\n\t\t\t// Listen for changes and update placeholder\n\t\t\tconst select = document.getElementById('datasource-select');\n\t\t\tselect.addEventListener('addItem', updatePlaceholder);\n\t\t\tselect.addEventListener('removeItem', updatePlaceholder);\n\t\t\t// Also watch input changes to hide placeholder while typing before an item is created\n\t\t\tconst clonedInput = document.querySelector('.choices__input--cloned');\n\t\t\tif (clonedInput) {\n\t\t\t\tclonedInput.addEventListener('input', updatePlaceholder);\n\t\t\t\tclonedInput.addEventListener('focus', updatePlaceholder);\n\t\t\t\tclonedInput.addEventListener('blur', updatePlaceholder);\n\t\t\t}\n\n\t\t\t// Initial update\n\t\t\tsetTimeout(() => {\n\t\t\t\tupdatePlaceholder();\n\t\t\t\t// Re-bind if Choices re-renders the cloned input\n\t\t\t\tconst observer = new MutationObserver(() => {\n\t\t\t\t\tconst ci = document.querySelector('.choices__input--cloned');\n\t\t\t\t\tif (ci && !ci.dataset._phBound) {\n\t\t\t\t\t\tci.addEventListener('input', updatePlaceholder);\n\t\t\t\t\t\tci.addEventListener('focus', updatePlaceholder);\n\t\t\t\t\t\tci.addEventListener('blur', updatePlaceholder);\n\t\t\t\t\t\tci.dataset._phBound = '1';\n\t\t\t\t\t\tupdatePlaceholder();\n\t\t\t\t\t}\n\t\t\t\t});\n\t\t\t\tconst inner = document.querySelector('.choices__inner');\n\t\t\t\tif (inner) observer.observe(inner, { subtree: true, childList: true });\n\t\t\t}, 120);\n\t\t}\n\n\t\tfunction addKeyboardShortcuts() {\n\t\t\tdocument.addEventListener('keydown', function(e) {\n\t\t\t\t// Ctrl/Cmd + D to focus datasource select\n\t\t\t\tif ((e.ctrlKey || e.metaKey) && e.key === 'd') {\n\t\t\t\t\te.preventDefault();\n\t\t\t\t\tconst choicesInput = document.querySelector('.choices__input--cloned');\n\t\t\t\t\tif (choicesInput) {\n\t\t\t\t\t\tchoicesInput.focus();\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t});\n\t\t}\n\n\t\tfunction initializeAdvancedSearch() {\n\t\t\tconst toggleBtn = document.getElementById('advanced-search-btn');\n\t\t\tconst advancedSection = document.getElementById('advanced-search-section');\n\t\t\tconst chevronIcon = toggleBtn.querySelector('.chevron-icon');\n\n\t\t\tif (!toggleBtn || !advancedSection) return;\n\n\t\t\t// Get stored preference or check if advanced options are set\n\t\t\tconst storageKey = 'ergs-advanced-search-expanded';\n\t\t\tlet shouldExpand = false;\n\n\t\t\t// Check if user has manually set the preference\n\t\t\tconst storedPreference = localStorage.getItem(storageKey);\n\t\t\tif (storedPreference !== null) {\n\t\t\t\tshouldExpand = storedPreference === 'true';\n\t\t\t} else {\n\t\t\t\t// Auto-expand only if advanced options are set and no preference stored\n\t\t\t\tconst hasAdvancedOptions = document.querySelector('#datasource-select').value ||\n\t\t\t\t\t\t\t\t\t\t document.querySelector('#start_date').value ||\n\t\t\t\t\t\t\t\t\t\t document.querySelector('#end_date').value ||\n\t\t\t\t\t\t\t\t\t\t (document.querySelector('#limit-select').value && document.querySelector('#limit-select').value !== '30');\n\t\t\t\tshouldExpand = hasAdvancedOptions;\n\t\t\t}\n\n\t\t\ttoggleBtn.addEventListener('click', function() {\n\t\t\t\tconst isHidden = advancedSection.style.display === 'none';\n\n\t\t\t\tif (isHidden) {\n\t\t\t\t\tadvancedSection.style.display = 'block';\n\t\t\t\t\ttoggleBtn.classList.add('expanded');\n\t\t\t\t\tchevronIcon.style.transform = 'rotate(180deg)';\n\t\t\t\t\tlocalStorage.setItem(storageKey, 'true');\n\t\t\t\t} else {\n\t\t\t\t\tadvancedSection.style.display = 'none';\n\t\t\t\t\ttoggleBtn.classList.remove('expanded');\n\t\t\t\t\tchevronIcon.style.transform = 'rotate(0deg)';\n\t\t\t\t\tlocalStorage.setItem(storageKey, 'false');\n\t\t\t\t}\n\t\t\t});\n\n\t\t\t// Apply initial state\n\t\t\tif (shouldExpand) {\n\t\t\t\tadvancedSection.style.display = 'block';\n\t\t\t\ttoggleBtn.classList.add('expanded');\n\t\t\t\tchevronIcon.style.transform = 'rotate(180deg)';\n\t\t\t}\n\t\t}\n\n\t\t// Initialize advanced search after DOM is loaded\n\t\tinitializeAdvancedSearch();\n\t</script>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
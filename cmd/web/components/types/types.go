@@ -25,7 +25,47 @@ type PageData struct {
 	NewestBlock         *time.Time
 	StartDate           *time.Time
 	EndDate             *time.Time
-	Version             string // Application version (for footer display)
+	Facets              *SearchFacets // Match counts for narrowing search results
+	InboxItems          []InboxItem   // Inbox page blocks, newest ingested first
+	InboxState          string        // Inbox page state: unread, read or archived
+	Version             string        // Application version (for footer display)
+}
+
+// SearchFacets represents the facet sidebar of the search page
+type SearchFacets struct {
+	Total     int
+	Groups    []FacetGroup
+	Intervals []FacetValue // Histogram interval switcher (day, week, month)
+	Histogram []HistogramBar
+}
+
+// FacetGroup represents a list of facet values, e.g. per datasource counts
+type FacetGroup struct {
+	Title  string
+	Values []FacetValue
+}
+
+// FacetValue represents a single facet value linking to the narrowed search
+type FacetValue struct {
+	Label  string
+	Count  int
+	URL    string
+	Active bool
+}
+
+// HistogramBar represents a bucket of the search date histogram
+type HistogramBar struct {
+	Label   string
+	Count   int
+	Percent int // Height relative to the largest bucket
+	URL     string
+}
+
+// InboxItem represents a block listed in the inbox
+type InboxItem struct {
+	Block      WebBlock
+	Datasource string
+	IngestedAt time.Time
 }
 
 // DatasourceInfo represents datasource information
@@ -45,4 +85,7 @@ type WebBlock struct {
 	Metadata      map[string]interface{}
 	Links         []string
 	FormattedText string
+	Snippet       string // HTML search snippet with matches in <mark>, search results only
+	Tags          []string
+	Note          string
 }
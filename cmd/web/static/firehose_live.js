@@ -267,6 +267,22 @@
     }
   }
 
+  // Drop a block deleted from its datasource (delete event)
+  function removeBlock(source, id) {
+    if (!blocksRoot || !source || !id) return;
+    seen.delete(blockKey(source, id));
+    blocksRoot
+      .querySelectorAll(".firehose-block-wrapper[data-block-id]")
+      .forEach((el) => {
+        if (
+          el.getAttribute("data-block-id") === id &&
+          el.getAttribute("data-block-source") === source
+        ) {
+          el.remove();
+        }
+      });
+  }
+
   function processBlocks(arr) {
     if (!Array.isArray(arr)) return;
     for (const b of arr) {
@@ -366,6 +382,9 @@
           );
         }
         break;
+      case "delete":
+        removeBlock(msg.datasource, msg.id);
+        break;
       case "heartbeat":
         setStatus("live", "LIVE");
         break;
@@ -5,6 +5,8 @@ document.addEventListener("DOMContentLoaded", function () {
   initializeNavigation();
   initializeMetadataToggles();
   initializePagination();
+  initializeAnnotations();
+  initializeInbox();
 });
 
 // Navigation enhancements
@@ -47,6 +49,161 @@ function initializeMetadataToggles() {
   });
 }
 
+// Block tags and notes. Listeners are delegated so blocks added to the page
+// later get them too.
+function initializeAnnotations() {
+  document.addEventListener("click", function (e) {
+    const button = e.target.closest(
+      ".block-annotate-tag, .block-annotate-note, .block-tag-remove",
+    );
+    if (!button) {
+      return;
+    }
+    const container = button.closest(".block-annotations");
+    if (!container) {
+      return;
+    }
+    e.preventDefault();
+
+    if (button.classList.contains("block-annotate-tag")) {
+      const input = prompt("Tags to add (space or comma separated):");
+      if (!input || !input.trim()) {
+        return;
+      }
+      const tags = input.split(/[\s,]+/).filter((tag) => tag);
+      updateAnnotations(container, "POST", "tags", { tags: tags });
+    } else if (button.classList.contains("block-annotate-note")) {
+      const current = container.querySelector(".block-note").textContent;
+      const note = prompt("Note (leave empty to remove it):", current);
+      if (note === null) {
+        return;
+      }
+      updateAnnotations(container, "PUT", "note", { note: note });
+    } else {
+      const tag = button.closest(".block-tag").dataset.tag;
+      updateAnnotations(
+        container,
+        "DELETE",
+        "tags/" + encodeURIComponent(tag),
+        null,
+      );
+    }
+  });
+}
+
+function updateAnnotations(container, method, path, body) {
+  const url =
+    "/api/blocks/" +
+    encodeURIComponent(container.dataset.datasource) +
+    "/" +
+    encodeURIComponent(container.dataset.blockId) +
+    "/" +
+    path;
+  const options = { method: method };
+  if (body) {
+    options.headers = { "Content-Type": "application/json" };
+    options.body = JSON.stringify(body);
+  }
+
+  fetch(url, options)
+    .then((response) =>
+      response.json().then((data) => {
+        if (!response.ok) {
+          throw new Error(data.message || data.error || response.statusText);
+        }
+        return data;
+      }),
+    )
+    .then((data) => renderAnnotations(container, data))
+    .catch((err) => alert("Could not update the block: " + err.message));
+}
+
+function renderAnnotations(container, data) {
+  const note = container.querySelector(".block-note");
+  note.textContent = data.note || "";
+  note.hidden = !data.note;
+
+  const tags = container.querySelector(".block-tags");
+  tags.querySelectorAll(".block-tag").forEach((el) => el.remove());
+  const addButton = tags.querySelector(".block-annotate-tag");
+  (data.tags || []).forEach((tag) => {
+    const chip = document.createElement("span");
+    chip.className = "block-tag";
+    chip.dataset.tag = tag;
+
+    const link = document.createElement("a");
+    link.href = "/search?q=" + encodeURIComponent("tag:" + tag);
+    link.textContent = "#" + tag;
+
+    const remove = document.createElement("button");
+    remove.type = "button";
+    remove.className = "block-tag-remove";
+    remove.title = "Remove tag";
+    remove.textContent = "×";
+
+    chip.append(link, remove);
+    tags.insertBefore(chip, addButton);
+  });
+}
+
+// Inbox read/archive controls
+function initializeInbox() {
+  document.addEventListener("click", function (e) {
+    const action = e.target.closest(".inbox-action");
+    if (action) {
+      e.preventDefault();
+      const item = action.closest(".inbox-item");
+      const url =
+        "/api/blocks/" +
+        encodeURIComponent(item.dataset.datasource) +
+        "/" +
+        encodeURIComponent(item.dataset.blockId) +
+        "/inbox";
+      inboxRequest("PUT", url, { state: action.dataset.state }).then(() => {
+        // The block left the listed state
+        item.classList.add("inbox-item-done");
+        setTimeout(() => item.remove(), 300);
+      });
+      return;
+    }
+
+    const markAll = e.target.closest(".inbox-mark-all");
+    if (markAll) {
+      e.preventDefault();
+      if (!confirm("Mark every unread block read?")) {
+        return;
+      }
+      const body = {};
+      if (markAll.dataset.datasource) {
+        body.datasources = [markAll.dataset.datasource];
+      }
+      inboxRequest("POST", "/api/inbox/read", body).then(() =>
+        window.location.reload(),
+      );
+    }
+  });
+}
+
+function inboxRequest(method, url, body) {
+  return fetch(url, {
+    method: method,
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(body),
+  })
+    .then((response) =>
+      response.json().then((data) => {
+        if (!response.ok) {
+          throw new Error(data.message || data.error || response.statusText);
+        }
+        return data;
+      }),
+    )
+    .catch((err) => {
+      alert("Could not update the inbox: " + err.message);
+      throw err;
+    });
+}
+
 // Global keyboard shortcuts
 document.addEventListener("keydown", function (e) {
   // Ctrl/Cmd + K to focus search
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -248,6 +249,53 @@ func TestFirehoseOrderedSliceIntegration(t *testing.T) {
 	}
 }
 
+func TestAPISearchFacets(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	req := httptest.NewRequest("GET", "/api/search?q=test&limit=5&interval=day", nil)
+	w := httptest.NewRecorder()
+	server.apiServer.HandleSearch(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response api.SearchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if response.TotalCount != 5 {
+		t.Errorf("Expected 5 results on the page, got %d", response.TotalCount)
+	}
+	facets := response.Facets
+	if facets == nil {
+		t.Fatal("Expected facets in response")
+	}
+	if facets.Total != 25 || facets.Interval != storage.FacetIntervalDay {
+		t.Errorf("Expected 25 matches by day, got %d by %s", facets.Total, facets.Interval)
+	}
+	want := []storage.FacetCount{{Value: "datasource_a", Count: 15}, {Value: "datasource_b", Count: 10}}
+	if !reflect.DeepEqual(facets.Datasources, want) {
+		t.Errorf("Expected datasource facets %v, got %v", want, facets.Datasources)
+	}
+	if len(facets.Types) != 1 || facets.Types[0] != (storage.FacetCount{Value: "mock", Count: 25}) {
+		t.Errorf("Expected 25 mock type matches, got %v", facets.Types)
+	}
+
+	// Web facets link to the narrowed search
+	webFacets := buildWebFacets(facets, req.URL.Query())
+	if got := webFacets.Groups[0].Values[0].URL; got != "/search?datasource=datasource_a&interval=day&limit=5&q=test" {
+		t.Errorf("Unexpected datasource facet URL %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/api/search?q=test&interval=year", nil)
+	w = httptest.NewRecorder()
+	server.apiServer.HandleSearch(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for invalid interval, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
 func TestAPISearchPagination(t *testing.T) {
 	server, cleanup := setupTestWebServer(t)
 	defer cleanup()
@@ -1611,3 +1659,41 @@ func getKeys(m map[string]interface{}) []string {
 	}
 	return keys
 }
+
+func TestWebInbox(t *testing.T) {
+	server, cleanup := setupTestWebServer(t)
+	defer cleanup()
+
+	st, err := server.storageManager.GetStorage("datasource_a")
+	if err != nil {
+		t.Fatalf("Failed to get storage: %v", err)
+	}
+	if _, err := st.SetInboxState([]string{"a_block_0", "a_block_1"}, storage.InboxArchived); err != nil {
+		t.Fatalf("Failed to archive blocks: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/inbox", nil)
+	w := httptest.NewRecorder()
+	server.handleInbox(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "23 unread blocks") {
+		t.Error("Expected the unread count in the page")
+	}
+	if n := strings.Count(body, `class="inbox-item"`); n != 23 {
+		t.Errorf("Expected 23 inbox items, got %d", n)
+	}
+	if strings.Contains(body, `data-block-id="a_block_0"`) {
+		t.Error("Archived blocks should not be listed as unread")
+	}
+
+	req = httptest.NewRequest("GET", "/inbox?state=archived", nil)
+	w = httptest.NewRecorder()
+	server.handleInbox(w, req)
+	body = w.Body.String()
+	if n := strings.Count(body, `class="inbox-item"`); n != 2 || !strings.Contains(body, `data-state="unread"`) {
+		t.Errorf("Expected the 2 archived blocks with a mark unread button, got %d", n)
+	}
+}
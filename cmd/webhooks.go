@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/rubiojr/ergs/pkg/config"
+	"github.com/rubiojr/ergs/pkg/webhooks"
+	"github.com/urfave/cli/v3"
+)
+
+// WebhooksCommand creates the webhooks command with subcommands
+func WebhooksCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "webhooks",
+		Usage: "Inspect outbound webhook deliveries",
+		Commands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List the configured webhooks and their deliveries per state",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return withWebhookLog(c.String("config"), func(cfg *config.Config, log *webhooks.Log) error {
+						counts, err := log.Counts()
+						if err != nil {
+							return err
+						}
+						if len(cfg.Webhooks) == 0 {
+							fmt.Println("No webhooks configured")
+						}
+						for _, wc := range cfg.Webhooks {
+							c := counts[wc.Name]
+							fmt.Printf("%s  %s\n", wc.Name, wc.URL)
+							fmt.Printf("   Pending: %d  Delivered: %d  Failed: %d\n", c[webhooks.StatePending], c[webhooks.StateDelivered], c[webhooks.StateFailed])
+						}
+						return nil
+					})
+				},
+			},
+			{
+				Name:  "deliveries",
+				Usage: "List recent deliveries, newest first",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "webhook",
+						Usage: "Only list deliveries to this webhook",
+					},
+					&cli.StringFlag{
+						Name:  "state",
+						Usage: "Only list pending, delivered or failed deliveries",
+					},
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "Maximum number of deliveries",
+						Value: 20,
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					state, err := webhooks.ParseDeliveryState(c.String("state"))
+					if err != nil {
+						return err
+					}
+					return withWebhookLog(c.String("config"), func(_ *config.Config, log *webhooks.Log) error {
+						deliveries, err := log.Deliveries(c.String("webhook"), state, int(c.Int("limit")))
+						if err != nil {
+							return err
+						}
+						if len(deliveries) == 0 {
+							fmt.Println("No deliveries")
+							return nil
+						}
+						for _, d := range deliveries {
+							fmt.Printf("%d. %s  %s  block %s of %s\n", d.ID, d.Webhook, d.State, d.BlockID, d.Datasource)
+							fmt.Printf("   Attempts: %d  Created: %s  Updated: %s", d.Attempts, formatTime(d.CreatedAt), formatTime(d.UpdatedAt))
+							if d.LastStatus != 0 {
+								fmt.Printf("  Status: %d", d.LastStatus)
+							}
+							fmt.Println()
+							if d.LastError != "" {
+								fmt.Printf("   Error: %s\n", d.LastError)
+							}
+						}
+						return nil
+					})
+				},
+			},
+			{
+				Name:      "retry",
+				Usage:     "Retry failed deliveries",
+				ArgsUsage: "<delivery id>...",
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if c.Args().Len() == 0 {
+						return fmt.Errorf("no delivery IDs given")
+					}
+					ids := make([]int64, 0, c.Args().Len())
+					for _, arg := range c.Args().Slice() {
+						id, err := strconv.ParseInt(arg, 10, 64)
+						if err != nil {
+							return fmt.Errorf("invalid delivery ID %q", arg)
+						}
+						ids = append(ids, id)
+					}
+					return withWebhookLog(c.String("config"), func(_ *config.Config, log *webhooks.Log) error {
+						n, err := log.Retry(ids)
+						if err != nil {
+							return err
+						}
+						if n < len(ids) {
+							fmt.Printf("%d of the deliveries were not found or had not failed\n", len(ids)-n)
+						}
+						fmt.Printf("Queued %d deliveries for retry\n", n)
+						return nil
+					})
+				},
+			},
+		},
+	}
+}
+
+// withWebhookLog opens the webhook delivery log and calls fn with it. The log
+// is created by the first delivery, so it's an error if it doesn't exist yet.
+func withWebhookLog(configPath string, fn func(*config.Config, *webhooks.Log) error) error {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	path := webhookLogPath(cfg)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return fmt.Errorf("no webhook delivery log at %s: configure webhooks and run 'ergs serve' or 'ergs fetch' first", path)
+	}
+	log, err := webhooks.OpenLog(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := log.Close(); err != nil {
+			fmt.Printf("Warning: failed to close webhook delivery log: %v\n", err)
+		}
+	}()
+	return fn(cfg, log)
+}
@@ -15,4 +15,7 @@ import (
 	_ "github.com/rubiojr/ergs/pkg/datasources/rtve"
 	_ "github.com/rubiojr/ergs/pkg/datasources/timestamp"
 	_ "github.com/rubiojr/ergs/pkg/datasources/zedthreads"
+
+	// Import the built-in block processors
+	_ "github.com/rubiojr/ergs/pkg/processors"
 )
@@ -25,7 +25,7 @@ import (
 const (
 	// expectedMigrationCount is the total number of migrations in the system.
 	// Update this constant when adding new migrations.
-	expectedMigrationCount = 6
+	expectedMigrationCount = 9
 )
 
 func TestMigrationSystemIntegration(t *testing.T) {
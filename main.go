@@ -33,9 +33,15 @@ func main() {
 			cmd.FetchCommand(),
 			cmd.FirehoseCommand(),
 			cmd.SearchCommand(),
+			cmd.PurgeCommand(),
+			cmd.TagCommand(),
+			cmd.NoteCommand(),
+			cmd.InboxCommand(),
+			cmd.WebhooksCommand(),
 			cmd.ListCommand(),
 			cmd.TodayCommand(),
 			cmd.ServeCommand(),
+			cmd.CtlCommand(),
 			cmd.WebCommand(),
 			cmd.ImporterCommand(),
 			cmd.StatsCommand(),
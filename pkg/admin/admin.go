@@ -0,0 +1,262 @@
+// Package admin serves the control API of a running 'ergs serve': HTTP on a
+// Unix socket in the storage directory, only reachable by the user running
+// it. It lists the datasources with their schedule, triggers fetches, pauses
+// and resumes scheduled fetches and changes log levels. 'ergs ctl' is its
+// client (see Client).
+//
+// Endpoints:
+//
+//	GET  /datasources               - datasources and their schedule
+//	POST /datasources/{name}/fetch  - fetch a datasource now
+//	POST /datasources/{name}/pause  - skip its scheduled fetches
+//	POST /datasources/{name}/resume - resume its scheduled fetches
+//	GET  /log                       - debug logging state
+//	PUT  /log                       - change the log level (see LogLevelRequest)
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/log"
+	"github.com/rubiojr/ergs/pkg/warehouse"
+)
+
+// SocketFile is the path of the admin socket, relative to the storage directory.
+const SocketFile = "internal/admin.sock"
+
+var adminLogger = log.ForService("admin")
+
+// Warehouse is the part of *warehouse.Warehouse the admin API controls.
+type Warehouse interface {
+	DatasourceStatuses() []warehouse.DatasourceStatus
+	FetchNow(name string) error
+	PauseDatasource(name string) error
+	ResumeDatasource(name string) error
+}
+
+// Datasource is a datasource and its schedule.
+type Datasource struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Interval between scheduled fetches, as a Go duration; "0s" for
+	// schema-only datasources
+	Interval  string     `json:"interval"`
+	Paused    bool       `json:"paused"`
+	Fetching  bool       `json:"fetching"`
+	NextRun   *time.Time `json:"next_run,omitempty"`
+	LastRun   *time.Time `json:"last_run,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// LogLevels is the debug logging state of the daemon.
+type LogLevels struct {
+	// Debug is true when debug logging is enabled for every service
+	Debug bool `json:"debug"`
+	// DebugServices have debug logging enabled on their own
+	DebugServices []string `json:"debug_services"`
+}
+
+// LogLevelRequest sets the log level, debug or info, of the given services,
+// or the global one if there are none. Services set to info still log
+// debug messages while global debug logging is enabled.
+type LogLevelRequest struct {
+	Level    string   `json:"level"`
+	Services []string `json:"services,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the admin API of a warehouse.
+type Server struct {
+	wh     Warehouse
+	server *http.Server
+	path   string
+}
+
+// NewServer returns the admin API server of wh.
+func NewServer(wh Warehouse) *Server {
+	return &Server{wh: wh}
+}
+
+// Handler returns the HTTP handler serving the admin API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /datasources", s.handleDatasources)
+	mux.HandleFunc("POST /datasources/{name}/fetch", s.handleDatasourceAction("fetch", s.wh.FetchNow))
+	mux.HandleFunc("POST /datasources/{name}/pause", s.handleDatasourceAction("pause", s.wh.PauseDatasource))
+	mux.HandleFunc("POST /datasources/{name}/resume", s.handleDatasourceAction("resume", s.wh.ResumeDatasource))
+	mux.HandleFunc("GET /log", s.handleLogLevels)
+	mux.HandleFunc("PUT /log", s.handleSetLogLevel)
+	return mux
+}
+
+// Listen serves the admin API in the background on a Unix socket at path,
+// readable and writable by the owner only. A stale socket left by a process
+// that didn't exit cleanly is replaced; one still answering is an error.
+func (s *Server) Listen(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating admin socket directory: %w", err)
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("admin socket %s is in use by another process", path)
+	}
+	if st, err := os.Stat(path); err == nil && !st.IsDir() {
+		_ = os.Remove(path)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("listen on admin socket %s: %w", path, err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = ln.Close()
+		return fmt.Errorf("restricting admin socket %s: %w", path, err)
+	}
+
+	s.path = path
+	s.server = &http.Server{Handler: s.Handler()}
+	go func() {
+		if err := s.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			adminLogger.Warnf("admin API on %s failed: %v", path, err)
+		}
+	}()
+	return nil
+}
+
+// Close stops serving and removes the socket.
+func (s *Server) Close() error {
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Close()
+	if rmErr := os.Remove(s.path); rmErr != nil && !os.IsNotExist(rmErr) && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+func (s *Server) handleDatasources(w http.ResponseWriter, r *http.Request) {
+	statuses := s.wh.DatasourceStatuses()
+	datasources := make([]Datasource, 0, len(statuses))
+	for _, st := range statuses {
+		datasources = append(datasources, newDatasource(st))
+	}
+	writeJSON(w, http.StatusOK, datasources)
+}
+
+// handleDatasourceAction runs action on the datasource in the path and
+// responds with its updated schedule.
+func (s *Server) handleDatasourceAction(verb string, action func(name string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := action(name); err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, warehouse.ErrUnknownDatasource):
+				status = http.StatusNotFound
+			case errors.Is(err, warehouse.ErrFetchInProgress):
+				status = http.StatusConflict
+			case errors.Is(err, warehouse.ErrNotRunning):
+				status = http.StatusServiceUnavailable
+			}
+			writeError(w, status, err.Error())
+			return
+		}
+		adminLogger.Infof("%s %s", verb, name)
+
+		for _, st := range s.wh.DatasourceStatuses() {
+			if st.Name == name {
+				writeJSON(w, http.StatusOK, newDatasource(st))
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s", warehouse.ErrUnknownDatasource, name))
+	}
+}
+
+func (s *Server) handleLogLevels(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, logLevels())
+}
+
+func (s *Server) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req LogLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	var debug bool
+	switch strings.ToLower(req.Level) {
+	case "debug":
+		debug = true
+	case "info":
+	default:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid log level %q, must be debug or info", req.Level))
+		return
+	}
+
+	if len(req.Services) == 0 {
+		log.SetGlobalDebug(debug)
+		adminLogger.Infof("global log level set to %s", strings.ToLower(req.Level))
+	}
+	for _, svc := range req.Services {
+		if debug {
+			log.EnableDebugFor(svc)
+		} else {
+			log.DisableDebugFor(svc)
+		}
+		adminLogger.Infof("log level of %s set to %s", svc, strings.ToLower(req.Level))
+	}
+	writeJSON(w, http.StatusOK, logLevels())
+}
+
+func newDatasource(st warehouse.DatasourceStatus) Datasource {
+	ds := Datasource{
+		Name:      st.Name,
+		Type:      st.Type,
+		Interval:  st.Interval.String(),
+		Paused:    st.Paused,
+		Fetching:  st.Fetching,
+		LastError: st.LastError,
+	}
+	if !st.NextRun.IsZero() {
+		next := st.NextRun
+		ds.NextRun = &next
+	}
+	if !st.LastRun.IsZero() {
+		last := st.LastRun
+		ds.LastRun = &last
+	}
+	return ds
+}
+
+func logLevels() LogLevels {
+	services := log.DebugServices()
+	if services == nil {
+		services = []string{}
+	}
+	return LogLevels{Debug: log.GlobalDebug(), DebugServices: services}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		adminLogger.Warnf("encoding response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
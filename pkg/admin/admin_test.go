@@ -0,0 +1,134 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/log"
+	"github.com/rubiojr/ergs/pkg/warehouse"
+)
+
+// fakeWarehouse has a single datasource, hn, being fetched
+type fakeWarehouse struct {
+	status warehouse.DatasourceStatus
+}
+
+func (f *fakeWarehouse) DatasourceStatuses() []warehouse.DatasourceStatus {
+	return []warehouse.DatasourceStatus{f.status}
+}
+
+func (f *fakeWarehouse) FetchNow(name string) error {
+	if err := f.check(name); err != nil {
+		return err
+	}
+	if f.status.Fetching {
+		return fmt.Errorf("%s: %w", name, warehouse.ErrFetchInProgress)
+	}
+	f.status.Fetching = true
+	return nil
+}
+
+func (f *fakeWarehouse) PauseDatasource(name string) error {
+	if err := f.check(name); err != nil {
+		return err
+	}
+	f.status.Paused = true
+	return nil
+}
+
+func (f *fakeWarehouse) ResumeDatasource(name string) error {
+	if err := f.check(name); err != nil {
+		return err
+	}
+	f.status.Paused = false
+	return nil
+}
+
+func (f *fakeWarehouse) check(name string) error {
+	if name != f.status.Name {
+		return fmt.Errorf("%w %s", warehouse.ErrUnknownDatasource, name)
+	}
+	return nil
+}
+
+func TestAdminAPI(t *testing.T) {
+	// Unix socket paths are short, so not under t.TempDir()
+	dir, err := os.MkdirTemp("", "ergs-admin")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	socketPath := filepath.Join(dir, SocketFile)
+
+	next := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	wh := &fakeWarehouse{status: warehouse.DatasourceStatus{Name: "hn", Type: "hackernews", Interval: 30 * time.Minute, NextRun: next}}
+	server := NewServer(wh)
+	if err := server.Listen(socketPath); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer func() { _ = server.Close() }()
+	if st, err := os.Stat(socketPath); err != nil || st.Mode().Perm() != 0600 {
+		t.Fatalf("expected the socket only accessible by its owner, got %v (%v)", st.Mode(), err)
+	}
+	if err := NewServer(wh).Listen(socketPath); err == nil {
+		t.Fatal("expected an error listening on a socket in use")
+	}
+
+	ctx := context.Background()
+	client := NewClient(socketPath)
+	datasources, err := client.Datasources(ctx)
+	if err != nil {
+		t.Fatalf("Datasources: %v", err)
+	}
+	if len(datasources) != 1 || datasources[0].Interval != "30m0s" || datasources[0].NextRun == nil || !datasources[0].NextRun.Equal(next) || datasources[0].LastRun != nil {
+		t.Fatalf("unexpected datasources: %+v", datasources)
+	}
+
+	if ds, err := client.Fetch(ctx, "hn"); err != nil || !ds.Fetching {
+		t.Fatalf("expected hn fetching, got %+v (%v)", ds, err)
+	}
+	if _, err := client.Fetch(ctx, "hn"); err == nil || !strings.Contains(err.Error(), "already in progress") {
+		t.Errorf("expected a fetch in progress error, got %v", err)
+	}
+	if _, err := client.Pause(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "unknown datasource missing") {
+		t.Errorf("expected an unknown datasource error, got %v", err)
+	}
+	if ds, err := client.Pause(ctx, "hn"); err != nil || !ds.Paused {
+		t.Fatalf("expected hn paused, got %+v (%v)", ds, err)
+	}
+	if ds, err := client.Resume(ctx, "hn"); err != nil || ds.Paused {
+		t.Fatalf("expected hn resumed, got %+v (%v)", ds, err)
+	}
+
+	const svc = "admin_test_service"
+	defer log.DisableDebugFor(svc)
+	levels, err := client.SetLogLevel(ctx, "debug", svc)
+	if err != nil || !slices.Contains(levels.DebugServices, svc) {
+		t.Fatalf("expected debug enabled for %s, got %+v (%v)", svc, levels, err)
+	}
+	if !log.DebugEnabledFor(svc) {
+		t.Errorf("expected debug logging enabled for %s", svc)
+	}
+	if _, err := client.SetLogLevel(ctx, "trace"); err == nil {
+		t.Error("expected an error setting an invalid log level")
+	}
+	if _, err := client.SetLogLevel(ctx, "info", svc); err != nil {
+		t.Fatalf("SetLogLevel: %v", err)
+	}
+	if levels, err := client.LogLevels(ctx); err != nil || slices.Contains(levels.DebugServices, svc) {
+		t.Fatalf("expected debug disabled for %s, got %+v (%v)", svc, levels, err)
+	}
+
+	if err := server.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := client.Datasources(ctx); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("expected a not running error once closed, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+)
+
+// Client calls the admin API of a running 'ergs serve'.
+type Client struct {
+	socketPath string
+	http       *http.Client
+}
+
+// NewClient returns a client of the admin API listening on the Unix socket
+// at socketPath.
+func NewClient(socketPath string) *Client {
+	return &Client{
+		socketPath: socketPath,
+		http: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// Datasources returns the datasources of the daemon and their schedule.
+func (c *Client) Datasources(ctx context.Context) ([]Datasource, error) {
+	var datasources []Datasource
+	err := c.do(ctx, http.MethodGet, "/datasources", nil, &datasources)
+	return datasources, err
+}
+
+// Fetch starts fetching a datasource now.
+func (c *Client) Fetch(ctx context.Context, name string) (Datasource, error) {
+	return c.datasourceAction(ctx, name, "fetch")
+}
+
+// Pause stops the scheduled fetches of a datasource.
+func (c *Client) Pause(ctx context.Context, name string) (Datasource, error) {
+	return c.datasourceAction(ctx, name, "pause")
+}
+
+// Resume resumes the scheduled fetches of a datasource.
+func (c *Client) Resume(ctx context.Context, name string) (Datasource, error) {
+	return c.datasourceAction(ctx, name, "resume")
+}
+
+// LogLevels returns the debug logging state of the daemon.
+func (c *Client) LogLevels(ctx context.Context) (LogLevels, error) {
+	var levels LogLevels
+	err := c.do(ctx, http.MethodGet, "/log", nil, &levels)
+	return levels, err
+}
+
+// SetLogLevel sets the log level, debug or info, of the given services, or
+// the global one if there are none.
+func (c *Client) SetLogLevel(ctx context.Context, level string, services ...string) (LogLevels, error) {
+	var levels LogLevels
+	err := c.do(ctx, http.MethodPut, "/log", LogLevelRequest{Level: level, Services: services}, &levels)
+	return levels, err
+}
+
+func (c *Client) datasourceAction(ctx context.Context, name, action string) (Datasource, error) {
+	var ds Datasource
+	err := c.do(ctx, http.MethodPost, "/datasources/"+url.PathEscape(name)+"/"+action, nil, &ds)
+	return ds, err
+}
+
+// do sends a request with body encoded as JSON, if not nil, and decodes the
+// response into out. Error responses are returned as errors.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, "http://ergs"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+			return fmt.Errorf("ergs serve is not running (no admin API at %s)", c.socketPath)
+		}
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+			return fmt.Errorf("admin API: %s", resp.Status)
+		}
+		return errors.New(errResp.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
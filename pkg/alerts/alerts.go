@@ -0,0 +1,247 @@
+// Package alerts delivers the blocks matching saved searches to sinks. Saved
+// searches are configured with [[saved_searches]] tables and evaluated by the
+// warehouse against every new block:
+//
+//	[[saved_searches]]
+//	name = 'mentions'
+//	query = 'ergs type:hackernews'
+//
+//	[[saved_searches.sinks]]
+//	type = 'webhook'
+//	url = 'https://hooks.example.com/ergs'
+//
+// Each match is sent to every sink of the search as an Alert, encoded as JSON.
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/log"
+)
+
+var alertsLogger = log.ForService("alerts")
+
+// Alert is a block matching a saved search.
+type Alert struct {
+	Search     string         `json:"search"`
+	ID         string         `json:"id"`
+	Datasource string         `json:"datasource"`
+	DSType     string         `json:"ds_type,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Text       string         `json:"text"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+}
+
+// SavedSearch is a named search query whose matches are sent to sinks.
+type SavedSearch struct {
+	Name  string
+	Query string // Search syntax, including operators (type:, source:, after:) and field filters
+	Sinks []Sink
+}
+
+// Sink delivers alerts somewhere.
+type Sink interface {
+	Send(ctx context.Context, alert Alert) error
+	// String describes the sink in logs
+	String() string
+}
+
+// WebhookSink POSTs each alert as JSON to a URL.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client // Defaults to http.DefaultClient
+}
+
+func (s *WebhookSink) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("encoding alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting alert: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("posting alert: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *WebhookSink) String() string { return "webhook " + s.URL }
+
+// ExecSink runs a command for each alert, with the alert JSON on its standard
+// input. The search name, block ID and datasource are also set in the
+// ERGS_SEARCH, ERGS_BLOCK_ID and ERGS_DATASOURCE environment variables.
+type ExecSink struct {
+	Command []string // Program and arguments; not run through a shell
+}
+
+func (s *ExecSink) Send(ctx context.Context, alert Alert) error {
+	if len(s.Command) == 0 {
+		return fmt.Errorf("empty command")
+	}
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("encoding alert: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, s.Command[0], s.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(append(body, '\n'))
+	// Don't wait for children still holding the output open once cancelled
+	cmd.WaitDelay = time.Second
+	cmd.Env = append(os.Environ(),
+		"ERGS_SEARCH="+alert.Search,
+		"ERGS_BLOCK_ID="+alert.ID,
+		"ERGS_DATASOURCE="+alert.Datasource,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("running %s: %w: %s", s.Command[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (s *ExecSink) String() string { return "exec " + strings.Join(s.Command, " ") }
+
+// FileSink appends each alert to a file as a line of JSON (NDJSON). The file
+// is reopened for every alert, so it can be rotated.
+type FileSink struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (s *FileSink) Send(ctx context.Context, alert Alert) error {
+	line, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("encoding alert: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", s.Path, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing %s: %w", s.Path, err)
+	}
+	return f.Close()
+}
+
+func (s *FileSink) String() string { return "file " + s.Path }
+
+// sendTimeout bounds the delivery of an alert to a sink
+const sendTimeout = 30 * time.Second
+
+// delivery is an alert waiting to be sent to sinks
+type delivery struct {
+	alert Alert
+	sinks []Sink
+}
+
+// Dispatcher sends alerts to their sinks in the background, in order, so slow
+// sinks never hold up ingestion. Alerts are dropped (and logged) when the
+// queue is full. Delivery errors are logged and not retried.
+type Dispatcher struct {
+	queue  chan delivery
+	done   chan struct{}
+	mu     sync.Mutex
+	closed bool
+
+	// Cancelled when Close gives up, aborting the send in progress
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewDispatcher starts a dispatcher queuing up to queueSize alerts.
+func NewDispatcher(queueSize int) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		queue:  make(chan delivery, queueSize),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	go d.run()
+	return d
+}
+
+// Send queues alert for delivery to sinks and reports whether it was queued.
+func (d *Dispatcher) Send(alert Alert, sinks []Sink) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	select {
+	case d.queue <- delivery{alert: alert, sinks: sinks}:
+		return true
+	default:
+		alertsLogger.Warnf("Dropping alert for block %s of saved search %s: queue full", alert.ID, alert.Search)
+		return false
+	}
+}
+
+// Close delivers the queued alerts and stops the dispatcher. Once ctx is
+// done, the send in progress is cancelled and the alerts still queued are
+// dropped (and logged).
+func (d *Dispatcher) Close(ctx context.Context) {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+	select {
+	case <-d.done:
+	case <-ctx.Done():
+		d.cancel()
+		<-d.done
+	}
+	d.cancel()
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	dropped := 0
+	for item := range d.queue {
+		if d.ctx.Err() != nil {
+			dropped++
+			continue
+		}
+		for _, sink := range item.sinks {
+			ctx, cancel := context.WithTimeout(d.ctx, sendTimeout)
+			if err := sink.Send(ctx, item.alert); err != nil {
+				alertsLogger.Warnf("Failed to send alert for block %s of saved search %s to %s: %v", item.alert.ID, item.alert.Search, sink, err)
+			} else {
+				alertsLogger.Debugf("Sent alert for block %s of saved search %s to %s", item.alert.ID, item.alert.Search, sink)
+			}
+			cancel()
+		}
+	}
+	if dropped > 0 {
+		alertsLogger.Warnf("Dropped %d queued alerts on shutdown", dropped)
+	}
+}
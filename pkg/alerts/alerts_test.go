@@ -0,0 +1,156 @@
+package alerts
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSinks(t *testing.T) {
+	alert := Alert{
+		Search:     "mentions",
+		ID:         "story-1",
+		Datasource: "hn",
+		DSType:     "hackernews",
+		CreatedAt:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Text:       "Show HN: ergs",
+		Metadata:   map[string]any{"score": float64(150)},
+	}
+
+	received := make(chan Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- a
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "alerts.ndjson")
+	execPath := filepath.Join(dir, "exec.json")
+
+	sinks := []Sink{
+		&WebhookSink{URL: server.URL},
+		&FileSink{Path: filePath},
+		&ExecSink{Command: []string{"sh", "-c", `cat > "$0" && echo "$ERGS_SEARCH $ERGS_BLOCK_ID $ERGS_DATASOURCE" >> "$0"`, execPath}},
+	}
+	dispatcher := NewDispatcher(10)
+	if !dispatcher.Send(alert, sinks) || !dispatcher.Send(alert, sinks[1:2]) {
+		t.Fatal("expected the alerts to be queued")
+	}
+	dispatcher.Close(context.Background())
+	if dispatcher.Send(alert, sinks) {
+		t.Error("expected a closed dispatcher to refuse alerts")
+	}
+
+	select {
+	case a := <-received:
+		if a.Search != "mentions" || a.ID != "story-1" || a.Metadata["score"] != float64(150) || !a.CreatedAt.Equal(alert.CreatedAt) {
+			t.Errorf("unexpected webhook alert: %+v", a)
+		}
+	default:
+		t.Error("webhook received no alert")
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		t.Fatalf("opening file sink: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var a Alert
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil || a.ID != "story-1" {
+			t.Errorf("unexpected file line %s: %v", scanner.Text(), err)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("file sink has %d lines, want 2", lines)
+	}
+
+	output, err := os.ReadFile(execPath)
+	if err != nil {
+		t.Fatalf("reading exec output: %v", err)
+	}
+	if !strings.Contains(string(output), `"id":"story-1"`) || !strings.HasSuffix(string(output), "mentions story-1 hn\n") {
+		t.Errorf("unexpected exec output: %s", output)
+	}
+}
+
+func TestSinkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	if err := (&WebhookSink{URL: server.URL}).Send(ctx, Alert{}); err == nil {
+		t.Error("expected an error for a failing webhook")
+	}
+	if err := (&ExecSink{Command: []string{"false"}}).Send(ctx, Alert{}); err == nil {
+		t.Error("expected an error for a failing command")
+	}
+	if err := (&FileSink{Path: filepath.Join(t.TempDir(), "missing", "alerts.ndjson")}).Send(ctx, Alert{}); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+// blockingSink never responds until its context is cancelled
+type blockingSink struct {
+	started chan struct{}
+}
+
+func (s *blockingSink) Send(ctx context.Context, alert Alert) error {
+	s.started <- struct{}{}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (s *blockingSink) String() string { return "blocking" }
+
+func TestDispatcherCloseDeadline(t *testing.T) {
+	sink := &blockingSink{started: make(chan struct{}, 1)}
+	filePath := filepath.Join(t.TempDir(), "alerts.ndjson")
+	dispatcher := NewDispatcher(10)
+	for i := range 3 {
+		alert := Alert{Search: "mentions", ID: fmt.Sprintf("story-%d", i)}
+		if !dispatcher.Send(alert, []Sink{sink, &FileSink{Path: filePath}}) {
+			t.Fatal("expected the alert to be queued")
+		}
+	}
+	<-sink.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	dispatcher.Close(ctx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected Close to give up at its deadline, took %s", elapsed)
+	}
+	// The other sinks of the alert in progress still get it, the queued
+	// alerts are dropped
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("reading file sink: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("expected only the alert in progress written, got %d lines", lines)
+	}
+}
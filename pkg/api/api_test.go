@@ -68,6 +68,13 @@ func (d *mockDatasource) Factory(instanceName string, config interface{}) (core.
 }
 
 func setupTestAPIServer(t *testing.T) (*http.ServeMux, func()) {
+	server, cleanup := newTestAPIServer(t)
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+	return mux, cleanup
+}
+
+func newTestAPIServer(t *testing.T) (*Server, func()) {
 	tempDir := t.TempDir()
 	storageManager := storage.NewManagerWithoutMigrationCheck(tempDir)
 	registry := core.NewRegistry()
@@ -148,8 +155,6 @@ func setupTestAPIServer(t *testing.T) (*http.ServeMux, func()) {
 	}
 
 	server := NewServer(registry, storageManager)
-	mux := http.NewServeMux()
-	server.RegisterRoutes(mux)
 
 	cleanup := func() {
 		if err := storageManager.Close(); err != nil {
@@ -160,7 +165,7 @@ func setupTestAPIServer(t *testing.T) (*http.ServeMux, func()) {
 		}
 	}
 
-	return mux, cleanup
+	return server, cleanup
 }
 
 func TestAPIListDatasources(t *testing.T) {
@@ -761,3 +766,232 @@ func TestAPIInvalidDateFormats(t *testing.T) {
 		})
 	}
 }
+
+func TestAPIDeleteBlocks(t *testing.T) {
+	server, cleanup := newTestAPIServer(t)
+	defer cleanup()
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+
+	deleteBlocks := func(query, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", "/api/blocks?"+query, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	// Disabled until an API key is configured
+	if w := deleteBlocks("q=Block", "secret"); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403 without an API key, got %d", w.Code)
+	}
+
+	server.SetAPIKey("secret")
+	if w := deleteBlocks("q=Block", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a token, got %d", w.Code)
+	}
+	if w := deleteBlocks("q=Block", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for an invalid token, got %d", w.Code)
+	}
+	if w := deleteBlocks("", "secret"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without filters, got %d", w.Code)
+	}
+	for _, query := range []string{
+		"datasource=bad%20name&q=Block",
+		"datasource=missing&q=Block",
+		"q=source:datasource1",
+		"datasource=datasource1&since=2030-01-01T00:00:00Z",
+		"datasource=datasource1&start_date=2030-01-01&since=2030-06-01T00:00:00Z",
+		"datasource=datasource1&q=Block&cursor=abc",
+		"datasource=datasource1&q=Block&limit=1",
+		"datasource=datasource1&q=Block&page=2",
+		"datasource=datasource1&q=Block&interval=day",
+	} {
+		if w := deleteBlocks(query, "secret"); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, w.Code)
+		}
+	}
+
+	var response DeleteBlocksResponse
+	w := deleteBlocks("q=Block&datasource=datasource1&end_date=2023-01-01&dry_run=true", "secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !response.DryRun || response.Total != 1 || response.Datasources["datasource1"] != 1 {
+		t.Errorf("Unexpected dry run response: %+v", response)
+	}
+
+	w = deleteBlocks("q=Block&datasource=datasource1", "secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	response = DeleteBlocksResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.DryRun || response.Total != 2 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+
+	req := httptest.NewRequest("GET", "/api/search?q=Block", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	var search SearchResponse
+	if err := json.NewDecoder(w.Body).Decode(&search); err != nil {
+		t.Fatalf("Failed to decode search response: %v", err)
+	}
+	if search.TotalCount != 1 || len(search.Results["datasource2"].Blocks) != 1 {
+		t.Errorf("Expected only the datasource2 block left, got %+v", search.Results)
+	}
+}
+
+func TestAPIAnnotations(t *testing.T) {
+	server, cleanup := newTestAPIServer(t)
+	defer cleanup()
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) AnnotationsResponse {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response AnnotationsResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	response := decode(do("POST", "/api/blocks/datasource1/1/tags", `{"tags": ["ToRead", "work"]}`))
+	if strings.Join(response.Tags, ",") != "toread,work" || response.ID != "1" || response.Datasource != "datasource1" {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+	response = decode(do("PUT", "/api/blocks/datasource1/1/note", `{"note": "follow up"}`))
+	if response.Note != "follow up" {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+	response = decode(do("DELETE", "/api/blocks/datasource1/1/tags/work", ""))
+	if strings.Join(response.Tags, ",") != "toread" || response.Note != "follow up" {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+	response = decode(do("GET", "/api/blocks/datasource1/1/annotations", ""))
+	if strings.Join(response.Tags, ",") != "toread" {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+
+	w := do("GET", "/api/search?q=tag:toread", "")
+	var search SearchResponse
+	if err := json.NewDecoder(w.Body).Decode(&search); err != nil {
+		t.Fatalf("Failed to decode search response: %v", err)
+	}
+	if search.TotalCount != 1 || len(search.Results["datasource1"].Blocks) != 1 {
+		t.Errorf("Expected block 1 to match tag:toread, got %+v", search.Results)
+	}
+
+	w = do("GET", "/api/tags", "")
+	var tags ListTagsResponse
+	if err := json.NewDecoder(w.Body).Decode(&tags); err != nil {
+		t.Fatalf("Failed to decode tags response: %v", err)
+	}
+	if tags.Count != 1 || tags.Tags[0].Tag != "toread" || tags.Tags[0].Count != 1 {
+		t.Errorf("Unexpected tags response: %+v", tags)
+	}
+
+	for _, tt := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{"POST", "/api/blocks/missing/1/tags", `{"tags": ["a"]}`, http.StatusNotFound},
+		{"POST", "/api/blocks/datasource1/404/tags", `{"tags": ["a"]}`, http.StatusNotFound},
+		{"POST", "/api/blocks/datasource1/1/tags", `{"tags": ["two words"]}`, http.StatusBadRequest},
+		{"POST", "/api/blocks/datasource1/1/tags", `{}`, http.StatusBadRequest},
+		{"PUT", "/api/blocks/datasource1/1/note", `not json`, http.StatusBadRequest},
+	} {
+		if w := do(tt.method, tt.path, tt.body); w.Code != tt.status {
+			t.Errorf("%s %s %s: expected status %d, got %d", tt.method, tt.path, tt.body, tt.status, w.Code)
+		}
+	}
+}
+
+func TestAPIInbox(t *testing.T) {
+	server, cleanup := newTestAPIServer(t)
+	defer cleanup()
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+	inbox := func(path string) InboxResponse {
+		t.Helper()
+		w := do("GET", path, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response InboxResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	response := inbox("/api/inbox")
+	if response.TotalCount != 3 || response.Count != 3 || response.State != "unread" || response.Counts["datasource1"] != 2 {
+		t.Errorf("Unexpected inbox: %+v", response)
+	}
+	if response.Blocks[0].IngestedAt.IsZero() || response.Blocks[0].Datasource == "" {
+		t.Errorf("Expected the datasource and ingestion time, got %+v", response.Blocks[0])
+	}
+
+	if w := do("PUT", "/api/blocks/datasource1/1/inbox", `{"state": "archived"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	response = inbox("/api/inbox?datasource=datasource1")
+	if response.TotalCount != 1 || len(response.Blocks) != 1 || response.Blocks[0].ID != "2" {
+		t.Errorf("Unexpected inbox: %+v", response)
+	}
+	response = inbox("/api/inbox?state=archived")
+	if len(response.Blocks) != 1 || response.Blocks[0].ID != "1" || response.Blocks[0].State != "archived" {
+		t.Errorf("Unexpected archived blocks: %+v", response)
+	}
+
+	w := do("POST", "/api/inbox/read", "")
+	var marked MarkAllReadResponse
+	if err := json.NewDecoder(w.Body).Decode(&marked); err != nil || marked.Total != 2 {
+		t.Errorf("Unexpected mark all read response (%v): %+v", err, marked)
+	}
+	if response = inbox("/api/inbox"); response.TotalCount != 0 {
+		t.Errorf("Expected an empty inbox, got %+v", response)
+	}
+
+	for _, tt := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/api/inbox?state=later", "", http.StatusBadRequest},
+		{"GET", "/api/inbox?limit=0", "", http.StatusBadRequest},
+		{"GET", "/api/inbox?datasource=missing", "", http.StatusNotFound},
+		{"PUT", "/api/blocks/datasource1/404/inbox", `{"state": "read"}`, http.StatusNotFound},
+		{"PUT", "/api/blocks/datasource1/1/inbox", `{}`, http.StatusBadRequest},
+		{"POST", "/api/inbox/read", `{"datasources": ["missing"]}`, http.StatusNotFound},
+	} {
+		if w := do(tt.method, tt.path, tt.body); w.Code != tt.status {
+			t.Errorf("%s %s %s: expected status %d, got %d", tt.method, tt.path, tt.body, tt.status, w.Code)
+		}
+	}
+}
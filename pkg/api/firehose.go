@@ -0,0 +1,139 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+	"github.com/rubiojr/ergs/pkg/realtime"
+	"github.com/rubiojr/ergs/pkg/storage"
+)
+
+// firehoseBlock is a block as streamed by the WebSocket and SSE firehoses
+type firehoseBlock struct {
+	ID            string                 `json:"id"`
+	Text          string                 `json:"text"`
+	Source        string                 `json:"source"`
+	DSType        string                 `json:"ds_type,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	FormattedHTML string                 `json:"formatted_html,omitempty"`
+}
+
+// pushHub is the part of the firehose hub streaming handlers register with
+type pushHub interface {
+	Register() (uint64, <-chan InternalEvent)
+	Unregister(id uint64)
+}
+
+// filteringHub is implemented by hubs that drop the events a listener is not
+// subscribed to
+type filteringHub interface {
+	SetFilter(id uint64, filter realtime.Filter)
+}
+
+// firehoseStreamParams parses the search parameters of a streaming firehose
+// request leniently, defaulting to the first page of 30 blocks.
+func firehoseStreamParams(r *http.Request) storage.SearchParams {
+	params, _ := storage.ParseSearchParams(r.URL.Query()) // ignore error; streaming paths are lenient
+	if params.Limit == 0 {
+		params.Limit = 30
+	}
+	if params.Page == 0 {
+		params.Page = 1
+	}
+	// If since is provided we force page=1 to return only newest after the boundary.
+	if params.Since != nil {
+		params.Page = 1
+	}
+	return params
+}
+
+// firehoseSnapshot searches with params and returns the blocks after
+// params.Since and the newest block time. Blocks are rendered to HTML when
+// render is set and the server has a renderer.
+func (s *Server) firehoseSnapshot(params storage.SearchParams, render bool) ([]firehoseBlock, time.Time, error) {
+	results, err := s.storageManager.GetSearchService().Search(params)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var (
+		blocks []firehoseBlock
+		newest time.Time
+	)
+	for dsName, dsBlocks := range results.Results {
+		for _, b := range dsBlocks {
+			// Apply 'since' boundary if present.
+			// Normalize comparison to second precision so a block whose stored created_at
+			// is the same second as the client 'since' cursor (but with greater sub‑second
+			// precision) is NOT included (prevents duplicate on reconnect).
+			if params.Since != nil {
+				if !b.CreatedAt().Truncate(time.Second).After(params.Since.Truncate(time.Second)) {
+					continue
+				}
+			}
+			blocks = append(blocks, s.firehoseStoredBlock(dsName, b, render))
+			if b.CreatedAt().After(newest) {
+				newest = b.CreatedAt()
+			}
+		}
+	}
+	return blocks, newest, nil
+}
+
+// firehoseStoredBlock returns a stored block of datasource, rendered to HTML
+// when render is set and the server has a renderer.
+func (s *Server) firehoseStoredBlock(datasource string, b core.Block, render bool) firehoseBlock {
+	md := b.Metadata()
+	if md == nil {
+		md = map[string]interface{}{}
+	}
+	if _, ok := md["datasource"]; !ok {
+		md["datasource"] = datasource
+	}
+	return firehoseBlock{
+		ID:            b.ID(),
+		Text:          b.Text(),
+		Source:        b.Source(),
+		DSType:        b.Type(),
+		CreatedAt:     b.CreatedAt(),
+		Metadata:      md,
+		FormattedHTML: s.renderFirehoseBlock(b, render),
+	}
+}
+
+// firehoseEventBlock returns the block of a hub event, rendered to HTML when
+// render is set and the server has a renderer.
+func (s *Server) firehoseEventBlock(be realtime.BlockEvent, render bool) firehoseBlock {
+	blk := firehoseBlock{
+		ID:        be.ID,
+		Text:      be.Text,
+		Source:    be.Datasource,
+		DSType:    be.DSType,
+		CreatedAt: be.CreatedAt,
+		Metadata:  be.Metadata,
+	}
+	if render && s.rendererService != nil {
+		gb := core.NewGenericBlock(be.ID, be.Text, be.Datasource, be.DSType, be.CreatedAt, be.Metadata)
+		blk.FormattedHTML = s.renderFirehoseBlock(gb, true)
+	}
+	return blk
+}
+
+// renderFirehoseBlock renders a block to HTML, returning "" when render is
+// not set or the server has no renderer.
+func (s *Server) renderFirehoseBlock(b core.Block, render bool) string {
+	if !render || s.rendererService == nil {
+		return ""
+	}
+	htmlStr, _ := s.rendererService.Render(b)
+	return htmlStr
+}
+
+// sortFirehoseBlocks sorts blocks oldest first
+func sortFirehoseBlocks(blocks []firehoseBlock) {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return blocks[i].CreatedAt.Before(blocks[j].CreatedAt)
+	})
+}
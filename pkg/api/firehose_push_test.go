@@ -171,6 +171,84 @@ func TestWebSocketPushModeAndModeField(t *testing.T) {
 	if blk["text"] != "push mode test" {
 		t.Fatalf("expected block text, got %v", blk["text"])
 	}
+
+	// Deletions are forwarded as "delete" messages so clients can drop the block.
+	hub.Broadcast(realtime.InternalEvent{Type: "delete", Block: realtime.BlockEvent{ID: "p1", Datasource: dsName}})
+	msg = readNextOfType(t, conn, "delete", 5*time.Second)
+	if msg["id"] != "p1" || msg["datasource"] != dsName {
+		t.Fatalf("unexpected delete message: %v", msg)
+	}
+}
+
+func TestWebSocketSubscription(t *testing.T) {
+	mgr, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("new manager: %v", err)
+	}
+	defer func() { _ = mgr.Close() }()
+	initDatasource(t, mgr, "sub_a")
+	initDatasource(t, mgr, "sub_b")
+	// type: filters resolve datasource types through the block prototypes
+	mgr.RegisterBlockPrototype("sub_a", &wsTestBlock{dsType: "typeA"})
+	mgr.RegisterBlockPrototype("sub_b", &wsTestBlock{dsType: "typeA"})
+
+	server := api.NewServer(core.GetGlobalRegistry(), mgr)
+	hub := realtime.NewFirehoseHub(16)
+	server.SetFirehoseHub(hub)
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	publish := func(ds, id, text string) {
+		now := time.Now().UTC()
+		storeBlock(t, mgr, ds, "typeA", &wsTestBlock{id: id, text: text, created: now, source: ds, dsType: "typeA"})
+		hub.Broadcast(realtime.BlockEvent{ID: id, Datasource: ds, DSType: "typeA", CreatedAt: now, Text: text})
+	}
+
+	conn, initMsg := wsConnect(t, ts.URL, "datasource=sub_b")
+	defer func() { _ = conn.Close() }()
+	if sub, _ := initMsg["subscription"].(map[string]any); sub == nil || fmt.Sprint(sub["datasources"]) != "[sub_b]" {
+		t.Fatalf("unexpected subscription in init: %v", initMsg["subscription"])
+	}
+
+	// Only blocks of sub_b are delivered
+	publish("sub_a", "a1", "first block")
+	publish("sub_b", "b1", "second block")
+	msg := readNextOfType(t, conn, "block", 5*time.Second)
+	if blk := msg["block"].(map[string]any); blk["id"] != "b1" {
+		t.Fatalf("expected block b1, got %v", blk["id"])
+	}
+
+	// Invalid subscriptions are rejected and leave the current one in place
+	if err := conn.WriteJSON(map[string]any{"type": "subscribe", "query": "after:someday"}); err != nil {
+		t.Fatalf("write subscribe: %v", err)
+	}
+	if msg := readNextOfType(t, conn, "error", 5*time.Second); msg["error"] != "invalid_subscription" {
+		t.Fatalf("expected invalid_subscription error, got %v", msg)
+	}
+
+	// A new subscription starts with a snapshot of the blocks it selects
+	if err := conn.WriteJSON(map[string]any{"type": "subscribe", "query": "needle type:typeA"}); err != nil {
+		t.Fatalf("write subscribe: %v", err)
+	}
+	msg = readNextOfType(t, conn, "init", 5*time.Second)
+	if msg["count"] != float64(0) {
+		t.Fatalf("expected an empty snapshot, got %v", msg)
+	}
+	publish("sub_a", "a2", "just hay")
+	publish("sub_a", "a3", "a needle in the hay")
+	msg = readNextOfType(t, conn, "block", 5*time.Second)
+	if blk := msg["block"].(map[string]any); blk["id"] != "a3" {
+		t.Fatalf("expected block a3, got %v", blk["id"])
+	}
+
+	// Deletes are filtered by datasource and type only
+	hub.Broadcast(realtime.InternalEvent{Type: "delete", Block: realtime.BlockEvent{ID: "x", Datasource: "sub_b", DSType: "other"}})
+	hub.Broadcast(realtime.InternalEvent{Type: "delete", Block: realtime.BlockEvent{ID: "a2", Datasource: "sub_a", DSType: "typeA"}})
+	if msg := readNextOfType(t, conn, "delete", 5*time.Second); msg["id"] != "a2" {
+		t.Fatalf("expected delete of a2, got %v", msg)
+	}
 }
 
 // ---- 3: Since precision deduplication (second truncation boundary) ------------
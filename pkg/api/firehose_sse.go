@@ -0,0 +1,329 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sseRetry is the reconnect delay, in milliseconds, suggested to SSE clients
+const sseRetry = 5000
+
+// sseCatchUpBatch is the most blocks of a datasource read at once when
+// catching up with its database
+const sseCatchUpBatch = 500
+
+// HandleFirehoseSSE streams the firehose as Server-Sent Events, for clients
+// that can't speak WebSocket (curl, EventSource, proxies buffering upgrades).
+//
+// It takes the same since, datasource, type, q and limit parameters as
+// /api/firehose/ws, and html=true adds the pre-rendered formatted_html to
+// blocks. The stream starts with an init event describing the subscription,
+// followed by the snapshot and then new blocks, one block event each. Every
+// block event has as ID the position of the stream in each datasource (see
+// sseCursor), so a client reconnecting with Last-Event-ID gets every block
+// stored after the ones it got, in the order they were stored, instead of a
+// snapshot. Deletes (push mode only) come as delete events and a heartbeat
+// event is sent every 30s.
+//
+// New blocks are read from the datasource databases: hub events only tell
+// which datasource to read, and without a hub they are polled every 5s.
+func (s *Server) HandleFirehoseSSE(w http.ResponseWriter, r *http.Request) {
+	params := firehoseStreamParams(r)
+	query := r.URL.Query()
+	sub, err := newFirehoseSubscription(query["datasource"], query["type"], query.Get("q"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid subscription", err.Error())
+		return
+	}
+	params = sub.requestParams(params)
+
+	// Last-Event-ID, sent by EventSource when reconnecting, resumes the stream
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("last_event_id")
+	}
+	var resume sseCursor
+	if lastEventID != "" {
+		if resume, err = parseSSECursor(lastEventID); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid Last-Event-ID", err.Error())
+			return
+		}
+	}
+
+	render := false
+	if v := query.Get("html"); v != "" {
+		if render, err = strconv.ParseBool(v); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid parameter", "html must be true or false")
+			return
+		}
+	}
+
+	// Register before reading the databases so no block stored meanwhile is
+	// missed
+	var eventsC <-chan InternalEvent
+	mode := "poll"
+	if rh, ok := s.firehoseHub.(pushHub); ok && rh != nil {
+		var subID uint64
+		subID, eventsC = rh.Register()
+		defer rh.Unregister(subID)
+		if f, ok := rh.(filteringHub); ok {
+			f.SetFilter(subID, sub.filter())
+		}
+		mode = "push"
+	}
+
+	searchService := s.storageManager.GetSearchService()
+	datasources := sub.datasources(searchService)
+	cursor := make(sseCursor, len(datasources))
+	var blocks []firehoseBlock
+	sent := make(map[string]bool)
+	if resume != nil {
+		// Datasources missing from the cursor were added since, all their
+		// blocks are new
+		for _, name := range datasources {
+			cursor[name] = resume[name]
+		}
+	} else {
+		// New streams follow the blocks stored after the snapshot; the ones
+		// stored while it is taken are in both and skipped
+		for _, name := range datasources {
+			if cursor[name], err = s.lastRowID(name); err != nil {
+				s.writeError(w, http.StatusInternalServerError, "Failed to retrieve blocks", err.Error())
+				return
+			}
+		}
+		if blocks, _, err = s.firehoseSnapshot(params, render); err != nil {
+			s.writeError(w, http.StatusInternalServerError, "Failed to retrieve blocks", err.Error())
+			return
+		}
+		sortFirehoseBlocks(blocks)
+		for _, b := range blocks {
+			sent[b.Source+"/"+b.ID] = true
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // Don't let nginx buffer the stream
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", sseRetry); err != nil {
+		return
+	}
+
+	// catchUp sends the matching blocks stored in datasource after the
+	// cursor, moving it past every block read
+	catchUp := func(datasource string) error {
+		st, err := s.storageManager.GetStorage(datasource)
+		if err != nil {
+			return err
+		}
+		// SQLite hands the rowids of deleted newest blocks out again
+		last, err := st.LastRowID()
+		if err != nil {
+			return err
+		}
+		cursor[datasource] = min(cursor[datasource], last)
+		for {
+			batch, err := st.GetBlocksAfterRowID(cursor[datasource], sseCatchUpBatch)
+			if err != nil {
+				return err
+			}
+			if len(batch) == 0 {
+				return nil
+			}
+			ids := make([]string, len(batch))
+			for i, ib := range batch {
+				ids[i] = ib.Block.ID()
+			}
+			matching, err := sub.matching(searchService, datasource, ids)
+			if err != nil {
+				return err
+			}
+			for _, ib := range batch {
+				cursor[datasource] = ib.RowID
+				if sent[datasource+"/"+ib.Block.ID()] || !slices.Contains(matching, ib.Block.ID()) {
+					continue
+				}
+				if err := writeSSE(w, "block", cursor.String(), s.firehoseStoredBlock(datasource, ib.Block, render)); err != nil {
+					return err
+				}
+			}
+			if len(batch) < sseCatchUpBatch {
+				return nil
+			}
+		}
+	}
+	catchUpAll := func() error {
+		var errs []error
+		for _, name := range datasources {
+			if err := catchUp(name); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	// catchUpFailed reports a failed catch up to the client, returning false
+	// when the stream is gone
+	catchUpFailed := func(err error) bool {
+		log.Printf("firehose sse: %s reading new blocks: %v", r.RemoteAddr, err)
+		return writeSSE(w, "error", "", map[string]any{
+			"error": "read_failed",
+			"info":  err.Error(),
+		}) == nil
+	}
+
+	log.Printf("firehose sse: %s init snapshot blocks=%d mode=%s resume=%q", r.RemoteAddr, len(blocks), mode, lastEventID)
+	if err := writeSSE(w, "init", "", map[string]any{
+		"count":         len(blocks),
+		"mode":          mode,
+		"subscription":  sub,
+		"since":         params.Since,
+		"last_event_id": lastEventID,
+	}); err != nil {
+		return
+	}
+	for _, b := range blocks {
+		if err := writeSSE(w, "block", cursor.String(), b); err != nil {
+			return
+		}
+	}
+	if err := catchUpAll(); err != nil && !catchUpFailed(err) {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		log.Printf("firehose sse: %s flush failed: %v", r.RemoteAddr, err)
+		return
+	}
+
+	pollTicker := time.NewTicker(5 * time.Second)
+	defer pollTicker.Stop()
+	heartbeatTicker := time.NewTicker(30 * time.Second)
+	defer heartbeatTicker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case evt, open := <-eventsC:
+			if !open {
+				return
+			}
+			be := evt.Block
+			if !sub.filter().Matches(evt) {
+				continue
+			}
+			if evt.Type == "delete" {
+				if err := writeSSE(w, "delete", "", map[string]any{
+					"id":         be.ID,
+					"datasource": be.Datasource,
+					"ds_type":    be.DSType,
+				}); err != nil {
+					return
+				}
+			} else {
+				if !slices.Contains(datasources, be.Datasource) {
+					// Added since the stream started
+					datasources = sub.datasources(searchService)
+					if !slices.Contains(datasources, be.Datasource) {
+						continue
+					}
+				}
+				if err := catchUp(be.Datasource); err != nil && !catchUpFailed(err) {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-pollTicker.C:
+			if eventsC != nil {
+				continue // hub push active; skip polling
+			}
+			if err := catchUpAll(); err != nil && !catchUpFailed(err) {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		case <-heartbeatTicker.C:
+			if err := writeSSE(w, "heartbeat", "", map[string]any{
+				"ts": time.Now().UTC().Format(time.RFC3339Nano),
+			}); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// lastRowID returns the rowid of the last block stored in datasource
+func (s *Server) lastRowID(datasource string) (int64, error) {
+	st, err := s.storageManager.GetStorage(datasource)
+	if err != nil {
+		return 0, err
+	}
+	return st.LastRowID()
+}
+
+// sseCursor is the position of an SSE stream: the rowid of the last block
+// read from each datasource. Rowids grow in the order blocks are stored, so
+// resuming past them gets exactly the blocks stored since, whatever their
+// created_at.
+type sseCursor map[string]int64
+
+// String encodes the cursor as a block event ID: name:rowid pairs sorted by
+// name and separated by commas. Datasource names can't contain either.
+func (c sseCursor) String() string {
+	names := slices.Sorted(maps.Keys(c))
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ":" + strconv.FormatInt(c[name], 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseSSECursor decodes a cursor encoded by sseCursor.String.
+func parseSSECursor(id string) (sseCursor, error) {
+	c := sseCursor{}
+	for _, part := range strings.Split(id, ",") {
+		name, rowID, ok := strings.Cut(part, ":")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("Last-Event-ID must be a block event ID of the stream")
+		}
+		n, err := strconv.ParseInt(rowID, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid position %q of %s in Last-Event-ID", rowID, name)
+		}
+		c[name] = n
+	}
+	return c, nil
+}
+
+// writeSSE writes v as a JSON Server-Sent Event, with an id field when id is
+// not empty.
+func writeSSE(w http.ResponseWriter, event, id string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
@@ -0,0 +1,196 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/api"
+	"github.com/rubiojr/ergs/pkg/core"
+	"github.com/rubiojr/ergs/pkg/realtime"
+	"github.com/rubiojr/ergs/pkg/storage"
+)
+
+type sseEvent struct {
+	id    string
+	event string
+	data  map[string]any
+}
+
+// sseStream is an open /api/firehose/sse response
+type sseStream struct {
+	t      *testing.T
+	cancel context.CancelFunc
+	lines  chan string
+}
+
+func sseConnect(t *testing.T, baseURL, rawQuery, lastEventID string) *sseStream {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/api/firehose/sse?"+rawQuery, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatalf("connect sse: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		cancel()
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		cancel()
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		defer func() { _ = resp.Body.Close() }()
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return &sseStream{t: t, cancel: cancel, lines: lines}
+}
+
+// next returns the next event of the desired type
+func (s *sseStream) next(desired string) sseEvent {
+	s.t.Helper()
+	timeout := time.After(5 * time.Second)
+	var evt sseEvent
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.t.Fatalf("stream closed waiting for %s event", desired)
+			}
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				evt.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				evt.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &evt.data); err != nil {
+					s.t.Fatalf("decode event data %q: %v", line, err)
+				}
+			case line == "":
+				if evt.event == desired {
+					return evt
+				}
+				evt = sseEvent{}
+			}
+		case <-timeout:
+			s.t.Fatalf("did not receive %s event within timeout", desired)
+		}
+	}
+}
+
+func TestFirehoseSSE(t *testing.T) {
+	mgr, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("new manager: %v", err)
+	}
+	defer func() { _ = mgr.Close() }()
+	initDatasource(t, mgr, "sse_a")
+	initDatasource(t, mgr, "sse_b")
+
+	server := api.NewServer(core.GetGlobalRegistry(), mgr)
+	hub := realtime.NewFirehoseHub(16)
+	server.SetFirehoseHub(hub)
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Second)
+	storeBlock(t, mgr, "sse_a", "test", &wsTestBlock{id: "old", text: "stored", created: base, source: "sse_a"})
+	storeBlock(t, mgr, "sse_b", "test", &wsTestBlock{id: "other", text: "stored", created: base, source: "sse_b"})
+
+	stream := sseConnect(t, ts.URL, "datasource=sse_a", "")
+	defer stream.cancel()
+	if init := stream.next("init"); init.data["mode"] != "push" || init.data["count"] != float64(1) {
+		t.Fatalf("unexpected init event: %v", init.data)
+	}
+	snap := stream.next("block")
+	if snap.data["id"] != "old" || snap.id != "sse_a:1" {
+		t.Fatalf("unexpected snapshot block: id=%s %v", snap.id, snap.data)
+	}
+
+	// New blocks of other datasources are not sent
+	created := base.Add(10 * time.Second)
+	hub.Broadcast(realtime.BlockEvent{ID: "skip", Datasource: "sse_b", CreatedAt: created, Text: "skipped"})
+	storeBlock(t, mgr, "sse_a", "test", &wsTestBlock{id: "new", text: "pushed", created: created, source: "sse_a"})
+	hub.Broadcast(realtime.BlockEvent{ID: "new", Datasource: "sse_a", CreatedAt: created, Text: "pushed"})
+	live := stream.next("block")
+	if live.data["id"] != "new" || live.id != "sse_a:2" {
+		t.Fatalf("unexpected live block: id=%s %v", live.id, live.data)
+	}
+	hub.Broadcast(realtime.InternalEvent{Type: "delete", Block: realtime.BlockEvent{ID: "old", Datasource: "sse_a"}})
+	if del := stream.next("delete"); del.data["id"] != "old" {
+		t.Fatalf("unexpected delete event: %v", del.data)
+	}
+	stream.cancel()
+
+	// Reconnecting with Last-Event-ID gets every block stored after the last
+	// one received, older ones and more than the limit included, in the order
+	// they were stored
+	for i, id := range []string{"late1", "late2", "late3"} {
+		storeBlock(t, mgr, "sse_a", "test", &wsTestBlock{id: id, text: "late", created: base.Add(-time.Duration(i+1) * time.Hour), source: "sse_a"})
+	}
+	resumed := sseConnect(t, ts.URL, "datasource=sse_a&limit=1", snap.id)
+	defer resumed.cancel()
+	if init := resumed.next("init"); init.data["count"] != float64(0) || init.data["last_event_id"] != "sse_a:1" {
+		t.Fatalf("expected no snapshot when resuming, got %v", init.data)
+	}
+	for i, want := range []string{"new", "late1", "late2", "late3"} {
+		blk := resumed.next("block")
+		if blk.data["id"] != want || blk.id != fmt.Sprintf("sse_a:%d", i+2) {
+			t.Fatalf("expected block %s after Last-Event-ID, got id=%s %v", want, blk.id, blk.data)
+		}
+	}
+	resumed.cancel()
+
+	// Resuming from the last event gets nothing, then the blocks stored later
+	again := sseConnect(t, ts.URL, "datasource=sse_a", "sse_a:5")
+	defer again.cancel()
+	again.next("init")
+	storeBlock(t, mgr, "sse_a", "test", &wsTestBlock{id: "next", text: "pushed", created: base, source: "sse_a"})
+	hub.Broadcast(realtime.BlockEvent{ID: "next", Datasource: "sse_a", CreatedAt: base, Text: "pushed"})
+	if blk := again.next("block"); blk.data["id"] != "next" || blk.id != "sse_a:6" {
+		t.Fatalf("expected block next after the last event, got id=%s %v", blk.id, blk.data)
+	}
+
+	// Invalid subscriptions are rejected before streaming
+	resp, err := http.Get(ts.URL + "/api/firehose/sse?q=after:someday")
+	if err != nil {
+		t.Fatalf("get sse: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an invalid subscription, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/api/firehose/sse", nil)
+	req.Header.Set("Last-Event-ID", base.Format(time.RFC3339Nano))
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get sse: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an invalid Last-Event-ID, got %d", resp.StatusCode)
+	}
+}
@@ -4,8 +4,14 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -57,6 +63,7 @@ func (s *Server) HandleListDatasources(w http.ResponseWriter, r *http.Request) {
 //   - q: Optional search query string for full-text search within the datasource
 //   - limit: Maximum number of results (default: 20, max: 100)
 //   - page: Page number for pagination (default: 1)
+//   - cursor: Opaque cursor from a previous response's next_cursor (takes precedence over page)
 //   - start_date: Date in YYYY-MM-DD format to filter blocks created on or after this date
 //   - end_date: Date in YYYY-MM-DD format to filter blocks created on or before this date (inclusive of entire day)
 //
@@ -77,7 +84,8 @@ func (s *Server) HandleListDatasources(w http.ResponseWriter, r *http.Request) {
 //	    }
 //	  ],
 //	  "count": 1,
-//	  "query": "bug fix"
+//	  "query": "bug fix",
+//	  "next_cursor": "eyJ0IjoiMjAyNC0wMS0xNVQxMDozMDowMFoiLCJkIjoiZ2l0aHViIiwiaSI6ImFiYzEyMyJ9"
 //	}
 //
 // Returns:
@@ -117,8 +125,8 @@ func (s *Server) HandleDatasourceBlocks(w http.ResponseWriter, r *http.Request)
 	searchService := s.storageManager.GetSearchService()
 	results, err := searchService.Search(params)
 	if err != nil {
-		// Handle FTS5 syntax errors more gracefully
-		if isFTS5SyntaxError(err) {
+		// Handle invalid queries and FTS5 syntax errors more gracefully
+		if isInvalidQueryError(err) {
 			s.writeError(w, http.StatusBadRequest, "Invalid search query", formatAPISearchError(err))
 		} else {
 			s.writeError(w, http.StatusInternalServerError, "Failed to retrieve blocks", err.Error())
@@ -148,6 +156,7 @@ func (s *Server) HandleDatasourceBlocks(w http.ResponseWriter, r *http.Request)
 		Blocks:     blockResponses,
 		Count:      len(blockResponses),
 		Query:      params.Query,
+		NextCursor: results.NextCursor,
 	}
 
 	s.writeJSON(w, http.StatusOK, response)
@@ -160,9 +169,11 @@ func (s *Server) HandleDatasourceBlocks(w http.ResponseWriter, r *http.Request)
 //   - q: Search query string (required) - supports full-text search with FTS5 syntax
 //   - limit: Maximum number of results per datasource (default: 30, max: 100)
 //   - page: Page number for pagination (default: 1)
+//   - cursor: Opaque cursor from a previous response's next_cursor (takes precedence over page)
 //   - start_date: Date in YYYY-MM-DD format to filter blocks created on or after this date
 //   - end_date: Date in YYYY-MM-DD format to filter blocks created on or before this date (inclusive of entire day)
 //   - datasources: Comma-separated list of datasource names to limit search scope
+//   - interval: Bucket size of the facets histogram: day, week or month (default: month)
 //
 // Search query syntax:
 //   - Simple terms: "authentication bug"
@@ -193,7 +204,15 @@ func (s *Server) HandleDatasourceBlocks(w http.ResponseWriter, r *http.Request)
 //	  "page": 1,
 //	  "limit": 30,
 //	  "total_pages": 1,
-//	  "has_more": false
+//	  "has_more": false,
+//	  "facets": {
+//	    "total": 2,
+//	    "datasources": [{"value": "github", "count": 1}, ...],
+//	    "types": [...],
+//	    "hostnames": [...],
+//	    "interval": "month",
+//	    "histogram": [{"start": "2024-01-01T00:00:00Z", "count": 2}]
+//	  }
 //	}
 //
 // Returns:
@@ -204,7 +223,11 @@ func (s *Server) HandleSearch(w http.ResponseWriter, r *http.Request) {
 	// Parse search parameters
 	params, err := storage.ParseSearchParams(r.URL.Query())
 	if err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid date format", err.Error())
+		if isInvalidQueryError(err) {
+			s.writeError(w, http.StatusBadRequest, "Invalid search query", err.Error())
+		} else {
+			s.writeError(w, http.StatusBadRequest, "Invalid date format", err.Error())
+		}
 		return
 	}
 
@@ -213,13 +236,15 @@ func (s *Server) HandleSearch(w http.ResponseWriter, r *http.Request) {
 		s.writeError(w, http.StatusBadRequest, "Missing query parameter", "Query parameter 'q' is required")
 		return
 	}
+	params.Snippets = true
+	params.Facets = true
 
 	// Perform search using search service
 	searchService := s.storageManager.GetSearchService()
 	results, err := searchService.Search(params)
 	if err != nil {
-		// Handle FTS5 syntax errors more gracefully
-		if isFTS5SyntaxError(err) {
+		// Handle invalid queries and FTS5 syntax errors more gracefully
+		if isInvalidQueryError(err) {
 			s.writeError(w, http.StatusBadRequest, "Invalid search query", formatAPISearchError(err))
 		} else {
 			s.writeError(w, http.StatusInternalServerError, "Search failed", err.Error())
@@ -238,6 +263,7 @@ func (s *Server) HandleSearch(w http.ResponseWriter, r *http.Request) {
 				Source:    block.Source(),
 				CreatedAt: block.CreatedAt(),
 				Metadata:  block.Metadata(),
+				Snippet:   storage.SnippetHTML(results.Snippet(datasourceName, block.ID())),
 			}
 		}
 
@@ -257,11 +283,100 @@ func (s *Server) HandleSearch(w http.ResponseWriter, r *http.Request) {
 		Limit:      results.Limit,
 		TotalPages: results.TotalPages,
 		HasMore:    results.HasMore,
+		NextCursor: results.NextCursor,
+		Facets:     results.Facets,
 	}
 
 	s.writeJSON(w, http.StatusOK, response)
 }
 
+// purgeIgnoredParams are the search parameters DELETE /api/blocks rejects.
+// Purge only applies the query, datasource and date filters.
+var purgeIgnoredParams = []string{"since", "cursor", "limit", "page", "interval"}
+
+// HandleDeleteBlocks handles DELETE /api/blocks requests.
+// It deletes the blocks matching a search, the same blocks GET /api/search
+// would return across every page. Requires an "Authorization: Bearer <api_key>"
+// header matching api_key in the [api] config section.
+//
+// Query parameters:
+//   - q: Search query, including field filters and operators like before:2024-01-01
+//   - datasource: Datasource filter (can be specified multiple times)
+//   - start_date: Date in YYYY-MM-DD format to only delete blocks created on or after this date
+//   - end_date: Date in YYYY-MM-DD format to only delete blocks created on or before this date
+//   - dry_run: When true, only count the matching blocks
+//
+// Every datasource must exist; operators in q only narrow them. Once the
+// operators are extracted, a query, a datasource or a date filter must be
+// left, so source: or type: alone can't delete whole datasources. The search
+// parameters a purge ignores (since, cursor, limit, page, interval) are
+// rejected instead of silently widening the deletion.
+//
+// Response format:
+//
+//	{
+//	  "datasources": {"firefox": 42},
+//	  "total": 42,
+//	  "dry_run": false
+//	}
+//
+// Returns:
+//   - HTTP 200: Success with DeleteBlocksResponse JSON body
+//   - HTTP 400: Bad request (no filters, invalid search syntax, invalid dates)
+//   - HTTP 401: Missing or invalid API token
+//   - HTTP 403: No api_key configured
+//   - HTTP 500: Internal server error
+func (s *Server) HandleDeleteBlocks(w http.ResponseWriter, r *http.Request) {
+	// Unknown datasources must not fall back to deleting from all of them
+	query := r.URL.Query()
+	known := s.storageManager.GetDatasourceNames()
+	for _, ds := range query["datasource"] {
+		if !slices.Contains(known, ds) {
+			s.writeError(w, http.StatusBadRequest, "Invalid datasource", fmt.Sprintf("unknown datasource %q", ds))
+			return
+		}
+	}
+
+	for _, param := range purgeIgnoredParams {
+		if query.Has(param) {
+			s.writeError(w, http.StatusBadRequest, "Invalid parameter", fmt.Sprintf("%s is not supported when deleting blocks", param))
+			return
+		}
+	}
+
+	// Purge resolves the operators in q itself, keeping them within the
+	// datasource parameter
+	values := maps.Clone(query)
+	delete(values, "q")
+	params, err := storage.ParseSearchParams(values)
+	params.Query = query.Get("q")
+	if err != nil {
+		if isInvalidQueryError(err) {
+			s.writeError(w, http.StatusBadRequest, "Invalid search query", err.Error())
+		} else {
+			s.writeError(w, http.StatusBadRequest, "Invalid date format", err.Error())
+		}
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	result, err := s.storageManager.GetSearchService().Purge(params, dryRun)
+	if err != nil {
+		if isInvalidQueryError(err) {
+			s.writeError(w, http.StatusBadRequest, "Invalid search query", formatAPISearchError(err))
+		} else {
+			s.writeError(w, http.StatusInternalServerError, "Delete failed", err.Error())
+		}
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, DeleteBlocksResponse{
+		Datasources: result.Datasources,
+		Total:       result.Total,
+		DryRun:      result.DryRun,
+	})
+}
+
 // HandleStats handles GET /api/stats requests.
 // It returns storage statistics for all configured datasources including
 // block counts, storage sizes, and last update timestamps.
@@ -345,6 +460,13 @@ func isFTS5SyntaxError(err error) bool {
 		strings.Contains(errStr, "SQL logic error")
 }
 
+// isInvalidQueryError checks if a search error is caused by the query itself,
+// either an invalid field filter or an FTS5 syntax error.
+func isInvalidQueryError(err error) bool {
+	var queryErr *storage.QueryError
+	return errors.As(err, &queryErr) || isFTS5SyntaxError(err)
+}
+
 // HandleFirehose handles GET /api/firehose requests.
 // It returns the latest blocks across all datasources ordered by creation time,
 // with pagination support.
@@ -352,6 +474,7 @@ func isFTS5SyntaxError(err error) bool {
 // Query parameters:
 //   - limit: Maximum number of results per page (default: 30, max: 1000)
 //   - page: Page number for pagination (default: 1)
+//   - cursor: Opaque cursor from a previous response's next_cursor (takes precedence over page)
 //   - start_date: Date in YYYY-MM-DD format to filter blocks created on or after this date
 //   - end_date: Date in YYYY-MM-DD format to filter blocks created on or before this date (inclusive of entire day)
 //
@@ -374,7 +497,8 @@ func isFTS5SyntaxError(err error) bool {
 //	  "page": 1,
 //	  "limit": 30,
 //	  "total_pages": 5,
-//	  "has_more": true
+//	  "has_more": true,
+//	  "next_cursor": "eyJ0IjoiMjAyNC0wMS0xNVQxMDozMDowMFoiLCJkIjoiZ2l0aHViIiwiaSI6ImFiYzEyMyJ9"
 //	}
 //
 // Returns:
@@ -401,7 +525,11 @@ func (s *Server) HandleFirehose(w http.ResponseWriter, r *http.Request) {
 	searchService := s.storageManager.GetSearchService()
 	results, err := searchService.Search(params)
 	if err != nil {
-		s.writeError(w, http.StatusInternalServerError, "Failed to retrieve blocks", err.Error())
+		if isInvalidQueryError(err) {
+			s.writeError(w, http.StatusBadRequest, "Invalid request", formatAPISearchError(err))
+		} else {
+			s.writeError(w, http.StatusInternalServerError, "Failed to retrieve blocks", err.Error())
+		}
 		return
 	}
 
@@ -434,6 +562,7 @@ func (s *Server) HandleFirehose(w http.ResponseWriter, r *http.Request) {
 		Limit:      results.Limit,
 		TotalPages: results.TotalPages,
 		HasMore:    results.HasMore,
+		NextCursor: results.NextCursor,
 	}
 
 	s.writeJSON(w, http.StatusOK, response)
@@ -444,6 +573,7 @@ func (s *Server) HandleFirehose(w http.ResponseWriter, r *http.Request) {
 // that API consumers can understand and act upon.
 //
 // Error transformations:
+//   - Invalid field filters → the filter error (e.g. unknown field "foo")
 //   - FTS5 forward slash errors → "Forward slashes (/) are not allowed in search terms"
 //   - Unmatched quote errors → "Unmatched single quotes detected. Use double quotes for phrase searches"
 //   - General syntax errors → "Invalid search syntax. Check for special characters or invalid operators"
@@ -459,6 +589,12 @@ func (s *Server) HandleFirehose(w http.ResponseWriter, r *http.Request) {
 // This function helps maintain API usability by hiding internal implementation details
 // while providing actionable guidance to fix search query issues.
 func formatAPISearchError(err error) string {
+	// Invalid field filters already carry a user-facing message
+	var queryErr *storage.QueryError
+	if errors.As(err, &queryErr) {
+		return queryErr.Msg
+	}
+
 	errStr := err.Error()
 
 	// Handle FTS5 syntax errors
@@ -480,3 +616,329 @@ func formatAPISearchError(err error) string {
 	// Fallback for unknown errors
 	return "Invalid search query format"
 }
+
+// annotationStorage returns the storage of the {datasource} path parameter,
+// writing a 404 response if the datasource doesn't exist.
+func (s *Server) annotationStorage(w http.ResponseWriter, r *http.Request) (*storage.GenericStorage, bool) {
+	datasourceName := r.PathValue("datasource")
+	if _, exists := s.registry.GetAllDatasources()[datasourceName]; !exists {
+		s.writeError(w, http.StatusNotFound, "Datasource not found", fmt.Sprintf("Datasource '%s' does not exist", datasourceName))
+		return nil, false
+	}
+	st, err := s.storageManager.GetStorage(datasourceName)
+	if err != nil {
+		s.writeError(w, http.StatusNotFound, "Datasource not found", err.Error())
+		return nil, false
+	}
+	return st, true
+}
+
+// writeAnnotations writes the current annotations of the {datasource}/{id} block.
+func (s *Server) writeAnnotations(w http.ResponseWriter, r *http.Request, st *storage.GenericStorage, status int) {
+	id := r.PathValue("id")
+	annotations, err := st.GetAnnotations([]string{id})
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to read annotations", err.Error())
+		return
+	}
+	response := AnnotationsResponse{Datasource: r.PathValue("datasource"), ID: id, Tags: []string{}}
+	if a := annotations[id]; a != nil {
+		response.Tags = a.Tags
+		response.Note = a.Note
+	}
+	s.writeJSON(w, status, response)
+}
+
+// writeAnnotationError maps annotation storage errors to responses.
+func (s *Server) writeAnnotationError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, storage.ErrBlockNotFound) {
+		s.writeError(w, http.StatusNotFound, "Block not found", fmt.Sprintf("Block '%s' does not exist in datasource '%s'", r.PathValue("id"), r.PathValue("datasource")))
+		return
+	}
+	s.writeError(w, http.StatusInternalServerError, "Failed to update annotations", err.Error())
+}
+
+// HandleGetAnnotations handles GET /api/blocks/{datasource}/{id}/annotations
+// requests, returning the tags and note of a block.
+//
+// Response format:
+//
+//	{
+//	  "datasource": "hn",
+//	  "id": "hn-42",
+//	  "tags": ["toread"],
+//	  "note": "Compare with the SQLite docs"
+//	}
+//
+// Returns:
+//   - HTTP 200: Success with AnnotationsResponse JSON body
+//   - HTTP 404: Datasource not found
+func (s *Server) HandleGetAnnotations(w http.ResponseWriter, r *http.Request) {
+	st, ok := s.annotationStorage(w, r)
+	if !ok {
+		return
+	}
+	s.writeAnnotations(w, r, st, http.StatusOK)
+}
+
+// HandleAddTags handles POST /api/blocks/{datasource}/{id}/tags requests,
+// adding tags to a block. The body is {"tags": ["toread", "work"]}; tags are
+// lowercased and may contain letters, digits and _-.:/
+//
+// Returns:
+//   - HTTP 200: Success with the block's AnnotationsResponse
+//   - HTTP 400: Invalid body or tag
+//   - HTTP 404: Datasource or block not found
+//   - HTTP 500: Internal server error
+func (s *Server) HandleAddTags(w http.ResponseWriter, r *http.Request) {
+	st, ok := s.annotationStorage(w, r)
+	if !ok {
+		return
+	}
+	var req AddTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Tags) == 0 {
+		s.writeError(w, http.StatusBadRequest, "Invalid request", `Body must be {"tags": ["tag", ...]}`)
+		return
+	}
+	for _, tag := range req.Tags {
+		if _, err := storage.NormalizeTag(tag); err != nil {
+			s.writeError(w, http.StatusBadRequest, "Invalid tag", err.Error())
+			return
+		}
+	}
+	if err := st.AddTags(r.PathValue("id"), req.Tags); err != nil {
+		s.writeAnnotationError(w, r, err)
+		return
+	}
+	s.writeAnnotations(w, r, st, http.StatusOK)
+}
+
+// HandleRemoveTag handles DELETE /api/blocks/{datasource}/{id}/tags/{tag}
+// requests, removing a tag from a block.
+//
+// Returns:
+//   - HTTP 200: Success with the block's AnnotationsResponse
+//   - HTTP 404: Datasource not found
+//   - HTTP 500: Internal server error
+func (s *Server) HandleRemoveTag(w http.ResponseWriter, r *http.Request) {
+	st, ok := s.annotationStorage(w, r)
+	if !ok {
+		return
+	}
+	if _, err := st.RemoveTags(r.PathValue("id"), []string{r.PathValue("tag")}); err != nil {
+		s.writeAnnotationError(w, r, err)
+		return
+	}
+	s.writeAnnotations(w, r, st, http.StatusOK)
+}
+
+// HandleSetNote handles PUT /api/blocks/{datasource}/{id}/note requests,
+// setting the note of a block. The body is {"note": "..."}; an empty note
+// removes it.
+//
+// Returns:
+//   - HTTP 200: Success with the block's AnnotationsResponse
+//   - HTTP 400: Invalid body
+//   - HTTP 404: Datasource or block not found
+//   - HTTP 500: Internal server error
+func (s *Server) HandleSetNote(w http.ResponseWriter, r *http.Request) {
+	st, ok := s.annotationStorage(w, r)
+	if !ok {
+		return
+	}
+	var req SetNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request", `Body must be {"note": "..."}`)
+		return
+	}
+	if err := st.SetNote(r.PathValue("id"), req.Note); err != nil {
+		s.writeAnnotationError(w, r, err)
+		return
+	}
+	s.writeAnnotations(w, r, st, http.StatusOK)
+}
+
+// HandleListTags handles GET /api/tags requests, returning every tag in use
+// and how many blocks have it.
+//
+// Response format:
+//
+//	{
+//	  "tags": [{"tag": "toread", "count": 12}],
+//	  "count": 1
+//	}
+//
+// Returns:
+//   - HTTP 200: Success with ListTagsResponse JSON body
+//   - HTTP 500: Internal server error
+func (s *Server) HandleListTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.storageManager.ListTags()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to list tags", err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusOK, ListTagsResponse{Tags: tags, Count: len(tags)})
+}
+
+// HandleInbox handles GET /api/inbox requests, listing the blocks ingested
+// since they were last looked at, newest ingested first.
+//
+// Query parameters:
+//   - state: unread (default), read or archived
+//   - datasource: only list blocks from these datasources (repeatable)
+//   - page, limit: pagination (default limit 30, max 100)
+//
+// Response format:
+//
+//	{
+//	  "state": "unread",
+//	  "blocks": [
+//	    {"id": "hn-42", "text": "...", "datasource": "hn", "ingested_at": "2024-01-15T10:30:00Z", "state": "unread", ...}
+//	  ],
+//	  "count": 1,
+//	  "total_count": 1,
+//	  "counts": {"hn": 1, "rss": 0},
+//	  "page": 1,
+//	  "limit": 30,
+//	  "has_more": false
+//	}
+//
+// Returns:
+//   - HTTP 200: Success with InboxResponse JSON body
+//   - HTTP 400: Invalid state or pagination parameters
+//   - HTTP 404: Datasource not found
+//   - HTTP 500: Internal server error
+func (s *Server) HandleInbox(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	state, err := storage.ParseInboxState(query.Get("state"))
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid state", err.Error())
+		return
+	}
+
+	params := storage.InboxParams{State: state, Page: 1, Limit: 30}
+	if v := query.Get("page"); v != "" {
+		if params.Page, err = strconv.Atoi(v); err != nil || params.Page < 1 {
+			s.writeError(w, http.StatusBadRequest, "Invalid page", "page must be a positive integer")
+			return
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if params.Limit, err = strconv.Atoi(v); err != nil || params.Limit < 1 || params.Limit > 100 {
+			s.writeError(w, http.StatusBadRequest, "Invalid limit", "limit must be between 1 and 100")
+			return
+		}
+	}
+	datasources := s.registry.GetAllDatasources()
+	for _, name := range query["datasource"] {
+		if _, exists := datasources[name]; !exists {
+			s.writeError(w, http.StatusNotFound, "Datasource not found", fmt.Sprintf("Datasource '%s' does not exist", name))
+			return
+		}
+		params.Datasources = append(params.Datasources, name)
+	}
+
+	result, err := s.storageManager.Inbox(params)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to list inbox", err.Error())
+		return
+	}
+
+	blocks := make([]InboxBlockResponse, len(result.Items))
+	for i, item := range result.Items {
+		blocks[i] = InboxBlockResponse{
+			BlockResponse: BlockResponse{
+				ID:        item.Block.ID(),
+				Text:      item.Block.Text(),
+				Source:    item.Block.Source(),
+				CreatedAt: item.Block.CreatedAt(),
+				Metadata:  item.Block.Metadata(),
+			},
+			Datasource: item.Datasource,
+			IngestedAt: item.IngestedAt,
+			State:      string(item.State),
+		}
+	}
+	s.writeJSON(w, http.StatusOK, InboxResponse{
+		State:      string(state),
+		Blocks:     blocks,
+		Count:      len(blocks),
+		TotalCount: result.TotalCount,
+		Counts:     result.Counts,
+		Page:       result.Page,
+		Limit:      result.Limit,
+		HasMore:    result.HasMore,
+	})
+}
+
+// HandleSetInboxState handles PUT /api/blocks/{datasource}/{id}/inbox
+// requests, marking a block read, archived or unread. The body is
+// {"state": "read"}.
+//
+// Returns:
+//   - HTTP 200: Success with InboxStateResponse JSON body
+//   - HTTP 400: Invalid body or state
+//   - HTTP 404: Datasource or block not found
+//   - HTTP 500: Internal server error
+func (s *Server) HandleSetInboxState(w http.ResponseWriter, r *http.Request) {
+	st, ok := s.annotationStorage(w, r)
+	if !ok {
+		return
+	}
+	var req SetInboxStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.State == "" {
+		s.writeError(w, http.StatusBadRequest, "Invalid request", `Body must be {"state": "read|archived|unread"}`)
+		return
+	}
+	state, err := storage.ParseInboxState(req.State)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid state", err.Error())
+		return
+	}
+	id := r.PathValue("id")
+	n, err := st.SetInboxState([]string{id}, state)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to update inbox", err.Error())
+		return
+	}
+	if n == 0 {
+		s.writeAnnotationError(w, r, storage.ErrBlockNotFound)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, InboxStateResponse{Datasource: r.PathValue("datasource"), ID: id, State: string(state)})
+}
+
+// HandleMarkAllRead handles POST /api/inbox/read requests, marking every
+// unread block read. The optional body {"datasources": ["hn"]} restricts it
+// to some datasources.
+//
+// Returns:
+//   - HTTP 200: Success with MarkAllReadResponse JSON body
+//   - HTTP 400: Invalid body
+//   - HTTP 404: Datasource not found
+//   - HTTP 500: Internal server error
+func (s *Server) HandleMarkAllRead(w http.ResponseWriter, r *http.Request) {
+	var req MarkAllReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		s.writeError(w, http.StatusBadRequest, "Invalid request", `Body must be empty or {"datasources": ["name", ...]}`)
+		return
+	}
+	datasources := s.registry.GetAllDatasources()
+	for _, name := range req.Datasources {
+		if _, exists := datasources[name]; !exists {
+			s.writeError(w, http.StatusNotFound, "Datasource not found", fmt.Sprintf("Datasource '%s' does not exist", name))
+			return
+		}
+	}
+
+	marked, err := s.storageManager.MarkAllRead(req.Datasources)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, "Failed to mark blocks read", err.Error())
+		return
+	}
+	response := MarkAllReadResponse{Datasources: marked}
+	for _, n := range marked {
+		response.Total += n
+	}
+	s.writeJSON(w, http.StatusOK, response)
+}
@@ -1,12 +1,12 @@
 package api
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/websocket"
-	"github.com/rubiojr/ergs/pkg/core"
 	"github.com/rubiojr/ergs/pkg/storage"
 )
 
@@ -27,10 +27,29 @@ func (s *Server) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/search", s.HandleSearch)
 	mux.HandleFunc("GET /api/firehose", s.HandleFirehose)
 	mux.HandleFunc("GET /api/stats", s.HandleStats)
+	mux.HandleFunc("GET /api/tags", s.HandleListTags)
 	mux.HandleFunc("GET /health", s.HandleHealth)
 
+	// Annotation routes. They never delete blocks, so the web UI can use them
+	// without the API key.
+	mux.HandleFunc("GET /api/blocks/{datasource}/{id}/annotations", s.HandleGetAnnotations)
+	mux.HandleFunc("POST /api/blocks/{datasource}/{id}/tags", s.HandleAddTags)
+	mux.HandleFunc("DELETE /api/blocks/{datasource}/{id}/tags/{tag}", s.HandleRemoveTag)
+	mux.HandleFunc("PUT /api/blocks/{datasource}/{id}/note", s.HandleSetNote)
+
+	// Inbox routes, unauthenticated for the same reason
+	mux.HandleFunc("GET /api/inbox", s.HandleInbox)
+	mux.HandleFunc("POST /api/inbox/read", s.HandleMarkAllRead)
+	mux.HandleFunc("PUT /api/blocks/{datasource}/{id}/inbox", s.HandleSetInboxState)
+
+	// Authenticated write routes
+	mux.HandleFunc("DELETE /api/blocks", s.requireAPIKey(s.HandleDeleteBlocks))
+
 	// WebSocket firehose route
 	mux.HandleFunc("GET /api/firehose/ws", s.HandleFirehoseWS)
+
+	// Server-Sent Events firehose route
+	mux.HandleFunc("GET /api/firehose/sse", s.HandleFirehoseSSE)
 }
 
 // HandleFirehoseWS upgrades the connection to a WebSocket and streams an initial
@@ -41,10 +60,14 @@ func (s *Server) RegisterRoutes(mux *http.ServeMux) {
 //  1. WebSocket handshake (RFC6455)
 //  2. Initial snapshot (equivalent to GET /api/firehose)
 //  3. Poll every 5s for new blocks (by timestamp) and push them
+//     (in push mode, blocks and {"type":"delete","id","datasource"} events
+//     are forwarded from the hub instead)
 //  4. Heartbeat frame (JSON) every 30s to keep connection alive
 //
-// NOTE: When a direct event hub adapter exposing Register/Unregister becomes
-// accessible inside the API server, replace the polling loop with push events.
+// Clients only get the blocks of their subscription: the datasources, types
+// and query given by the datasource, type and q parameters, replaced by
+// sending {"type":"subscribe","datasources":[...],"types":[...],"query":"..."}.
+// Every subscription starts with a new init snapshot.
 func (s *Server) HandleFirehoseWS(w http.ResponseWriter, r *http.Request) {
 	// Upgrade using gorilla/websocket
 	conn, err := wsUpgrader.Upgrade(w, r, nil)
@@ -57,91 +80,41 @@ func (s *Server) HandleFirehoseWS(w http.ResponseWriter, r *http.Request) {
 	// Initial snapshot (equivalent to firehose REST)
 	// Honor optional ?since=RFC3339 (high precision) plus standard limit/page overrides.
 	searchService := s.storageManager.GetSearchService()
-	rawParams, _ := storage.ParseSearchParams(r.URL.Query()) // ignore error; WebSocket path is lenient
-	// Firehose semantics: always empty query (all recent blocks)
-	rawParams.Query = ""
-	if rawParams.Limit == 0 {
-		rawParams.Limit = 30
-	}
-	if rawParams.Page == 0 {
-		rawParams.Page = 1
-	}
-	params := rawParams
-	// If since is provided we force page=1 to return only newest after the boundary.
-	if params.Since != nil {
-		params.Page = 1
-	}
+	rawParams := firehoseStreamParams(r)
 
-	results, err := searchService.Search(params)
+	// The subscription is the one thing not handled leniently: a client
+	// asking for some datasources must not get all of them.
+	query := r.URL.Query()
+	sub, err := newFirehoseSubscription(query["datasource"], query["type"], query.Get("q"))
 	if err != nil {
 		_ = conn.WriteJSON(map[string]any{
 			"type":  "error",
-			"error": "initial_search_failed",
+			"error": "invalid_subscription",
 			"info":  err.Error(),
 		})
 		return
 	}
+	params := sub.requestParams(rawParams)
 
-	type wsBlock struct {
-		ID            string                 `json:"id"`
-		Text          string                 `json:"text"`
-		Source        string                 `json:"source"`
-		DSType        string                 `json:"ds_type,omitempty"`
-		CreatedAt     time.Time              `json:"created_at"`
-		Metadata      map[string]interface{} `json:"metadata,omitempty"`
-		FormattedHTML string                 `json:"formatted_html,omitempty"`
+	// snapshot searches with params and returns the blocks after params.Since
+	// and the newest block time
+	snapshot := func(params storage.SearchParams) ([]firehoseBlock, time.Time, error) {
+		return s.firehoseSnapshot(params, true)
 	}
 
-	var (
-		allBlocks []wsBlock
-		newest    time.Time
-	)
-	for dsName, blocks := range results.Results {
-		for _, b := range blocks {
-			md := b.Metadata()
-			if md == nil {
-				md = map[string]interface{}{}
-			}
-			if _, ok := md["datasource"]; !ok {
-				md["datasource"] = dsName
-			}
-			// Apply 'since' boundary if present.
-			// Normalize comparison to second precision so a block whose stored created_at
-			// is the same second as the client 'since' cursor (but with greater sub‑second
-			// precision) is NOT included (prevents duplicate on reconnect).
-			if params.Since != nil {
-				if !b.CreatedAt().Truncate(time.Second).After(params.Since.Truncate(time.Second)) {
-					continue
-				}
-			}
-			var formatted string
-			if s.rendererService != nil {
-				if htmlStr, _ := s.rendererService.Render(b); htmlStr != "" {
-					formatted = htmlStr
-				}
-			}
-			allBlocks = append(allBlocks, wsBlock{
-				ID:            b.ID(),
-				Text:          b.Text(),
-				Source:        b.Source(),
-				DSType:        b.Type(),
-				CreatedAt:     b.CreatedAt(),
-				Metadata:      md,
-				FormattedHTML: formatted,
-			})
-			if b.CreatedAt().After(newest) {
-				newest = b.CreatedAt()
-			}
-		}
+	allBlocks, newest, err := snapshot(params)
+	if err != nil {
+		_ = conn.WriteJSON(map[string]any{
+			"type":  "error",
+			"error": "initial_search_failed",
+			"info":  err.Error(),
+		})
+		return
 	}
 
 	// Determine (preliminarily) whether push mode is expected (hub supports Register)
-	type registerableHub interface {
-		Register() (uint64, <-chan InternalEvent)
-		Unregister(id uint64)
-	}
 	pushCapable := false
-	if _, ok := s.firehoseHub.(registerableHub); ok && s.firehoseHub != nil {
+	if _, ok := s.firehoseHub.(pushHub); ok && s.firehoseHub != nil {
 		pushCapable = true
 	}
 	mode := "poll"
@@ -157,10 +130,11 @@ func (s *Server) HandleFirehoseWS(w http.ResponseWriter, r *http.Request) {
 	}())
 
 	if err := conn.WriteJSON(map[string]any{
-		"type":   "init",
-		"count":  len(allBlocks),
-		"blocks": allBlocks,
-		"mode":   mode,
+		"type":         "init",
+		"count":        len(allBlocks),
+		"blocks":       allBlocks,
+		"mode":         mode,
+		"subscription": sub,
 		"since": func() any {
 			if params.Since != nil {
 				return params.Since.UTC()
@@ -171,32 +145,39 @@ func (s *Server) HandleFirehoseWS(w http.ResponseWriter, r *http.Request) {
 		log.Printf("firehose ws: init write failed %s: %v", r.RemoteAddr, err)
 		return
 	}
-	// Advance since cursor to newest we have just sent so fallback polling (if used) only fetches later blocks.
-	if newest.IsZero() {
-		// If no blocks returned and since provided, keep existing since; else set to current time.
-		if params.Since == nil {
-			now := time.Now().UTC()
-			params.Since = &now
+	// advanceSince moves the since cursor to the newest block sent so fallback
+	// polling (if used) only fetches later blocks.
+	advanceSince := func(newest time.Time) {
+		if newest.IsZero() {
+			// If no blocks returned and since provided, keep existing since; else set to current time.
+			if params.Since == nil {
+				now := time.Now().UTC()
+				params.Since = &now
+			}
+		} else {
+			params.Since = &newest
 		}
-	} else {
-		params.Since = &newest
 	}
+	advanceSince(newest)
 
-	// Attempt to use realtime hub (push) if available (registerableHub interface already declared above)
+	// Attempt to use realtime hub (push) if available
 	var (
-		subID   uint64
-		eventsC <-chan InternalEvent
+		subID    uint64
+		eventsC  <-chan InternalEvent
+		filterer filteringHub
 	)
-	if rh, okAssert := s.firehoseHub.(interface {
-		Register() (uint64, <-chan InternalEvent)
-		Unregister(id uint64)
-	}); okAssert && rh != nil {
+	if rh, okAssert := s.firehoseHub.(pushHub); okAssert && rh != nil {
 		subID, eventsC = rh.Register()
 		log.Printf("firehose ws: %s registered listener id=%d (push mode active)", r.RemoteAddr, subID)
 		defer func() {
 			rh.Unregister(subID)
 			log.Printf("firehose ws: %s unregistered listener id=%d", r.RemoteAddr, subID)
 		}()
+		// Let the hub drop the events of other datasources
+		if f, ok := rh.(filteringHub); ok {
+			filterer = f
+			filterer.SetFilter(subID, sub.filter())
+		}
 	} else {
 		eventsC = nil // fallback polling mode
 		log.Printf("firehose ws: %s operating in polling mode (no push hub)", r.RemoteAddr)
@@ -208,11 +189,25 @@ func (s *Server) HandleFirehoseWS(w http.ResponseWriter, r *http.Request) {
 	heartbeatTicker := time.NewTicker(30 * time.Second)
 	defer heartbeatTicker.Stop()
 
-	// Reader to detect close (we ignore incoming messages)
+	// Reader to detect close and receive subscription changes
+	subscribeC := make(chan subscribeRequest)
+	readDone := make(chan struct{})
+	handlerDone := make(chan struct{})
+	defer close(handlerDone)
 	go func() {
+		defer close(readDone)
 		for {
-			if _, _, err := conn.ReadMessage(); err != nil {
-				_ = conn.SetReadDeadline(time.Now().Add(-1 * time.Hour))
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req subscribeRequest
+			if err := json.Unmarshal(data, &req); err != nil || req.Type != "subscribe" {
+				continue // ignore other messages
+			}
+			select {
+			case subscribeC <- req:
+			case <-handlerDone:
 				return
 			}
 		}
@@ -220,6 +215,51 @@ func (s *Server) HandleFirehoseWS(w http.ResponseWriter, r *http.Request) {
 
 	for {
 		select {
+		case <-readDone:
+			return
+		case req := <-subscribeC:
+			newSub, err := newFirehoseSubscription(req.Datasources, req.Types, req.Query)
+			if err != nil {
+				if err := conn.WriteJSON(map[string]any{
+					"type":  "error",
+					"error": "invalid_subscription",
+					"info":  err.Error(),
+				}); err != nil {
+					return
+				}
+				continue
+			}
+			sub = newSub
+			if filterer != nil {
+				filterer.SetFilter(subID, sub.filter())
+			}
+			// A new subscription starts with the latest blocks it selects
+			params = sub.requestParams(rawParams)
+			params.Since = nil
+			params.Page = 1
+			blocks, newest, err := snapshot(params)
+			if err != nil {
+				if err := conn.WriteJSON(map[string]any{
+					"type":  "error",
+					"error": "subscription_search_failed",
+					"info":  err.Error(),
+				}); err != nil {
+					return
+				}
+				continue
+			}
+			log.Printf("firehose ws: %s subscribed datasources=%v types=%v query=%q blocks=%d", r.RemoteAddr, sub.Datasources, sub.Types, sub.Query, len(blocks))
+			if err := conn.WriteJSON(map[string]any{
+				"type":         "init",
+				"count":        len(blocks),
+				"blocks":       blocks,
+				"mode":         mode,
+				"subscription": sub,
+				"since":        nil,
+			}); err != nil {
+				return
+			}
+			advanceSince(newest)
 		case evt, open := <-eventsC:
 			if !open {
 				eventsC = nil
@@ -227,29 +267,30 @@ func (s *Server) HandleFirehoseWS(w http.ResponseWriter, r *http.Request) {
 			}
 
 			be := evt.Block
-			var formatted string
-			if s.rendererService != nil {
-				gb := core.NewGenericBlock(be.ID, be.Text, be.Datasource, be.DSType, be.CreatedAt, be.Metadata)
-				if htmlStr, _ := s.rendererService.Render(gb); htmlStr != "" {
-					formatted = htmlStr
-				}
+			// Events queued before a subscription change may not match it
+			if !sub.filter().Matches(evt) {
+				continue
 			}
-
-			blockPayload := map[string]any{
-				"id":         be.ID,
-				"text":       be.Text,
-				"source":     be.Datasource,
-				"ds_type":    be.DSType,
-				"created_at": be.CreatedAt,
-				"metadata":   be.Metadata,
+			if evt.Type == "delete" {
+				if err := conn.WriteJSON(map[string]any{
+					"type":       "delete",
+					"id":         be.ID,
+					"datasource": be.Datasource,
+				}); err != nil {
+					return
+				}
+				continue
 			}
-			if formatted != "" {
-				blockPayload["formatted_html"] = formatted
+			if ok, err := sub.matches(searchService, be); err != nil {
+				log.Printf("firehose ws: %s matching block %s of %s: %v", r.RemoteAddr, be.ID, be.Datasource, err)
+				continue
+			} else if !ok {
+				continue
 			}
 
 			msg := map[string]any{
 				"type":  "block",
-				"block": blockPayload,
+				"block": s.firehoseEventBlock(be, true),
 			}
 			if err := conn.WriteJSON(msg); err != nil {
 				return
@@ -262,7 +303,7 @@ func (s *Server) HandleFirehoseWS(w http.ResponseWriter, r *http.Request) {
 				continue // hub push active; skip polling
 			}
 			// For polling mode, always use the evolving since boundary (high precision).
-			results, err := searchService.Search(params)
+			newBlocks, newestPoll, err := snapshot(params)
 			if err != nil {
 				_ = conn.WriteJSON(map[string]any{
 					"type":  "error",
@@ -271,47 +312,10 @@ func (s *Server) HandleFirehoseWS(w http.ResponseWriter, r *http.Request) {
 				})
 				continue
 			}
-			var newBlocks []wsBlock
-			for dsName, blocks := range results.Results {
-				for _, b := range blocks {
-					// Filter using 'since' boundary at second precision (same logic as init snapshot)
-					if params.Since != nil {
-						if !b.CreatedAt().Truncate(time.Second).After(params.Since.Truncate(time.Second)) {
-							continue
-						}
-					}
-					md := b.Metadata()
-					if md == nil {
-						md = map[string]interface{}{}
-					}
-					if _, ok := md["datasource"]; !ok {
-						md["datasource"] = dsName
-					}
-					var formatted string
-					if s.rendererService != nil {
-						if htmlStr, _ := s.rendererService.Render(b); htmlStr != "" {
-							formatted = htmlStr
-						}
-					}
-					newBlocks = append(newBlocks, wsBlock{
-						ID:            b.ID(),
-						Text:          b.Text(),
-						Source:        b.Source(),
-						DSType:        b.Type(),
-						CreatedAt:     b.CreatedAt(),
-						Metadata:      md,
-						FormattedHTML: formatted,
-					})
-					if b.CreatedAt().After(newest) {
-						newest = b.CreatedAt()
-					}
-				}
-			}
 			if len(newBlocks) > 0 {
 				// Advance since cursor to newest emitted block
-				if newestPoll := newBlocks[0].CreatedAt; newestPoll.After(*params.Since) {
-					// Because results are ordered newest-first, first element is newest
-					params.Since = &newBlocks[0].CreatedAt
+				if newestPoll.After(*params.Since) {
+					params.Since = &newestPoll
 				}
 				if err := conn.WriteJSON(map[string]any{
 					"type":   "block_batch",
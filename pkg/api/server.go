@@ -1,9 +1,11 @@
 package api
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/rubiojr/ergs/cmd/web/components/types"
 	"github.com/rubiojr/ergs/pkg/core"
@@ -28,6 +30,7 @@ type Server struct {
 	storageManager  *storage.Manager
 	firehoseHub     FirehoseHubAdapter // optional; nil when realtime firehose disabled
 	rendererService *render.Service    // optional; when set, enriches WS events with formatted HTML
+	apiKey          string             // Bearer token for write endpoints; empty disables them
 }
 
 func NewServer(registry *core.Registry, storageManager *storage.Manager) *Server {
@@ -49,6 +52,42 @@ func (s *Server) SetRendererService(r *render.Service) {
 	s.rendererService = r
 }
 
+// SetAPIKey sets the Bearer token required by write endpoints such as
+// DELETE /api/blocks. With an empty key those endpoints are disabled.
+func (s *Server) SetAPIKey(key string) {
+	s.apiKey = key
+}
+
+// requireAPIKey wraps a write endpoint, rejecting requests without a valid
+// "Authorization: Bearer <api_key>" header.
+func (s *Server) requireAPIKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.apiKey == "" {
+			s.writeError(w, http.StatusForbidden, "disabled", "Write endpoints are disabled: set api_key in the [api] config section")
+			return
+		}
+
+		authHeader := r.Header.Get("Authorization")
+		if authHeader == "" {
+			s.writeError(w, http.StatusUnauthorized, "missing_auth", "Authorization header required")
+			return
+		}
+
+		// Expected format: "Bearer <token>"
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			s.writeError(w, http.StatusUnauthorized, "invalid_auth", "Authorization header must be 'Bearer <token>'")
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(parts[1]), []byte(s.apiKey)) != 1 {
+			s.writeError(w, http.StatusUnauthorized, "invalid_token", "Invalid API token")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
 func (s *Server) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rubiojr/ergs/pkg/realtime"
+	"github.com/rubiojr/ergs/pkg/storage"
+)
+
+// firehoseSubscription selects the blocks a firehose WebSocket client gets:
+// those of the given datasources and datasource types matching the query.
+// The zero value subscribes to every block.
+type firehoseSubscription struct {
+	Datasources []string `json:"datasources,omitempty"`
+	Types       []string `json:"types,omitempty"`
+	Query       string   `json:"query,omitempty"`
+
+	// params holds the search parameters of the subscription, with the query
+	// operators applied
+	params storage.SearchParams
+}
+
+// subscribeRequest is the message a firehose WebSocket client sends to
+// replace its subscription.
+type subscribeRequest struct {
+	Type string `json:"type"`
+	firehoseSubscription
+}
+
+// newFirehoseSubscription validates a subscription and resolves the operators
+// in its query (source:, type:, after:...).
+func newFirehoseSubscription(datasources, types []string, query string) (*firehoseSubscription, error) {
+	sub := &firehoseSubscription{
+		Datasources: nonEmpty(datasources),
+		Types:       nonEmpty(types),
+		Query:       strings.TrimSpace(query),
+	}
+	if err := storage.ValidateQuery(sub.Query); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	sub.params = sub.searchParams()
+	if err := storage.ApplyQueryOperators(&sub.params); err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return sub, nil
+}
+
+// searchParams returns the subscription as search parameters, before the
+// query operators are applied.
+func (sub *firehoseSubscription) searchParams() storage.SearchParams {
+	return storage.SearchParams{
+		Query:             sub.Query,
+		DatasourceFilters: append([]string(nil), sub.Datasources...),
+		DatasourceTypes:   append([]string(nil), sub.Types...),
+	}
+}
+
+// requestParams returns the search parameters of the subscription, keeping
+// the date range, limit and page of the request parameters.
+func (sub *firehoseSubscription) requestParams(request storage.SearchParams) storage.SearchParams {
+	params := request
+	params.Query = sub.params.Query
+	params.DatasourceFilters = sub.params.DatasourceFilters
+	params.DatasourceTypes = sub.params.DatasourceTypes
+	if sub.params.StartDate != nil {
+		params.StartDate = sub.params.StartDate
+	}
+	if sub.params.EndDate != nil {
+		params.EndDate = sub.params.EndDate
+	}
+	return params
+}
+
+// filter returns the part of the subscription the hub checks: datasources
+// and types, including those set by source: and type: operators.
+func (sub *firehoseSubscription) filter() realtime.Filter {
+	return realtime.Filter{
+		Datasources: sub.params.DatasourceFilters,
+		Types:       sub.params.DatasourceTypes,
+	}
+}
+
+// matches reports whether a block that passed the hub filter matches the rest
+// of the subscription. Full-text terms, field filters and dates are checked
+// against the stored block.
+func (sub *firehoseSubscription) matches(search *storage.SearchService, be realtime.BlockEvent) (bool, error) {
+	ids, err := sub.matching(search, be.Datasource, []string{be.ID})
+	if err != nil {
+		return false, err
+	}
+	return len(ids) > 0, nil
+}
+
+// matching returns the IDs, among the stored blocks of datasource with the
+// given IDs, that match the query and dates of the subscription. The
+// datasource must already pass the hub filter.
+func (sub *firehoseSubscription) matching(search *storage.SearchService, datasource string, ids []string) ([]string, error) {
+	if sub.params.Query == "" && sub.params.StartDate == nil && sub.params.EndDate == nil {
+		return ids, nil
+	}
+	return search.MatchBlocks(sub.searchParams(), datasource, ids)
+}
+
+// datasources returns the existing datasources the subscription selects.
+func (sub *firehoseSubscription) datasources(search *storage.SearchService) []string {
+	return search.TargetDatasources(sub.params)
+}
+
+// nonEmpty returns the non-empty, trimmed values, splitting comma-separated ones
+func nonEmpty(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
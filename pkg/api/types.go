@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/rubiojr/ergs/cmd/web/components/types"
+	"github.com/rubiojr/ergs/pkg/storage"
 )
 
 type BlockResponse struct {
@@ -12,6 +13,7 @@ type BlockResponse struct {
 	Source    string                 `json:"source"`
 	CreatedAt time.Time              `json:"created_at"`
 	Metadata  map[string]interface{} `json:"metadata"`
+	Snippet   string                 `json:"snippet,omitempty"`
 }
 
 type ListDatasourcesResponse struct {
@@ -24,6 +26,7 @@ type ListBlocksResponse struct {
 	Blocks     []BlockResponse `json:"blocks"`
 	Count      int             `json:"count"`
 	Query      string          `json:"query,omitempty"`
+	NextCursor string          `json:"next_cursor,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -39,6 +42,71 @@ type SearchResponse struct {
 	Limit      int                           `json:"limit"`
 	TotalPages int                           `json:"total_pages"`
 	HasMore    bool                          `json:"has_more"`
+	NextCursor string                        `json:"next_cursor,omitempty"`
+	Facets     *storage.SearchFacets         `json:"facets,omitempty"`
+}
+
+type DeleteBlocksResponse struct {
+	Datasources map[string]int `json:"datasources"`
+	Total       int            `json:"total"`
+	DryRun      bool           `json:"dry_run"`
+}
+
+type AnnotationsResponse struct {
+	Datasource string   `json:"datasource"`
+	ID         string   `json:"id"`
+	Tags       []string `json:"tags"`
+	Note       string   `json:"note,omitempty"`
+}
+
+type AddTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type SetNoteRequest struct {
+	Note string `json:"note"`
+}
+
+type ListTagsResponse struct {
+	Tags  []storage.TagCount `json:"tags"`
+	Count int                `json:"count"`
+}
+
+type InboxBlockResponse struct {
+	BlockResponse
+	Datasource string    `json:"datasource"`
+	IngestedAt time.Time `json:"ingested_at"`
+	State      string    `json:"state"`
+}
+
+type InboxResponse struct {
+	State      string               `json:"state"`
+	Blocks     []InboxBlockResponse `json:"blocks"`
+	Count      int                  `json:"count"`
+	TotalCount int                  `json:"total_count"`
+	Counts     map[string]int       `json:"counts"`
+	Page       int                  `json:"page"`
+	Limit      int                  `json:"limit"`
+	HasMore    bool                 `json:"has_more"`
+}
+
+type SetInboxStateRequest struct {
+	State string `json:"state"`
+}
+
+type InboxStateResponse struct {
+	Datasource string `json:"datasource"`
+	ID         string `json:"id"`
+	State      string `json:"state"`
+}
+
+type MarkAllReadRequest struct {
+	Datasources []string `json:"datasources"`
+}
+
+type MarkAllReadResponse struct {
+	Datasources map[string]int `json:"datasources"`
+	Total       int            `json:"total"`
 }
 
 type HealthResponse struct {
@@ -54,4 +122,5 @@ type FirehoseResponse struct {
 	Limit      int             `json:"limit"`
 	TotalPages int             `json:"total_pages"`
 	HasMore    bool            `json:"has_more"`
+	NextCursor string          `json:"next_cursor,omitempty"`
 }
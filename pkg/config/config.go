@@ -19,8 +19,14 @@ type Config struct {
 	FetchInterval   Duration                  `toml:"fetch_interval"`
 	Importer        *ImporterConfig           `toml:"importer,omitempty"`
 	Home            *HomeConfig               `toml:"home,omitempty"`
+	API             *APIConfig                `toml:"api,omitempty"`
 	Datasources     map[string]DatasourceInfo `toml:"datasources"`
 	EventSocketPath string                    `toml:"event_socket_path,omitempty"` // Optional Unix domain socket path for realtime warehouse->web events
+	EventLogSize    int                       `toml:"event_log_size,omitempty"`    // Recent bridge events kept for consumers to replay
+	EventBridge     *EventBridgeConfig        `toml:"event_bridge,omitempty"`      // Event bridge connections over TCP
+	GlobalIndex     bool                      `toml:"global_index,omitempty"`      // Search a single index of all datasources instead of each database
+	SavedSearches   []SavedSearchConfig       `toml:"saved_searches,omitempty"`    // Searches whose new matches are sent to sinks
+	Webhooks        []WebhookConfig           `toml:"webhooks,omitempty"`          // External URLs new blocks are POSTed to
 }
 
 type ImporterConfig struct {
@@ -29,6 +35,21 @@ type ImporterConfig struct {
 	Port   string `toml:"port,omitempty"`
 }
 
+// APIConfig configures the API served by the web command.
+type APIConfig struct {
+	APIKey string `toml:"api_key"` // Bearer token required by write endpoints; they are disabled when empty
+}
+
+// EventBridgeConfig configures event bridge connections over TCP, so 'ergs web'
+// can run on a different host than 'ergs serve'.
+type EventBridgeConfig struct {
+	Listen  string `toml:"listen,omitempty"`   // Address the warehouse also accepts consumers on, e.g. ':7777'
+	TLSCert string `toml:"tls_cert,omitempty"` // Certificate file; with tls_key, the listener uses TLS
+	TLSKey  string `toml:"tls_key,omitempty"`  // Private key file of tls_cert
+	TLSCA   string `toml:"tls_ca,omitempty"`   // CA certificate consumers verify the warehouse with; system roots if empty
+	Token   string `toml:"token,omitempty"`    // Shared token TCP consumers authenticate with
+}
+
 type HomeConfig struct {
 	Datasources string `toml:"datasources"` // Comma-separated list of datasource names
 }
@@ -51,8 +72,82 @@ type DatasourceInfo struct {
 	Type string `toml:"type"`
 	// Interval specifies how often this datasource should be fetched.
 	// If not specified, defaults to 30 minutes.
-	Interval *Duration   `toml:"interval,omitempty"`
-	Config   interface{} `toml:"config"`
+	Interval *Duration `toml:"interval,omitempty"`
+	// Tokenizer is the FTS5 tokenizer of the datasource's full-text index
+	// (porter, unicode61, "unicode61 remove_diacritics 2" or trigram).
+	// If not specified, defaults to porter.
+	Tokenizer string `toml:"tokenizer,omitempty"`
+	// Retention limits how many blocks are kept. If not specified, every
+	// block is kept.
+	Retention *RetentionConfig `toml:"retention,omitempty"`
+	// Exclude lists blocks that must never be stored, e.g. browser visits to
+	// banking or medical sites.
+	Exclude *ExcludeConfig `toml:"exclude,omitempty"`
+	// Processors transform blocks before they are stored, in order. Each table
+	// has a type (a registered processor) and the options of that processor.
+	Processors []map[string]interface{} `toml:"processors,omitempty"`
+	Config     interface{}              `toml:"config"`
+}
+
+// ExcludeConfig holds the exclusion rules of a datasource. Blocks matching any
+// rule are dropped by the warehouse; `ergs datasource apply-exclusions` removes
+// the ones already stored.
+type ExcludeConfig struct {
+	// Domains match the host of the block's url (or link) field and its subdomains.
+	Domains []string `toml:"domains,omitempty"`
+	// URLPatterns are regular expressions matched against the block's url (or link) field.
+	URLPatterns []string `toml:"url_patterns,omitempty"`
+	// Keywords match the block's title field and text, case-insensitively.
+	Keywords []string `toml:"keywords,omitempty"`
+}
+
+// SavedSearchConfig is a search query evaluated by the warehouse against every
+// new block. Matching blocks are sent to its sinks.
+type SavedSearchConfig struct {
+	Name  string       `toml:"name"`
+	Query string       `toml:"query"`
+	Sinks []SinkConfig `toml:"sinks"`
+}
+
+// SinkConfig is where the matches of a saved search are sent.
+type SinkConfig struct {
+	// Type is webhook, exec or file.
+	Type string `toml:"type"`
+	// URL receives a POST with each match as JSON (webhook).
+	URL string `toml:"url,omitempty"`
+	// Command is run for each match, with the match as JSON on its standard
+	// input (exec). The first element is the program, the rest its arguments.
+	Command []string `toml:"command,omitempty"`
+	// Path has each match appended as a line of JSON (file).
+	Path string `toml:"path,omitempty"`
+}
+
+// WebhookConfig is an external URL the warehouse POSTs new blocks to.
+type WebhookConfig struct {
+	// Name identifies the webhook in the delivery log.
+	Name string `toml:"name"`
+	URL  string `toml:"url"`
+	// Secret signs every delivery with HMAC-SHA256 when set.
+	Secret string `toml:"secret,omitempty"`
+	// Datasources and Types only send blocks of these datasource names and
+	// types. If not specified, every block is sent.
+	Datasources []string `toml:"datasources,omitempty"`
+	Types       []string `toml:"types,omitempty"`
+	// MaxAttempts is how many times a delivery is tried before it fails.
+	// If not specified, defaults to 8.
+	MaxAttempts int `toml:"max_attempts,omitempty"`
+}
+
+// RetentionConfig is the retention policy of a datasource. Blocks are pruned
+// by `ergs serve` on its hourly optimization run.
+type RetentionConfig struct {
+	// MaxAge deletes blocks created longer ago than this.
+	MaxAge *Duration `toml:"max_age,omitempty"`
+	// MaxBlocks keeps only the newest MaxBlocks blocks.
+	MaxBlocks int `toml:"max_blocks,omitempty"`
+	// DownsampleAfter keeps only the newest block of each day for blocks
+	// created longer ago than this.
+	DownsampleAfter *Duration `toml:"downsample_after,omitempty"`
 }
 
 func GetDefaultConfig() (*Config, error) {
@@ -188,6 +283,22 @@ func (c *Config) GetDatasourceInterval(name string) time.Duration {
 	return info.Interval.Duration
 }
 
+func (c *Config) GetDatasourceTokenizer(name string) string {
+	return c.Datasources[name].Tokenizer
+}
+
+func (c *Config) GetDatasourceRetention(name string) *RetentionConfig {
+	return c.Datasources[name].Retention
+}
+
+func (c *Config) GetDatasourceExclusions(name string) *ExcludeConfig {
+	return c.Datasources[name].Exclude
+}
+
+func (c *Config) GetDatasourceProcessors(name string) []map[string]interface{} {
+	return c.Datasources[name].Processors
+}
+
 func (c *Config) ListDatasources() []string {
 	names := make([]string, 0, len(c.Datasources))
 	for name := range c.Datasources {
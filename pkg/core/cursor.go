@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+)
+
+// CursorStore persists incremental fetch checkpoints for a single datasource instance.
+//
+// Datasources use it to resume from where the previous fetch stopped (last visit id,
+// last event id, feed ETag, ...) instead of re-reading everything on every run.
+// The warehouse hands a CursorStore to FetchBlocks through the context; retrieve it
+// with CursorStoreFromContext.
+//
+// Cursor updates are only persisted once every block emitted during the fetch has
+// been stored and FetchBlocks returned without error, so a failed or cancelled fetch
+// is retried from the previous checkpoint.
+//
+// Example:
+//
+//	cursors := core.CursorStoreFromContext(ctx)
+//	lastID, _, err := cursors.GetCursor("last_visit_id")
+//	// ... fetch rows newer than lastID and send blocks ...
+//	cursors.SetCursor("last_visit_id", newestID)
+type CursorStore interface {
+	// GetCursor returns the value stored for key and whether it exists.
+	GetCursor(key string) (string, bool, error)
+
+	// SetCursor records a new value for key.
+	SetCursor(key, value string) error
+}
+
+type cursorStoreKey struct{}
+
+// WithCursorStore returns a copy of ctx carrying the given CursorStore.
+func WithCursorStore(ctx context.Context, store CursorStore) context.Context {
+	return context.WithValue(ctx, cursorStoreKey{}, store)
+}
+
+// CursorStoreFromContext returns the CursorStore attached to ctx.
+// When no store is present (e.g. a datasource used directly in tests) a no-op
+// store is returned: it never has cursors and discards updates, so datasources
+// fall back to a full fetch.
+func CursorStoreFromContext(ctx context.Context) CursorStore {
+	if store, ok := ctx.Value(cursorStoreKey{}).(CursorStore); ok && store != nil {
+		return store
+	}
+	return noopCursorStore{}
+}
+
+// noopCursorStore is the CursorStore used when none was provided.
+type noopCursorStore struct{}
+
+func (noopCursorStore) GetCursor(key string) (string, bool, error) { return "", false, nil }
+
+func (noopCursorStore) SetCursor(key, value string) error { return nil }
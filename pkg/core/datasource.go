@@ -68,13 +68,19 @@ type Datasource interface {
 	//		case blockCh <- d.convertToBlock(item):
 	//		}
 	//	}
+	//
+	// Blocks with an already stored ID replace the stored block. To remove a block
+	// whose item was deleted from the source, send NewTombstone(id, d.Name()).
 	FetchBlocks(ctx context.Context, blockCh chan<- Block) error
 
 	// Schema defines the database schema for blocks from this datasource.
 	// Returns a map of field names to SQLite column types.
 	//
-	// Supported types: "TEXT", "INTEGER", "REAL", "BLOB"
-	// These fields become searchable and are stored in the FTS table.
+	// Supported types: "TEXT", "INTEGER", "REAL", "NUMERIC", "BLOB"
+	// ("BOOLEAN" and "DATETIME" are accepted as aliases).
+	// Each field is materialized as a typed, indexed column generated from the
+	// block metadata, so Metadata() keys must match the schema field names.
+	// Changes to the schema are migrated automatically.
 	//
 	// Example:
 	//	return map[string]any{
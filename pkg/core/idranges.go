@@ -0,0 +1,117 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IDRanges is a set of non-negative integer IDs kept as sorted ranges of consecutive IDs.
+//
+// Datasources detecting deletions remember the IDs seen by the previous fetch
+// in a cursor. When IDs are mostly consecutive, like the row IDs of a browser
+// history, IDRanges keeps that cursor small: its string form is "1-5,8,10-12".
+//
+// Example:
+//
+//	previous, _ := core.ParseIDRanges(value)
+//	current := &core.IDRanges{}
+//	// ... current.Add(id) for every ID still in the source ...
+//	for _, id := range previous.Missing(current) {
+//		blockCh <- core.NewTombstone(fmt.Sprintf("visit-%d", id), d.instanceName)
+//	}
+//	cursors.SetCursor("visit_ids", current.String())
+type IDRanges struct {
+	ranges []idRange
+}
+
+type idRange struct {
+	first, last int64
+}
+
+// ParseIDRanges parses the string form of an IDRanges.
+func ParseIDRanges(s string) (*IDRanges, error) {
+	r := &IDRanges{}
+	if s == "" {
+		return r, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.ParseInt(first, 10, 64)
+		if err != nil || from < 0 {
+			return nil, fmt.Errorf("invalid ID range %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.ParseInt(last, 10, 64); err != nil || to < from {
+				return nil, fmt.Errorf("invalid ID range %q", part)
+			}
+		}
+		n := len(r.ranges)
+		switch {
+		case n > 0 && from <= r.ranges[n-1].last:
+			return nil, fmt.Errorf("ID range %q out of order", part)
+		case n > 0 && from == r.ranges[n-1].last+1:
+			r.ranges[n-1].last = to
+		default:
+			r.ranges = append(r.ranges, idRange{first: from, last: to})
+		}
+	}
+	return r, nil
+}
+
+// Add adds id to the set.
+func (r *IDRanges) Add(id int64) {
+	// Index of the first range ending at or after id - 1, the only candidates
+	// to contain or extend to id
+	i := sort.Search(len(r.ranges), func(i int) bool { return r.ranges[i].last >= id-1 })
+	switch {
+	case i < len(r.ranges) && r.ranges[i].first <= id && id <= r.ranges[i].last:
+		return
+	case i < len(r.ranges) && r.ranges[i].last == id-1:
+		r.ranges[i].last = id
+		if i+1 < len(r.ranges) && r.ranges[i+1].first == id+1 {
+			r.ranges[i].last = r.ranges[i+1].last
+			r.ranges = append(r.ranges[:i+1], r.ranges[i+2:]...)
+		}
+	case i < len(r.ranges) && r.ranges[i].first == id+1:
+		r.ranges[i].first = id
+	default:
+		r.ranges = append(r.ranges, idRange{})
+		copy(r.ranges[i+1:], r.ranges[i:])
+		r.ranges[i] = idRange{first: id, last: id}
+	}
+}
+
+// Contains reports whether id is in the set.
+func (r *IDRanges) Contains(id int64) bool {
+	i := sort.Search(len(r.ranges), func(i int) bool { return r.ranges[i].last >= id })
+	return i < len(r.ranges) && r.ranges[i].first <= id
+}
+
+// Missing returns the IDs of the set, in ascending order, that are not in other.
+func (r *IDRanges) Missing(other *IDRanges) []int64 {
+	var missing []int64
+	for _, rg := range r.ranges {
+		for id := rg.first; id <= rg.last; id++ {
+			if !other.Contains(id) {
+				missing = append(missing, id)
+			}
+		}
+	}
+	return missing
+}
+
+// String returns the set as comma separated IDs and ranges of IDs, e.g. "1-5,8,10-12".
+func (r *IDRanges) String() string {
+	parts := make([]string, 0, len(r.ranges))
+	for _, rg := range r.ranges {
+		if rg.first == rg.last {
+			parts = append(parts, strconv.FormatInt(rg.first, 10))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", rg.first, rg.last))
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIDRanges(t *testing.T) {
+	r := &IDRanges{}
+	for _, id := range []int64{5, 1, 2, 3, 10, 12, 4, 11, 8, 3} {
+		r.Add(id)
+	}
+	if got := r.String(); got != "1-5,8,10-12" {
+		t.Fatalf("expected 1-5,8,10-12, got %q", got)
+	}
+	for id, want := range map[int64]bool{0: false, 1: true, 5: true, 6: false, 8: true, 9: false, 12: true, 13: false} {
+		if r.Contains(id) != want {
+			t.Errorf("Contains(%d) = %v, want %v", id, !want, want)
+		}
+	}
+
+	parsed, err := ParseIDRanges(r.String())
+	if err != nil {
+		t.Fatalf("ParseIDRanges: %v", err)
+	}
+	if parsed.String() != r.String() {
+		t.Fatalf("expected %q parsed back, got %q", r.String(), parsed.String())
+	}
+	if empty, err := ParseIDRanges(""); err != nil || empty.String() != "" {
+		t.Fatalf("expected an empty set, got %q (%v)", empty.String(), err)
+	}
+	for _, invalid := range []string{"a", "1-", "5-3", "3,1", "1-5,4", "-1"} {
+		if _, err := ParseIDRanges(invalid); err == nil {
+			t.Errorf("expected an error parsing %q", invalid)
+		}
+	}
+
+	current, _ := ParseIDRanges("2-4,10,12-20")
+	if missing := r.Missing(current); !slices.Equal(missing, []int64{1, 5, 8, 11}) {
+		t.Fatalf("expected 1, 5, 8 and 11 missing, got %v", missing)
+	}
+	if missing := r.Missing(r); len(missing) != 0 {
+		t.Fatalf("expected nothing missing, got %v", missing)
+	}
+}
@@ -0,0 +1,119 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Processor transforms blocks between a datasource's FetchBlocks and storage.
+//
+// Processors are configured per datasource instance and run in order by the
+// warehouse, so filtering and cleanup rules (redacting tokens, stripping
+// tracking parameters from URLs, tagging, dropping noise) don't have to be
+// hard-coded in each datasource. Tombstones are never processed.
+//
+// Blocks are immutable: a processor that changes a block returns a copy,
+// usually built with RewriteBlock.
+//
+// Example implementation pattern:
+//
+//	type upperProcessor struct{}
+//
+//	func (upperProcessor) Process(block Block) (Block, error) {
+//		return RewriteBlock(block, strings.ToUpper(block.Text()), block.Metadata()), nil
+//	}
+//
+// Registration pattern:
+//
+//	func init() {
+//		RegisterProcessor("upper", func(options map[string]interface{}) (Processor, error) {
+//			return upperProcessor{}, nil
+//		})
+//	}
+type Processor interface {
+	// Process returns the block to store: block itself, a rewritten copy, or
+	// nil to drop it. Errors abort storing the block.
+	Process(block Block) (Block, error)
+}
+
+// ProcessorFactory creates a processor from its options, the keys of its
+// [[datasources.<name>.processors]] table in the config file other than type.
+// Factories should reject invalid options so mistakes surface on startup.
+type ProcessorFactory func(options map[string]interface{}) (Processor, error)
+
+var (
+	processorFactories   = make(map[string]ProcessorFactory)
+	processorFactoriesMu sync.RWMutex
+)
+
+// RegisterProcessor allows processors to register themselves during init()
+func RegisterProcessor(name string, factory ProcessorFactory) {
+	processorFactoriesMu.Lock()
+	defer processorFactoriesMu.Unlock()
+	processorFactories[name] = factory
+}
+
+// NewProcessor creates a processor of a registered type
+func NewProcessor(name string, options map[string]interface{}) (Processor, error) {
+	processorFactoriesMu.RLock()
+	factory, exists := processorFactories[name]
+	processorFactoriesMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown processor type %q (available: %v)", name, ProcessorTypes())
+	}
+	return factory(options)
+}
+
+// ProcessorTypes returns the registered processor types, sorted
+func ProcessorTypes() []string {
+	processorFactoriesMu.RLock()
+	defer processorFactoriesMu.RUnlock()
+	types := make([]string, 0, len(processorFactories))
+	for name := range processorFactories {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Pipeline runs processors in order, each receiving the block returned by the
+// previous one. A processor dropping the block stops the pipeline.
+type Pipeline []Processor
+
+// Process runs the pipeline on block, returning nil if a processor dropped it
+func (p Pipeline) Process(block Block) (Block, error) {
+	for _, processor := range p {
+		processed, err := processor.Process(block)
+		if err != nil || processed == nil {
+			return nil, err
+		}
+		block = processed
+	}
+	return block, nil
+}
+
+// RewriteBlock returns a copy of block with new text and metadata. The copy is
+// rebuilt with the block's Factory, so PrettyText and Summary reflect the new
+// metadata, while Text and Metadata return exactly the given values.
+func RewriteBlock(block Block, text string, metadata map[string]interface{}) Block {
+	generic := NewGenericBlock(block.ID(), text, block.Source(), block.Type(), block.CreatedAt(), metadata)
+	return &rewrittenBlock{
+		Block:    block.Factory(generic, block.Source()),
+		text:     text,
+		metadata: metadata,
+	}
+}
+
+// rewrittenBlock is a block whose text and metadata were changed by a processor
+type rewrittenBlock struct {
+	Block
+	text     string
+	metadata map[string]interface{}
+}
+
+// Text returns the rewritten text
+func (b *rewrittenBlock) Text() string { return b.text }
+
+// Metadata returns the rewritten metadata
+func (b *rewrittenBlock) Metadata() map[string]interface{} { return b.metadata }
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type upperProcessor struct{}
+
+func (upperProcessor) Process(block Block) (Block, error) {
+	return RewriteBlock(block, strings.ToUpper(block.Text()), block.Metadata()), nil
+}
+
+type dropAllProcessor struct{}
+
+func (dropAllProcessor) Process(block Block) (Block, error) { return nil, nil }
+
+func TestPipeline(t *testing.T) {
+	block := NewGenericBlock("id-1", "hello", "src", "test", time.Now(), map[string]interface{}{"title": "Hello"})
+
+	processed, err := Pipeline{upperProcessor{}}.Process(block)
+	if err != nil {
+		t.Fatalf("Process error: %v", err)
+	}
+	if processed.Text() != "HELLO" || processed.ID() != "id-1" || processed.Source() != "src" || processed.Type() != "test" {
+		t.Errorf("unexpected block: %s %s %s %s", processed.ID(), processed.Text(), processed.Source(), processed.Type())
+	}
+	if block.Text() != "hello" {
+		t.Error("the original block was modified")
+	}
+	if processed.Summary() != "📄 Hello" {
+		t.Errorf("Summary() = %q", processed.Summary())
+	}
+
+	processed, err = Pipeline{dropAllProcessor{}, upperProcessor{}}.Process(block)
+	if err != nil || processed != nil {
+		t.Errorf("expected the block to be dropped, got %v (err %v)", processed, err)
+	}
+
+	processed, err = Pipeline(nil).Process(block)
+	if err != nil || processed != block {
+		t.Errorf("an empty pipeline should return the block unchanged")
+	}
+}
+
+func TestNewProcessor(t *testing.T) {
+	RegisterProcessor("test_upper", func(options map[string]interface{}) (Processor, error) {
+		return upperProcessor{}, nil
+	})
+
+	if _, err := NewProcessor("test_upper", nil); err != nil {
+		t.Errorf("NewProcessor error: %v", err)
+	}
+	if _, err := NewProcessor("missing", nil); err == nil {
+		t.Error("expected an error for an unknown processor type")
+	}
+}
@@ -0,0 +1,72 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tombstone is a Block that marks a previously emitted block as deleted.
+//
+// FetchBlocks can only send blocks, so datasources signal that an item is gone
+// from the underlying source (cleared browser history, a deleted thread...) by
+// sending a tombstone carrying the ID of the block to remove. The warehouse
+// deletes the stored block instead of storing the tombstone, and broadcasts a
+// "delete" event so realtime clients can drop it.
+//
+// Example:
+//
+//	select {
+//	case <-ctx.Done():
+//		return ctx.Err()
+//	case blockCh <- core.NewTombstone(threadID, d.instanceName):
+//	}
+type Tombstone struct {
+	id        string
+	source    string
+	deletedAt time.Time
+}
+
+// NewTombstone creates a tombstone for the block id of the datasource instance source.
+func NewTombstone(id, source string) *Tombstone {
+	return &Tombstone{id: id, source: source, deletedAt: time.Now().UTC()}
+}
+
+// IsTombstone reports whether block marks a deletion.
+func IsTombstone(block Block) bool {
+	_, ok := block.(*Tombstone)
+	return ok
+}
+
+// ID returns the ID of the deleted block
+func (t *Tombstone) ID() string { return t.id }
+
+// Text returns an empty string, tombstones are never indexed
+func (t *Tombstone) Text() string { return "" }
+
+// CreatedAt returns when the tombstone was created
+func (t *Tombstone) CreatedAt() time.Time { return t.deletedAt }
+
+// Source returns the datasource instance name of the deleted block
+func (t *Tombstone) Source() string { return t.source }
+
+// Type returns an empty string, the warehouse knows the datasource type
+func (t *Tombstone) Type() string { return "" }
+
+// Metadata returns nil, tombstones carry no data
+func (t *Tombstone) Metadata() map[string]interface{} { return nil }
+
+// PrettyText returns a human-readable description of the deletion
+func (t *Tombstone) PrettyText() string {
+	return fmt.Sprintf("🗑️ Deleted block\n  ID: %s\n  Source: %s", t.id, t.source)
+}
+
+// Summary returns a one-line description of the deletion
+func (t *Tombstone) Summary() string {
+	return fmt.Sprintf("🗑️ Deleted %s", t.id)
+}
+
+// Factory returns a tombstone for the block. Tombstones are never stored, so
+// this only exists to satisfy the Block interface.
+func (t *Tombstone) Factory(genericBlock *GenericBlock, source string) Block {
+	return &Tombstone{id: genericBlock.ID(), source: source, deletedAt: genericBlock.CreatedAt()}
+}
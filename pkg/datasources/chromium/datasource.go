@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
@@ -35,6 +36,9 @@ func (f *BlockFactory) CreateFromGeneric(id, text string, createdAt time.Time, s
 
 type Config struct {
 	DatabasePath string `toml:"database_path"`
+	// SyncDeletions removes the stored visits deleted from the browser
+	// history, including the ones the browser expires on its own
+	SyncDeletions bool `toml:"sync_deletions"`
 }
 
 func (c *Config) Validate() error {
@@ -152,13 +156,55 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 		return fmt.Errorf("required Chromium tables not found in database")
 	}
 
+	// Resume from the last visit id seen in a previous fetch
+	cursors := core.CursorStoreFromContext(ctx)
+	var lastVisitID int64
+	if value, ok, err := cursors.GetCursor("last_visit_id"); err != nil {
+		l.Warnf("Failed to read fetch cursor, fetching full history: %v", err)
+	} else if ok {
+		if lastVisitID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			l.Warnf("Invalid fetch cursor %q, fetching full history", value)
+			lastVisitID = 0
+		}
+	}
+	storedVisitID := lastVisitID
+
+	// SQLite hands out the ids of deleted newest visits again, e.g. after
+	// clearing the last hour of history, so the cursor follows the newest
+	// visit left. Visits reusing an id before the next fetch are found by
+	// their date instead, newer than any visit fetched before.
+	var maxID int64
+	if err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM visits").Scan(&maxID); err != nil {
+		return fmt.Errorf("reading last visit id: %w", err)
+	}
+	if maxID < lastVisitID {
+		l.Debugf("Visits after id %d were deleted, resuming from visit id %d", lastVisitID, maxID)
+		lastVisitID = maxID
+	}
+	lastVisitDate, storedVisitDate := int64(0), int64(-1)
+	if value, ok, err := cursors.GetCursor("last_visit_date"); err == nil && ok {
+		if lastVisitDate, err = strconv.ParseInt(value, 10, 64); err == nil {
+			storedVisitDate = lastVisitDate
+		}
+	}
+	if storedVisitDate < 0 {
+		// Cursors saved before the visit date was tracked
+		if err := db.QueryRowContext(ctx, "SELECT COALESCE(MAX(visit_time), 0) FROM visits WHERE id <= ?", lastVisitID).Scan(&lastVisitDate); err != nil {
+			return fmt.Errorf("reading last visit date: %w", err)
+		}
+	}
+	if lastVisitID > 0 {
+		l.Debugf("Resuming from visit id %d", lastVisitID)
+	}
+
 	rows, err := db.QueryContext(ctx, `
 		SELECT u.url, u.title, v.visit_time, v.id
 		FROM urls u
 		INNER JOIN visits v
 		ON u.id = v.url
+		WHERE v.id > ? OR v.visit_time > ?
 		ORDER BY v.visit_time DESC
-	`)
+	`, lastVisitID, lastVisitDate)
 	if err != nil {
 		return fmt.Errorf("querying database: %w", err)
 	}
@@ -169,6 +215,7 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 	}()
 
 	visitCount := 0
+	maxVisitID, maxVisitDate := lastVisitID, lastVisitDate
 	for rows.Next() {
 		select {
 		case <-ctx.Done():
@@ -204,6 +251,12 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 			return ctx.Err()
 		case blockCh <- block:
 			visitCount++
+			if visitID > maxVisitID {
+				maxVisitID = visitID
+			}
+			if visitTime > maxVisitDate {
+				maxVisitDate = visitTime
+			}
 		}
 	}
 
@@ -211,10 +264,87 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 		return fmt.Errorf("row iteration error: %w", err)
 	}
 
-	l.Debugf("Fetched %d Chromium visits", visitCount)
+	if maxVisitID != storedVisitID {
+		if err := cursors.SetCursor("last_visit_id", strconv.FormatInt(maxVisitID, 10)); err != nil {
+			l.Warnf("Failed to save fetch cursor: %v", err)
+		}
+	}
+	if maxVisitDate != storedVisitDate {
+		if err := cursors.SetCursor("last_visit_date", strconv.FormatInt(maxVisitDate, 10)); err != nil {
+			l.Warnf("Failed to save fetch cursor: %v", err)
+		}
+	}
+
+	deleted := 0
+	if d.config.SyncDeletions {
+		if deleted, err = d.sendDeletedVisits(ctx, l, db, cursors, blockCh); err != nil {
+			return fmt.Errorf("detecting deleted visits: %w", err)
+		}
+	}
+
+	l.Debugf("Fetched %d Chromium visits, %d deleted", visitCount, deleted)
 	return nil
 }
 
+// sendDeletedVisits sends a tombstone for every visit seen by the previous
+// fetch that is no longer in the history, and records the current visit IDs
+// for the next fetch.
+func (d *Datasource) sendDeletedVisits(ctx context.Context, l *log.Logger, db *sql.DB, cursors core.CursorStore, blockCh chan<- core.Block) (int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT v.id
+		FROM visits v
+		INNER JOIN urls u
+		ON u.id = v.url
+		ORDER BY v.id
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("querying visit IDs: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	current := &core.IDRanges{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return 0, fmt.Errorf("scanning visit ID: %w", err)
+		}
+		current.Add(id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	// Without the IDs of a previous fetch there is nothing to compare against
+	deleted := 0
+	if value, ok, err := cursors.GetCursor("visit_ids"); err != nil {
+		return 0, fmt.Errorf("reading visit IDs cursor: %w", err)
+	} else if ok {
+		previous, err := core.ParseIDRanges(value)
+		if err != nil {
+			// Replaced with the current visit IDs below
+			l.Warnf("Invalid visit IDs cursor, not detecting deleted visits this time: %v", err)
+			previous = &core.IDRanges{}
+		}
+		for _, id := range previous.Missing(current) {
+			select {
+			case <-ctx.Done():
+				return deleted, ctx.Err()
+			case blockCh <- core.NewTombstone(fmt.Sprintf("chromium-visit-%d", id), d.instanceName):
+				deleted++
+			}
+		}
+	}
+
+	if err := cursors.SetCursor("visit_ids", current.String()); err != nil {
+		return deleted, fmt.Errorf("saving visit IDs cursor: %w", err)
+	}
+	return deleted, nil
+}
+
 func (d *Datasource) checkTables(ctx context.Context, db *sql.DB) (bool, error) {
 	var exists bool
 	err := db.QueryRowContext(ctx, "SELECT 1 FROM sqlite_master WHERE type='table' AND name='urls'").Scan(&exists)
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
@@ -120,6 +121,163 @@ func TestChromiumDataFetching(t *testing.T) {
 	}
 }
 
+// memoryCursors is an in-memory core.CursorStore
+type memoryCursors map[string]string
+
+func (m memoryCursors) GetCursor(key string) (string, bool, error) {
+	value, ok := m[key]
+	return value, ok, nil
+}
+
+func (m memoryCursors) SetCursor(key, value string) error {
+	m[key] = value
+	return nil
+}
+
+func TestChromiumDeletedVisits(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "History")
+	if err := createTestDatabase(dbPath); err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	config := &Config{DatabasePath: dbPath}
+	ds, err := NewDatasource("test-chromium", config)
+	if err != nil {
+		t.Fatalf("Failed to create datasource: %v", err)
+	}
+
+	cursors := memoryCursors{}
+	ctx := core.WithCursorStore(context.Background(), cursors)
+	fetch := func() []core.Block {
+		t.Helper()
+		blockCh := make(chan core.Block, 10)
+		if err := ds.FetchBlocks(ctx, blockCh); err != nil {
+			t.Fatalf("FetchBlocks failed: %v", err)
+		}
+		close(blockCh)
+		var blocks []core.Block
+		for block := range blockCh {
+			blocks = append(blocks, block)
+		}
+		return blocks
+	}
+	deleteVisit := func(id int) {
+		t.Helper()
+		db, err := sql.Open("sqlite3", dbPath)
+		if err != nil {
+			t.Fatalf("Failed to open test database: %v", err)
+		}
+		defer func() { _ = db.Close() }()
+		if _, err := db.Exec("DELETE FROM visits WHERE id = ?", id); err != nil {
+			t.Fatalf("Failed to delete visit: %v", err)
+		}
+	}
+
+	if blocks := fetch(); len(blocks) != 2 {
+		t.Fatalf("Expected 2 blocks, got %d", len(blocks))
+	}
+
+	// Deletions are only reported when enabled
+	deleteVisit(1)
+	if blocks := fetch(); len(blocks) != 0 {
+		t.Fatalf("Expected no blocks with sync_deletions disabled, got %v", blocks)
+	}
+
+	// The first fetch with sync_deletions has nothing to compare against
+	config.SyncDeletions = true
+	if blocks := fetch(); len(blocks) != 0 {
+		t.Fatalf("Expected no blocks, got %v", blocks)
+	}
+
+	// An invalid cursor is replaced, without tombstones
+	cursors["visit_ids"] = "invalid"
+	if blocks := fetch(); len(blocks) != 0 {
+		t.Fatalf("Expected no blocks with an invalid cursor, got %v", blocks)
+	}
+	if cursors["visit_ids"] != "2" {
+		t.Fatalf("Expected the visit IDs cursor replaced, got %q", cursors["visit_ids"])
+	}
+
+	deleteVisit(2)
+	blocks := fetch()
+	if len(blocks) != 1 || !core.IsTombstone(blocks[0]) || blocks[0].ID() != "chromium-visit-2" || blocks[0].Source() != "test-chromium" {
+		t.Fatalf("Expected a tombstone for chromium-visit-2, got %v", blocks)
+	}
+	if blocks := fetch(); len(blocks) != 0 {
+		t.Errorf("Expected no blocks once the deletion was reported, got %d", len(blocks))
+	}
+}
+
+func TestChromiumReusedVisitIDs(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "History")
+	if err := createTestDatabase(dbPath); err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	ds, err := NewDatasource("test-chromium", &Config{DatabasePath: dbPath})
+	if err != nil {
+		t.Fatalf("Failed to create datasource: %v", err)
+	}
+
+	ctx := core.WithCursorStore(context.Background(), memoryCursors{})
+	fetch := func() []string {
+		t.Helper()
+		blockCh := make(chan core.Block, 10)
+		if err := ds.FetchBlocks(ctx, blockCh); err != nil {
+			t.Fatalf("FetchBlocks failed: %v", err)
+		}
+		close(blockCh)
+		var ids []string
+		for block := range blockCh {
+			ids = append(ids, block.ID())
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	exec := func(query string, args ...any) {
+		t.Helper()
+		db, err := sql.Open("sqlite3", dbPath)
+		if err != nil {
+			t.Fatalf("Failed to open test database: %v", err)
+		}
+		defer func() { _ = db.Close() }()
+		if _, err := db.Exec(query, args...); err != nil {
+			t.Fatalf("Failed to update test database: %v", err)
+		}
+	}
+	// Every new visit is a minute newer than the previous one
+	visitTime := time.Now()
+	addVisit := func(id int) {
+		t.Helper()
+		visitTime = visitTime.Add(time.Minute)
+		exec("INSERT INTO visits (id, url, visit_time, transition) VALUES (?, 1, ?, 0)", id, (visitTime.Unix()+11644473600)*1000000)
+	}
+
+	if ids := fetch(); len(ids) != 2 {
+		t.Fatalf("Expected 2 visits, got %v", ids)
+	}
+
+	// The newest visit is deleted, and its id reused by the next visit
+	exec("DELETE FROM visits WHERE id = 2")
+	if ids := fetch(); len(ids) != 0 {
+		t.Fatalf("Expected no visits, got %v", ids)
+	}
+	addVisit(2)
+	if ids := fetch(); !slices.Equal(ids, []string{"chromium-visit-2"}) {
+		t.Fatalf("Expected the visit reusing id 2 fetched, got %v", ids)
+	}
+
+	// Also when the ids are reused before the next fetch
+	exec("DELETE FROM visits WHERE id = 2")
+	addVisit(2)
+	addVisit(3)
+	if ids := fetch(); !slices.Equal(ids, []string{"chromium-visit-2", "chromium-visit-3"}) {
+		t.Fatalf("Expected the visits reusing id 2 and 3 fetched, got %v", ids)
+	}
+	if ids := fetch(); len(ids) != 0 {
+		t.Fatalf("Expected no visits, got %v", ids)
+	}
+}
+
 func TestBlockFactory(t *testing.T) {
 	factory := &BlockFactory{}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -150,8 +151,23 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 		PerPage: 100,
 	}
 
+	// Events are returned newest first: stop paging once we reach the newest
+	// event seen during the previous fetch.
+	cursors := core.CursorStoreFromContext(ctx)
+	var lastEventID int64
+	if value, ok, err := cursors.GetCursor("last_event_id"); err != nil {
+		l.Warnf("Failed to read fetch cursor, fetching all events: %v", err)
+	} else if ok {
+		if lastEventID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			l.Warnf("Invalid fetch cursor %q, fetching all events", value)
+			lastEventID = 0
+		}
+	}
+
 	eventCount := 0
 	pageCount := 0
+	newestEventID := lastEventID
+	reachedCursor := false
 
 	for {
 		select {
@@ -173,6 +189,16 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 				continue
 			}
 
+			if id, err := strconv.ParseInt(event.GetID(), 10, 64); err == nil {
+				if lastEventID > 0 && id <= lastEventID {
+					reachedCursor = true
+					break
+				}
+				if id > newestEventID {
+					newestEventID = id
+				}
+			}
+
 			block, err := d.convertEventToBlock(ctx, event)
 			if err != nil {
 				l.Warnf("Failed to convert event: %v", err)
@@ -191,6 +217,11 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 			}
 		}
 
+		if reachedCursor {
+			l.Debugf("Reached previously fetched event %d, stopping", lastEventID)
+			break
+		}
+
 		if resp.NextPage == 0 {
 			break
 		}
@@ -200,6 +231,12 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 		time.Sleep(100 * time.Millisecond)
 	}
 
+	if newestEventID > lastEventID {
+		if err := cursors.SetCursor("last_event_id", strconv.FormatInt(newestEventID, 10)); err != nil {
+			l.Warnf("Failed to save fetch cursor: %v", err)
+		}
+	}
+
 	l.Debugf("Fetched %d GitHub events across %d pages", eventCount, pageCount)
 	return nil
 }
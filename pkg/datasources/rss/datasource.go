@@ -3,6 +3,7 @@ package rss
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"html"
 	"net/http"
@@ -167,6 +168,7 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 	l := log.ForService("rss:" + d.instanceName)
 	l.Debugf("Fetching RSS feeds from %d URLs", len(d.config.URLs))
 
+	cursors := core.CursorStoreFromContext(ctx)
 	totalFetched := 0
 	itemsPerFeed := d.config.MaxItems / len(d.config.URLs)
 	if itemsPerFeed == 0 {
@@ -182,7 +184,11 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 
 		l.Debugf("Fetching RSS feed: %s", feedURL)
 
-		items, feedTitle, err := d.fetchFeed(ctx, feedURL)
+		items, feedTitle, err := d.fetchFeed(ctx, feedURL, cursors)
+		if errors.Is(err, errNotModified) {
+			l.Debugf("Feed not modified since last fetch: %s", feedURL)
+			continue
+		}
 		if err != nil {
 			l.Warnf("Failed to fetch feed %s: %v", feedURL, err)
 			continue
@@ -219,7 +225,11 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 	return nil
 }
 
-func (d *Datasource) fetchFeed(ctx context.Context, feedURL string) ([]FeedItem, string, error) {
+// errNotModified is returned by fetchFeed when the server reports the feed
+// has not changed since the ETag/Last-Modified saved in the fetch cursors.
+var errNotModified = errors.New("feed not modified")
+
+func (d *Datasource) fetchFeed(ctx context.Context, feedURL string, cursors core.CursorStore) ([]FeedItem, string, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
 		return nil, "", err
@@ -227,6 +237,14 @@ func (d *Datasource) fetchFeed(ctx context.Context, feedURL string) ([]FeedItem,
 
 	req.Header.Set("User-Agent", "ergs/1.0 RSS Reader")
 
+	// Conditional request using the validators from the previous fetch
+	if etag, ok, err := cursors.GetCursor("etag:" + feedURL); err == nil && ok {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified, ok, err := cursors.GetCursor("last_modified:" + feedURL); err == nil && ok {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
 	resp, err := d.client.Do(req)
 	if err != nil {
 		return nil, "", err
@@ -237,6 +255,10 @@ func (d *Datasource) fetchFeed(ctx context.Context, feedURL string) ([]FeedItem,
 		}
 	}()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, "", errNotModified
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
@@ -270,6 +292,9 @@ func (d *Datasource) fetchFeed(ctx context.Context, feedURL string) ([]FeedItem,
 		if err := decoder.Decode(&atom); err != nil {
 			return nil, "", fmt.Errorf("failed to parse as RSS or Atom: %w", err)
 		}
+		if err := saveValidators(cursors, feedURL, resp.Header); err != nil {
+			return nil, "", err
+		}
 
 		// Convert Atom to common format
 		items := make([]FeedItem, len(atom.Entries))
@@ -287,6 +312,10 @@ func (d *Datasource) fetchFeed(ctx context.Context, feedURL string) ([]FeedItem,
 		return items, atom.Title, nil
 	}
 
+	if err := saveValidators(cursors, feedURL, resp.Header); err != nil {
+		return nil, "", err
+	}
+
 	// Convert RSS to common format
 	items := make([]FeedItem, len(rss.Channel.Items))
 	for i, item := range rss.Channel.Items {
@@ -419,3 +448,20 @@ func (d *Datasource) Close() error {
 func (d *Datasource) Factory(instanceName string, config interface{}) (core.Datasource, error) {
 	return NewDatasource(instanceName, config)
 }
+
+// saveValidators remembers the ETag and Last-Modified of a parsed feed, so
+// the next fetch can skip it while unchanged. Feeds failing to parse are
+// fetched in full again.
+func saveValidators(cursors core.CursorStore, feedURL string, header http.Header) error {
+	if etag := header.Get("ETag"); etag != "" {
+		if err := cursors.SetCursor("etag:"+feedURL, etag); err != nil {
+			return fmt.Errorf("saving etag cursor: %w", err)
+		}
+	}
+	if lastModified := header.Get("Last-Modified"); lastModified != "" {
+		if err := cursors.SetCursor("last_modified:"+feedURL, lastModified); err != nil {
+			return fmt.Errorf("saving last-modified cursor: %w", err)
+		}
+	}
+	return nil
+}
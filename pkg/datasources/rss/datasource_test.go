@@ -0,0 +1,66 @@
+package rss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// memoryCursors is an in-memory core.CursorStore
+type memoryCursors map[string]string
+
+func (m memoryCursors) GetCursor(key string) (string, bool, error) {
+	value, ok := m[key]
+	return value, ok, nil
+}
+
+func (m memoryCursors) SetCursor(key, value string) error {
+	m[key] = value
+	return nil
+}
+
+func TestFetchFeedValidators(t *testing.T) {
+	body := "<html>maintenance</html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	ds, err := NewDatasource("news", &Config{URLs: []string{server.URL}})
+	if err != nil {
+		t.Fatalf("NewDatasource: %v", err)
+	}
+	d := ds.(*Datasource)
+	cursors := memoryCursors{}
+
+	// A feed failing to parse is fetched in full again next time
+	if _, _, err := d.fetchFeed(context.Background(), server.URL, cursors); err == nil {
+		t.Fatal("expected an error parsing an invalid feed")
+	}
+	if len(cursors) != 0 {
+		t.Fatalf("expected no validators saved for an invalid feed, got %v", cursors)
+	}
+
+	body = `<rss><channel><title>News</title><item><title>Hello</title><guid>1</guid></item></channel></rss>`
+	items, title, err := d.fetchFeed(context.Background(), server.URL, cursors)
+	if err != nil {
+		t.Fatalf("fetchFeed: %v", err)
+	}
+	if title != "News" || len(items) != 1 {
+		t.Fatalf("unexpected feed %q: %+v", title, items)
+	}
+	if cursors["etag:"+server.URL] != `"v1"` || cursors["last_modified:"+server.URL] == "" {
+		t.Fatalf("expected the validators saved, got %v", cursors)
+	}
+
+	if _, _, err := d.fetchFeed(context.Background(), server.URL, cursors); err != errNotModified {
+		t.Fatalf("expected the feed not modified, got %v", err)
+	}
+}
@@ -208,11 +208,23 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 		return fmt.Errorf("required Zed threads schema not found in database")
 	}
 
+	// Only read threads updated since the previous fetch
+	cursors := core.CursorStoreFromContext(ctx)
+	lastUpdatedAt, _, err := cursors.GetCursor("last_updated_at")
+	if err != nil {
+		l.Warnf("Failed to read fetch cursor, fetching all threads: %v", err)
+		lastUpdatedAt = ""
+	}
+	if lastUpdatedAt != "" {
+		l.Debugf("Resuming from threads updated after %s", lastUpdatedAt)
+	}
+
 	rows, err := db.QueryContext(ctx, `
 		SELECT id, summary, updated_at, data_type, data
 		FROM threads
+		WHERE updated_at > ?
 		ORDER BY updated_at DESC
-	`)
+	`, lastUpdatedAt)
 	if err != nil {
 		return fmt.Errorf("querying database: %w", err)
 	}
@@ -223,6 +235,7 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 	}()
 
 	threadCount := 0
+	maxUpdatedAt := lastUpdatedAt
 	for rows.Next() {
 		select {
 		case <-ctx.Done():
@@ -265,6 +278,9 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 			return ctx.Err()
 		case blockCh <- block:
 			threadCount++
+			if updatedAtStr > maxUpdatedAt {
+				maxUpdatedAt = updatedAtStr
+			}
 		}
 	}
 
@@ -272,10 +288,81 @@ func (d *Datasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block)
 		return fmt.Errorf("row iteration error: %w", err)
 	}
 
-	l.Debugf("Fetched %d Zed threads", threadCount)
+	if maxUpdatedAt > lastUpdatedAt {
+		if err := cursors.SetCursor("last_updated_at", maxUpdatedAt); err != nil {
+			l.Warnf("Failed to save fetch cursor: %v", err)
+		}
+	}
+
+	deleted, err := d.sendDeletedThreads(ctx, db, cursors, blockCh)
+	if err != nil {
+		return fmt.Errorf("detecting deleted threads: %w", err)
+	}
+
+	l.Debugf("Fetched %d Zed threads, %d deleted", threadCount, deleted)
 	return nil
 }
 
+// sendDeletedThreads sends a tombstone for every thread seen by the previous
+// fetch that is no longer in the database, and records the current thread IDs
+// for the next fetch.
+func (d *Datasource) sendDeletedThreads(ctx context.Context, db *sql.DB, cursors core.CursorStore, blockCh chan<- core.Block) (int, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id FROM threads ORDER BY id")
+	if err != nil {
+		return 0, fmt.Errorf("querying thread IDs: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	current := make(map[string]bool)
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return 0, fmt.Errorf("scanning thread ID: %w", err)
+		}
+		current[id] = true
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	// Without the IDs of a previous fetch there is nothing to compare against
+	deleted := 0
+	if value, ok, err := cursors.GetCursor("thread_ids"); err != nil {
+		return 0, fmt.Errorf("reading thread IDs cursor: %w", err)
+	} else if ok {
+		var previous []string
+		if err := json.Unmarshal([]byte(value), &previous); err != nil {
+			return 0, fmt.Errorf("parsing thread IDs cursor: %w", err)
+		}
+		for _, id := range previous {
+			if current[id] {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return deleted, ctx.Err()
+			case blockCh <- core.NewTombstone(id, d.instanceName):
+				deleted++
+			}
+		}
+	}
+
+	data, err := json.Marshal(ids)
+	if err != nil {
+		return deleted, err
+	}
+	if err := cursors.SetCursor("thread_ids", string(data)); err != nil {
+		return deleted, fmt.Errorf("saving thread IDs cursor: %w", err)
+	}
+	return deleted, nil
+}
+
 func (d *Datasource) decompressThreadData(compressedData []byte) (*ThreadData, error) {
 	decompressed, err := d.decoder.DecodeAll(compressedData, nil)
 	if err != nil {
@@ -149,6 +149,71 @@ func TestZedThreadsDataFetching(t *testing.T) {
 	}
 }
 
+// memoryCursors is an in-memory core.CursorStore
+type memoryCursors map[string]string
+
+func (m memoryCursors) GetCursor(key string) (string, bool, error) {
+	value, ok := m[key]
+	return value, ok, nil
+}
+
+func (m memoryCursors) SetCursor(key, value string) error {
+	m[key] = value
+	return nil
+}
+
+func TestZedThreadsDeletedThreads(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test_threads.db")
+	if err := createTestDatabase(dbPath); err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+
+	ds, err := NewDatasource("test-zedthreads", &Config{DatabasePath: dbPath})
+	if err != nil {
+		t.Fatalf("Failed to create datasource: %v", err)
+	}
+	defer func() { _ = ds.Close() }()
+
+	ctx := core.WithCursorStore(context.Background(), memoryCursors{})
+	fetch := func() []core.Block {
+		t.Helper()
+		blockCh := make(chan core.Block, 10)
+		if err := ds.FetchBlocks(ctx, blockCh); err != nil {
+			t.Fatalf("FetchBlocks failed: %v", err)
+		}
+		close(blockCh)
+		var blocks []core.Block
+		for block := range blockCh {
+			blocks = append(blocks, block)
+		}
+		return blocks
+	}
+
+	// The first fetch has nothing to compare against
+	for _, block := range fetch() {
+		if core.IsTombstone(block) {
+			t.Errorf("Unexpected tombstone for %s", block.ID())
+		}
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM threads WHERE id = 'thread-1'"); err != nil {
+		t.Fatalf("Failed to delete thread: %v", err)
+	}
+	_ = db.Close()
+
+	blocks := fetch()
+	if len(blocks) != 1 || !core.IsTombstone(blocks[0]) || blocks[0].ID() != "thread-1" || blocks[0].Source() != "test-zedthreads" {
+		t.Fatalf("Expected a tombstone for thread-1, got %v", blocks)
+	}
+	if blocks := fetch(); len(blocks) != 0 {
+		t.Errorf("Expected no blocks once the deletion was reported, got %d", len(blocks))
+	}
+}
+
 func TestBlockFactory(t *testing.T) {
 	factory := &BlockFactory{}
 
@@ -0,0 +1,66 @@
+-- Migration 007: Pass old column values to the FTS5 'delete' command in the sync triggers
+--
+-- Purpose:
+--   Make deleting and updating blocks keep blocks_fts consistent, so blocks can be
+--   pruned (retention policies) and removed without corrupting the full-text index.
+--
+-- Prior State:
+--   Migration 001: Created base schema (blocks + blocks_fts)
+--   Migration 002: Added hostname column and rebuilt FTS
+--   Migration 003: Added FTS synchronization triggers
+--   Migration 004: Added updated_at and its index
+--   Migration 005: Added ingested_at and its index
+--   Migration 006: Added created_at index
+--
+-- Problem:
+--   blocks_fts is an external content table (content='blocks'). For those, FTS5
+--   removes the tokens of a row using the column values passed to the 'delete'
+--   command, it can't read them back from the already-modified content table.
+--   The DELETE and UPDATE triggers from migration 003 only passed the rowid:
+--     INSERT INTO blocks_fts(blocks_fts, rowid) VALUES('delete', old.rowid);
+--   so the tokens of deleted or updated rows stayed in the index, pointing at
+--   rows that no longer exist ("fts5: missing row N from content table").
+--
+-- Changes in this migration:
+--   1. Recreates blocks_ad_fts and blocks_au_fts passing every indexed old value
+--   2. Rebuilds blocks_fts to drop tokens left behind by the old triggers
+--
+-- Safety:
+--   * Triggers are dropped with IF EXISTS before being recreated
+--   * The insert trigger from migration 003 is unchanged
+--   * The rebuild only reads the blocks table, whatever the tokenizer of blocks_fts
+--
+-- ---------------------------------------------------------------------------
+-- 1. Recreate the DELETE trigger
+-- ---------------------------------------------------------------------------
+DROP TRIGGER IF EXISTS blocks_ad_fts;
+
+CREATE TRIGGER blocks_ad_fts
+AFTER DELETE ON blocks
+BEGIN
+    INSERT INTO blocks_fts(blocks_fts, rowid, text, source, datasource, metadata, hostname)
+    VALUES ('delete', old.rowid, old.text, old.source, old.datasource, old.metadata, old.hostname);
+END;
+
+-- ---------------------------------------------------------------------------
+-- 2. Recreate the UPDATE trigger
+-- ---------------------------------------------------------------------------
+DROP TRIGGER IF EXISTS blocks_au_fts;
+
+CREATE TRIGGER blocks_au_fts
+AFTER UPDATE ON blocks
+BEGIN
+    -- Remove old version
+    INSERT INTO blocks_fts(blocks_fts, rowid, text, source, datasource, metadata, hostname)
+    VALUES ('delete', old.rowid, old.text, old.source, old.datasource, old.metadata, old.hostname);
+    -- Add new version
+    INSERT INTO blocks_fts(rowid, text, source, datasource, metadata, hostname)
+    VALUES (new.rowid, new.text, new.source, new.datasource, new.metadata, new.hostname);
+END;
+
+-- ---------------------------------------------------------------------------
+-- 3. Rebuild the index from the blocks table
+-- ---------------------------------------------------------------------------
+INSERT INTO blocks_fts(blocks_fts) VALUES('rebuild');
+
+-- End of migration 007
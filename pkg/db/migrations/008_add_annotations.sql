@@ -0,0 +1,53 @@
+-- Migration 008: Add the block annotation tables (user tags and notes)
+--
+-- Purpose:
+--   Let users curate stored blocks with tags (searchable with tag:name) and a
+--   free-text note, from the web UI, the API and the CLI.
+--
+-- Prior State:
+--   Migration 001: Created base schema (blocks + blocks_fts)
+--   Migration 002: Added hostname column and rebuilt FTS
+--   Migration 003: Added FTS synchronization triggers
+--   Migration 004: Added updated_at and its index
+--   Migration 005: Added ingested_at and its index
+--   Migration 006: Added created_at index
+--   Migration 007: Fixed the FTS delete and update triggers
+--
+-- Design:
+--   Annotations live in their own tables keyed by block ID, not in the blocks
+--   row. Datasources re-fetch blocks and StoreBlocks upserts them, replacing
+--   text and metadata; annotations are never touched by those upserts. For the
+--   same reason they are kept when a block is deleted and apply again if the
+--   block is fetched later.
+--
+-- Changes in this migration:
+--   1. Creates block_tags, one row per tag of a block, and its tag index
+--   2. Creates block_notes, one note per block
+--
+-- Safety:
+--   * Tables and index creation guarded with IF NOT EXISTS for idempotency
+--   * Existing tables are not modified
+--
+-- ---------------------------------------------------------------------------
+-- 1. Tags
+-- ---------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS block_tags (
+    block_id   TEXT NOT NULL,
+    tag        TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (block_id, tag)
+);
+
+-- tag:name searches look blocks up by tag
+CREATE INDEX IF NOT EXISTS idx_block_tags_tag ON block_tags(tag);
+
+-- ---------------------------------------------------------------------------
+-- 2. Notes
+-- ---------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS block_notes (
+    block_id   TEXT PRIMARY KEY,
+    note       TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- End of migration 008
@@ -0,0 +1,51 @@
+-- Migration 009: Add the inbox read markers
+--
+-- Purpose:
+--   Triage newly ingested blocks like a feed reader: the inbox lists the
+--   blocks ingested since they were last looked at, and each can be marked
+--   read or archived (ergs inbox, the web UI /inbox page and /api/inbox).
+--
+-- Prior State:
+--   Migration 001: Created base schema (blocks + blocks_fts)
+--   Migration 002: Added hostname column and rebuilt FTS
+--   Migration 003: Added FTS synchronization triggers
+--   Migration 004: Added updated_at and its index
+--   Migration 005: Added ingested_at and its index
+--   Migration 006: Added created_at index
+--   Migration 007: Fixed the FTS delete and update triggers
+--   Migration 008: Added the block annotation tables (tags and notes)
+--
+-- Design:
+--   A block without a block_inbox row is unread; read and archived blocks
+--   have a row with their state. Like annotations, the markers are keyed by
+--   block ID and never touched by StoreBlocks upserts, so re-fetching a block
+--   doesn't bring it back to the inbox. The inbox is ordered by ingested_at.
+--
+-- Changes in this migration:
+--   1. Creates block_inbox and its state index
+--   2. Marks every existing block as read, so the inbox starts with the
+--      blocks ingested after upgrading instead of the whole history
+--
+-- Safety:
+--   * Table and index creation guarded with IF NOT EXISTS for idempotency
+--   * The backfill uses INSERT OR IGNORE
+--   * Existing tables are not modified
+--
+-- ---------------------------------------------------------------------------
+-- 1. Inbox markers
+-- ---------------------------------------------------------------------------
+CREATE TABLE IF NOT EXISTS block_inbox (
+    block_id   TEXT PRIMARY KEY,
+    state      TEXT NOT NULL CHECK (state IN ('read', 'archived')),
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_block_inbox_state ON block_inbox(state);
+
+-- ---------------------------------------------------------------------------
+-- 2. Existing blocks start read
+-- ---------------------------------------------------------------------------
+INSERT OR IGNORE INTO block_inbox (block_id, state)
+SELECT id, 'read' FROM blocks;
+
+-- End of migration 009
@@ -5,6 +5,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -148,6 +149,20 @@ func DebugEnabledFor(name string) bool {
 	return false
 }
 
+// DebugServices returns the services/datasources with debug logging enabled
+// specifically, sorted by name.
+func DebugServices() []string {
+	var names []string
+	serviceDebug.Range(func(k, v any) bool {
+		if v.(*atomic.Bool).Load() {
+			names = append(names, k.(string))
+		}
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
 // SetOutput sets the output writer for all subsequently created loggers.
 // Existing loggers will also adopt the new writer.
 func SetOutput(w io.Writer) {
@@ -2,6 +2,7 @@ package log
 
 import (
 	"bytes"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -88,3 +89,15 @@ func TestWarnIncludesPrefix(t *testing.T) {
 		t.Fatalf("expected warn message in output, got: %q", out)
 	}
 }
+
+func TestDebugServices(t *testing.T) {
+	const name = "debug_services_listed"
+	EnableDebugFor(name)
+	if !slices.Contains(DebugServices(), name) {
+		t.Fatalf("expected %s in debug services, got %v", name, DebugServices())
+	}
+	DisableDebugFor(name)
+	if slices.Contains(DebugServices(), name) {
+		t.Fatalf("expected %s not in debug services after disabling, got %v", name, DebugServices())
+	}
+}
@@ -0,0 +1,134 @@
+// Package processors provides the built-in block processors, configured per
+// datasource with [[datasources.<name>.processors]] tables:
+//
+//	[[datasources.github.processors]]
+//	type = 'redact'
+//
+//	[[datasources.firefox.processors]]
+//	type = 'strip_url_params'
+//
+//	[[datasources.hn.processors]]
+//	type = 'tag'
+//	match = '(?i)\bgolang\b'
+//	tags = ['go']
+//
+// Processors register themselves with core.RegisterProcessor when the package
+// is imported.
+package processors
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func init() {
+	core.RegisterProcessor("redact", newRedactProcessor)
+	core.RegisterProcessor("strip_url_params", newStripURLParamsProcessor)
+	core.RegisterProcessor("tag", newTagProcessor)
+	core.RegisterProcessor("drop", newDropProcessor)
+	core.RegisterProcessor("rewrite", newRewriteProcessor)
+}
+
+// textField selects the block text in processor field options
+const textField = "text"
+
+// fieldValue returns the block text for the text field, or the string value
+// of a metadata field.
+func fieldValue(block core.Block, field string) string {
+	if field == "" || field == textField {
+		return block.Text()
+	}
+	value, _ := block.Metadata()[field].(string)
+	return value
+}
+
+// withFieldValue returns a copy of block with field set to value
+func withFieldValue(block core.Block, field, value string) core.Block {
+	if field == "" || field == textField {
+		return core.RewriteBlock(block, value, block.Metadata())
+	}
+	metadata := maps.Clone(block.Metadata())
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata[field] = value
+	return core.RewriteBlock(block, block.Text(), metadata)
+}
+
+// checkOptions rejects options a processor doesn't know about, catching typos
+// in the config file.
+func checkOptions(options map[string]interface{}, known ...string) error {
+	for key := range options {
+		found := false
+		for _, k := range known {
+			if key == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return nil
+}
+
+// stringOption returns a string option, or "" when it's not set
+func stringOption(options map[string]interface{}, key string) (string, error) {
+	value, exists := options[key]
+	if !exists {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("option %q must be a string", key)
+	}
+	return s, nil
+}
+
+// stringsOption returns a list of strings option. A single string is accepted
+// as a list of one.
+func stringsOption(options map[string]interface{}, key string) ([]string, error) {
+	switch value := options[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []string:
+		return value, nil
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("option %q must be a list of strings", key)
+			}
+			values = append(values, s)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("option %q must be a list of strings", key)
+	}
+}
+
+// regexpOption compiles a regular expression option
+func regexpOption(options map[string]interface{}, key string, required bool) (*regexp.Regexp, error) {
+	pattern, err := stringOption(options, key)
+	if err != nil {
+		return nil, err
+	}
+	if pattern == "" {
+		if required {
+			return nil, fmt.Errorf("option %q is required", key)
+		}
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s pattern %q: %w", key, pattern, err)
+	}
+	return re, nil
+}
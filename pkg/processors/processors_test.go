@@ -0,0 +1,131 @@
+package processors
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func newBlock(text string, metadata map[string]interface{}) core.Block {
+	return core.NewGenericBlock("id-1", text, "test", "test", time.Now(), metadata)
+}
+
+func mustProcessor(t *testing.T, name string, options map[string]interface{}) core.Processor {
+	t.Helper()
+	p, err := core.NewProcessor(name, options)
+	if err != nil {
+		t.Fatalf("NewProcessor(%s) error: %v", name, err)
+	}
+	return p
+}
+
+func TestRedact(t *testing.T) {
+	token := "ghp_" + strings.Repeat("a", 36)
+	block := newBlock("pushed with "+token, map[string]interface{}{"body": "token " + token, "stars": 3})
+
+	processed, err := mustProcessor(t, "redact", nil).Process(block)
+	if err != nil {
+		t.Fatalf("Process error: %v", err)
+	}
+	if processed.Text() != "pushed with [REDACTED]" {
+		t.Errorf("Text() = %q", processed.Text())
+	}
+	if processed.Metadata()["body"] != "token [REDACTED]" || processed.Metadata()["stars"] != 3 {
+		t.Errorf("Metadata() = %v", processed.Metadata())
+	}
+	if block.Metadata()["body"] != "token "+token {
+		t.Error("the original metadata was modified")
+	}
+
+	p := mustProcessor(t, "redact", map[string]interface{}{
+		"patterns":    []interface{}{`password=\S+`},
+		"replacement": "***",
+		"fields":      []interface{}{"text"},
+	})
+	processed, _ = p.Process(newBlock("login password=hunter2", map[string]interface{}{"body": "password=hunter2"}))
+	if processed.Text() != "login ***" || processed.Metadata()["body"] != "password=hunter2" {
+		t.Errorf("unexpected block: %q %v", processed.Text(), processed.Metadata())
+	}
+
+	clean := newBlock("nothing to see", nil)
+	if processed, _ := mustProcessor(t, "redact", nil).Process(clean); processed != clean {
+		t.Error("blocks without secrets should be returned unchanged")
+	}
+}
+
+func TestStripURLParams(t *testing.T) {
+	rawURL := "HTTPS://Example.COM:443/post?id=7&utm_source=feed&utm_medium=rss&fbclid=abc"
+	block := newBlock("Post "+rawURL, map[string]interface{}{"url": rawURL})
+
+	processed, err := mustProcessor(t, "strip_url_params", nil).Process(block)
+	if err != nil {
+		t.Fatalf("Process error: %v", err)
+	}
+	want := "https://example.com/post?id=7"
+	if processed.Metadata()["url"] != want {
+		t.Errorf("url = %v, want %s", processed.Metadata()["url"], want)
+	}
+	if processed.Text() != "Post "+want {
+		t.Errorf("Text() = %q", processed.Text())
+	}
+
+	p := mustProcessor(t, "strip_url_params", map[string]interface{}{"fields": "link", "params": []interface{}{"ref"}})
+	processed, _ = p.Process(newBlock("", map[string]interface{}{"link": "https://example.com/?ref=hn&q=go"}))
+	if processed.Metadata()["link"] != "https://example.com/?q=go" {
+		t.Errorf("link = %v", processed.Metadata()["link"])
+	}
+}
+
+func TestTagDropRewrite(t *testing.T) {
+	tag := mustProcessor(t, "tag", map[string]interface{}{"match": `(?i)\bgolang\b`, "tags": []interface{}{"go", "lang"}})
+	processed, _ := tag.Process(newBlock("Why Golang rocks", nil))
+	tags, _ := processed.Metadata()["tags"].([]string)
+	if strings.Join(tags, ",") != "go,lang" {
+		t.Errorf("tags = %v", processed.Metadata()["tags"])
+	}
+	// Tags loaded from storage are merged without duplicates
+	processed, _ = tag.Process(newBlock("golang", map[string]interface{}{"tags": []interface{}{"go", "news"}}))
+	tags, _ = processed.Metadata()["tags"].([]string)
+	if strings.Join(tags, ",") != "go,news,lang" {
+		t.Errorf("tags = %v", processed.Metadata()["tags"])
+	}
+	other := newBlock("rust", nil)
+	if processed, _ := tag.Process(other); processed != other {
+		t.Error("blocks not matching should be returned unchanged")
+	}
+
+	drop := mustProcessor(t, "drop", map[string]interface{}{"match": `^Ask HN`, "field": "title"})
+	if processed, _ := drop.Process(newBlock("", map[string]interface{}{"title": "Ask HN: anything"})); processed != nil {
+		t.Error("expected the block to be dropped")
+	}
+	if processed, _ := drop.Process(newBlock("Ask HN", map[string]interface{}{"title": "Show HN"})); processed == nil {
+		t.Error("expected the block to be kept")
+	}
+
+	rewrite := mustProcessor(t, "rewrite", map[string]interface{}{"match": `(\w+)@example\.com`, "replace": "$1@…"})
+	processed, _ = rewrite.Process(newBlock("mail john@example.com", nil))
+	if processed.Text() != "mail john@…" {
+		t.Errorf("Text() = %q", processed.Text())
+	}
+}
+
+func TestProcessorOptions(t *testing.T) {
+	invalid := []struct {
+		name    string
+		options map[string]interface{}
+	}{
+		{"redact", map[string]interface{}{"patterns": []interface{}{"("}}},
+		{"redact", map[string]interface{}{"pattern": "x"}},
+		{"tag", map[string]interface{}{"match": "x"}},
+		{"drop", nil},
+		{"rewrite", map[string]interface{}{"match": 3}},
+		{"strip_url_params", map[string]interface{}{"params": []interface{}{1}}},
+	}
+	for _, tt := range invalid {
+		if _, err := core.NewProcessor(tt.name, tt.options); err == nil {
+			t.Errorf("%s %v: expected an error", tt.name, tt.options)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package processors
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"sort"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+// defaultRedactReplacement replaces redacted secrets
+const defaultRedactReplacement = "[REDACTED]"
+
+// secretPatterns match common API tokens and credentials. They are used when
+// a redact processor has no patterns of its own.
+var secretPatterns = []string{
+	`gh[pousr]_[A-Za-z0-9]{36,}`,                                       // GitHub tokens
+	`github_pat_[A-Za-z0-9_]{22,}`,                                     // GitHub fine-grained tokens
+	`glpat-[A-Za-z0-9_\-]{20,}`,                                        // GitLab tokens
+	`xox[abprs]-[A-Za-z0-9\-]{10,}`,                                    // Slack tokens
+	`(?:AKIA|ASIA)[0-9A-Z]{16}`,                                        // AWS access key IDs
+	`sk-[A-Za-z0-9_\-]{20,}`,                                           // OpenAI style API keys
+	`(?i)bearer\s+[A-Za-z0-9\-._~+/]{20,}=*`,                           // Authorization headers
+	`eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`, // JWTs
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+}
+
+// redactProcessor replaces secrets in the block text and metadata.
+//
+// Options:
+//   - patterns: regular expressions to redact (default: common API tokens)
+//   - replacement: text replacing each match (default: [REDACTED])
+//   - fields: "text" and metadata fields to redact (default: the text and
+//     every string metadata field)
+type redactProcessor struct {
+	patterns    []*regexp.Regexp
+	replacement string
+	fields      []string
+}
+
+func newRedactProcessor(options map[string]interface{}) (core.Processor, error) {
+	if err := checkOptions(options, "patterns", "replacement", "fields"); err != nil {
+		return nil, err
+	}
+	patterns, err := stringsOption(options, "patterns")
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		patterns = secretPatterns
+	}
+	p := &redactProcessor{replacement: defaultRedactReplacement}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	if _, exists := options["replacement"]; exists {
+		if p.replacement, err = stringOption(options, "replacement"); err != nil {
+			return nil, err
+		}
+	}
+	if p.fields, err = stringsOption(options, "fields"); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *redactProcessor) Process(block core.Block) (core.Block, error) {
+	fields := p.fields
+	if len(fields) == 0 {
+		fields = []string{textField}
+		for field, value := range block.Metadata() {
+			if _, ok := value.(string); ok {
+				fields = append(fields, field)
+			}
+		}
+		sort.Strings(fields[1:])
+	}
+
+	text := block.Text()
+	var metadata map[string]interface{}
+	changed := false
+	for _, field := range fields {
+		value := fieldValue(block, field)
+		redacted := p.redact(value)
+		if redacted == value {
+			continue
+		}
+		changed = true
+		if field == textField {
+			text = redacted
+			continue
+		}
+		if metadata == nil {
+			metadata = maps.Clone(block.Metadata())
+		}
+		metadata[field] = redacted
+	}
+	if !changed {
+		return block, nil
+	}
+	if metadata == nil {
+		metadata = block.Metadata()
+	}
+	return core.RewriteBlock(block, text, metadata), nil
+}
+
+func (p *redactProcessor) redact(s string) string {
+	for _, re := range p.patterns {
+		s = re.ReplaceAllLiteralString(s, p.replacement)
+	}
+	return s
+}
@@ -0,0 +1,166 @@
+package processors
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+// tagsField is the metadata field holding the tags added by tag processors
+const tagsField = "tags"
+
+// tagProcessor adds tags to the blocks matching a regular expression.
+//
+// Options:
+//   - tags: tags to add (required)
+//   - match: regular expression selecting the blocks (default: every block)
+//   - field: "text" or the metadata field to match (default: text)
+type tagProcessor struct {
+	tags  []string
+	match *regexp.Regexp
+	field string
+}
+
+func newTagProcessor(options map[string]interface{}) (core.Processor, error) {
+	if err := checkOptions(options, "tags", "match", "field"); err != nil {
+		return nil, err
+	}
+	tags, err := stringsOption(options, "tags")
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("option %q is required", "tags")
+	}
+	match, err := regexpOption(options, "match", false)
+	if err != nil {
+		return nil, err
+	}
+	field, err := stringOption(options, "field")
+	if err != nil {
+		return nil, err
+	}
+	return &tagProcessor{tags: tags, match: match, field: field}, nil
+}
+
+func (p *tagProcessor) Process(block core.Block) (core.Block, error) {
+	if p.match != nil && !p.match.MatchString(fieldValue(block, p.field)) {
+		return block, nil
+	}
+
+	tags := blockTags(block)
+	added := false
+	for _, tag := range p.tags {
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+			added = true
+		}
+	}
+	if !added {
+		return block, nil
+	}
+	metadata := maps.Clone(block.Metadata())
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata[tagsField] = tags
+	return core.RewriteBlock(block, block.Text(), metadata), nil
+}
+
+// blockTags returns the tags in the block metadata, which are a []string when
+// added by a processor and a []interface{} once loaded from storage.
+func blockTags(block core.Block) []string {
+	switch tags := block.Metadata()[tagsField].(type) {
+	case []string:
+		return slices.Clone(tags)
+	case []interface{}:
+		var result []string
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// dropProcessor drops the blocks matching a regular expression.
+//
+// Options:
+//   - match: regular expression selecting the blocks (required)
+//   - field: "text" or the metadata field to match (default: text)
+type dropProcessor struct {
+	match *regexp.Regexp
+	field string
+}
+
+func newDropProcessor(options map[string]interface{}) (core.Processor, error) {
+	if err := checkOptions(options, "match", "field"); err != nil {
+		return nil, err
+	}
+	match, err := regexpOption(options, "match", true)
+	if err != nil {
+		return nil, err
+	}
+	field, err := stringOption(options, "field")
+	if err != nil {
+		return nil, err
+	}
+	return &dropProcessor{match: match, field: field}, nil
+}
+
+func (p *dropProcessor) Process(block core.Block) (core.Block, error) {
+	if p.match.MatchString(fieldValue(block, p.field)) {
+		return nil, nil
+	}
+	return block, nil
+}
+
+// rewriteProcessor replaces the matches of a regular expression.
+//
+// Options:
+//   - match: regular expression to replace (required)
+//   - replace: replacement, $1 or ${name} expand to submatches (default: "")
+//   - field: "text" or the metadata field to rewrite (default: text)
+type rewriteProcessor struct {
+	match   *regexp.Regexp
+	replace string
+	field   string
+}
+
+func newRewriteProcessor(options map[string]interface{}) (core.Processor, error) {
+	if err := checkOptions(options, "match", "replace", "field"); err != nil {
+		return nil, err
+	}
+	match, err := regexpOption(options, "match", true)
+	if err != nil {
+		return nil, err
+	}
+	replace, err := stringOption(options, "replace")
+	if err != nil {
+		return nil, err
+	}
+	field, err := stringOption(options, "field")
+	if err != nil {
+		return nil, err
+	}
+	return &rewriteProcessor{match: match, replace: replace, field: field}, nil
+}
+
+func (p *rewriteProcessor) Process(block core.Block) (core.Block, error) {
+	if p.field != "" && p.field != textField {
+		if _, ok := block.Metadata()[p.field].(string); !ok {
+			return block, nil
+		}
+	}
+	value := fieldValue(block, p.field)
+	rewritten := p.match.ReplaceAllString(value, p.replace)
+	if rewritten == value {
+		return block, nil
+	}
+	return withFieldValue(block, p.field, rewritten), nil
+}
@@ -0,0 +1,119 @@
+package processors
+
+import (
+	"maps"
+	"net/url"
+	"strings"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+// defaultURLFields hold the URL of a block in the built-in datasources
+var defaultURLFields = []string{"url", "link"}
+
+// trackingParams are query parameters added by analytics and ad networks.
+// A trailing * matches any parameter with that prefix.
+var trackingParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "gclsrc", "msclkid", "yclid", "igshid",
+	"mc_cid", "mc_eid", "_hsenc", "_hsmi", "mkt_tok", "ref_src", "ref_url",
+}
+
+// stripURLParamsProcessor removes tracking parameters from URLs and normalizes
+// them (lowercase scheme and host, no default port). Occurrences of the
+// original URL in the block text are rewritten too.
+//
+// Options:
+//   - fields: metadata fields holding URLs (default: url and link)
+//   - params: extra parameters to remove, a trailing * matches a prefix
+type stripURLParamsProcessor struct {
+	fields []string
+	params []string
+}
+
+func newStripURLParamsProcessor(options map[string]interface{}) (core.Processor, error) {
+	if err := checkOptions(options, "fields", "params"); err != nil {
+		return nil, err
+	}
+	fields, err := stringsOption(options, "fields")
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		fields = defaultURLFields
+	}
+	params, err := stringsOption(options, "params")
+	if err != nil {
+		return nil, err
+	}
+	return &stripURLParamsProcessor{
+		fields: fields,
+		params: append(append([]string{}, trackingParams...), params...),
+	}, nil
+}
+
+func (p *stripURLParamsProcessor) Process(block core.Block) (core.Block, error) {
+	text := block.Text()
+	var metadata map[string]interface{}
+	for _, field := range p.fields {
+		rawURL, ok := block.Metadata()[field].(string)
+		if !ok || rawURL == "" {
+			continue
+		}
+		cleaned := p.clean(rawURL)
+		if cleaned == rawURL {
+			continue
+		}
+		if metadata == nil {
+			metadata = maps.Clone(block.Metadata())
+		}
+		metadata[field] = cleaned
+		text = strings.ReplaceAll(text, rawURL, cleaned)
+	}
+	if metadata == nil {
+		return block, nil
+	}
+	return core.RewriteBlock(block, text, metadata), nil
+}
+
+// clean returns rawURL without tracking parameters, normalized. URLs that
+// don't parse are returned unchanged.
+func (p *stripURLParamsProcessor) clean(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		removed := false
+		for key := range query {
+			if p.isTracking(key) {
+				query.Del(key)
+				removed = true
+			}
+		}
+		if removed {
+			u.RawQuery = query.Encode()
+		}
+	}
+	return u.String()
+}
+
+func (p *stripURLParamsProcessor) isTracking(key string) bool {
+	key = strings.ToLower(key)
+	for _, param := range p.params {
+		if prefix, ok := strings.CutSuffix(param, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == param {
+			return true
+		}
+	}
+	return false
+}
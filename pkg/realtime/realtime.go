@@ -7,14 +7,16 @@ package realtime
 // Design Goals:
 //   - Zero external dependencies beyond the standard library.
 //   - Best‑effort fan‑out: slow listeners drop events (never backpressure ingestion).
-//   - No persistence or replay semantics (ephemeral stream).
-//   - Simple, extensible event envelope (currently only block events).
+//   - No persistence or replay semantics in the hub (ephemeral stream); replay
+//     happens between the warehouse event bridge and its consumer.
+//   - Simple, extensible event envelope (currently block and delete events).
 //
 // If durable or replayable semantics are needed in the future, this package
 // becomes the seam where a broker (Redis Streams, NATS, Kafka, etc.) can be
 // introduced behind a compatible interface.
 
 import (
+	"slices"
 	"sync"
 	"time"
 )
@@ -30,7 +32,9 @@ import (
 //   - CreatedAt:   Original creation time (UTC recommended).
 //   - Text:        Raw searchable text (same content indexed in FTS).
 //   - Metadata:    Arbitrary datasource‑specific metadata (non-durable across protocol versions).
+//   - Seq:         Event bridge sequence number (0 when unknown).
 type BlockEvent struct {
+	Seq        uint64         `json:"seq,omitempty"`
 	ID         string         `json:"id"`
 	Datasource string         `json:"datasource"`
 	DSType     string         `json:"ds_type"`
@@ -39,30 +43,53 @@ type BlockEvent struct {
 	Metadata   map[string]any `json:"metadata,omitempty"`
 	// Future extension points:
 	//   ProtocolVersion int    `json:"proto_version,omitempty"`
-	//   Sequence        uint64 `json:"seq,omitempty"`
 }
 
 // InternalEvent is the hub's internal envelope allowing future introduction
 // of additional event kinds (heartbeat, info, etc.) without changing channel
-// element types. For now only Type == "block" is produced.
+// element types. Type is "block" for stored blocks and "delete" for deleted
+// ones; delete events only set Block.Seq, Block.ID, Block.Datasource and
+// Block.DSType.
 type InternalEvent struct {
 	Type  string     `json:"type"`
 	Block BlockEvent `json:"block"`
 }
 
+// Filter selects the events delivered to a listener by datasource. Empty
+// fields match every event; with both set, an event has to match both.
+type Filter struct {
+	Datasources []string `json:"datasources,omitempty"` // Datasource instance names
+	Types       []string `json:"types,omitempty"`       // Datasource types (e.g. "github")
+}
+
+// Matches reports whether the event passes the filter.
+func (f Filter) Matches(e InternalEvent) bool {
+	if len(f.Datasources) > 0 && !slices.Contains(f.Datasources, e.Block.Datasource) {
+		return false
+	}
+	return len(f.Types) == 0 || slices.Contains(f.Types, e.Block.DSType)
+}
+
 // FirehoseHub is an in‑memory fan‑out dispatcher. Each registered listener
-// receives events via its own buffered channel. If a listener's channel buffer
-// is full when an event arrives, that event is *dropped for that listener only*.
+// receives events via its own buffered channel, optionally only those
+// matching its Filter. If a listener's channel buffer is full when an event
+// arrives, that event is *dropped for that listener only*.
 // This prevents a single slow consumer from degrading overall ingestion / delivery.
 //
 // The hub is concurrency‑safe.
 type FirehoseHub struct {
 	mu        sync.RWMutex
-	listeners map[uint64]chan InternalEvent
+	listeners map[uint64]*hubListener
 	nextID    uint64
 	bufSize   int
 }
 
+// hubListener is a registered listener and the filter of its events
+type hubListener struct {
+	ch     chan InternalEvent
+	filter Filter
+}
+
 // NewFirehoseHub constructs a new hub with per-listener buffer size.
 // If bufSize <= 0, a default of 32 is used.
 func NewFirehoseHub(bufSize int) *FirehoseHub {
@@ -70,7 +97,7 @@ func NewFirehoseHub(bufSize int) *FirehoseHub {
 		bufSize = 32
 	}
 	return &FirehoseHub{
-		listeners: make(map[uint64]chan InternalEvent),
+		listeners: make(map[uint64]*hubListener),
 		bufSize:   bufSize,
 	}
 }
@@ -83,22 +110,33 @@ func (h *FirehoseHub) Register() (uint64, <-chan InternalEvent) {
 	id := h.nextID
 	h.nextID++
 	ch := make(chan InternalEvent, h.bufSize)
-	h.listeners[id] = ch
+	h.listeners[id] = &hubListener{ch: ch}
 	return id, ch
 }
 
+// SetFilter replaces the filter of the listener with the given id; the zero
+// Filter delivers every event. Unknown ids are ignored.
+func (h *FirehoseHub) SetFilter(id uint64, filter Filter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if l, ok := h.listeners[id]; ok {
+		l.filter = filter
+	}
+}
+
 // Unregister removes the listener with the given id and closes its channel.
 // It is safe to call multiple times; unknown ids are ignored.
 func (h *FirehoseHub) Unregister(id uint64) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	if ch, ok := h.listeners[id]; ok {
+	if l, ok := h.listeners[id]; ok {
 		delete(h.listeners, id)
-		close(ch)
+		close(l.ch)
 	}
 }
 
-// Broadcast delivers an event to all registered listeners (best effort).
+// Broadcast delivers an event to all registered listeners whose filter it
+// matches (best effort).
 // Accepted input types:
 //   - InternalEvent
 //   - BlockEvent (will be wrapped as InternalEvent{Type:"block"})
@@ -117,9 +155,12 @@ func (h *FirehoseHub) Broadcast(event interface{}) {
 
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	for _, ch := range h.listeners {
+	for _, l := range h.listeners {
+		if !l.filter.Matches(ie) {
+			continue
+		}
 		select {
-		case ch <- ie:
+		case l.ch <- ie:
 		default:
 			// Drop for slow listener.
 		}
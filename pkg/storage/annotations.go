@@ -0,0 +1,301 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrBlockNotFound is returned when annotating a block that is not stored.
+var ErrBlockNotFound = errors.New("block not found")
+
+// maxTagLength is the maximum length of a tag, in bytes.
+const maxTagLength = 64
+
+// validTag matches normalized tags. Tags are used as tag:name search terms, so
+// they can't contain whitespace, quotes or parentheses.
+var validTag = regexp.MustCompile(`^[\p{Ll}\p{Lo}\p{N}][\p{Ll}\p{Lo}\p{N}_\-.:/]*$`)
+
+// Annotations are the user tags and note of a block. They are stored apart
+// from the block, so re-fetching it never wipes them. They are kept when the
+// block is deleted by retention or its source, but purged along with it.
+type Annotations struct {
+	Tags []string `json:"tags"`
+	Note string   `json:"note,omitempty"`
+}
+
+// IsZero reports whether a has no tags and no note.
+func (a *Annotations) IsZero() bool {
+	return a == nil || (len(a.Tags) == 0 && a.Note == "")
+}
+
+// NormalizeTag lowercases and trims a tag, checking it can be searched with
+// tag:name.
+func NormalizeTag(tag string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(tag))
+	if normalized == "" {
+		return "", fmt.Errorf("empty tag")
+	}
+	if len(normalized) > maxTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+	}
+	if !validTag.MatchString(normalized) {
+		return "", fmt.Errorf("invalid tag %q: use letters, digits and _-.:/", tag)
+	}
+	return normalized, nil
+}
+
+// normalizeTags normalizes tags, removing duplicates.
+func normalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		t, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	return normalized, nil
+}
+
+// BlockExists reports whether a block with the given ID is stored.
+func (s *GenericStorage) BlockExists(id string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM blocks WHERE id = ?)", id).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("looking up block %s: %w", id, err)
+	}
+	return exists, nil
+}
+
+// AddTags tags a stored block. Tags are normalized with NormalizeTag; adding a
+// tag the block already has is a no-op. Returns ErrBlockNotFound if the block
+// is not stored.
+func (s *GenericStorage) AddTags(id string, tags []string) error {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	exists, err := s.BlockExists(id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrBlockNotFound
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil {
+				fmt.Printf("Warning: failed to rollback transaction: %v\n", err)
+			}
+		}
+	}()
+
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO block_tags (block_id, tag) VALUES (?, ?)", id, tag); err != nil {
+			return fmt.Errorf("tagging block %s: %w", id, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err == nil {
+		committed = true
+	}
+	return err
+}
+
+// RemoveTags removes tags from a block and returns how many it had.
+func (s *GenericStorage) RemoveTags(id string, tags []string) (int, error) {
+	removed := 0
+	for _, tag := range tags {
+		result, err := s.db.Exec("DELETE FROM block_tags WHERE block_id = ? AND tag = ?", id, strings.ToLower(strings.TrimSpace(tag)))
+		if err != nil {
+			return removed, fmt.Errorf("untagging block %s: %w", id, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return removed, err
+		}
+		removed += int(n)
+	}
+	return removed, nil
+}
+
+// SetNote sets the note of a stored block, an empty note removing it. Returns
+// ErrBlockNotFound if the block is not stored.
+func (s *GenericStorage) SetNote(id, note string) error {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		if _, err := s.db.Exec("DELETE FROM block_notes WHERE block_id = ?", id); err != nil {
+			return fmt.Errorf("removing note of block %s: %w", id, err)
+		}
+		return nil
+	}
+
+	exists, err := s.BlockExists(id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrBlockNotFound
+	}
+	_, err = s.db.Exec(`
+		INSERT INTO block_notes (block_id, note, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(block_id) DO UPDATE SET
+			note=excluded.note,
+			updated_at=CURRENT_TIMESTAMP
+	`, id, note)
+	if err != nil {
+		return fmt.Errorf("storing note of block %s: %w", id, err)
+	}
+	return nil
+}
+
+// deleteAnnotations deletes the tags and notes of the given blocks.
+func (s *GenericStorage) deleteAnnotations(ids []string) error {
+	for start := 0; start < len(ids); start += pruneBatchSize {
+		batch := ids[start:min(start+pruneBatchSize, len(ids))]
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		for _, table := range []string{"block_tags", "block_notes"} {
+			if _, err := s.db.Exec("DELETE FROM "+table+" WHERE block_id IN ("+placeholders+")", args...); err != nil {
+				return fmt.Errorf("deleting annotations: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// GetAnnotations returns the annotations of the given blocks, keyed by block
+// ID. Blocks without tags or note are left out. Tags are sorted.
+func (s *GenericStorage) GetAnnotations(ids []string) (map[string]*Annotations, error) {
+	annotations := make(map[string]*Annotations)
+	if len(ids) == 0 {
+		return annotations, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	get := func(id string) *Annotations {
+		if annotations[id] == nil {
+			annotations[id] = &Annotations{Tags: []string{}}
+		}
+		return annotations[id]
+	}
+
+	rows, err := s.db.Query("SELECT block_id, tag FROM block_tags WHERE block_id IN ("+placeholders+") ORDER BY tag", args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying tags: %w", err)
+	}
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("scanning tag: %w", err)
+		}
+		a := get(id)
+		a.Tags = append(a.Tags, tag)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	rows, err = s.db.Query("SELECT block_id, note FROM block_notes WHERE block_id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying notes: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+	for rows.Next() {
+		var id, note string
+		if err := rows.Scan(&id, &note); err != nil {
+			return nil, fmt.Errorf("scanning note: %w", err)
+		}
+		get(id).Note = note
+	}
+	return annotations, rows.Err()
+}
+
+// TagCount is a tag and the number of blocks tagged with it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// ListTags returns every tag used in the datasource and how many stored blocks
+// have it, sorted by tag.
+func (s *GenericStorage) ListTags() ([]TagCount, error) {
+	// Annotations outlive their blocks (see Annotations), but only stored
+	// blocks are counted
+	rows, err := s.db.Query(`
+		SELECT t.tag, COUNT(*) FROM block_tags t
+		WHERE EXISTS (SELECT 1 FROM blocks b WHERE b.id = t.block_id)
+		GROUP BY t.tag ORDER BY t.tag`)
+	if err != nil {
+		return nil, fmt.Errorf("querying tags: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	var tags []TagCount
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, fmt.Errorf("scanning tag: %w", err)
+		}
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
+
+// ListTags returns the tags used across all datasources and how many blocks
+// have each, sorted by tag.
+func (m *Manager) ListTags() ([]TagCount, error) {
+	counts := make(map[string]int)
+	for _, name := range m.SearchAllDatasources() {
+		storage, err := m.GetStorage(name)
+		if err != nil {
+			return nil, fmt.Errorf("getting storage for %s: %w", name, err)
+		}
+		tags, err := storage.ListTags()
+		if err != nil {
+			return nil, fmt.Errorf("listing tags of %s: %w", name, err)
+		}
+		for _, tc := range tags {
+			counts[tc.Tag] += tc.Count
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+	return tags, nil
+}
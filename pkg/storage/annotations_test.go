@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestAnnotations(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	schema := map[string]any{"title": "TEXT"}
+	for _, name := range []string{"hn", "rss"} {
+		if err := manager.InitializeDatasourceStorage(name, schema); err != nil {
+			t.Fatalf("InitializeDatasourceStorage error: %v", err)
+		}
+	}
+	hn, _ := manager.GetStorage("hn")
+	rss, _ := manager.GetStorage("rss")
+
+	now := time.Now()
+	story := core.NewGenericBlock("story-1", "sqlite internals", "hn", "hackernews", now, map[string]interface{}{"title": "SQLite"})
+	if err := hn.StoreBlocks([]core.Block{story, core.NewGenericBlock("story-2", "sqlite tips", "hn", "hackernews", now, nil)}, "hackernews"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+	// Tags added by a tag processor are searchable too
+	post := core.NewGenericBlock("post-1", "sqlite release", "rss", "rss", now, map[string]interface{}{"tags": []string{"ToRead"}})
+	if err := rss.StoreBlocks([]core.Block{post}, "rss"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+
+	if err := hn.AddTags("story-1", []string{"ToRead", "db", "toread"}); err != nil {
+		t.Fatalf("AddTags error: %v", err)
+	}
+	if err := hn.SetNote("story-1", "  read on the train "); err != nil {
+		t.Fatalf("SetNote error: %v", err)
+	}
+	if err := hn.AddTags("missing", []string{"x"}); !errors.Is(err, ErrBlockNotFound) {
+		t.Errorf("expected ErrBlockNotFound, got %v", err)
+	}
+	if err := hn.AddTags("story-1", []string{"two words"}); err == nil {
+		t.Error("expected an error for an invalid tag")
+	}
+
+	// A re-fetch upsert keeps the annotations
+	if err := hn.StoreBlocks([]core.Block{story}, "hackernews"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+	annotations, err := hn.GetAnnotations([]string{"story-1", "story-2"})
+	if err != nil {
+		t.Fatalf("GetAnnotations error: %v", err)
+	}
+	a := annotations["story-1"]
+	if a == nil || len(a.Tags) != 2 || a.Tags[0] != "db" || a.Tags[1] != "toread" || a.Note != "read on the train" {
+		t.Errorf("annotations = %+v", a)
+	}
+	if _, ok := annotations["story-2"]; ok {
+		t.Error("story-2 has no annotations")
+	}
+
+	search := manager.GetSearchService()
+	ids := func(query string) map[string]bool {
+		t.Helper()
+		results, err := search.Search(SearchParams{Query: query, Page: 1, Limit: 10})
+		if err != nil {
+			t.Fatalf("Search(%q) error: %v", query, err)
+		}
+		found := make(map[string]bool)
+		for _, b := range results.Ordered {
+			found[b.ID()] = true
+		}
+		return found
+	}
+	if got := ids("tag:toread"); len(got) != 2 || !got["story-1"] || !got["post-1"] {
+		t.Errorf("tag:toread = %v", got)
+	}
+	if got := ids("sqlite tag:db"); len(got) != 1 || !got["story-1"] {
+		t.Errorf("sqlite tag:db = %v", got)
+	}
+	if got := ids("tag:toread tag:db"); len(got) != 1 {
+		t.Errorf("tag:toread tag:db = %v", got)
+	}
+	var queryErr *QueryError
+	if _, err := search.Search(SearchParams{Query: "tag:a OR sqlite", Page: 1, Limit: 10}); !errors.As(err, &queryErr) {
+		t.Errorf("expected a query error combining tags with OR, got %v", err)
+	}
+
+	tags, err := manager.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags error: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != (TagCount{Tag: "db", Count: 1}) || tags[1] != (TagCount{Tag: "toread", Count: 1}) {
+		t.Errorf("ListTags = %v", tags)
+	}
+
+	if n, err := hn.RemoveTags("story-1", []string{"db", "nope"}); err != nil || n != 1 {
+		t.Errorf("RemoveTags = %d, %v", n, err)
+	}
+	if err := hn.SetNote("story-1", ""); err != nil {
+		t.Fatalf("SetNote error: %v", err)
+	}
+	annotations, _ = hn.GetAnnotations([]string{"story-1"})
+	if a := annotations["story-1"]; a == nil || len(a.Tags) != 1 || a.Note != "" {
+		t.Errorf("annotations = %+v", a)
+	}
+
+	// Tags of deleted blocks are kept but not counted
+	if err := hn.AddTags("story-2", []string{"later"}); err != nil {
+		t.Fatalf("AddTags error: %v", err)
+	}
+	if _, err := hn.DeleteBlocks([]string{"story-2"}); err != nil {
+		t.Fatalf("DeleteBlocks error: %v", err)
+	}
+	if tags, err := manager.ListTags(); err != nil || len(tags) != 1 || tags[0] != (TagCount{Tag: "toread", Count: 1}) {
+		t.Errorf("ListTags = %v, %v", tags, err)
+	}
+	if annotations, _ := hn.GetAnnotations([]string{"story-2"}); annotations["story-2"] == nil {
+		t.Error("expected the tags of a deleted block kept")
+	}
+
+	// Purged blocks lose their annotations
+	if err := hn.SetNote("story-1", "secret"); err != nil {
+		t.Fatalf("SetNote error: %v", err)
+	}
+	if result, err := search.Purge(SearchParams{Query: "internals", DatasourceFilters: []string{"hn"}}, false); err != nil || result.Total != 1 {
+		t.Fatalf("Purge = %+v, %v", result, err)
+	}
+	if annotations, _ := hn.GetAnnotations([]string{"story-1"}); len(annotations) != 0 {
+		t.Errorf("expected no annotations left for a purged block, got %+v", annotations["story-1"])
+	}
+}
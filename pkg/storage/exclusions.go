@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+// urlFields are the metadata fields holding the URL of a block, in the order
+// they are checked.
+var urlFields = []string{"url", "link"}
+
+// ExclusionRules select blocks that must never be stored, e.g. browser visits
+// to banking or medical sites.
+type ExclusionRules struct {
+	// domains match the host of the block URL and its subdomains
+	domains []string
+
+	// urlPatterns match anywhere in the block URL
+	urlPatterns []*regexp.Regexp
+
+	// keywords match the block title and text, case-insensitively
+	keywords []string
+}
+
+// NewExclusionRules compiles exclusion rules. Domains and keywords are
+// case-insensitive; URL patterns are Go regular expressions.
+func NewExclusionRules(domains, urlPatterns, keywords []string) (*ExclusionRules, error) {
+	rules := &ExclusionRules{}
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			rules.domains = append(rules.domains, domain)
+		}
+	}
+	for _, pattern := range urlPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL pattern %q: %w", pattern, err)
+		}
+		rules.urlPatterns = append(rules.urlPatterns, re)
+	}
+	for _, keyword := range keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" {
+			rules.keywords = append(rules.keywords, keyword)
+		}
+	}
+	return rules, nil
+}
+
+// IsZero reports whether the rules exclude nothing.
+func (r *ExclusionRules) IsZero() bool {
+	return r == nil || (len(r.domains) == 0 && len(r.urlPatterns) == 0 && len(r.keywords) == 0)
+}
+
+// Match reports whether block is excluded by the rules. Domains and URL patterns
+// apply to the url (or link) metadata field, keywords to the title field and the
+// block text.
+func (r *ExclusionRules) Match(block core.Block) bool {
+	if r.IsZero() {
+		return false
+	}
+	metadata := block.Metadata()
+
+	if rawURL := firstString(metadata, urlFields); rawURL != "" {
+		if len(r.domains) > 0 {
+			if u, err := url.Parse(rawURL); err == nil {
+				host := strings.ToLower(u.Hostname())
+				for _, domain := range r.domains {
+					if host == domain || strings.HasSuffix(host, "."+domain) {
+						return true
+					}
+				}
+			}
+		}
+		for _, re := range r.urlPatterns {
+			if re.MatchString(rawURL) {
+				return true
+			}
+		}
+	}
+
+	if len(r.keywords) > 0 {
+		title := strings.ToLower(firstString(metadata, []string{"title"}))
+		text := strings.ToLower(block.Text())
+		for _, keyword := range r.keywords {
+			if strings.Contains(title, keyword) || strings.Contains(text, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// firstString returns the first non-empty string value among the given metadata fields.
+func firstString(metadata map[string]interface{}, fields []string) string {
+	for _, field := range fields {
+		if value, ok := metadata[field].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// DeleteExcluded deletes the stored blocks matched by the rules and returns
+// their IDs. With dryRun the matches are only returned. Blocks are scanned
+// and deleted in batches; the FTS triggers keep blocks_fts in sync.
+func (s *GenericStorage) DeleteExcluded(rules *ExclusionRules, dryRun bool) ([]string, error) {
+	if rules.IsZero() {
+		return nil, nil
+	}
+
+	var matched []string
+	var lastRowID int64
+	for {
+		rows, err := s.db.Query(`
+			SELECT rowid, id, text, source, datasource, metadata
+			FROM blocks WHERE rowid > ? ORDER BY rowid LIMIT ?`, lastRowID, pruneBatchSize)
+		if err != nil {
+			return matched, fmt.Errorf("querying blocks: %w", err)
+		}
+		n := 0
+		for rows.Next() {
+			var id, text, source, datasourceType, metadataStr string
+			if err := rows.Scan(&lastRowID, &id, &text, &source, &datasourceType, &metadataStr); err != nil {
+				_ = rows.Close()
+				return matched, fmt.Errorf("scanning row: %w", err)
+			}
+			n++
+			var metadata map[string]interface{}
+			if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
+				_ = rows.Close()
+				return matched, fmt.Errorf("unmarshaling metadata for block %s: %w", id, err)
+			}
+			if rules.Match(core.NewGenericBlock(id, text, source, datasourceType, time.Time{}, metadata)) {
+				matched = append(matched, id)
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return matched, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	if dryRun {
+		return matched, nil
+	}
+	for start := 0; start < len(matched); start += pruneBatchSize {
+		end := min(start+pruneBatchSize, len(matched))
+		if _, err := s.DeleteBlocks(matched[start:end]); err != nil {
+			return matched[:start], err
+		}
+	}
+	return matched, nil
+}
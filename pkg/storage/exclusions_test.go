@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestExclusionRulesMatch(t *testing.T) {
+	rules, err := NewExclusionRules([]string{"MyBank.com"}, []string{`^https?://[^/]+/health/`}, []string{"Diagnosis"})
+	if err != nil {
+		t.Fatalf("NewExclusionRules error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		metadata map[string]interface{}
+		want     bool
+	}{
+		{"domain", "", map[string]interface{}{"url": "https://mybank.com/accounts"}, true},
+		{"subdomain", "", map[string]interface{}{"url": "https://login.mybank.com"}, true},
+		{"other domain", "", map[string]interface{}{"url": "https://notmybank.com"}, false},
+		{"url pattern", "", map[string]interface{}{"url": "https://clinic.example/health/results"}, true},
+		{"link field", "", map[string]interface{}{"link": "https://www.mybank.com/news"}, true},
+		{"title keyword", "", map[string]interface{}{"url": "https://example.com", "title": "My diagnosis"}, true},
+		{"text keyword", "read about the DIAGNOSIS", nil, true},
+		{"no match", "weather", map[string]interface{}{"url": "https://weather.example", "title": "Forecast"}, false},
+	}
+	for _, tt := range tests {
+		block := core.NewGenericBlock("id", tt.text, "firefox", "firefox", time.Now(), tt.metadata)
+		if got := rules.Match(block); got != tt.want {
+			t.Errorf("%s: Match() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	var none *ExclusionRules
+	if none.Match(core.NewGenericBlock("id", "diagnosis", "firefox", "firefox", time.Now(), nil)) {
+		t.Error("nil rules should match nothing")
+	}
+	if _, err := NewExclusionRules(nil, []string{"("}, nil); err == nil {
+		t.Error("expected an error for an invalid URL pattern")
+	}
+}
+
+func TestDeleteExcluded(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	if err := manager.InitializeDatasourceStorage("firefox", map[string]any{"url": "TEXT", "title": "TEXT"}); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	storage, err := manager.GetStorage("firefox")
+	if err != nil {
+		t.Fatalf("GetStorage error: %v", err)
+	}
+	now := time.Now()
+	blocks := []core.Block{
+		core.NewGenericBlock("bank", "bank login", "firefox", "firefox", now, map[string]interface{}{"url": "https://mybank.com", "title": "Bank"}),
+		core.NewGenericBlock("news", "daily news", "firefox", "firefox", now, map[string]interface{}{"url": "https://news.example", "title": "News"}),
+	}
+	if err := storage.StoreBlocks(blocks, "firefox"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+
+	rules, _ := NewExclusionRules([]string{"mybank.com"}, nil, nil)
+	ids, err := storage.DeleteExcluded(rules, true)
+	if err != nil || len(ids) != 1 || ids[0] != "bank" {
+		t.Fatalf("dry run = %v, %v", ids, err)
+	}
+	if stored, _ := storage.GetBlocksByID([]string{"bank"}); len(stored) != 1 {
+		t.Fatal("dry run deleted the block")
+	}
+
+	if _, err := storage.DeleteExcluded(rules, false); err != nil {
+		t.Fatalf("DeleteExcluded error: %v", err)
+	}
+	stored, err := storage.GetBlocksByID([]string{"bank", "news"})
+	if err != nil {
+		t.Fatalf("GetBlocksByID error: %v", err)
+	}
+	if _, ok := stored["news"]; len(stored) != 1 || !ok {
+		t.Errorf("expected only news to be left, got %v", stored)
+	}
+	if err := storage.FTSIntegrityCheck(); err != nil {
+		t.Errorf("FTSIntegrityCheck error: %v", err)
+	}
+}
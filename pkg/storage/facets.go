@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FacetInterval is the bucket size of a search facet date histogram.
+type FacetInterval string
+
+const (
+	FacetIntervalDay   FacetInterval = "day"
+	FacetIntervalWeek  FacetInterval = "week"
+	FacetIntervalMonth FacetInterval = "month"
+)
+
+// ParseFacetInterval validates a histogram interval name. An empty name
+// selects FacetIntervalMonth.
+func ParseFacetInterval(name string) (FacetInterval, error) {
+	switch FacetInterval(name) {
+	case "":
+		return FacetIntervalMonth, nil
+	case FacetIntervalDay, FacetIntervalWeek, FacetIntervalMonth:
+		return FacetInterval(name), nil
+	}
+	return "", queryErrorf("invalid interval %q: use day, week or month", name)
+}
+
+// bucketExpr returns the SQL expression truncating created_at to the start of
+// its bucket, as a YYYY-MM-DD date. Weeks start on Monday.
+func (i FacetInterval) bucketExpr(alias string) string {
+	switch i {
+	case FacetIntervalDay:
+		return "date(" + alias + "created_at)"
+	case FacetIntervalWeek:
+		return "date(" + alias + "created_at, '-6 days', 'weekday 1')"
+	default:
+		return "date(" + alias + "created_at, 'start of month')"
+	}
+}
+
+// FacetCount is the number of matching blocks sharing a facet value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// HistogramBucket is the number of matching blocks created in the interval
+// starting at Start (UTC).
+type HistogramBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// SearchFacets summarizes every block matching a search, not just the current
+// page. Counts are sorted by count, highest first; the histogram by date.
+type SearchFacets struct {
+	// Total is the exact number of blocks matching the search.
+	Total int `json:"total"`
+
+	// Datasources counts matches per datasource instance name.
+	Datasources []FacetCount `json:"datasources"`
+
+	// Types counts matches per datasource type (e.g. hackernews).
+	Types []FacetCount `json:"types"`
+
+	// Hostnames counts matches per hostname the blocks were fetched on.
+	// Blocks without a hostname are counted under "".
+	Hostnames []FacetCount `json:"hostnames"`
+
+	// Interval is the bucket size of Histogram.
+	Interval FacetInterval `json:"interval"`
+
+	// Histogram counts matches per Interval, oldest first. Empty buckets are omitted.
+	Histogram []HistogramBucket `json:"histogram"`
+}
+
+// facetResult holds the facet counts of a single datasource database.
+type facetResult struct {
+	datasource string
+	types      map[string]int
+	hostnames  map[string]int
+	histogram  map[string]int
+	err        error
+}
+
+// facetDatasourcesInParallel counts the blocks matching q in every datasource,
+// in parallel, and merges the counts. The cursor and limit of q are ignored.
+func (s *SearchService) facetDatasourcesInParallel(datasources []string, q storageQuery, interval FacetInterval) (*SearchFacets, error) {
+	q.cursor = nil
+	resultChan := make(chan facetResult, len(datasources))
+
+	for _, datasource := range datasources {
+		go func(ds string) {
+			storage, err := s.manager.GetStorage(ds)
+			if err != nil {
+				resultChan <- facetResult{datasource: ds, err: err}
+				return
+			}
+			result := s.executeStorageFacets(storage, ds, q, interval)
+			result.datasource = ds
+			resultChan <- result
+		}(datasource)
+	}
+
+	facets := &SearchFacets{Interval: interval}
+	datasourceCounts := make(map[string]int)
+	typeCounts := make(map[string]int)
+	hostnameCounts := make(map[string]int)
+	histogram := make(map[string]int)
+	var firstErr error
+	for i := 0; i < len(datasources); i++ {
+		result := <-resultChan
+		if result.err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("counting %s: %w", result.datasource, result.err)
+			}
+			continue
+		}
+		for value, count := range result.types {
+			typeCounts[value] += count
+			datasourceCounts[result.datasource] += count
+			facets.Total += count
+		}
+		for value, count := range result.hostnames {
+			hostnameCounts[value] += count
+		}
+		for day, count := range result.histogram {
+			histogram[day] += count
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	facets.Datasources = sortedFacetCounts(datasourceCounts)
+	facets.Types = sortedFacetCounts(typeCounts)
+	facets.Hostnames = sortedFacetCounts(hostnameCounts)
+	facets.Histogram = make([]HistogramBucket, 0, len(histogram))
+	for day, count := range histogram {
+		start, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			continue
+		}
+		facets.Histogram = append(facets.Histogram, HistogramBucket{Start: start, Count: count})
+	}
+	sort.Slice(facets.Histogram, func(i, j int) bool {
+		return facets.Histogram[i].Start.Before(facets.Histogram[j].Start)
+	})
+	return facets, nil
+}
+
+// executeStorageFacets runs the facet COUNT queries against a single datasource database.
+func (s *SearchService) executeStorageFacets(storage *GenericStorage, datasource string, q storageQuery, interval FacetInterval) facetResult {
+	conditions, args, ok, err := q.conditions(storage, datasource, "b.")
+	if err != nil {
+		return facetResult{err: err}
+	}
+	if !ok {
+		return facetResult{}
+	}
+
+	from := " FROM blocks b"
+	if q.query.fts != "" {
+		from += " JOIN blocks_fts fts ON b.rowid = fts.rowid"
+		conditions = append([]string{"blocks_fts MATCH ?"}, conditions...)
+		args = append([]interface{}{escapeFTS5Query(q.query.fts)}, args...)
+	}
+	if len(conditions) > 0 {
+		from += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	result := facetResult{types: make(map[string]int), hostnames: make(map[string]int), histogram: make(map[string]int)}
+	count := func(sqlQuery string, into func(string, string, int)) error {
+		rows, err := storage.db.Query(sqlQuery, args...)
+		if err != nil {
+			return fmt.Errorf("counting blocks: %w", err)
+		}
+		defer func() {
+			if err := rows.Close(); err != nil {
+				fmt.Printf("Warning: failed to close rows: %v\n", err)
+			}
+		}()
+		for rows.Next() {
+			var a, b string
+			var n int
+			if err := rows.Scan(&a, &b, &n); err != nil {
+				return fmt.Errorf("scanning facet row: %w", err)
+			}
+			into(a, b, n)
+		}
+		return rows.Err()
+	}
+
+	err = count("SELECT b.datasource, COALESCE(b.hostname, ''), COUNT(*)"+from+" GROUP BY 1, 2", func(dsType, hostname string, n int) {
+		result.types[dsType] += n
+		result.hostnames[hostname] += n
+	})
+	if err == nil {
+		err = count("SELECT COALESCE("+interval.bucketExpr("b.")+", ''), '', COUNT(*)"+from+" GROUP BY 1", func(day, _ string, n int) {
+			result.histogram[day] += n
+		})
+	}
+	if err != nil {
+		return facetResult{err: err}
+	}
+	return result
+}
+
+// sortedFacetCounts returns counts sorted by count (highest first), then value.
+func sortedFacetCounts(counts map[string]int) []FacetCount {
+	facets := make([]FacetCount, 0, len(counts))
+	for value, count := range counts {
+		facets = append(facets, FacetCount{Value: value, Count: count})
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count == facets[j].Count {
+			return facets[i].Value < facets[j].Value
+		}
+		return facets[i].Count > facets[j].Count
+	})
+	return facets
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestSearchFacets(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	// Monday 2025-03-03, plus blocks in the previous week and month
+	base := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	stores := []struct {
+		datasource, dsType, hostname string
+		days                         []int
+	}{
+		{"hn", "hackernews", "laptop", []int{0, 0, 1, -1}},
+		{"hn2", "hackernews", "desktop", []int{2, -7}},
+		{"feeds", "rss", "laptop", []int{0, -40}},
+	}
+	for _, st := range stores {
+		if err := manager.InitializeDatasourceStorage(st.datasource, map[string]any{"title": "TEXT"}); err != nil {
+			t.Fatalf("InitializeDatasourceStorage error: %v", err)
+		}
+		storage, err := manager.GetStorage(st.datasource)
+		if err != nil {
+			t.Fatalf("GetStorage error: %v", err)
+		}
+		var blocks []core.Block
+		for i, day := range st.days {
+			blocks = append(blocks, core.NewGenericBlock(fmt.Sprintf("%s-%d", st.datasource, i), "facet golang block", st.datasource, st.dsType, base.AddDate(0, 0, day), nil))
+		}
+		blocks = append(blocks, core.NewGenericBlock(st.datasource+"-other", "unrelated", st.datasource, st.dsType, base, nil))
+		if err := storage.StoreBlocks(blocks, st.dsType); err != nil {
+			t.Fatalf("StoreBlocks error: %v", err)
+		}
+		// Stored blocks get the local hostname
+		if _, err := storage.GetDB().Exec("UPDATE blocks SET hostname = ?", st.hostname); err != nil {
+			t.Fatalf("setting hostname: %v", err)
+		}
+	}
+
+	search := manager.GetSearchService()
+	results, err := search.Search(SearchParams{Query: "golang", Page: 1, Limit: 2, Facets: true, FacetInterval: FacetIntervalWeek})
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	facets := results.Facets
+	if facets == nil {
+		t.Fatal("expected facets")
+	}
+	if results.TotalCount != 2 || facets.Total != 8 {
+		t.Errorf("expected 2 results on the page and 8 matches, got %d and %d", results.TotalCount, facets.Total)
+	}
+	if want := []FacetCount{{"hn", 4}, {"feeds", 2}, {"hn2", 2}}; !reflect.DeepEqual(facets.Datasources, want) {
+		t.Errorf("datasource facets = %v, want %v", facets.Datasources, want)
+	}
+	if want := []FacetCount{{"hackernews", 6}, {"rss", 2}}; !reflect.DeepEqual(facets.Types, want) {
+		t.Errorf("type facets = %v, want %v", facets.Types, want)
+	}
+	if want := []FacetCount{{"laptop", 6}, {"desktop", 2}}; !reflect.DeepEqual(facets.Hostnames, want) {
+		t.Errorf("hostname facets = %v, want %v", facets.Hostnames, want)
+	}
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	wantWeeks := []HistogramBucket{{day(2025, 1, 20), 1}, {day(2025, 2, 24), 2}, {day(2025, 3, 3), 5}}
+	if !reflect.DeepEqual(facets.Histogram, wantWeeks) {
+		t.Errorf("weekly histogram = %v, want %v", facets.Histogram, wantWeeks)
+	}
+
+	// Facets follow the other filters and ignore pagination
+	params := SearchParams{Query: "golang source:hn source:hn2", Page: 2, Limit: 1, Facets: true}
+	if err := ApplyQueryOperators(&params); err != nil {
+		t.Fatalf("ApplyQueryOperators error: %v", err)
+	}
+	results, err = search.Search(params)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	wantMonths := []HistogramBucket{{day(2025, 2, 1), 1}, {day(2025, 3, 1), 5}}
+	if results.Facets.Total != 6 || results.Facets.Interval != FacetIntervalMonth || !reflect.DeepEqual(results.Facets.Histogram, wantMonths) {
+		t.Errorf("unexpected monthly facets: %+v", results.Facets)
+	}
+
+	if _, err := ParseFacetInterval("year"); err == nil {
+		t.Error("expected error for invalid interval")
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
@@ -17,6 +19,12 @@ import (
 type GenericStorage struct {
 	db             *sql.DB
 	datasourceName string
+
+	// schema holds the fields materialized by InitializeSchema
+	schema []SchemaField
+	// tokenizer is the FTS5 tokenizer set by ApplyTokenizer, if any
+	tokenizer string
+	mu        sync.RWMutex
 }
 
 // NewGenericStorage creates a new GenericStorage instance with the specified database path
@@ -64,14 +72,39 @@ func (s *GenericStorage) GetDB() *sql.DB {
 	return s.db
 }
 
-// InitializeSchema initializes the storage schema with the provided configuration.
-// This is a no-op in the current implementation as datasource-specific tables
-// are not used - all data is stored in the unified blocks table.
+// InitializeSchema materializes the datasource's Schema() fields as typed, indexed
+// columns on the blocks table. Each field becomes a virtual generated column
+// (field_<name>) over json_extract(metadata, '$.<name>'), so numeric comparisons
+// and filters on schema fields use real types and an index.
+//
+// The materialized columns are diffed against the schema on every call: new fields
+// are added, removed fields are dropped and fields whose type changed are recreated.
 func (s *GenericStorage) InitializeSchema(schema map[string]any) error {
-	// Schema functionality removed as datasource-specific tables are not used
+	fields, err := parseSchema(schema)
+	if err != nil {
+		return err
+	}
+
+	if err := s.applySchema(fields); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.schema = fields
+	s.mu.Unlock()
 	return nil
 }
 
+// SchemaFields returns the schema fields materialized for this storage by the
+// last InitializeSchema call, sorted by name.
+func (s *GenericStorage) SchemaFields() []SchemaField {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fields := make([]SchemaField, len(s.schema))
+	copy(fields, s.schema)
+	return fields
+}
+
 // StoreBlock stores a single block in the database with the specified datasource type.
 // This is a convenience method that calls StoreBlocks with a single-element slice.
 func (s *GenericStorage) StoreBlock(block core.Block, datasourceType string) error {
@@ -229,6 +262,196 @@ func (s *GenericStorage) GetBlocksSince(since time.Time) ([]core.Block, error) {
 	return blocks, rows.Err()
 }
 
+// GetBlocksByID retrieves the blocks with the given IDs, keyed by ID.
+// IDs that don't exist are left out of the result.
+func (s *GenericStorage) GetBlocksByID(ids []string) (map[string]core.Block, error) {
+	blocks := make(map[string]core.Block, len(ids))
+	if len(ids) == 0 {
+		return blocks, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := s.db.Query(`
+		SELECT id, text, created_at, source, datasource, metadata, hostname
+		FROM blocks
+		WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying blocks: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	for rows.Next() {
+		var id, text, source, datasourceType, metadataStr string
+		var hostname sql.NullString
+		var createdAt time.Time
+
+		if err := rows.Scan(&id, &text, &createdAt, &source, &datasourceType, &metadataStr, &hostname); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
+			return nil, fmt.Errorf("unmarshaling metadata for block %s: %w", id, err)
+		}
+
+		blocks[id] = core.NewGenericBlockWithHostname(id, text, source, datasourceType, hostname.String, createdAt, metadata)
+	}
+
+	return blocks, rows.Err()
+}
+
+// IngestedBlock is a stored block and its rowid. Rowids grow in ingest
+// order; blocks updated in place keep theirs.
+type IngestedBlock struct {
+	RowID int64
+	Block core.Block
+}
+
+// LastRowID returns the rowid of the last block stored, 0 when there are none.
+func (s *GenericStorage) LastRowID() (int64, error) {
+	var rowID int64
+	if err := s.db.QueryRow("SELECT COALESCE(MAX(rowid), 0) FROM blocks").Scan(&rowID); err != nil {
+		return 0, fmt.Errorf("reading last block: %w", err)
+	}
+	return rowID, nil
+}
+
+// GetBlocksAfterRowID returns up to limit blocks stored after the one with
+// the given rowid, in ingest order.
+func (s *GenericStorage) GetBlocksAfterRowID(rowID int64, limit int) ([]IngestedBlock, error) {
+	rows, err := s.db.Query(`
+		SELECT rowid, id, text, created_at, source, datasource, metadata, hostname
+		FROM blocks
+		WHERE rowid > ?
+		ORDER BY rowid
+		LIMIT ?`, rowID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying blocks: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	var blocks []IngestedBlock
+	for rows.Next() {
+		var blockRowID int64
+		var id, text, source, datasourceType, metadataStr string
+		var hostname sql.NullString
+		var createdAt time.Time
+
+		if err := rows.Scan(&blockRowID, &id, &text, &createdAt, &source, &datasourceType, &metadataStr, &hostname); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
+			return nil, fmt.Errorf("unmarshaling metadata for block %s: %w", id, err)
+		}
+
+		blocks = append(blocks, IngestedBlock{
+			RowID: blockRowID,
+			Block: core.NewGenericBlockWithHostname(id, text, source, datasourceType, hostname.String, createdAt, metadata),
+		})
+	}
+
+	return blocks, rows.Err()
+}
+
+// DeleteBlocks deletes the blocks with the given IDs and returns how many
+// existed. The FTS triggers remove them from blocks_fts.
+func (s *GenericStorage) DeleteBlocks(ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	result, err := s.db.Exec("DELETE FROM blocks WHERE id IN ("+placeholders+")", args...)
+	if err != nil {
+		return 0, fmt.Errorf("deleting blocks: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// GetFetchMetadata returns the value stored under key in the fetch_metadata table.
+// The boolean result reports whether the key exists.
+func (s *GenericStorage) GetFetchMetadata(key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM fetch_metadata WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("reading fetch metadata %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// SetFetchMetadata stores multiple key/value pairs in the fetch_metadata table
+// using a single transaction, replacing existing values.
+func (s *GenericStorage) SetFetchMetadata(values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil {
+				fmt.Printf("Warning: failed to rollback transaction: %v\n", err)
+			}
+		}
+	}()
+
+	for key, value := range values {
+		_, err := tx.Exec(`
+			INSERT INTO fetch_metadata (key, value, updated_at)
+			VALUES (?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(key) DO UPDATE SET
+				value=excluded.value,
+				updated_at=CURRENT_TIMESTAMP
+		`, key, value)
+		if err != nil {
+			return fmt.Errorf("storing fetch metadata %s: %w", key, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err == nil {
+		committed = true
+	}
+	return err
+}
+
+// DeleteFetchMetadata removes all fetch_metadata entries whose key starts with prefix
+// and returns the number of entries removed.
+func (s *GenericStorage) DeleteFetchMetadata(prefix string) (int64, error) {
+	result, err := s.db.Exec("DELETE FROM fetch_metadata WHERE substr(key, 1, ?) = ?", len(prefix), prefix)
+	if err != nil {
+		return 0, fmt.Errorf("deleting fetch metadata: %w", err)
+	}
+	return result.RowsAffected()
+}
+
 // GetStats returns statistics about the stored data including total block count,
 // oldest block timestamp, and newest block timestamp. The returned map contains
 // "total_blocks", "oldest_block", and "newest_block" keys.
@@ -268,6 +491,15 @@ func (s *GenericStorage) GetStats() (map[string]interface{}, error) {
 		stats["newest_block"] = newestBlock
 	}
 
+	prunedBlocks, lastPruned, err := s.prunedStats()
+	if err != nil {
+		return nil, fmt.Errorf("getting pruned blocks: %w", err)
+	}
+	if prunedBlocks > 0 {
+		stats["pruned_blocks"] = prunedBlocks
+		stats["last_pruned"] = lastPruned
+	}
+
 	return stats, nil
 }
 
@@ -424,6 +656,8 @@ func (s *GenericStorage) FTSIntegrityCheck() error {
 
 // FTSRebuild rebuilds the FTS5 full-text search index from the blocks table.
 // This can fix corrupted FTS indexes and should be used after data recovery.
+// If a tokenizer was set with ApplyTokenizer and the index uses another one,
+// the index is recreated with it.
 func (s *GenericStorage) FTSRebuild() error {
 	// First check if the FTS table exists
 	var count int
@@ -436,10 +670,24 @@ func (s *GenericStorage) FTSRebuild() error {
 		return fmt.Errorf("FTS table 'blocks_fts' does not exist")
 	}
 
-	// Rebuild the FTS index
-	_, err = s.db.Exec("INSERT INTO blocks_fts(blocks_fts) VALUES('rebuild')")
+	s.mu.RLock()
+	tokenizer := s.tokenizer
+	s.mu.RUnlock()
+	current, err := s.FTSTokenizer()
 	if err != nil {
-		return fmt.Errorf("rebuilding FTS index: %w", err)
+		return err
+	}
+
+	if tokenizer != "" && tokenizer != current {
+		// Recreating the table rebuilds the index
+		if err := s.runSchemaMigrations([]schemaMigration{tokenizerMigration(tokenizer)}); err != nil {
+			return fmt.Errorf("rebuilding FTS index: %w", err)
+		}
+	} else {
+		_, err = s.db.Exec("INSERT INTO blocks_fts(blocks_fts) VALUES('rebuild')")
+		if err != nil {
+			return fmt.Errorf("rebuilding FTS index: %w", err)
+		}
 	}
 
 	// Optimize the FTS index
@@ -0,0 +1,401 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+// GlobalIndexFile is the path of the global search index, relative to the storage directory.
+const GlobalIndexFile = "internal/index.db"
+
+const globalIndexSchema = `
+CREATE TABLE IF NOT EXISTS entries (
+    id INTEGER PRIMARY KEY,
+    datasource TEXT NOT NULL,
+    block_id TEXT NOT NULL,
+    type TEXT NOT NULL,
+    source TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    UNIQUE(datasource, block_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_entries_order ON entries(created_at DESC, datasource, block_id);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS entries_fts USING fts5(
+    text,
+    source,
+    datasource,
+    metadata,
+    hostname,
+    tokenize='porter'
+);
+
+CREATE TABLE IF NOT EXISTS index_meta (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+`
+
+// GlobalIndex is a single SQLite database indexing the blocks of every
+// datasource, so cross-datasource searches run one FTS query instead of one per
+// datasource database. It holds the FTS columns plus created_at, source and
+// type; matching blocks are hydrated from the datasource that owns them.
+//
+// The warehouse keeps the index up to date on ingest. It is only used for
+// searches once it has been built from the datasource databases with Rebuild,
+// since blocks stored before the index existed would be missing otherwise.
+type GlobalIndex struct {
+	db *sql.DB
+}
+
+// OpenGlobalIndex opens (creating it if needed) the global index database at dbPath.
+func OpenGlobalIndex(dbPath string) (*GlobalIndex, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening global index: %w", err)
+	}
+
+	pragmas := []string{
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA synchronous = NORMAL",
+		"PRAGMA busy_timeout = 30000",
+		"PRAGMA cache_size = -64000",
+	}
+	for _, pragma := range pragmas {
+		if _, err := db.Exec(pragma); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("applying pragma %q: %w", pragma, err)
+		}
+	}
+
+	if _, err := db.Exec(globalIndexSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("creating global index schema: %w", err)
+	}
+
+	return &GlobalIndex{db: db}, nil
+}
+
+// Close closes the global index database.
+func (g *GlobalIndex) Close() error {
+	return g.db.Close()
+}
+
+// Ready reports whether the index has been built and can serve searches.
+func (g *GlobalIndex) Ready() bool {
+	var builtAt string
+	err := g.db.QueryRow("SELECT value FROM index_meta WHERE key = 'built_at'").Scan(&builtAt)
+	return err == nil
+}
+
+// indexEntry is a block as stored in the global index.
+type indexEntry struct {
+	id, text, source, dsType, metadata, hostname string
+	createdAt                                    time.Time
+}
+
+// IndexBlocks adds or updates blocks of a datasource in the index.
+func (g *GlobalIndex) IndexBlocks(datasource, datasourceType string, blocks []core.Block) error {
+	entries := make([]indexEntry, 0, len(blocks))
+	for _, block := range blocks {
+		// Same hostname and metadata encoding as GenericStorage.StoreBlocks
+		genericBlock := core.ToGenericBlockWithAutoHostname(block)
+		metadataJSON, err := json.Marshal(genericBlock.Metadata())
+		if err != nil {
+			return fmt.Errorf("marshaling metadata for block %s: %w", genericBlock.ID(), err)
+		}
+		entries = append(entries, indexEntry{
+			id:        genericBlock.ID(),
+			text:      genericBlock.Text(),
+			source:    genericBlock.Source(),
+			dsType:    datasourceType,
+			metadata:  string(metadataJSON),
+			hostname:  genericBlock.Hostname(),
+			createdAt: genericBlock.CreatedAt().UTC(),
+		})
+	}
+
+	tx, err := g.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	writer, err := newIndexWriter(tx)
+	if err != nil {
+		return err
+	}
+	defer writer.close()
+	for _, e := range entries {
+		if err := writer.add(datasource, e); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RemoveBlocks removes blocks of a datasource from the index.
+func (g *GlobalIndex) RemoveBlocks(datasource string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx, err := g.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for start := 0; start < len(ids); start += pruneBatchSize {
+		batch := ids[start:min(start+pruneBatchSize, len(ids))]
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		args := make([]any, 0, len(batch)+1)
+		args = append(args, datasource)
+		for _, id := range batch {
+			args = append(args, id)
+		}
+		where := "datasource = ? AND block_id IN (" + placeholders + ")"
+		if _, err := tx.Exec("DELETE FROM entries_fts WHERE rowid IN (SELECT id FROM entries WHERE "+where+")", args...); err != nil {
+			return fmt.Errorf("removing blocks from index FTS: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM entries WHERE "+where, args...); err != nil {
+			return fmt.Errorf("removing blocks from index: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// indexWriter upserts index entries and their FTS rows within a transaction.
+type indexWriter struct {
+	stmt, ftsStmt *sql.Stmt
+}
+
+func newIndexWriter(tx *sql.Tx) (*indexWriter, error) {
+	stmt, err := tx.Prepare(`
+		INSERT INTO entries (datasource, block_id, type, source, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(datasource, block_id) DO UPDATE SET
+			type=excluded.type,
+			source=excluded.source,
+			created_at=excluded.created_at
+		RETURNING id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("preparing index statement: %w", err)
+	}
+
+	ftsStmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO entries_fts (rowid, text, source, datasource, metadata, hostname)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		_ = stmt.Close()
+		return nil, fmt.Errorf("preparing index FTS statement: %w", err)
+	}
+	return &indexWriter{stmt: stmt, ftsStmt: ftsStmt}, nil
+}
+
+func (w *indexWriter) add(datasource string, e indexEntry) error {
+	var rowID int64
+	if err := w.stmt.QueryRow(datasource, e.id, e.dsType, e.source, e.createdAt).Scan(&rowID); err != nil {
+		return fmt.Errorf("indexing block %s: %w", e.id, err)
+	}
+	if _, err := w.ftsStmt.Exec(rowID, e.text, e.source, e.dsType, e.metadata, e.hostname); err != nil {
+		return fmt.Errorf("indexing block %s into FTS: %w", e.id, err)
+	}
+	return nil
+}
+
+func (w *indexWriter) close() {
+	_ = w.stmt.Close()
+	_ = w.ftsStmt.Close()
+}
+
+// Rebuild replaces the index contents with every block of the given datasource
+// databases and marks the index ready. progressFn, if not nil, is called
+// before each datasource is indexed.
+func (g *GlobalIndex) Rebuild(m *Manager, datasources []string, progressFn func(datasource string)) error {
+	tx, err := g.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, stmt := range []string{"DELETE FROM entries", "DELETE FROM entries_fts", "DELETE FROM index_meta"} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("clearing global index: %w", err)
+		}
+	}
+
+	writer, err := newIndexWriter(tx)
+	if err != nil {
+		return err
+	}
+	defer writer.close()
+
+	for _, name := range datasources {
+		if progressFn != nil {
+			progressFn(name)
+		}
+		storage, err := m.GetStorage(name)
+		if err != nil {
+			return fmt.Errorf("getting storage for %s: %w", name, err)
+		}
+		err = storage.forEachIndexEntry(func(e indexEntry) error {
+			return writer.add(name, e)
+		})
+		if err != nil {
+			return fmt.Errorf("indexing %s: %w", name, err)
+		}
+	}
+
+	if _, err := tx.Exec("INSERT INTO index_meta (key, value) VALUES ('built_at', ?)", time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("marking global index ready: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing global index: %w", err)
+	}
+
+	if _, err := g.db.Exec("INSERT INTO entries_fts(entries_fts) VALUES('optimize')"); err != nil {
+		return fmt.Errorf("optimizing global index: %w", err)
+	}
+	return nil
+}
+
+// forEachIndexEntry calls fn with every block of the storage as a global index entry.
+func (s *GenericStorage) forEachIndexEntry(fn func(indexEntry) error) error {
+	rows, err := s.db.Query("SELECT id, text, created_at, source, datasource, metadata, hostname FROM blocks")
+	if err != nil {
+		return fmt.Errorf("querying blocks: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	for rows.Next() {
+		var e indexEntry
+		var hostname sql.NullString
+		if err := rows.Scan(&e.id, &e.text, &e.createdAt, &e.source, &e.dsType, &e.metadata, &hostname); err != nil {
+			return fmt.Errorf("scanning row: %w", err)
+		}
+		e.createdAt = e.createdAt.UTC()
+		e.hostname = hostname.String
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// canServe reports whether the global index can answer a query. Field filters
+// and tags need the typed columns and annotation tables that only exist in the
+// datasource databases.
+func (g *GlobalIndex) canServe(query *parsedQuery) bool {
+	return len(query.filters) == 0 && len(query.tags) == 0 && g.Ready()
+}
+
+// searchGlobalIndex returns the blocks matching q in the given datasources, in global
+// search order, skipping the first offset matches. Blocks are hydrated from
+// their datasource databases.
+func (s *SearchService) searchGlobalIndex(g *GlobalIndex, datasources []string, q storageQuery, offset int) ([]mergedBlock, error) {
+	var conditions []string
+	var args []interface{}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(datasources)), ",")
+	conditions = append(conditions, "e.datasource IN ("+placeholders+")")
+	for _, ds := range datasources {
+		args = append(args, ds)
+	}
+	if q.startDate != nil {
+		conditions = append(conditions, "e.created_at >= ?")
+		args = append(args, q.startDate.Format(time.RFC3339))
+	}
+	if q.endDate != nil {
+		conditions = append(conditions, "e.created_at <= ?")
+		args = append(args, q.endDate.Format(time.RFC3339))
+	}
+	if q.cursor != nil {
+		cond, cursorArgs := q.cursor.globalSeekCondition("e.")
+		conditions = append(conditions, cond)
+		args = append(args, cursorArgs...)
+	}
+
+	withSnippets := q.snippets && q.query.fts != ""
+	columns := "e.datasource, e.block_id"
+	from := "entries e"
+	if q.query.fts != "" {
+		from += " JOIN entries_fts ON entries_fts.rowid = e.id"
+		conditions = append([]string{"entries_fts MATCH ?"}, conditions...)
+		args = append([]interface{}{escapeFTS5Query(q.query.fts)}, args...)
+		if withSnippets {
+			columns += fmt.Sprintf(", snippet(entries_fts, -1, '%s', '%s', '…', %d)", SnippetMatchStart, SnippetMatchEnd, snippetTokens)
+		}
+	}
+	sqlQuery := "SELECT " + columns + " FROM " + from +
+		" WHERE " + strings.Join(conditions, " AND ") +
+		" ORDER BY e.created_at DESC, e.datasource ASC, e.block_id ASC LIMIT ? OFFSET ?"
+	args = append(args, q.limit, offset)
+
+	rows, err := g.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying global index: %w", err)
+	}
+	type indexMatch struct {
+		datasource, blockID, snippet string
+	}
+	var matches []indexMatch
+	idsByDatasource := make(map[string][]string)
+	for rows.Next() {
+		var match indexMatch
+		dest := []any{&match.datasource, &match.blockID}
+		if withSnippets {
+			dest = append(dest, &match.snippet)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("scanning global index row: %w", err)
+		}
+		matches = append(matches, match)
+		idsByDatasource[match.datasource] = append(idsByDatasource[match.datasource], match.blockID)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("reading global index: %w", err)
+	}
+
+	// Hydrate the blocks from the databases that own them
+	hydrated := make(map[string]map[string]core.Block, len(idsByDatasource))
+	for ds, ids := range idsByDatasource {
+		storage, err := s.manager.GetStorage(ds)
+		if err != nil {
+			return nil, fmt.Errorf("getting storage for %s: %w", ds, err)
+		}
+		blocks, err := storage.GetBlocksByID(ids)
+		if err != nil {
+			return nil, fmt.Errorf("hydrating blocks of %s: %w", ds, err)
+		}
+		hydrated[ds] = blocks
+	}
+
+	merged := make([]mergedBlock, 0, len(matches))
+	for _, match := range matches {
+		// Blocks deleted since they were indexed are skipped
+		block, ok := hydrated[match.datasource][match.blockID]
+		if !ok {
+			continue
+		}
+		merged = append(merged, mergedBlock{
+			block:      block,
+			datasource: match.datasource,
+			createdAt:  createdAtKey(block.CreatedAt()),
+			snippet:    match.snippet,
+		})
+	}
+	return merged, nil
+}
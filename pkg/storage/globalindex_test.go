@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestGlobalIndexSearch(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	base := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	for _, ds := range []string{"alpha", "beta"} {
+		if err := manager.InitializeDatasourceStorage(ds, map[string]any{"title": "TEXT"}); err != nil {
+			t.Fatalf("InitializeDatasourceStorage error: %v", err)
+		}
+		storage, err := manager.GetStorage(ds)
+		if err != nil {
+			t.Fatalf("GetStorage error: %v", err)
+		}
+		var blocks []core.Block
+		for i := 0; i < 5; i++ {
+			// Every other block shares its created_at with the other datasource
+			createdAt := base.Add(-time.Duration(i) * time.Hour)
+			if i%2 == 1 && ds == "beta" {
+				createdAt = createdAt.Add(time.Minute)
+			}
+			blocks = append(blocks, core.NewGenericBlock(fmt.Sprintf("%s-%d", ds, i), "indexed golang block", ds, ds+"type", createdAt, nil))
+		}
+		blocks = append(blocks, core.NewGenericBlock(ds+"-other", "unrelated", ds, ds+"type", base, nil))
+		if err := storage.StoreBlocks(blocks, ds+"type"); err != nil {
+			t.Fatalf("StoreBlocks error: %v", err)
+		}
+	}
+
+	search := manager.GetSearchService()
+	ids := func(blocks []core.Block) []string {
+		out := make([]string, 0, len(blocks))
+		for _, b := range blocks {
+			out = append(out, b.ID())
+		}
+		return out
+	}
+	searchIDs := func(params SearchParams) ([]string, *SearchResults) {
+		t.Helper()
+		results, err := search.Search(params)
+		if err != nil {
+			t.Fatalf("Search error: %v", err)
+		}
+		return ids(results.Ordered), results
+	}
+
+	// Results of the per-datasource fan-out, to compare the index against
+	var wantPages [][]string
+	for page := 1; page <= 4; page++ {
+		got, _ := searchIDs(SearchParams{Query: "golang", Page: page, Limit: 3})
+		wantPages = append(wantPages, got)
+	}
+	wantAll, _ := searchIDs(SearchParams{Page: 1, Limit: 20})
+
+	if err := manager.OpenGlobalIndex(); err != nil {
+		t.Fatalf("OpenGlobalIndex error: %v", err)
+	}
+	index := manager.GlobalIndex()
+	if index.Ready() {
+		t.Fatal("expected a new index not to be ready")
+	}
+	if got, _ := searchIDs(SearchParams{Query: "golang", Page: 1, Limit: 3}); !reflect.DeepEqual(got, wantPages[0]) {
+		t.Errorf("search before rebuild = %v, want %v", got, wantPages[0])
+	}
+
+	var progress []string
+	if err := index.Rebuild(manager, []string{"alpha", "beta"}, func(ds string) { progress = append(progress, ds) }); err != nil {
+		t.Fatalf("Rebuild error: %v", err)
+	}
+	if !index.Ready() {
+		t.Fatal("expected the index to be ready after rebuild")
+	}
+	if !reflect.DeepEqual(progress, []string{"alpha", "beta"}) {
+		t.Errorf("progress = %v", progress)
+	}
+
+	// Page numbers, cursors and empty queries give the same results as the fan-out
+	for page, want := range wantPages {
+		got, results := searchIDs(SearchParams{Query: "golang", Page: page + 1, Limit: 3})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("page %d = %v, want %v", page+1, got, want)
+		}
+		if results.HasMore != (page < 3) {
+			t.Errorf("page %d HasMore = %v", page+1, results.HasMore)
+		}
+	}
+	var cursorIDs []string
+	params := SearchParams{Query: "golang", Page: 1, Limit: 3}
+	for {
+		got, results := searchIDs(params)
+		cursorIDs = append(cursorIDs, got...)
+		if results.NextCursor == "" {
+			break
+		}
+		params.Cursor = results.NextCursor
+	}
+	var wantCursorIDs []string
+	for _, p := range wantPages {
+		wantCursorIDs = append(wantCursorIDs, p...)
+	}
+	if !reflect.DeepEqual(cursorIDs, wantCursorIDs) {
+		t.Errorf("cursor pages = %v, want %v", cursorIDs, wantCursorIDs)
+	}
+	if got, _ := searchIDs(SearchParams{Page: 1, Limit: 20}); !reflect.DeepEqual(got, wantAll) {
+		t.Errorf("empty query = %v, want %v", got, wantAll)
+	}
+
+	// Snippets and datasource filters come from the index as well
+	_, results := searchIDs(SearchParams{Query: "golang", Page: 1, Limit: 1, Snippets: true, DatasourceFilters: []string{"beta"}})
+	if len(results.Results["beta"]) != 1 || len(results.Results) != 1 {
+		t.Fatalf("expected one beta result, got %v", results.Results)
+	}
+	if snippet := results.Snippet("beta", results.Results["beta"][0].ID()); snippet == "" {
+		t.Error("expected a snippet from the global index")
+	}
+
+	// Blocks indexed on ingest are searchable, deleted ones are skipped
+	alpha, _ := manager.GetStorage("alpha")
+	fresh := core.NewGenericBlock("alpha-fresh", "freshly ingested block", "alpha", "alphatype", base.Add(time.Hour), nil)
+	if err := alpha.StoreBlocks([]core.Block{fresh}, "alphatype"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+	if err := index.IndexBlocks("alpha", "alphatype", []core.Block{fresh}); err != nil {
+		t.Fatalf("IndexBlocks error: %v", err)
+	}
+	if got, _ := searchIDs(SearchParams{Query: "freshly", Page: 1, Limit: 10}); !reflect.DeepEqual(got, []string{"alpha-fresh"}) {
+		t.Errorf("search for ingested block = %v", got)
+	}
+	// Matches come from the index, blocks from their database
+	reindexed := core.NewGenericBlock("alpha-fresh", "reindexed", "alpha", "alphatype", base.Add(time.Hour), nil)
+	if err := index.IndexBlocks("alpha", "alphatype", []core.Block{reindexed}); err != nil {
+		t.Fatalf("IndexBlocks error: %v", err)
+	}
+	_, results = searchIDs(SearchParams{Query: "reindexed", Page: 1, Limit: 10})
+	if len(results.Ordered) != 1 || results.Ordered[0].Text() != "freshly ingested block" {
+		t.Errorf("expected the stored block for an index match, got %v", results.Ordered)
+	}
+	if _, err := alpha.GetDB().Exec("DELETE FROM blocks WHERE id = ?", "alpha-fresh"); err != nil {
+		t.Fatalf("deleting block: %v", err)
+	}
+	if got, _ := searchIDs(SearchParams{Query: "reindexed", Page: 1, Limit: 10}); len(got) != 0 {
+		t.Errorf("expected deleted block to be skipped, got %v", got)
+	}
+
+	if err := index.RemoveBlocks("alpha", []string{"alpha-0", "alpha-1", "missing"}); err != nil {
+		t.Fatalf("RemoveBlocks error: %v", err)
+	}
+	var entries, ftsRows int
+	if err := index.db.QueryRow("SELECT (SELECT COUNT(*) FROM entries), (SELECT COUNT(*) FROM entries_fts)").Scan(&entries, &ftsRows); err != nil {
+		t.Fatalf("counting index entries: %v", err)
+	}
+	if entries != 11 || ftsRows != 11 {
+		t.Errorf("expected 11 index entries left, got %d (%d FTS rows)", entries, ftsRows)
+	}
+
+	// Field filters need the datasource databases
+	query, err := parseSearchQuery("golang title:x")
+	if err != nil {
+		t.Fatalf("parseSearchQuery error: %v", err)
+	}
+	if index.canServe(query) {
+		t.Error("expected field filter queries to fall back to the fan-out")
+	}
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+// InboxState is the triage state of a block in the inbox.
+type InboxState string
+
+const (
+	// InboxUnread blocks have not been looked at since they were ingested
+	InboxUnread InboxState = "unread"
+	// InboxRead blocks were marked read
+	InboxRead InboxState = "read"
+	// InboxArchived blocks were archived
+	InboxArchived InboxState = "archived"
+)
+
+// ParseInboxState parses an inbox state name, an empty name being unread.
+func ParseInboxState(name string) (InboxState, error) {
+	switch state := InboxState(strings.ToLower(strings.TrimSpace(name))); state {
+	case "":
+		return InboxUnread, nil
+	case InboxUnread, InboxRead, InboxArchived:
+		return state, nil
+	}
+	return "", fmt.Errorf("invalid inbox state %q: use unread, read or archived", name)
+}
+
+// InboxItem is a block listed in the inbox.
+type InboxItem struct {
+	Block      core.Block
+	Datasource string
+	IngestedAt time.Time
+	State      InboxState
+}
+
+// InboxParams select the inbox blocks to list.
+type InboxParams struct {
+	State       InboxState // Defaults to InboxUnread
+	Datasources []string   // Only list blocks from these datasources (all when empty)
+	Page        int
+	Limit       int
+}
+
+// InboxResult is a page of inbox blocks, newest ingested first.
+type InboxResult struct {
+	Items      []InboxItem
+	TotalCount int            // Blocks in the state across the selected datasources
+	Counts     map[string]int // Blocks in the state per datasource, selected or not
+	Page       int
+	Limit      int
+	HasMore    bool
+}
+
+// inboxCondition returns the SQL condition selecting the blocks in state.
+func inboxCondition(state InboxState) (string, []interface{}) {
+	if state == InboxUnread {
+		return "NOT EXISTS (SELECT 1 FROM block_inbox i WHERE i.block_id = blocks.id)", nil
+	}
+	return "EXISTS (SELECT 1 FROM block_inbox i WHERE i.block_id = blocks.id AND i.state = ?)", []interface{}{string(state)}
+}
+
+// CountInbox returns how many blocks are in state.
+func (s *GenericStorage) CountInbox(state InboxState) (int, error) {
+	condition, args := inboxCondition(state)
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM blocks WHERE "+condition, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("counting %s blocks: %w", state, err)
+	}
+	return count, nil
+}
+
+// InboxBlocks returns up to limit blocks in state, newest ingested first.
+func (s *GenericStorage) InboxBlocks(state InboxState, limit int) ([]InboxItem, error) {
+	condition, args := inboxCondition(state)
+	rows, err := s.db.Query(`
+		SELECT id, text, created_at, source, datasource, metadata, hostname, ingested_at
+		FROM blocks
+		WHERE `+condition+`
+		ORDER BY ingested_at DESC, rowid DESC
+		LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("querying %s blocks: %w", state, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	var items []InboxItem
+	for rows.Next() {
+		var id, text, source, datasourceType, metadataStr string
+		var hostname sql.NullString
+		var createdAt time.Time
+		var ingestedAt sql.NullTime
+
+		if err := rows.Scan(&id, &text, &createdAt, &source, &datasourceType, &metadataStr, &hostname, &ingestedAt); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
+			return nil, fmt.Errorf("unmarshaling metadata for block %s: %w", id, err)
+		}
+
+		items = append(items, InboxItem{
+			Block:      core.NewGenericBlockWithHostname(id, text, source, datasourceType, hostname.String, createdAt, metadata),
+			Datasource: s.datasourceName,
+			IngestedAt: ingestedAt.Time,
+			State:      state,
+		})
+	}
+	return items, rows.Err()
+}
+
+// SetInboxState moves the blocks with the given IDs to state and returns how
+// many of them are stored. IDs of blocks that are not stored are ignored.
+func (s *GenericStorage) SetInboxState(ids []string, state InboxState) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	var found int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM blocks WHERE id IN ("+placeholders+")", args...).Scan(&found); err != nil {
+		return 0, fmt.Errorf("looking up blocks: %w", err)
+	}
+
+	if state == InboxUnread {
+		if _, err := s.db.Exec("DELETE FROM block_inbox WHERE block_id IN ("+placeholders+")", args...); err != nil {
+			return 0, fmt.Errorf("marking blocks unread: %w", err)
+		}
+		return found, nil
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO block_inbox (block_id, state, updated_at)
+		SELECT id, ?, CURRENT_TIMESTAMP FROM blocks WHERE id IN (`+placeholders+`)
+		ON CONFLICT(block_id) DO UPDATE SET
+			state=excluded.state,
+			updated_at=CURRENT_TIMESTAMP
+	`, append([]interface{}{string(state)}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("marking blocks %s: %w", state, err)
+	}
+	return found, nil
+}
+
+// MarkAllRead marks every unread block read and returns how many there were.
+func (s *GenericStorage) MarkAllRead() (int, error) {
+	condition, _ := inboxCondition(InboxUnread)
+	result, err := s.db.Exec("INSERT INTO block_inbox (block_id, state) SELECT id, 'read' FROM blocks WHERE " + condition)
+	if err != nil {
+		return 0, fmt.Errorf("marking blocks read: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// inboxDatasources returns the loaded datasources, restricted to names when
+// not empty, sorted by name.
+func (m *Manager) inboxDatasources(names []string) []string {
+	var datasources []string
+	for _, name := range m.SearchAllDatasources() {
+		if len(names) == 0 || slices.Contains(names, name) {
+			datasources = append(datasources, name)
+		}
+	}
+	sort.Strings(datasources)
+	return datasources
+}
+
+// Inbox lists the blocks in params.State across datasources, newest ingested
+// first.
+func (m *Manager) Inbox(params InboxParams) (*InboxResult, error) {
+	if params.State == "" {
+		params.State = InboxUnread
+	}
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 30
+	}
+
+	result := &InboxResult{
+		Counts: make(map[string]int),
+		Page:   params.Page,
+		Limit:  params.Limit,
+	}
+	// Each datasource returns its newest blocks up to the end of the page;
+	// merging them gives the page across datasources.
+	end := params.Page * params.Limit
+	var items []InboxItem
+	for _, name := range m.inboxDatasources(nil) {
+		storage, err := m.GetStorage(name)
+		if err != nil {
+			return nil, fmt.Errorf("getting storage for %s: %w", name, err)
+		}
+		count, err := storage.CountInbox(params.State)
+		if err != nil {
+			return nil, fmt.Errorf("counting inbox of %s: %w", name, err)
+		}
+		result.Counts[name] = count
+		if count == 0 || (len(params.Datasources) > 0 && !slices.Contains(params.Datasources, name)) {
+			continue
+		}
+		result.TotalCount += count
+
+		dsItems, err := storage.InboxBlocks(params.State, end)
+		if err != nil {
+			return nil, fmt.Errorf("listing inbox of %s: %w", name, err)
+		}
+		items = append(items, dsItems...)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].IngestedAt.Equal(items[j].IngestedAt) {
+			return items[i].IngestedAt.After(items[j].IngestedAt)
+		}
+		return items[i].Block.CreatedAt().After(items[j].Block.CreatedAt())
+	})
+
+	start := (params.Page - 1) * params.Limit
+	if start < len(items) {
+		items = items[start:min(end, len(items))]
+		blocks := make([]core.Block, len(items))
+		for i, item := range items {
+			blocks[i] = item.Block
+		}
+		blocks, err := m.convertBlocksToProperTypes(blocks)
+		if err != nil {
+			return nil, err
+		}
+		for i := range items {
+			items[i].Block = blocks[i]
+		}
+		result.Items = items
+	}
+	result.HasMore = end < result.TotalCount
+	return result, nil
+}
+
+// CountInbox returns how many blocks of the given datasources (all when
+// empty) are in state.
+func (m *Manager) CountInbox(state InboxState, datasources []string) (int, error) {
+	total := 0
+	for _, name := range m.inboxDatasources(datasources) {
+		storage, err := m.GetStorage(name)
+		if err != nil {
+			return 0, fmt.Errorf("getting storage for %s: %w", name, err)
+		}
+		count, err := storage.CountInbox(state)
+		if err != nil {
+			return 0, fmt.Errorf("counting inbox of %s: %w", name, err)
+		}
+		total += count
+	}
+	return total, nil
+}
+
+// MarkAllRead marks every unread block of the given datasources (all when
+// empty) read and returns how many there were per datasource.
+func (m *Manager) MarkAllRead(datasources []string) (map[string]int, error) {
+	marked := make(map[string]int)
+	for _, name := range m.inboxDatasources(datasources) {
+		storage, err := m.GetStorage(name)
+		if err != nil {
+			return marked, fmt.Errorf("getting storage for %s: %w", name, err)
+		}
+		n, err := storage.MarkAllRead()
+		if err != nil {
+			return marked, fmt.Errorf("marking %s read: %w", name, err)
+		}
+		marked[name] = n
+	}
+	return marked, nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestInbox(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	for _, name := range []string{"hn", "rss"} {
+		if err := manager.InitializeDatasourceStorage(name, map[string]any{"title": "TEXT"}); err != nil {
+			t.Fatalf("InitializeDatasourceStorage error: %v", err)
+		}
+	}
+	hn, _ := manager.GetStorage("hn")
+	rss, _ := manager.GetStorage("rss")
+
+	now := time.Now()
+	var stories []core.Block
+	for _, id := range []string{"story-1", "story-2", "story-3"} {
+		stories = append(stories, core.NewGenericBlock(id, id, "hn", "hackernews", now, nil))
+	}
+	if err := hn.StoreBlocks(stories, "hackernews"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+	if err := rss.StoreBlocks([]core.Block{core.NewGenericBlock("post-1", "post", "rss", "rss", now, nil)}, "rss"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+
+	result, err := manager.Inbox(InboxParams{Limit: 3})
+	if err != nil {
+		t.Fatalf("Inbox error: %v", err)
+	}
+	if result.TotalCount != 4 || len(result.Items) != 3 || !result.HasMore || result.Counts["hn"] != 3 || result.Counts["rss"] != 1 {
+		t.Errorf("unexpected result: total %d, items %d, more %v, counts %v", result.TotalCount, len(result.Items), result.HasMore, result.Counts)
+	}
+	if result.Items[0].IngestedAt.IsZero() {
+		t.Error("expected the ingestion time to be set")
+	}
+
+	n, err := hn.SetInboxState([]string{"story-1", "missing"}, InboxRead)
+	if err != nil || n != 1 {
+		t.Fatalf("SetInboxState = %d, %v", n, err)
+	}
+	if _, err := hn.SetInboxState([]string{"story-2"}, InboxArchived); err != nil {
+		t.Fatalf("SetInboxState error: %v", err)
+	}
+	// A re-fetch doesn't bring read blocks back to the inbox
+	if err := hn.StoreBlocks(stories, "hackernews"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+
+	result, _ = manager.Inbox(InboxParams{Datasources: []string{"hn"}})
+	if result.TotalCount != 1 || len(result.Items) != 1 || result.Items[0].Block.ID() != "story-3" || result.Counts["rss"] != 1 {
+		t.Errorf("unexpected unread result: %+v", result)
+	}
+	result, _ = manager.Inbox(InboxParams{State: InboxArchived})
+	if len(result.Items) != 1 || result.Items[0].Block.ID() != "story-2" || result.Items[0].State != InboxArchived {
+		t.Errorf("unexpected archived result: %+v", result)
+	}
+
+	if _, err := hn.SetInboxState([]string{"story-2"}, InboxUnread); err != nil {
+		t.Fatalf("SetInboxState error: %v", err)
+	}
+	marked, err := manager.MarkAllRead([]string{"hn"})
+	if err != nil || marked["hn"] != 2 {
+		t.Errorf("MarkAllRead = %v, %v", marked, err)
+	}
+	if count, _ := hn.CountInbox(InboxRead); count != 3 {
+		t.Errorf("read blocks = %d, want 3", count)
+	}
+	if count, _ := rss.CountInbox(InboxUnread); count != 1 {
+		t.Errorf("unread rss blocks = %d, want 1", count)
+	}
+
+	if _, err := ParseInboxState("later"); err == nil {
+		t.Error("expected an error for an invalid state")
+	}
+}
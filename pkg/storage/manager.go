@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,6 +34,26 @@ func (e *PendingMigrationsError) Unwrap() error {
 	return ErrPendingMigrations
 }
 
+// PendingSchemaChangesError is returned when the database of a datasource
+// doesn't match its schema or tokenizer yet, and the changes are left to
+// 'ergs migrate'. It matches ErrPendingMigrations.
+type PendingSchemaChangesError struct {
+	Datasource string   // Name of the datasource with pending changes
+	Changes    []string // Description of each pending change
+}
+
+func (e *PendingSchemaChangesError) Error() string {
+	return fmt.Sprintf("database '%s' has pending schema changes (%s). Run 'ergs migrate' first", e.Datasource, strings.Join(e.Changes, ", "))
+}
+
+func (e *PendingSchemaChangesError) Is(target error) bool {
+	return target == ErrPendingMigrations
+}
+
+func (e *PendingSchemaChangesError) Unwrap() error {
+	return ErrPendingMigrations
+}
+
 // Manager manages storage operations across multiple datasources.
 // It provides a unified interface for creating, accessing, and searching
 // across multiple storage backends while handling migrations and maintaining
@@ -42,6 +63,8 @@ type Manager struct {
 	storages        map[string]*GenericStorage
 	blockPrototypes map[string]core.Block
 	searchService   *SearchService
+	globalIndex     *GlobalIndex
+	tokenizers      map[string]string
 	mu              sync.RWMutex
 
 	// Stats cache
@@ -59,6 +82,7 @@ func NewManager(storageDir string, datasources ...string) (*Manager, error) {
 		storageDir:      storageDir,
 		storages:        make(map[string]*GenericStorage),
 		blockPrototypes: make(map[string]core.Block),
+		tokenizers:      make(map[string]string),
 		statsCache:      make(map[string]interface{}),
 		statsCacheTTL:   5 * time.Minute,
 	}
@@ -88,6 +112,7 @@ func NewManagerWithoutMigrationCheck(storageDir string) *Manager {
 		storageDir:      storageDir,
 		storages:        make(map[string]*GenericStorage),
 		blockPrototypes: make(map[string]core.Block),
+		tokenizers:      make(map[string]string),
 		statsCache:      make(map[string]interface{}),
 		statsCacheTTL:   5 * time.Minute,
 	}
@@ -173,7 +198,8 @@ func (m *Manager) EnsureStorageWithMigrations(datasourceName string) (*GenericSt
 }
 
 // InitializeDatasourceStorage initializes storage for a datasource with the given schema.
-// It ensures migrations are applied and then initializes the storage schema.
+// It ensures migrations are applied and then initializes the storage schema and
+// FTS tokenizer (see SetTokenizer).
 func (m *Manager) InitializeDatasourceStorage(datasourceName string, schema map[string]any) error {
 	// Skip storage initialization if schema is nil or empty
 	// This allows datasources like "importer" to not create their own databases
@@ -186,7 +212,59 @@ func (m *Manager) InitializeDatasourceStorage(datasourceName string, schema map[
 		return err
 	}
 
-	return storage.InitializeSchema(schema)
+	if err := storage.InitializeSchema(schema); err != nil {
+		return err
+	}
+
+	return storage.ApplyTokenizer(m.Tokenizer(datasourceName))
+}
+
+// OpenDatasourceStorage opens the storage of a datasource for commands that
+// only read or delete blocks. New databases are initialized as
+// InitializeDatasourceStorage does, but existing ones are left as they are:
+// if the schema or tokenizer changed, adding indexes or rebuilding the
+// full-text index is left to 'ergs migrate' and the warehouse, and a
+// *PendingSchemaChangesError is returned.
+func (m *Manager) OpenDatasourceStorage(datasourceName string, schema map[string]any) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	dbPath := filepath.Join(m.storageDir, fmt.Sprintf("%s.db", datasourceName))
+	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+		return m.InitializeDatasourceStorage(datasourceName, schema)
+	}
+
+	storage, err := m.EnsureStorageWithMigrations(datasourceName)
+	if err != nil {
+		return err
+	}
+	return storage.LoadSchema(schema, m.Tokenizer(datasourceName))
+}
+
+// SetTokenizer sets the FTS5 tokenizer of a datasource. It is applied when the
+// datasource storage is initialized, rebuilding the full-text index if the
+// tokenizer changed. An empty tokenizer selects DefaultTokenizer.
+func (m *Manager) SetTokenizer(datasourceName, tokenizer string) error {
+	tokenizer, err := NormalizeTokenizer(tokenizer)
+	if err != nil {
+		return fmt.Errorf("datasource %s: %w", datasourceName, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokenizers[datasourceName] = tokenizer
+	return nil
+}
+
+// Tokenizer returns the FTS5 tokenizer configured for a datasource.
+func (m *Manager) Tokenizer(datasourceName string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if tokenizer, ok := m.tokenizers[datasourceName]; ok {
+		return tokenizer
+	}
+	return DefaultTokenizer
 }
 
 // RegisterBlockPrototype registers a block prototype for a specific datasource type.
@@ -209,6 +287,35 @@ func (m *Manager) GetDatasourceNames() []string {
 	return names
 }
 
+// OpenGlobalIndex opens the global search index (creating it if needed) and
+// uses it for searches once it has been built. See GlobalIndex.
+func (m *Manager) OpenGlobalIndex() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.globalIndex != nil {
+		return nil
+	}
+
+	dbPath := filepath.Join(m.storageDir, GlobalIndexFile)
+	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
+		return fmt.Errorf("creating global index directory: %w", err)
+	}
+	index, err := OpenGlobalIndex(dbPath)
+	if err != nil {
+		return err
+	}
+	m.globalIndex = index
+	return nil
+}
+
+// GlobalIndex returns the global search index, or nil if it hasn't been opened.
+func (m *Manager) GlobalIndex() *GlobalIndex {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.globalIndex
+}
+
 // GetSearchService returns the search service for external access.
 // The search service provides advanced search capabilities across all datasources.
 func (m *Manager) GetSearchService() *SearchService {
@@ -291,6 +398,28 @@ func (m *Manager) SearchAllDatasources() []string {
 	return datasourceNames
 }
 
+// filterDatasourcesByType returns the datasources whose registered block
+// prototype is of one of the given types.
+func (m *Manager) filterDatasourcesByType(datasourceNames []string, types []string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var filtered []string
+	for _, name := range datasourceNames {
+		prototype, exists := m.blockPrototypes[name]
+		if !exists {
+			continue
+		}
+		for _, t := range types {
+			if prototype.Type() == t {
+				filtered = append(filtered, name)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
 // SearchAllDatasourcesPaged searches all datasources with pagination support.
 // Returns a map of datasource names to their matching blocks.
 func (m *Manager) SearchAllDatasourcesPaged(query string, limit, page, pageSize int) (map[string][]core.Block, error) {
@@ -439,6 +568,13 @@ func (m *Manager) Close() error {
 
 	m.storages = make(map[string]*GenericStorage)
 
+	if m.globalIndex != nil {
+		if err := m.globalIndex.Close(); err != nil {
+			errors = append(errors, fmt.Errorf("closing global index: %w", err))
+		}
+		m.globalIndex = nil
+	}
+
 	if len(errors) > 0 {
 		return fmt.Errorf("errors closing storages: %v", errors)
 	}
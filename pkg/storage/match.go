@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ValidateQuery checks that a search query, including its operators, parses.
+// Field filters aren't checked against the datasource schemas.
+func ValidateQuery(query string) error {
+	params := SearchParams{Query: query}
+	if err := ApplyQueryOperators(&params); err != nil {
+		return err
+	}
+	_, err := parseSearchQuery(params.Query)
+	return err
+}
+
+// MatchBlocks returns the IDs of the stored blocks of datasource, among ids,
+// that a search with params would return. Query operators are applied on every
+// call, so relative dates (after:7d) move with time. Field filters on fields
+// the datasource doesn't have match nothing instead of failing.
+//
+// It is meant to check a few freshly stored blocks against saved searches:
+// the datasource's full-text index is only queried for the given blocks.
+func (s *SearchService) MatchBlocks(params SearchParams, datasource string, ids []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if err := ApplyQueryOperators(&params); err != nil {
+		return nil, err
+	}
+	if !slices.Contains(s.targetDatasources(params), datasource) {
+		return nil, nil
+	}
+	query, err := parseSearchQuery(params.Query)
+	if err != nil {
+		return nil, err
+	}
+	storage, err := s.manager.GetStorage(datasource)
+	if err != nil {
+		return nil, fmt.Errorf("getting storage for %s: %w", datasource, err)
+	}
+
+	q := storageQuery{
+		query:     query,
+		startDate: params.StartDate,
+		endDate:   params.EndDate,
+	}
+	conditions, args, ok, err := q.conditions(storage, datasource, "b.")
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	selectQuery := "SELECT b.id FROM blocks b"
+	if q.query.fts != "" {
+		selectQuery += " JOIN blocks_fts fts ON b.rowid = fts.rowid"
+		conditions = append([]string{"blocks_fts MATCH ?"}, conditions...)
+		args = append([]interface{}{escapeFTS5Query(q.query.fts)}, args...)
+	}
+	conditions = append(conditions, "b.id IN ("+strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")+")")
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	selectQuery += " WHERE " + strings.Join(conditions, " AND ")
+
+	rows, err := storage.db.Query(selectQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("matching blocks of %s: %w", datasource, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	var matched []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		matched = append(matched, id)
+	}
+	return matched, rows.Err()
+}
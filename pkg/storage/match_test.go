@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestMatchBlocks(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	if err := manager.InitializeDatasourceStorage("hn", map[string]any{"title": "TEXT", "score": "INTEGER"}); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	if err := manager.InitializeDatasourceStorage("rss", map[string]any{"title": "TEXT"}); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	hn, _ := manager.GetStorage("hn")
+	rss, _ := manager.GetStorage("rss")
+
+	now := time.Now()
+	if err := hn.StoreBlocks([]core.Block{
+		core.NewGenericBlock("story-1", "Show HN: ergs, a data warehouse", "hn", "hackernews", now, map[string]interface{}{"title": "ergs", "score": 150}),
+		core.NewGenericBlock("story-2", "ergs again", "hn", "hackernews", now, map[string]interface{}{"title": "ergs", "score": 10}),
+		core.NewGenericBlock("story-3", "Something else", "hn", "hackernews", now, map[string]interface{}{"title": "other", "score": 300}),
+		core.NewGenericBlock("story-4", "ergs last year", "hn", "hackernews", now.AddDate(-1, 0, 0), map[string]interface{}{"title": "ergs", "score": 200}),
+	}, "hackernews"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+	if err := rss.StoreBlocks([]core.Block{
+		core.NewGenericBlock("post-1", "ergs release notes", "rss", "rss", now, map[string]interface{}{"title": "ergs"}),
+	}, "rss"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+
+	search := manager.GetSearchService()
+	hnIDs := []string{"story-1", "story-2", "story-3", "story-4"}
+	tests := []struct {
+		query      string
+		datasource string
+		ids        []string
+		want       []string
+	}{
+		{"ergs", "hn", hnIDs, []string{"story-1", "story-2", "story-4"}},
+		{"ergs", "hn", []string{"story-2", "story-3"}, []string{"story-2"}},
+		{"ergs score:>100", "hn", hnIDs, []string{"story-1", "story-4"}},
+		{"ergs after:7d", "hn", hnIDs, []string{"story-1", "story-2"}},
+		{"ergs type:hackernews", "rss", []string{"post-1"}, nil},
+		{"ergs source:rss", "rss", []string{"post-1"}, []string{"post-1"}},
+		// rss has no score field
+		{"ergs score:>100", "rss", []string{"post-1"}, nil},
+		{"score:>100", "hn", hnIDs, []string{"story-1", "story-3", "story-4"}},
+	}
+	for _, tt := range tests {
+		got, err := search.MatchBlocks(SearchParams{Query: tt.query}, tt.datasource, tt.ids)
+		if err != nil {
+			t.Errorf("MatchBlocks(%q, %s) error: %v", tt.query, tt.datasource, err)
+			continue
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("MatchBlocks(%q, %s) = %v, want %v", tt.query, tt.datasource, got, tt.want)
+		}
+	}
+
+	if _, err := search.MatchBlocks(SearchParams{Query: "after:someday"}, "hn", hnIDs); err == nil {
+		t.Error("expected an error for an invalid operator")
+	}
+	if err := ValidateQuery("ergs type:hackernews stars:>10"); err != nil {
+		t.Errorf("ValidateQuery error: %v", err)
+	}
+	if err := ValidateQuery("tag:\"bad tag\""); err == nil {
+		t.Error("expected an error for an invalid tag")
+	}
+}
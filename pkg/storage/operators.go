@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ApplyQueryOperators extracts the meta operators from params.Query and maps
+// them onto the other search parameters:
+//
+//   - after:<when>  → StartDate (inclusive)
+//   - before:<when> → EndDate (exclusive)
+//   - on:<when>     → StartDate and EndDate spanning that whole day
+//   - source:<name> → DatasourceFilters (datasource instance name)
+//   - type:<type>   → DatasourceTypes (datasource type, e.g. hackernews)
+//
+// <when> is a date (2025-01-01), a relative time (12h, 7d, 2w) or one of
+// today and yesterday. Operators only ever narrow the search: dates narrow an
+// existing date range, and source: narrows the datasources already in
+// DatasourceFilters, naming one outside of them being an error. The
+// operators are removed from params.Query; the rest is searched as usual.
+func ApplyQueryOperators(params *SearchParams) error {
+	return applyQueryOperators(params, time.Now())
+}
+
+func applyQueryOperators(params *SearchParams, now time.Time) error {
+	selected := params.DatasourceFilters
+	var sources []string
+	query, err := extractTerms(params.Query, func(term string) (bool, error) {
+		name, value, ok := strings.Cut(term, ":")
+		if !ok {
+			return false, nil
+		}
+
+		switch name {
+		case "after", "before", "on":
+			at, day, err := parseDateOperator(value, now)
+			if err != nil {
+				return false, queryErrorf("invalid %s value %q: use a date (2006-01-02), a relative time (12h, 7d, 2w), today or yesterday", name, value)
+			}
+			switch name {
+			case "after":
+				narrowStartDate(params, at)
+			case "before":
+				narrowEndDate(params, at.Add(-time.Nanosecond))
+			case "on":
+				narrowStartDate(params, day)
+				narrowEndDate(params, day.AddDate(0, 0, 1).Add(-time.Nanosecond))
+			}
+		case "source":
+			// Wildcards, groups and quoted values remain FTS5 column filters
+			if !isValidDatasourceName(value) {
+				return false, nil
+			}
+			if len(selected) > 0 && !slices.Contains(selected, value) {
+				return false, queryErrorf("source:%s is not one of the selected datasources (%s)", value, strings.Join(selected, ", "))
+			}
+			sources = appendUnique(sources, value)
+		case "type":
+			if !isValidDatasourceName(value) {
+				return false, queryErrorf("invalid datasource type %q", value)
+			}
+			params.DatasourceTypes = appendUnique(params.DatasourceTypes, value)
+		default:
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	params.Query = query
+	if len(sources) > 0 {
+		params.DatasourceFilters = sources
+	}
+	return nil
+}
+
+// parseDateOperator resolves a date operator value to a point in time and the
+// start of the day containing it. Relative times are measured back from now.
+func parseDateOperator(value string, now time.Time) (time.Time, time.Time, error) {
+	var t time.Time
+	switch value {
+	case "today":
+		t = startOfDay(now)
+	case "yesterday":
+		t = startOfDay(now).AddDate(0, 0, -1)
+	default:
+		if d, err := parseRelativeDuration(value); err == nil {
+			t = now.Add(-d)
+		} else if parsed, err := time.Parse("2006-01-02", value); err == nil {
+			t = parsed
+		} else {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	return t.UTC(), startOfDay(t).UTC(), nil
+}
+
+// parseRelativeDuration parses durations such as 12h, 7d or 2w.
+func parseRelativeDuration(value string) (time.Duration, error) {
+	if len(value) < 2 {
+		return 0, strconv.ErrSyntax
+	}
+
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 0 {
+		return 0, strconv.ErrSyntax
+	}
+
+	switch value[len(value)-1] {
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	default:
+		return 0, strconv.ErrSyntax
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func narrowStartDate(params *SearchParams, t time.Time) {
+	if params.StartDate == nil || t.After(*params.StartDate) {
+		params.StartDate = &t
+	}
+}
+
+func narrowEndDate(params *SearchParams, t time.Time) {
+	if params.EndDate == nil || t.Before(*params.EndDate) {
+		params.EndDate = &t
+	}
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// searchCursor is the position of a block in the global search order
+// (created_at DESC, datasource ASC, id ASC). Results after a cursor are
+// fetched by seeking each datasource database to that position.
+type searchCursor struct {
+	CreatedAt  time.Time `json:"t"`
+	Datasource string    `json:"d"`
+	ID         string    `json:"i"`
+}
+
+// encode returns the opaque string form of the cursor handed out to clients.
+func (c *searchCursor) encode() string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeSearchCursor parses a cursor previously returned as SearchResults.NextCursor.
+func decodeSearchCursor(s string) (*searchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, queryErrorf("invalid cursor")
+	}
+	var c searchCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.CreatedAt.IsZero() || c.Datasource == "" {
+		return nil, queryErrorf("invalid cursor")
+	}
+	return &c, nil
+}
+
+// createdAtKey is the sort key of a creation time. It matches the way
+// created_at is stored (UTC RFC 3339 text), so results merged across
+// datasources are ordered exactly like each database orders them.
+func createdAtKey(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// seekCondition returns the condition selecting the blocks of a datasource
+// that come after the cursor in the global search order.
+func (c *searchCursor) seekCondition(datasource, tableAlias string) (string, []any) {
+	createdAt := createdAtKey(c.CreatedAt)
+	switch {
+	case datasource < c.Datasource:
+		// Blocks with the same created_at sort before the cursor's datasource
+		return tableAlias + "created_at < ?", []any{createdAt}
+	case datasource > c.Datasource:
+		return tableAlias + "created_at <= ?", []any{createdAt}
+	default:
+		return "(" + tableAlias + "created_at < ? OR (" + tableAlias + "created_at = ? AND " + tableAlias + "id > ?))",
+			[]any{createdAt, createdAt, c.ID}
+	}
+}
+
+// globalSeekCondition returns the condition selecting the global index entries
+// that come after the cursor in the global search order.
+func (c *searchCursor) globalSeekCondition(tableAlias string) (string, []any) {
+	createdAt := createdAtKey(c.CreatedAt)
+	return "(" + tableAlias + "created_at < ? OR (" + tableAlias + "created_at = ? AND (" +
+			tableAlias + "datasource > ? OR (" + tableAlias + "datasource = ? AND " + tableAlias + "block_id > ?))))",
+		[]any{createdAt, createdAt, c.Datasource, c.Datasource, c.ID}
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestCursorPagination(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	// Blocks share timestamps across and within datasources to exercise tie-breaking
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, ds := range []string{"dsA", "dsB", "dsC"} {
+		if err := manager.InitializeDatasourceStorage(ds, map[string]any{"title": "TEXT"}); err != nil {
+			t.Fatalf("InitializeDatasourceStorage error: %v", err)
+		}
+		st, err := manager.GetStorage(ds)
+		if err != nil {
+			t.Fatalf("GetStorage error: %v", err)
+		}
+		var blocks []core.Block
+		for i := 0; i < 12; i++ {
+			createdAt := base.Add(-time.Duration(i/3) * time.Minute)
+			blocks = append(blocks, core.NewGenericBlock(fmt.Sprintf("%s-%02d", ds, i), "cursor test block", ds, ds, createdAt, nil))
+		}
+		if err := st.StoreBlocks(blocks, ds); err != nil {
+			t.Fatalf("StoreBlocks error: %v", err)
+		}
+	}
+
+	search := manager.GetSearchService()
+	all, err := search.Search(SearchParams{Page: 1, Limit: 100})
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(all.Ordered) != 36 || all.NextCursor != "" {
+		t.Fatalf("expected 36 blocks and no next cursor, got %d and %q", len(all.Ordered), all.NextCursor)
+	}
+	var want []string
+	for _, block := range all.Ordered {
+		want = append(want, block.ID())
+	}
+
+	for _, query := range []string{"", "cursor"} {
+		var got []string
+		params := SearchParams{Query: query, Page: 1, Limit: 5}
+		for pages := 0; ; pages++ {
+			if pages > 10 {
+				t.Fatalf("query %q: cursor pagination did not terminate", query)
+			}
+			results, err := search.Search(params)
+			if err != nil {
+				t.Fatalf("Search error: %v", err)
+			}
+			for _, block := range results.Ordered {
+				got = append(got, block.ID())
+			}
+
+			// New blocks arriving between pages must not shift the results
+			if pages == 0 {
+				st, _ := manager.GetStorage("dsB")
+				if err := st.StoreBlock(core.NewGenericBlock("dsB-new", "cursor test block", "dsB", "dsB", time.Now(), nil), "dsB"); err != nil {
+					t.Fatalf("StoreBlock error: %v", err)
+				}
+			}
+
+			if results.NextCursor == "" {
+				if results.HasMore {
+					t.Fatal("expected a next cursor when more results are available")
+				}
+				break
+			}
+			params.Cursor = results.NextCursor
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("query %q: cursor pages = %v, want %v", query, got, want)
+		}
+
+		st, _ := manager.GetStorage("dsB")
+		if _, err := st.GetDB().Exec("DELETE FROM blocks WHERE id = 'dsB-new'"); err != nil {
+			t.Fatalf("deleting block: %v", err)
+		}
+	}
+
+	var queryErr *QueryError
+	if _, err := search.Search(SearchParams{Page: 1, Limit: 5, Cursor: "not-a-cursor"}); !errors.As(err, &queryErr) {
+		t.Errorf("expected QueryError for invalid cursor, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PurgeResult counts the blocks matched (or deleted) by a purge in each datasource.
+type PurgeResult struct {
+	Datasources map[string]int
+	Total       int
+	DryRun      bool
+}
+
+// Purge deletes the blocks matching params, the same blocks Search would return
+// for them across every page. Query, datasource, type and date filters apply;
+// pagination and facets are ignored, and since or a cursor are an error. With
+// dryRun the matches are only counted.
+//
+// The query operators are resolved here, so params.DatasourceFilters are the
+// datasources explicitly selected for deletion, and source: can only narrow
+// them. Operators alone don't select blocks to delete: once they are
+// extracted, a query, a date range or an explicit datasource must be left, so
+// a request like "source:github" can't wipe a datasource by accident.
+//
+// Blocks are deleted in batches, each in its own transaction, so the FTS
+// triggers keep blocks_fts in sync. Deleted blocks are also removed from the
+// global index, and their annotations deleted.
+func (s *SearchService) Purge(params SearchParams, dryRun bool) (*PurgeResult, error) {
+	// Purge has no since or cursor condition, ignoring them would delete more
+	// than asked for
+	if params.Since != nil || params.Cursor != "" {
+		return nil, queryErrorf("purge doesn't support since or cursor")
+	}
+	selected := append([]string(nil), params.DatasourceFilters...)
+	if err := ApplyQueryOperators(&params); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(params.Query) == "" && params.StartDate == nil && params.EndDate == nil && len(selected) == 0 {
+		return nil, queryErrorf("purge needs a query, a date range or a datasource")
+	}
+
+	datasources := s.targetDatasources(params)
+	if len(selected) > 0 {
+		for _, name := range datasources {
+			if !slices.Contains(selected, name) {
+				return nil, queryErrorf("purge would delete blocks of %s, which is not one of the selected datasources", name)
+			}
+		}
+	}
+
+	result := &PurgeResult{Datasources: make(map[string]int), DryRun: dryRun}
+	if len(datasources) == 0 {
+		return result, nil
+	}
+	query, err := s.parseQuery(params.Query, datasources)
+	if err != nil {
+		return nil, err
+	}
+	q := storageQuery{
+		query:     query,
+		startDate: params.StartDate,
+		endDate:   params.EndDate,
+	}
+
+	for _, name := range datasources {
+		storage, err := s.manager.GetStorage(name)
+		if err != nil {
+			return result, fmt.Errorf("getting storage for %s: %w", name, err)
+		}
+		n, ids, err := purgeStorage(storage, name, q, dryRun)
+		if err != nil {
+			return result, fmt.Errorf("purging %s: %w", name, err)
+		}
+		if n == 0 {
+			continue
+		}
+		result.Datasources[name] = n
+		result.Total += n
+
+		if index := s.manager.GlobalIndex(); index != nil && !dryRun {
+			if err := index.RemoveBlocks(name, ids); err != nil {
+				return result, fmt.Errorf("removing purged blocks of %s from the global index: %w", name, err)
+			}
+		}
+	}
+
+	if result.Total > 0 && !dryRun {
+		s.manager.InvalidateStatsCache()
+	}
+	return result, nil
+}
+
+// purgeStorage counts or deletes the blocks of a datasource database matching q,
+// returning the IDs of the deleted blocks.
+func purgeStorage(storage *GenericStorage, datasource string, q storageQuery, dryRun bool) (int, []string, error) {
+	conditions, args, ok, err := q.conditions(storage, datasource, "b.")
+	if err != nil || !ok {
+		return 0, nil, err
+	}
+
+	selectQuery := "SELECT b.rowid FROM blocks b"
+	if q.query.fts != "" {
+		selectQuery += " JOIN blocks_fts fts ON b.rowid = fts.rowid"
+		conditions = append([]string{"blocks_fts MATCH ?"}, conditions...)
+		args = append([]interface{}{escapeFTS5Query(q.query.fts)}, args...)
+	}
+	if len(conditions) > 0 {
+		selectQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	if dryRun {
+		var count int
+		err := storage.db.QueryRow("SELECT COUNT(*) FROM ("+selectQuery+")", args...).Scan(&count)
+		return count, nil, err
+	}
+
+	var ids []string
+	n, err := storage.deleteInBatches(&ids, selectQuery+" LIMIT ?", append(args, pruneBatchSize)...)
+	// Purged blocks are gone for good, their tags and notes with them
+	if annErr := storage.deleteAnnotations(ids); err == nil {
+		err = annErr
+	}
+	return n, ids, err
+}
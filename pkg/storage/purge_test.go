@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestPurge(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	schema := map[string]any{"url": "TEXT", "title": "TEXT"}
+	for _, name := range []string{"firefox", "chrome"} {
+		if err := manager.InitializeDatasourceStorage(name, schema); err != nil {
+			t.Fatalf("InitializeDatasourceStorage error: %v", err)
+		}
+	}
+
+	// Ten bank visits and ten news visits per browser, one a day in January 2024
+	for _, name := range []string{"firefox", "chrome"} {
+		storage, err := manager.GetStorage(name)
+		if err != nil {
+			t.Fatalf("GetStorage error: %v", err)
+		}
+		var blocks []core.Block
+		for day := 1; day <= 10; day++ {
+			createdAt := time.Date(2024, 1, day, 12, 0, 0, 0, time.UTC)
+			blocks = append(blocks,
+				core.NewGenericBlock(fmt.Sprintf("bank-%d", day), "online bank login", "https://bank.example", name, createdAt,
+					map[string]interface{}{"url": "https://bank.example", "title": "My Bank"}),
+				core.NewGenericBlock(fmt.Sprintf("news-%d", day), "daily news", "https://news.example", name, createdAt,
+					map[string]interface{}{"url": "https://news.example", "title": "News"}),
+			)
+		}
+		if err := storage.StoreBlocks(blocks, name); err != nil {
+			t.Fatalf("StoreBlocks error: %v", err)
+		}
+	}
+
+	search := manager.GetSearchService()
+	count := func(params SearchParams) int {
+		t.Helper()
+		params.Page, params.Limit = 1, 100
+		results, err := search.Search(params)
+		if err != nil {
+			t.Fatalf("Search error: %v", err)
+		}
+		return len(results.Ordered)
+	}
+
+	before := time.Date(2024, 1, 5, 23, 59, 59, 0, time.UTC)
+	params := SearchParams{Query: "bank", DatasourceFilters: []string{"firefox"}, EndDate: &before}
+	result, err := search.Purge(params, true)
+	if err != nil {
+		t.Fatalf("Purge dry run error: %v", err)
+	}
+	if !result.DryRun || result.Total != 5 || result.Datasources["firefox"] != 5 {
+		t.Errorf("dry run result = %+v", result)
+	}
+	if got := count(SearchParams{Query: "bank"}); got != 20 {
+		t.Fatalf("dry run deleted blocks, %d bank visits left", got)
+	}
+
+	result, err = search.Purge(params, false)
+	if err != nil {
+		t.Fatalf("Purge error: %v", err)
+	}
+	if result.Total != 5 || len(result.Datasources) != 1 {
+		t.Errorf("Purge result = %+v", result)
+	}
+	if got := count(SearchParams{Query: "bank"}); got != 15 {
+		t.Errorf("expected 15 bank visits left, got %d", got)
+	}
+	if got := count(SearchParams{Query: "news"}); got != 20 {
+		t.Errorf("expected the news visits to be kept, got %d", got)
+	}
+
+	// Field filters match like they do in searches
+	result, err = search.Purge(SearchParams{Query: `title:"My Bank"`}, false)
+	if err != nil {
+		t.Fatalf("Purge error: %v", err)
+	}
+	if result.Datasources["firefox"] != 5 || result.Datasources["chrome"] != 10 {
+		t.Errorf("Purge result = %+v", result)
+	}
+	if got := count(SearchParams{}); got != 20 {
+		t.Errorf("expected 20 blocks left, got %d", got)
+	}
+
+	for _, name := range []string{"firefox", "chrome"} {
+		storage, _ := manager.GetStorage(name)
+		if err := storage.FTSIntegrityCheck(); err != nil {
+			t.Errorf("%s FTSIntegrityCheck error: %v", name, err)
+		}
+	}
+
+	var queryErr *QueryError
+	if _, err := search.Purge(SearchParams{}, false); !errors.As(err, &queryErr) {
+		t.Errorf("expected a query error without filters, got %v", err)
+	}
+	if _, err := search.Purge(SearchParams{Query: "site:bank"}, false); !errors.As(err, &queryErr) {
+		t.Errorf("expected a query error for an unknown field, got %v", err)
+	}
+
+	// Operators never widen the explicit datasources, nor select blocks to
+	// delete on their own. Since and cursors have no purge condition.
+	since := time.Now().Add(24 * time.Hour)
+	for _, params := range []SearchParams{
+		{DatasourceFilters: []string{"firefox"}, Since: &since},
+		{DatasourceFilters: []string{"firefox"}, Cursor: "abc"},
+		{Query: "source:chrome", DatasourceFilters: []string{"firefox"}},
+		{Query: "source:chrome"},
+		{Query: "type:chrome"},
+	} {
+		if _, err := search.Purge(params, false); !errors.As(err, &queryErr) {
+			t.Errorf("expected a query error purging %+v, got %v", params, err)
+		}
+	}
+	if got := count(SearchParams{}); got != 20 {
+		t.Errorf("expected the refused purges to keep every block, got %d", got)
+	}
+	result, err = search.Purge(SearchParams{Query: "source:firefox news", DatasourceFilters: []string{"firefox", "chrome"}}, true)
+	if err != nil {
+		t.Fatalf("Purge error: %v", err)
+	}
+	if result.Total != 10 || result.Datasources["firefox"] != 10 {
+		t.Errorf("expected source: to narrow the purge to firefox, got %+v", result)
+	}
+}
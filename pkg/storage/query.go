@@ -0,0 +1,345 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ftsColumns are the blocks_fts columns. name:value terms using one of these
+// names are FTS5 column filters and are passed through to MATCH untouched.
+var ftsColumns = map[string]bool{
+	"text":       true,
+	"source":     true,
+	"datasource": true,
+	"metadata":   true,
+	"hostname":   true,
+}
+
+// QueryError reports an invalid search query, such as a filter on an unknown
+// field or a malformed filter value.
+type QueryError struct {
+	Msg string
+}
+
+func (e *QueryError) Error() string {
+	return "invalid search query: " + e.Msg
+}
+
+func queryErrorf(format string, args ...any) *QueryError {
+	return &QueryError{Msg: fmt.Sprintf(format, args...)}
+}
+
+// fieldFilter is a field-qualified term (e.g. stars:>100) that filters on a
+// datasource Schema() field instead of being matched by FTS5.
+type fieldFilter struct {
+	field string
+	op    string // =, >, >=, <, <=
+	value string
+}
+
+// parsedQuery is a search query split into its FTS5 part, its field filters
+// and its tag:name terms.
+type parsedQuery struct {
+	fts     string
+	filters []fieldFilter
+	tags    []string
+}
+
+// queryToken is a top-level token of a search query, with its byte span in
+// the original query so unconsumed parts are passed to FTS5 verbatim.
+type queryToken struct {
+	text       string
+	start, end int
+	depth      int
+}
+
+// tokenizeQuery splits a query into whitespace separated tokens, keeping
+// double-quoted strings together and emitting parentheses as their own tokens.
+func tokenizeQuery(query string) []queryToken {
+	var tokens []queryToken
+	depth := 0
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, queryToken{text: "(", start: i, end: i + 1, depth: depth})
+			depth++
+			i++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+			tokens = append(tokens, queryToken{text: ")", start: i, end: i + 1, depth: depth})
+			i++
+		default:
+			start := i
+			for i < len(query) {
+				c = query[i]
+				if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '(' || c == ')' {
+					break
+				}
+				if c == '"' {
+					// Consume the quoted string, "" being an escaped quote
+					i++
+					for i < len(query) {
+						if query[i] == '"' {
+							if i+1 < len(query) && query[i+1] == '"' {
+								i += 2
+								continue
+							}
+							break
+						}
+						i++
+					}
+				}
+				i++
+			}
+			if i > len(query) {
+				i = len(query)
+			}
+			tokens = append(tokens, queryToken{text: query[start:i], start: start, end: i, depth: depth})
+		}
+	}
+	return tokens
+}
+
+// parseFieldFilter parses a name:value token into a field filter. Returns false
+// for tokens that are plain FTS5 terms or FTS5 column filters.
+func parseFieldFilter(token string) (fieldFilter, bool) {
+	name, value, ok := strings.Cut(token, ":")
+	if !ok || value == "" || !isValidFieldName(name) || ftsColumns[strings.ToLower(name)] {
+		return fieldFilter{}, false
+	}
+
+	op := "="
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(value, candidate) {
+			op = candidate
+			value = value[len(candidate):]
+			break
+		}
+	}
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = strings.ReplaceAll(value[1:len(value)-1], `""`, `"`)
+	}
+
+	return fieldFilter{field: name, op: op, value: value}, true
+}
+
+// parseSearchQuery extracts top-level field filters (name:value, name:>value, ...)
+// and tags (tag:name) from a search query. Everything else, including FTS5
+// column filters such as datasource:github, is kept as the FTS5 query. Field
+// filters and tags are always ANDed with the rest of the query.
+func parseSearchQuery(query string) (*parsedQuery, error) {
+	parsed := &parsedQuery{}
+	fts, err := extractTerms(query, func(term string) (bool, error) {
+		if value, ok := strings.CutPrefix(term, "tag:"); ok {
+			tag, err := NormalizeTag(strings.Trim(value, `"`))
+			if err != nil {
+				return false, queryErrorf("%v", err)
+			}
+			parsed.tags = appendUnique(parsed.tags, tag)
+			return true, nil
+		}
+		filter, ok := parseFieldFilter(term)
+		if !ok {
+			return false, nil
+		}
+		if filter.value == "" {
+			return false, queryErrorf("missing value for field %s", filter.field)
+		}
+		parsed.filters = append(parsed.filters, filter)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	parsed.fts = fts
+	return parsed, nil
+}
+
+// extractTerms removes the top-level terms consumed by extract from a query,
+// together with the AND operator joining them to the rest of the query, and
+// returns what is left verbatim. Consumed terms can't be combined using OR/NOT.
+func extractTerms(query string, extract func(term string) (bool, error)) (string, error) {
+	tokens := tokenizeQuery(query)
+	removed := make([]bool, len(tokens))
+	extracted := false
+
+	isOp := func(i int, ops ...string) bool {
+		if i < 0 || i >= len(tokens) || removed[i] || tokens[i].depth != 0 {
+			return false
+		}
+		for _, op := range ops {
+			if tokens[i].text == op {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i, token := range tokens {
+		if token.depth != 0 {
+			continue
+		}
+		ok, err := extract(token.text)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+		if isOp(i-1, "OR", "NOT") || isOp(i+1, "OR", "NOT") {
+			return "", queryErrorf("%s can only be combined with other terms using AND", token.text)
+		}
+
+		extracted = true
+		removed[i] = true
+		if isOp(i-1, "AND") {
+			removed[i-1] = true
+		} else if isOp(i+1, "AND") {
+			removed[i+1] = true
+		}
+	}
+
+	if !extracted {
+		return query, nil
+	}
+
+	// Keep the remaining parts of the query verbatim
+	var parts []string
+	prev := 0
+	for i, token := range tokens {
+		if !removed[i] {
+			continue
+		}
+		if part := strings.TrimSpace(query[prev:token.start]); part != "" {
+			parts = append(parts, part)
+		}
+		prev = token.end
+	}
+	if part := strings.TrimSpace(query[prev:]); part != "" {
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// validateFieldFilters checks that every filtered field is declared in the
+// Schema() of at least one of the given storages.
+func validateFieldFilters(filters []fieldFilter, storages []*GenericStorage) error {
+	known := make(map[string]bool)
+	for _, st := range storages {
+		for _, field := range st.SchemaFields() {
+			known[field.Name] = true
+		}
+	}
+
+	for _, filter := range filters {
+		if known[filter.field] {
+			continue
+		}
+		names := make([]string, 0, len(known))
+		for name := range known {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return queryErrorf("unknown field %q: the searched datasources declare no fields", filter.field)
+		}
+		return queryErrorf("unknown field %q (available fields: %s)", filter.field, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// fieldConditions translates field filters into SQL conditions over the typed
+// field columns of a storage. Returns false if the storage does not declare one
+// of the filtered fields, in which case none of its blocks can match.
+func fieldConditions(storage *GenericStorage, filters []fieldFilter, tableAlias string) ([]string, []any, bool, error) {
+	if len(filters) == 0 {
+		return nil, nil, true, nil
+	}
+
+	schema := make(map[string]SchemaField)
+	for _, field := range storage.SchemaFields() {
+		schema[field.Name] = field
+	}
+
+	var conditions []string
+	var args []any
+	for _, filter := range filters {
+		field, ok := schema[filter.field]
+		if !ok {
+			return nil, nil, false, nil
+		}
+		column := tableAlias + field.Column
+
+		switch field.Type {
+		case "INTEGER", "REAL", "NUMERIC":
+			value, err := parseNumericFilterValue(filter.value)
+			if err != nil {
+				return nil, nil, false, queryErrorf("field %s expects a number, got %q", filter.field, filter.value)
+			}
+			conditions = append(conditions, fmt.Sprintf("%s %s ?", column, filter.op))
+			args = append(args, value)
+		case "TEXT":
+			if filter.op == "=" && strings.HasSuffix(filter.value, "*") {
+				conditions = append(conditions, fmt.Sprintf("%s LIKE ? ESCAPE '\\'", column))
+				args = append(args, escapeLike(strings.TrimSuffix(filter.value, "*"))+"%")
+			} else if filter.op == "=" {
+				conditions = append(conditions, fmt.Sprintf("%s = ? COLLATE NOCASE", column))
+				args = append(args, filter.value)
+			} else {
+				conditions = append(conditions, fmt.Sprintf("%s %s ?", column, filter.op))
+				args = append(args, filter.value)
+			}
+		default:
+			return nil, nil, false, queryErrorf("field %s of type %s cannot be filtered", filter.field, field.Type)
+		}
+	}
+
+	return conditions, args, true, nil
+}
+
+// tagConditions translates tags into SQL conditions matching the blocks having
+// every tag, either as a user tag (block_tags) or in the tags metadata field
+// set by tag processors. Columns are prefixed with tableAlias.
+func tagConditions(tags []string, tableAlias string) ([]string, []any) {
+	if tableAlias == "" {
+		tableAlias = "blocks."
+	}
+	var conditions []string
+	var args []any
+	for _, tag := range tags {
+		conditions = append(conditions, fmt.Sprintf(
+			"(%[1]sid IN (SELECT block_id FROM block_tags WHERE tag = ?) OR "+
+				"EXISTS (SELECT 1 FROM json_each(%[1]smetadata, '$.tags') WHERE lower(json_each.value) = ?))",
+			tableAlias))
+		args = append(args, tag, tag)
+	}
+	return conditions, args
+}
+
+// parseNumericFilterValue parses a numeric filter value. Booleans are accepted
+// since BOOLEAN schema fields are stored as integers.
+func parseNumericFilterValue(value string) (any, error) {
+	switch strings.ToLower(value) {
+	case "true":
+		return int64(1), nil
+	case "false":
+		return int64(0), nil
+	}
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return i, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// escapeLike escapes LIKE wildcards so they match literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		fts     string
+		filters []fieldFilter
+	}{
+		{query: "golang", fts: "golang"},
+		{query: "datasource:github text:golang", fts: "datasource:github text:golang"},
+		{query: `"datasource":github`, fts: `"datasource":github`},
+		{query: "(type:repo OR type:issue)", fts: "(type:repo OR type:issue)"},
+		{
+			query:   "repo_name:rubiojr/ergs stars:>100 language:go",
+			filters: []fieldFilter{{"repo_name", "=", "rubiojr/ergs"}, {"stars", ">", "100"}, {"language", "=", "go"}},
+		},
+		{
+			query:   "fuel consumption:>=2.5",
+			fts:     "fuel",
+			filters: []fieldFilter{{"consumption", ">=", "2.5"}},
+		},
+		{
+			query:   `golang AND title:"hello world" AND (web OR api)`,
+			fts:     "golang AND (web OR api)",
+			filters: []fieldFilter{{"title", "=", "hello world"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			parsed, err := parseSearchQuery(tt.query)
+			if err != nil {
+				t.Fatalf("parseSearchQuery(%q) error: %v", tt.query, err)
+			}
+			if parsed.fts != tt.fts {
+				t.Errorf("fts = %q, want %q", parsed.fts, tt.fts)
+			}
+			if !reflect.DeepEqual(parsed.filters, tt.filters) {
+				t.Errorf("filters = %v, want %v", parsed.filters, tt.filters)
+			}
+		})
+	}
+
+	for _, query := range []string{"stars:>", "golang OR stars:>1", "NOT stars:1"} {
+		if _, err := parseSearchQuery(query); err == nil {
+			t.Errorf("expected error for query %q", query)
+		}
+	}
+}
+
+func TestSearchFieldFilters(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	if err := manager.InitializeDatasourceStorage("github", map[string]any{
+		"repo_name": "TEXT",
+		"stars":     "INTEGER",
+		"language":  "TEXT",
+	}); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	if err := manager.InitializeDatasourceStorage("notes", map[string]any{
+		"title": "TEXT",
+	}); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+
+	st, err := manager.GetStorage("github")
+	if err != nil {
+		t.Fatalf("GetStorage error: %v", err)
+	}
+	now := time.Now().UTC()
+	blocks := []core.Block{
+		core.NewGenericBlock("1", "ergs personal data warehouse", "github", "github", now, map[string]interface{}{"repo_name": "rubiojr/ergs", "stars": 150, "language": "Go"}),
+		core.NewGenericBlock("2", "another go project", "github", "github", now.Add(-time.Hour), map[string]interface{}{"repo_name": "rubiojr/other", "stars": 20, "language": "Go"}),
+		core.NewGenericBlock("3", "a rust project", "github", "github", now.Add(-2*time.Hour), map[string]interface{}{"repo_name": "someone/rusty", "stars": 500, "language": "Rust"}),
+	}
+	if err := st.StoreBlocks(blocks, "github"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+
+	notes, err := manager.GetStorage("notes")
+	if err != nil {
+		t.Fatalf("GetStorage error: %v", err)
+	}
+	if err := notes.StoreBlock(core.NewGenericBlock("n1", "go project notes", "notes", "notes", now, map[string]interface{}{"title": "go"}), "notes"); err != nil {
+		t.Fatalf("StoreBlock error: %v", err)
+	}
+
+	search := manager.GetSearchService()
+	tests := []struct {
+		query string
+		ids   []string
+	}{
+		{query: "stars:>100", ids: []string{"1", "3"}},
+		{query: "stars:>100 language:go", ids: []string{"1"}},
+		{query: "repo_name:rubiojr/ergs", ids: []string{"1"}},
+		{query: "repo_name:rubiojr/*", ids: []string{"1", "2"}},
+		{query: "project language:go", ids: []string{"2"}},
+		{query: "project AND stars:<=500", ids: []string{"2", "3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			results, err := search.Search(SearchParams{Query: tt.query, Page: 1, Limit: 10})
+			if err != nil {
+				t.Fatalf("Search(%q) error: %v", tt.query, err)
+			}
+			var ids []string
+			for _, block := range results.Ordered {
+				ids = append(ids, block.ID())
+			}
+			if !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, ids, tt.ids)
+			}
+		})
+	}
+
+	var queryErr *QueryError
+	_, err = search.Search(SearchParams{Query: "golang nope:1", Page: 1, Limit: 10})
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("expected QueryError for unknown field, got %v", err)
+	}
+	if !contains(queryErr.Msg, `unknown field "nope"`) {
+		t.Errorf("unexpected error message: %s", queryErr.Msg)
+	}
+
+	_, err = search.Search(SearchParams{Query: "stars:many", Page: 1, Limit: 10})
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("expected QueryError for non-numeric value, got %v", err)
+	}
+}
+
+func TestApplyQueryOperators(t *testing.T) {
+	now := time.Date(2025, 3, 10, 15, 30, 0, 0, time.UTC)
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	endOf := func(s string) time.Time {
+		return day(s).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	tests := []struct {
+		query   string
+		want    string
+		start   *time.Time
+		end     *time.Time
+		sources []string
+		types   []string
+	}{
+		{query: "golang", want: "golang"},
+		{query: "golang after:7d", want: "golang", start: ptr(now.Add(-7 * 24 * time.Hour))},
+		{query: "before:2025-01-01 golang", want: "golang", end: ptr(day("2025-01-01").Add(-time.Nanosecond))},
+		{query: "on:yesterday", start: ptr(day("2025-03-09")), end: ptr(endOf("2025-03-09"))},
+		{query: "after:2025-01-01 after:today", start: ptr(day("2025-03-10"))},
+		{query: "rust AND source:work_github AND type:hackernews", want: "rust", sources: []string{"work_github"}, types: []string{"hackernews"}},
+		{query: "source:git* text:golang", want: "source:git* text:golang"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			params := SearchParams{Query: tt.query}
+			if err := applyQueryOperators(&params, now); err != nil {
+				t.Fatalf("applyQueryOperators(%q) error: %v", tt.query, err)
+			}
+			if params.Query != tt.want {
+				t.Errorf("Query = %q, want %q", params.Query, tt.want)
+			}
+			if !reflect.DeepEqual(params.StartDate, tt.start) {
+				t.Errorf("StartDate = %v, want %v", params.StartDate, tt.start)
+			}
+			if !reflect.DeepEqual(params.EndDate, tt.end) {
+				t.Errorf("EndDate = %v, want %v", params.EndDate, tt.end)
+			}
+			if !reflect.DeepEqual(params.DatasourceFilters, tt.sources) {
+				t.Errorf("DatasourceFilters = %v, want %v", params.DatasourceFilters, tt.sources)
+			}
+			if !reflect.DeepEqual(params.DatasourceTypes, tt.types) {
+				t.Errorf("DatasourceTypes = %v, want %v", params.DatasourceTypes, tt.types)
+			}
+		})
+	}
+
+	for _, query := range []string{"after:tomorrow", "before:", "golang OR on:today"} {
+		params := SearchParams{Query: query}
+		if err := applyQueryOperators(&params, now); err == nil {
+			t.Errorf("expected error for query %q", query)
+		}
+	}
+
+	// source: narrows explicitly selected datasources, never widens them
+	params := SearchParams{Query: "golang source:hn", DatasourceFilters: []string{"hn", "firefox"}}
+	if err := applyQueryOperators(&params, now); err != nil {
+		t.Fatalf("applyQueryOperators error: %v", err)
+	}
+	if !reflect.DeepEqual(params.DatasourceFilters, []string{"hn"}) {
+		t.Errorf("DatasourceFilters = %v, want [hn]", params.DatasourceFilters)
+	}
+	params = SearchParams{Query: "golang source:github", DatasourceFilters: []string{"firefox"}}
+	var queryErr *QueryError
+	if err := applyQueryOperators(&params, now); !errors.As(err, &queryErr) {
+		t.Errorf("expected a query error for a source outside the selection, got %v", err)
+	}
+}
+
+func TestSearchTypeOperator(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	now := time.Now().UTC()
+	for _, name := range []string{"hn", "work_github"} {
+		if err := manager.InitializeDatasourceStorage(name, map[string]any{"title": "TEXT"}); err != nil {
+			t.Fatalf("InitializeDatasourceStorage error: %v", err)
+		}
+		st, err := manager.GetStorage(name)
+		if err != nil {
+			t.Fatalf("GetStorage error: %v", err)
+		}
+		if err := st.StoreBlock(core.NewGenericBlock(name+"-1", "golang news", name, name, now, nil), name); err != nil {
+			t.Fatalf("StoreBlock error: %v", err)
+		}
+	}
+	manager.RegisterBlockPrototype("hn", core.NewGenericBlock("", "", "", "hackernews", now, nil))
+	manager.RegisterBlockPrototype("work_github", core.NewGenericBlock("", "", "", "github", now, nil))
+
+	params, err := ParseSearchParams(map[string][]string{"q": {"golang type:hackernews"}})
+	if err != nil {
+		t.Fatalf("ParseSearchParams error: %v", err)
+	}
+	results, err := manager.GetSearchService().Search(params)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(results.Ordered) != 1 || results.Ordered[0].ID() != "hn-1" {
+		t.Errorf("expected only hn-1, got %v", results.Ordered)
+	}
+
+	// The datasource parameter and source: combine as an intersection
+	params, err = ParseSearchParams(map[string][]string{"q": {"golang source:work_github"}, "datasource": {"hn", "work_github"}})
+	if err != nil {
+		t.Fatalf("ParseSearchParams error: %v", err)
+	}
+	results, err = manager.GetSearchService().Search(params)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(results.Ordered) != 1 || results.Ordered[0].ID() != "work_github-1" {
+		t.Errorf("expected only work_github-1, got %v", results.Ordered)
+	}
+	_, err = ParseSearchParams(map[string][]string{"q": {"golang source:work_github"}, "datasource": {"hn"}})
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) {
+		t.Errorf("expected a query error for source: outside the datasource parameter, got %v", err)
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
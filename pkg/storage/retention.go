@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// pruneBatchSize is the number of blocks deleted per transaction when pruning,
+// so large prunes don't hold the write lock for long.
+const pruneBatchSize = 1000
+
+// Keys of the pruning history in the fetch_metadata table.
+const (
+	prunedTotalKey = "retention:pruned_total"
+	lastPrunedKey  = "retention:last_pruned"
+)
+
+// RetentionPolicy limits how many blocks a datasource keeps. Zero values disable
+// the corresponding rule.
+type RetentionPolicy struct {
+	// MaxAge deletes blocks created longer ago than this.
+	MaxAge time.Duration
+
+	// MaxBlocks keeps only the newest MaxBlocks blocks.
+	MaxBlocks int
+
+	// DownsampleAfter keeps only the newest block of each day (UTC) for blocks
+	// created longer ago than this.
+	DownsampleAfter time.Duration
+}
+
+// IsZero reports whether the policy keeps every block.
+func (p RetentionPolicy) IsZero() bool {
+	return p.MaxAge <= 0 && p.MaxBlocks <= 0 && p.DownsampleAfter <= 0
+}
+
+// Validate checks the policy for negative limits.
+func (p RetentionPolicy) Validate() error {
+	if p.MaxAge < 0 {
+		return fmt.Errorf("max_age must not be negative")
+	}
+	if p.MaxBlocks < 0 {
+		return fmt.Errorf("max_blocks must not be negative")
+	}
+	if p.DownsampleAfter < 0 {
+		return fmt.Errorf("downsample_after must not be negative")
+	}
+	return nil
+}
+
+// PruneResult counts the blocks deleted by each retention rule.
+type PruneResult struct {
+	Expired     int // older than MaxAge
+	Downsampled int // not the newest block of their day
+	OverLimit   int // beyond MaxBlocks
+
+	// IDs of every deleted block
+	IDs []string
+}
+
+// Total returns the number of deleted blocks.
+func (r PruneResult) Total() int {
+	return r.Expired + r.Downsampled + r.OverLimit
+}
+
+// Prune deletes the blocks not kept by the policy, relative to now. Blocks are
+// deleted in batches, each in its own transaction; the FTS triggers keep
+// blocks_fts in sync. The number of pruned blocks is recorded for GetStats.
+func (s *GenericStorage) Prune(policy RetentionPolicy, now time.Time) (PruneResult, error) {
+	var result PruneResult
+	if err := policy.Validate(); err != nil {
+		return result, err
+	}
+
+	var err error
+	if policy.MaxAge > 0 {
+		cutoff := createdAtKey(now.Add(-policy.MaxAge))
+		result.Expired, err = s.deleteInBatches(&result.IDs,
+			"SELECT rowid FROM blocks WHERE created_at < ? LIMIT ?", cutoff, pruneBatchSize)
+		if err != nil {
+			return result, fmt.Errorf("deleting expired blocks: %w", err)
+		}
+	}
+
+	if policy.DownsampleAfter > 0 {
+		cutoff := createdAtKey(now.Add(-policy.DownsampleAfter))
+		result.Downsampled, err = s.deleteInBatches(&result.IDs, `
+			SELECT rowid FROM (
+				SELECT rowid, ROW_NUMBER() OVER (PARTITION BY date(created_at) ORDER BY created_at DESC, id DESC) AS n
+				FROM blocks WHERE created_at < ?
+			) WHERE n > 1 LIMIT ?`, cutoff, pruneBatchSize)
+		if err != nil {
+			return result, fmt.Errorf("downsampling blocks: %w", err)
+		}
+	}
+
+	if policy.MaxBlocks > 0 {
+		result.OverLimit, err = s.deleteInBatches(&result.IDs,
+			"SELECT rowid FROM blocks ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?", pruneBatchSize, policy.MaxBlocks)
+		if err != nil {
+			return result, fmt.Errorf("deleting blocks over the limit: %w", err)
+		}
+	}
+
+	if result.Total() > 0 {
+		if err := s.recordPruned(result.Total(), now); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// deleteInBatches repeatedly deletes the blocks whose rowids are selected by
+// selectQuery (limited to a batch) until it selects none, appending the deleted
+// block IDs to ids.
+func (s *GenericStorage) deleteInBatches(ids *[]string, selectQuery string, args ...any) (int, error) {
+	deleted := 0
+	for {
+		rows, err := s.db.Query("DELETE FROM blocks WHERE rowid IN ("+selectQuery+") RETURNING id", args...)
+		if err != nil {
+			return deleted, err
+		}
+		n := 0
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				_ = rows.Close()
+				return deleted, fmt.Errorf("scanning deleted block: %w", err)
+			}
+			*ids = append(*ids, id)
+			n++
+		}
+		if err := rows.Close(); err != nil {
+			return deleted, err
+		}
+		deleted += n
+		if n == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// recordPruned adds n to the pruning history of the storage.
+func (s *GenericStorage) recordPruned(n int, at time.Time) error {
+	total, _, err := s.prunedStats()
+	if err != nil {
+		return err
+	}
+	return s.SetFetchMetadata(map[string]string{
+		prunedTotalKey: strconv.Itoa(total + n),
+		lastPrunedKey:  at.UTC().Format(time.RFC3339),
+	})
+}
+
+// prunedStats returns the number of blocks pruned so far and when the last ones
+// were pruned (zero if never).
+func (s *GenericStorage) prunedStats() (int, time.Time, error) {
+	value, ok, err := s.GetFetchMetadata(prunedTotalKey)
+	if err != nil || !ok {
+		return 0, time.Time{}, err
+	}
+	total, _ := strconv.Atoi(value)
+
+	var last time.Time
+	if value, ok, err := s.GetFetchMetadata(lastPrunedKey); err != nil {
+		return 0, time.Time{}, err
+	} else if ok {
+		last, _ = time.Parse(time.RFC3339, value)
+	}
+	return total, last, nil
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestPrune(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	if err := manager.InitializeDatasourceStorage("weather", map[string]any{"temp": "REAL"}); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	storage, err := manager.GetStorage("weather")
+	if err != nil {
+		t.Fatalf("GetStorage error: %v", err)
+	}
+
+	// Four samples a day (20:00 to 23:00) for ten days, newest first
+	now := time.Date(2025, 3, 10, 23, 30, 0, 0, time.UTC)
+	var blocks []core.Block
+	for day := 0; day < 10; day++ {
+		for hour := 0; hour < 4; hour++ {
+			createdAt := time.Date(2025, 3, 10-day, 23-hour, 0, 0, 0, time.UTC)
+			blocks = append(blocks, core.NewGenericBlock(fmt.Sprintf("d%d-h%d", day, hour), "sunny weather sample", "weather", "weather", createdAt, nil))
+		}
+	}
+	if err := storage.StoreBlocks(blocks, "weather"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+
+	remaining := func() []string {
+		t.Helper()
+		rows, err := storage.GetDB().Query("SELECT id FROM blocks")
+		if err != nil {
+			t.Fatalf("querying blocks: %v", err)
+		}
+		defer func() { _ = rows.Close() }()
+		var ids []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("scanning id: %v", err)
+			}
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	if result, err := storage.Prune(RetentionPolicy{}, now); err != nil || result.Total() != 0 {
+		t.Fatalf("zero policy pruned %d blocks, err %v", result.Total(), err)
+	}
+
+	// Days 8 and 9 expire; days 3 to 7 keep their newest sample
+	result, err := storage.Prune(RetentionPolicy{MaxAge: 8 * 24 * time.Hour, DownsampleAfter: 3 * 24 * time.Hour}, now)
+	if err != nil {
+		t.Fatalf("Prune error: %v", err)
+	}
+	if result.Expired != 8 || result.Downsampled != 15 || result.OverLimit != 0 || len(result.IDs) != 23 {
+		t.Errorf("Prune result = %+v", result)
+	}
+	ids := remaining()
+	if len(ids) != 17 {
+		t.Fatalf("expected 17 blocks left, got %v", ids)
+	}
+	for day := 3; day < 8; day++ {
+		found := false
+		for _, id := range ids {
+			if id == fmt.Sprintf("d%d-h0", day) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected the newest sample of day %d to be kept", day)
+		}
+	}
+
+	result, err = storage.Prune(RetentionPolicy{MaxBlocks: 5}, now)
+	if err != nil {
+		t.Fatalf("Prune error: %v", err)
+	}
+	if result.OverLimit != 12 {
+		t.Errorf("expected 12 blocks over the limit, got %+v", result)
+	}
+	want := []string{"d0-h0", "d0-h1", "d0-h2", "d0-h3", "d1-h0"}
+	if got := remaining(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("remaining blocks = %v, want %v", got, want)
+	}
+
+	// The FTS index follows the deletions
+	if err := storage.FTSIntegrityCheck(); err != nil {
+		t.Errorf("FTSIntegrityCheck error: %v", err)
+	}
+	if found, err := manager.SearchBlocks("weather", "sunny", 100); err != nil || len(found) != 5 {
+		t.Errorf("expected 5 search results, got %d (err %v)", len(found), err)
+	}
+
+	stats, err := storage.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats error: %v", err)
+	}
+	if stats["pruned_blocks"] != 35 || !stats["last_pruned"].(time.Time).Equal(now) {
+		t.Errorf("pruning stats = %v, %v", stats["pruned_blocks"], stats["last_pruned"])
+	}
+
+	if _, err := storage.Prune(RetentionPolicy{MaxBlocks: -1}, now); err == nil {
+		t.Error("expected an error for a negative limit")
+	}
+}
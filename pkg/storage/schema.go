@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rubiojr/ergs/pkg/log"
+)
+
+var schemaLogger = log.ForService("storage")
+
+// fieldColumnPrefix prefixes the typed columns materialized from a datasource's
+// Schema() so they never collide with the core blocks columns (id, text, metadata, ...).
+const fieldColumnPrefix = "field_"
+
+// SchemaField describes a schema-declared metadata field materialized as a typed,
+// indexed column on the blocks table.
+type SchemaField struct {
+	Name   string // Field name as declared in Schema() and stored in metadata
+	Column string // Generated column name on the blocks table
+	Type   string // SQLite column type (TEXT, INTEGER, REAL, BLOB, NUMERIC)
+}
+
+// FieldColumn returns the blocks column name used for a schema field.
+func FieldColumn(field string) string {
+	return fieldColumnPrefix + field
+}
+
+// normalizeColumnType maps the types accepted in Datasource.Schema() to the
+// SQLite column type used for the generated column.
+func normalizeColumnType(fieldType any) (string, error) {
+	s, ok := fieldType.(string)
+	if !ok {
+		return "", fmt.Errorf("type must be a string, got %T", fieldType)
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "TEXT", "VARCHAR", "STRING", "DATETIME", "DATE", "TIMESTAMP":
+		return "TEXT", nil
+	case "INTEGER", "INT", "BIGINT", "BOOLEAN", "BOOL":
+		return "INTEGER", nil
+	case "REAL", "FLOAT", "DOUBLE":
+		return "REAL", nil
+	case "NUMERIC":
+		return "NUMERIC", nil
+	case "BLOB":
+		return "BLOB", nil
+	default:
+		return "", fmt.Errorf("unsupported type %q", s)
+	}
+}
+
+// isValidFieldName reports whether a schema field name can be safely used
+// as part of a column name and a JSON path.
+func isValidFieldName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// parseSchema validates a Datasource.Schema() map and converts it to SchemaFields
+// sorted by name.
+func parseSchema(schema map[string]any) ([]SchemaField, error) {
+	fields := make([]SchemaField, 0, len(schema))
+	for name, fieldType := range schema {
+		if !isValidFieldName(name) {
+			return nil, fmt.Errorf("invalid schema field name %q", name)
+		}
+		colType, err := normalizeColumnType(fieldType)
+		if err != nil {
+			return nil, fmt.Errorf("schema field %s: %w", name, err)
+		}
+		fields = append(fields, SchemaField{Name: name, Column: FieldColumn(name), Type: colType})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields, nil
+}
+
+// materializedFields returns the schema field columns currently present on the blocks table.
+func (s *GenericStorage) materializedFields() (map[string]SchemaField, error) {
+	rows, err := s.db.Query("PRAGMA table_xinfo(blocks)")
+	if err != nil {
+		return nil, fmt.Errorf("reading blocks columns: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	fields := make(map[string]SchemaField)
+	for rows.Next() {
+		var cid, notNull, pk, hidden int
+		var name, colType string
+		var defaultValue any
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk, &hidden); err != nil {
+			return nil, fmt.Errorf("scanning blocks column: %w", err)
+		}
+		if !strings.HasPrefix(name, fieldColumnPrefix) {
+			continue
+		}
+		field := strings.TrimPrefix(name, fieldColumnPrefix)
+		fields[field] = SchemaField{Name: field, Column: name, Type: strings.ToUpper(colType)}
+	}
+	return fields, rows.Err()
+}
+
+// schemaMigration is a single change required to bring the blocks table in line
+// with a datasource's Schema().
+type schemaMigration struct {
+	description string
+	statements  []string
+}
+
+// diffSchema computes the changes needed to go from the materialized fields to the desired ones.
+func diffSchema(existing map[string]SchemaField, desired []SchemaField) []schemaMigration {
+	var migrations []schemaMigration
+
+	wanted := make(map[string]SchemaField, len(desired))
+	for _, field := range desired {
+		wanted[field.Name] = field
+	}
+
+	// Removed or retyped fields are dropped first
+	var dropped []string
+	for name, field := range existing {
+		if w, ok := wanted[name]; ok && w.Type == field.Type {
+			continue
+		}
+		dropped = append(dropped, name)
+	}
+	sort.Strings(dropped)
+	for _, name := range dropped {
+		field := existing[name]
+		migrations = append(migrations, schemaMigration{
+			description: fmt.Sprintf("drop field %s", name),
+			statements: []string{
+				fmt.Sprintf("DROP INDEX IF EXISTS idx_blocks_%s", field.Column),
+				fmt.Sprintf("ALTER TABLE blocks DROP COLUMN %s", field.Column),
+			},
+		})
+	}
+
+	for _, field := range desired {
+		if e, ok := existing[field.Name]; ok && e.Type == field.Type {
+			continue
+		}
+		migrations = append(migrations, schemaMigration{
+			description: fmt.Sprintf("add field %s (%s)", field.Name, field.Type),
+			statements: []string{
+				fmt.Sprintf("ALTER TABLE blocks ADD COLUMN %s %s GENERATED ALWAYS AS (json_extract(metadata, '$.%s')) VIRTUAL",
+					field.Column, field.Type, field.Name),
+				fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_blocks_%s ON blocks(%s)", field.Column, field.Column),
+			},
+		})
+	}
+
+	return migrations
+}
+
+// applySchema materializes the given schema fields as typed, indexed generated columns
+// over the metadata JSON, adding, dropping or retyping columns as the schema changes.
+func (s *GenericStorage) applySchema(fields []SchemaField) error {
+	existing, err := s.materializedFields()
+	if err != nil {
+		return err
+	}
+
+	return s.runSchemaMigrations(diffSchema(existing, fields))
+}
+
+// schemaChanges returns the schema migrations InitializeSchema and
+// ApplyTokenizer would run for schema and tokenizer, along with the parsed
+// fields and normalized tokenizer.
+func (s *GenericStorage) schemaChanges(schema map[string]any, tokenizer string) ([]SchemaField, string, []schemaMigration, error) {
+	fields, err := parseSchema(schema)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	tokenizer, err = NormalizeTokenizer(tokenizer)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	existing, err := s.materializedFields()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	migrations := diffSchema(existing, fields)
+
+	current, err := s.FTSTokenizer()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if current != tokenizer {
+		migrations = append(migrations, tokenizerMigration(tokenizer))
+	}
+	return fields, tokenizer, migrations, nil
+}
+
+// PendingSchemaChanges describes the changes InitializeSchema and
+// ApplyTokenizer would make to the database for schema and tokenizer.
+func (s *GenericStorage) PendingSchemaChanges(schema map[string]any, tokenizer string) ([]string, error) {
+	_, _, migrations, err := s.schemaChanges(schema, tokenizer)
+	if err != nil {
+		return nil, err
+	}
+	return describeMigrations(migrations), nil
+}
+
+// LoadSchema uses schema and tokenizer as InitializeSchema and ApplyTokenizer
+// do, but without changing the database: when it doesn't match them yet, a
+// *PendingSchemaChangesError is returned.
+func (s *GenericStorage) LoadSchema(schema map[string]any, tokenizer string) error {
+	fields, tokenizer, migrations, err := s.schemaChanges(schema, tokenizer)
+	if err != nil {
+		return err
+	}
+	if len(migrations) > 0 {
+		return &PendingSchemaChangesError{Datasource: s.datasourceName, Changes: describeMigrations(migrations)}
+	}
+
+	s.mu.Lock()
+	s.schema = fields
+	s.tokenizer = tokenizer
+	s.mu.Unlock()
+	return nil
+}
+
+// describeMigrations returns the description of each migration
+func describeMigrations(migrations []schemaMigration) []string {
+	descriptions := make([]string, len(migrations))
+	for i, migration := range migrations {
+		descriptions[i] = migration.description
+	}
+	return descriptions
+}
+
+// runSchemaMigrations applies schema migrations in a single transaction.
+func (s *GenericStorage) runSchemaMigrations(migrations []schemaMigration) error {
+	if len(migrations) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil {
+				fmt.Printf("Warning: failed to rollback transaction: %v\n", err)
+			}
+		}
+	}()
+
+	for _, migration := range migrations {
+		schemaLogger.Infof("Applying schema migration for %s: %s", s.datasourceName, migration.description)
+		for _, stmt := range migration.statements {
+			if _, err := tx.Exec(stmt); err != nil {
+				return fmt.Errorf("schema migration %q: %w", migration.description, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing schema migrations: %w", err)
+	}
+	committed = true
+	return nil
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+	"github.com/rubiojr/ergs/pkg/db"
+)
+
+// TestInitializeSchemaTypedColumns verifies schema fields become typed, indexed
+// generated columns and that schema changes are diffed and migrated.
+func TestInitializeSchemaTypedColumns(t *testing.T) {
+	st, err := NewGenericStorage(t.TempDir()+"/test.db", "testds")
+	if err != nil {
+		t.Fatalf("NewGenericStorage error: %v", err)
+	}
+	defer func() { _ = st.Close() }()
+
+	if err := db.InitializeDatabase(st.GetDB()); err != nil {
+		t.Fatalf("InitializeDatabase error: %v", err)
+	}
+
+	if err := st.InitializeSchema(map[string]any{
+		"repo_name": "TEXT",
+		"stars":     "INTEGER",
+		"public":    "BOOLEAN",
+	}); err != nil {
+		t.Fatalf("InitializeSchema error: %v", err)
+	}
+
+	now := time.Now().UTC()
+	blocks := []core.Block{
+		core.NewGenericBlock("a", "a", "testds", "github", now, map[string]interface{}{"repo_name": "rubiojr/ergs", "stars": 150, "public": true}),
+		core.NewGenericBlock("b", "b", "testds", "github", now, map[string]interface{}{"repo_name": "rubiojr/other", "stars": 20, "public": false}),
+		core.NewGenericBlock("c", "c", "testds", "github", now, map[string]interface{}{"repo_name": "rubiojr/nostars"}),
+	}
+	if err := st.StoreBlocks(blocks, "github"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+
+	var id string
+	if err := st.GetDB().QueryRow("SELECT id FROM blocks WHERE field_stars > 100").Scan(&id); err != nil {
+		t.Fatalf("querying typed column: %v", err)
+	}
+	if id != "a" {
+		t.Errorf("expected block a, got %s", id)
+	}
+
+	var count int
+	if err := st.GetDB().QueryRow("SELECT COUNT(*) FROM blocks WHERE field_public = 0").Scan(&count); err != nil {
+		t.Fatalf("querying boolean column: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 non-public block, got %d", count)
+	}
+
+	var plan string
+	rows, err := st.GetDB().Query("EXPLAIN QUERY PLAN SELECT id FROM blocks WHERE field_stars > 100")
+	if err != nil {
+		t.Fatalf("explain query plan: %v", err)
+	}
+	for rows.Next() {
+		var a, b, c int
+		var detail string
+		if err := rows.Scan(&a, &b, &c, &detail); err != nil {
+			t.Fatalf("scanning plan: %v", err)
+		}
+		plan += detail
+	}
+	_ = rows.Close()
+	if want := "idx_blocks_field_stars"; !contains(plan, want) {
+		t.Errorf("expected query plan to use %s, got %q", want, plan)
+	}
+
+	// Change the schema: drop public, retype stars, add language
+	if err := st.InitializeSchema(map[string]any{
+		"repo_name": "TEXT",
+		"stars":     "REAL",
+		"language":  "TEXT",
+	}); err != nil {
+		t.Fatalf("InitializeSchema (changed) error: %v", err)
+	}
+
+	fields, err := st.materializedFields()
+	if err != nil {
+		t.Fatalf("materializedFields error: %v", err)
+	}
+	if _, ok := fields["public"]; ok {
+		t.Error("expected public field to be dropped")
+	}
+	if fields["stars"].Type != "REAL" {
+		t.Errorf("expected stars to be REAL, got %q", fields["stars"].Type)
+	}
+	if _, ok := fields["language"]; !ok {
+		t.Error("expected language field to be added")
+	}
+	if got := len(st.SchemaFields()); got != 3 {
+		t.Errorf("expected 3 schema fields, got %d", got)
+	}
+
+	// Existing rows are still readable and searchable after the migration
+	if err := st.GetDB().QueryRow("SELECT COUNT(*) FROM blocks WHERE field_stars >= 20").Scan(&count); err != nil {
+		t.Fatalf("querying retyped column: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 blocks with stars >= 20, got %d", count)
+	}
+}
+
+func TestInitializeSchemaRejectsInvalidFields(t *testing.T) {
+	st, err := NewGenericStorage(t.TempDir()+"/test.db", "testds")
+	if err != nil {
+		t.Fatalf("NewGenericStorage error: %v", err)
+	}
+	defer func() { _ = st.Close() }()
+
+	if err := db.InitializeDatabase(st.GetDB()); err != nil {
+		t.Fatalf("InitializeDatabase error: %v", err)
+	}
+
+	invalid := []map[string]any{
+		{"bad-name": "TEXT"},
+		{"x') --": "TEXT"},
+		{"ok": "JSONB"},
+		{"ok": 42},
+	}
+	for _, schema := range invalid {
+		if err := st.InitializeSchema(schema); err == nil {
+			t.Errorf("expected error for schema %v", schema)
+		}
+	}
+}
+
+// TestOpenDatasourceStorageLeavesSchemaChanges verifies commands opening
+// existing databases get a pending changes error instead of migrating them.
+func TestOpenDatasourceStorageLeavesSchemaChanges(t *testing.T) {
+	dir := t.TempDir()
+	manager := NewManagerWithoutMigrationCheck(dir)
+	schema := map[string]any{"title": "TEXT"}
+
+	// New databases are initialized
+	if err := manager.OpenDatasourceStorage("news", schema); err != nil {
+		t.Fatalf("OpenDatasourceStorage error: %v", err)
+	}
+	st, err := manager.GetStorage("news")
+	if err != nil {
+		t.Fatalf("GetStorage error: %v", err)
+	}
+	if fields := st.SchemaFields(); len(fields) != 1 || fields[0].Name != "title" {
+		t.Fatalf("unexpected schema fields: %+v", fields)
+	}
+	_ = manager.Close()
+
+	manager = NewManagerWithoutMigrationCheck(dir)
+	defer func() { _ = manager.Close() }()
+	changed := map[string]any{"title": "TEXT", "score": "INTEGER"}
+	if err := manager.SetTokenizer("news", "trigram"); err != nil {
+		t.Fatalf("SetTokenizer error: %v", err)
+	}
+	err = manager.OpenDatasourceStorage("news", changed)
+	var pending *PendingSchemaChangesError
+	if !errors.As(err, &pending) || !errors.Is(err, ErrPendingMigrations) {
+		t.Fatalf("expected a pending schema changes error, got %v", err)
+	}
+	if len(pending.Changes) != 2 || pending.Changes[0] != "add field score (INTEGER)" {
+		t.Errorf("unexpected pending changes: %v", pending.Changes)
+	}
+	st, err = manager.GetStorage("news")
+	if err != nil {
+		t.Fatalf("GetStorage error: %v", err)
+	}
+	if tokenizer, _ := st.FTSTokenizer(); tokenizer != DefaultTokenizer {
+		t.Errorf("expected the index left alone, got tokenizer %q", tokenizer)
+	}
+	if changes, err := st.PendingSchemaChanges(changed, "trigram"); err != nil || len(changes) != 2 {
+		t.Errorf("PendingSchemaChanges = %v, %v; want 2 changes", changes, err)
+	}
+
+	// Once migrated, the storage opens
+	if err := manager.InitializeDatasourceStorage("news", changed); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	if err := manager.OpenDatasourceStorage("news", changed); err != nil {
+		t.Fatalf("OpenDatasourceStorage error after migrating: %v", err)
+	}
+}
@@ -32,6 +32,11 @@ type SearchParams struct {
 	// Example: ["github", "rss", "firefox"]
 	DatasourceFilters []string
 
+	// DatasourceTypes limits the search to datasource instances of the given
+	// types (e.g. "hackernews"), as set by the type: query operator.
+	// Combined with DatasourceFilters, only instances matching both are searched.
+	DatasourceTypes []string
+
 	// Page is the page number for pagination (1-based).
 	// Defaults to 1 if not specified.
 	Page int
@@ -49,6 +54,23 @@ type SearchParams struct {
 	// If nil, no end date filtering is applied.
 	EndDate *time.Time
 
+	// Snippets requests an FTS5 snippet of the best matching column for every
+	// result of a full-text query, available through SearchResults.Snippet.
+	Snippets bool
+
+	// Facets requests exact match counts per datasource, datasource type and
+	// hostname plus a date histogram of all matches, in SearchResults.Facets.
+	Facets bool
+
+	// FacetInterval is the bucket size of the facet date histogram.
+	// Defaults to FacetIntervalMonth.
+	FacetInterval FacetInterval
+
+	// Cursor is an opaque position returned as SearchResults.NextCursor. When set,
+	// results start right after it and Page is ignored. Unlike page numbers,
+	// cursors stay stable while new blocks arrive and cost the same at any depth.
+	Cursor string
+
 	// Since is an optional high-precision time boundary (created_at > Since).
 	// Takes precedence over StartDate when provided. Used by real-time clients
 	// to request only blocks strictly newer than a previously seen cursor.
@@ -70,6 +92,7 @@ type SearchResults struct {
 
 	// TotalCount is the number of results returned on this page.
 	// Note: This is NOT the total across all pages due to distributed search complexity.
+	// Request Facets for the exact number of matches.
 	TotalCount int
 
 	// HasMore indicates whether there are more results available on subsequent pages.
@@ -87,6 +110,18 @@ type SearchResults struct {
 
 	// Query is the search term used (matches input parameter).
 	Query string
+
+	// NextCursor is the cursor to pass as SearchParams.Cursor to fetch the next
+	// page. Empty when there are no more results.
+	NextCursor string
+
+	// Snippets holds the search snippets of this page's blocks when requested
+	// with SearchParams.Snippets. Use Snippet to look one up.
+	Snippets map[string]string
+
+	// Facets summarizes all matches across pages when requested with
+	// SearchParams.Facets. Nil otherwise.
+	Facets *SearchFacets
 }
 
 // SearchService provides search functionality across all datasources.
@@ -137,90 +172,108 @@ func NewSearchService(manager *Manager) *SearchService {
 //	}
 //	results, err := searchService.Search(params)
 func (s *SearchService) Search(params SearchParams) (*SearchResults, error) {
-	resultsMap, ordered, totalResults, hasMoreResults, totalPages, err := s.executeSearch(params)
-	if err != nil {
-		return nil, err
-	}
-
-	return &SearchResults{
-		Results:    resultsMap,
-		Ordered:    ordered,
-		TotalCount: totalResults,
-		HasMore:    hasMoreResults,
-		TotalPages: totalPages,
-		Page:       params.Page,
-		Limit:      params.Limit,
-		Query:      params.Query,
-	}, nil
+	return s.executeSearch(params)
 }
 
 // executeSearch performs the actual search operation with all parameters.
-func (s *SearchService) executeSearch(params SearchParams) (map[string][]core.Block, []core.Block, int, bool, int, error) {
-	// Determine which datasources to search
-	datasources := params.DatasourceFilters
-	if len(datasources) == 0 {
-		datasources = s.manager.SearchAllDatasources()
+func (s *SearchService) executeSearch(params SearchParams) (*SearchResults, error) {
+	var facets *SearchFacets
+	page := func(results map[string][]core.Block, ordered []core.Block, totalResults int, hasMore bool, totalPages int, nextCursor string) *SearchResults {
+		return &SearchResults{
+			Results:    results,
+			Ordered:    ordered,
+			TotalCount: totalResults,
+			HasMore:    hasMore,
+			TotalPages: totalPages,
+			Page:       params.Page,
+			Limit:      params.Limit,
+			Query:      params.Query,
+			NextCursor: nextCursor,
+			Facets:     facets,
+		}
 	}
 
-	// Filter to only include datasources that actually exist
-	validDatasources := make([]string, 0, len(datasources))
-	s.manager.mu.RLock()
-	for _, name := range datasources {
-		if _, exists := s.manager.storages[name]; exists {
-			validDatasources = append(validDatasources, name)
+	var cursor *searchCursor
+	if params.Cursor != "" {
+		var err error
+		if cursor, err = decodeSearchCursor(params.Cursor); err != nil {
+			return nil, err
 		}
 	}
-	s.manager.mu.RUnlock()
 
-	if len(validDatasources) == 0 {
-		return make(map[string][]core.Block), []core.Block{}, 0, false, 1, nil
+	var interval FacetInterval
+	if params.Facets {
+		var err error
+		if interval, err = ParseFacetInterval(string(params.FacetInterval)); err != nil {
+			return nil, err
+		}
 	}
 
-	// Get enough results to support paging - fetch more than needed from each datasource
-	// Fetch extra to determine if there are more pages
-	requestLimit := (params.Page + 1) * params.Limit
-	results := s.searchDatasourcesInParallel(validDatasources, params.Query, requestLimit, true, params.StartDate, params.EndDate)
+	validDatasources := s.targetDatasources(params)
 
-	// Check for any errors first - fail fast like original behavior
-	for _, result := range results {
-		if result.err != nil {
-			return nil, nil, 0, false, 0, fmt.Errorf("searching %s: %w", result.datasource, result.err)
+	if len(validDatasources) == 0 {
+		if params.Facets {
+			facets = &SearchFacets{Interval: interval}
 		}
+		return page(make(map[string][]core.Block), []core.Block{}, 0, false, 1, ""), nil
 	}
 
-	// Build a global list of all blocks across all datasources and then sort
-	// them strictly by block creation time (descending). This provides a true
-	// firehose ordering (newest first) instead of grouping by datasource.
-	type dsBlock struct {
-		block      core.Block
-		datasource string
+	query, err := s.parseQuery(params.Query, validDatasources)
+	if err != nil {
+		return nil, err
 	}
 
-	var merged []dsBlock
-	for _, result := range results {
-		for _, block := range result.blocks {
-			merged = append(merged, dsBlock{block: block, datasource: result.datasource})
+	// Get enough results to support paging - fetch more than needed from each datasource
+	// Fetch extra to determine if there are more pages. With a cursor every database
+	// seeks straight to it, so a single page (plus one) is enough.
+	requestLimit := (params.Page + 1) * params.Limit
+	if cursor != nil {
+		requestLimit = params.Limit + 1
+	}
+	q := storageQuery{
+		query:       query,
+		limit:       requestLimit,
+		orderByTime: true,
+		startDate:   params.StartDate,
+		endDate:     params.EndDate,
+		cursor:      cursor,
+		snippets:    params.Snippets,
+	}
+
+	// The global index answers the whole query at once, already paginated. It
+	// tokenizes like DefaultTokenizer, so other tokenizers need their own databases.
+	offset := 0
+	globalIndex := s.manager.GlobalIndex()
+	useGlobalIndex := globalIndex != nil && globalIndex.canServe(query) && s.manager.usesDefaultTokenizer(validDatasources)
+	if useGlobalIndex {
+		if cursor == nil {
+			offset = (params.Page - 1) * params.Limit
 		}
+		q.limit = params.Limit + 1
 	}
 
-	// Global stable sort by CreatedAt (newest first)
-	// Deterministic ordering:
-	// 1. Newest CreatedAt first
-	// 2. If same CreatedAt, order by datasource name (ascending)
-	// 3. If same datasource and CreatedAt, order by block ID (ascending)
-	// This prevents unstable ordering across calls which previously caused
-	// duplicates/omissions when paginating (seen in TestPaginationBehaviorDetailed).
-	sort.SliceStable(merged, func(i, j int) bool {
-		ti := merged[i].block.CreatedAt()
-		tj := merged[j].block.CreatedAt()
-		if ti.Equal(tj) {
-			if merged[i].datasource == merged[j].datasource {
-				return merged[i].block.ID() < merged[j].block.ID()
-			}
-			return merged[i].datasource < merged[j].datasource
+	// Facet counts cover every match, so they run alongside the page search
+	var facetsErr error
+	facetsDone := make(chan struct{})
+	go func() {
+		defer close(facetsDone)
+		if params.Facets {
+			facets, facetsErr = s.facetDatasourcesInParallel(validDatasources, q, interval)
 		}
-		return ti.After(tj)
-	})
+	}()
+	var merged []mergedBlock
+	if useGlobalIndex {
+		merged, err = s.searchGlobalIndex(globalIndex, validDatasources, q, offset)
+	} else {
+		merged, err = s.searchDatasources(validDatasources, q)
+	}
+	<-facetsDone
+	if err != nil {
+		return nil, err
+	}
+	if facetsErr != nil {
+		return nil, facetsErr
+	}
 
 	// Reconstruct parallel slices for pagination logic below
 	var allBlocks []core.Block
@@ -232,12 +285,16 @@ func (s *SearchService) executeSearch(params SearchParams) (map[string][]core.Bl
 		blockToDatasource[i] = item.datasource
 	}
 
-	// Apply pagination to the flattened list
+	// Apply pagination to the flattened list. Cursor and global index results
+	// already start at the requested page.
 	startIndex := (params.Page - 1) * params.Limit
+	if cursor != nil || useGlobalIndex {
+		startIndex = 0
+	}
 	endIndex := startIndex + params.Limit
 
 	if startIndex >= len(allBlocks) {
-		return make(map[string][]core.Block), []core.Block{}, 0, false, params.Page, nil
+		return page(make(map[string][]core.Block), []core.Block{}, 0, false, params.Page, ""), nil
 	}
 
 	if endIndex > len(allBlocks) {
@@ -246,6 +303,10 @@ func (s *SearchService) executeSearch(params SearchParams) (map[string][]core.Bl
 
 	// Group the paginated results back by datasource
 	groupedResults := make(map[string][]core.Block)
+	var snippets map[string]string
+	if params.Snippets {
+		snippets = make(map[string]string)
+	}
 	// Build ordered page slice (firehose view) first from raw blocks to ensure
 	// stable, globally chronological ordering; then convert types.
 	orderedPage := make([]core.Block, 0, endIndex-startIndex)
@@ -253,6 +314,9 @@ func (s *SearchService) executeSearch(params SearchParams) (map[string][]core.Bl
 		orderedPage = append(orderedPage, allBlocks[i])
 		dsName := blockToDatasource[i]
 		block := allBlocks[i]
+		if merged[i].snippet != "" {
+			snippets[snippetKey(dsName, block.ID())] = merged[i].snippet
+		}
 		convertedBlocks, err := s.manager.convertBlocksToProperTypes([]core.Block{block})
 		if err != nil {
 			continue
@@ -270,12 +334,23 @@ func (s *SearchService) executeSearch(params SearchParams) (map[string][]core.Bl
 	}
 
 	if totalResults == 0 && params.Page > 1 {
-		return make(map[string][]core.Block), []core.Block{}, 0, false, params.Page, nil
+		return page(make(map[string][]core.Block), []core.Block{}, 0, false, params.Page, ""), nil
 	}
 
 	// Check if there are more results by looking at remaining blocks beyond the current page
 	hasMoreResults := endIndex < len(allBlocks)
 
+	// The next page starts right after the last block of this one
+	var nextCursor string
+	if hasMoreResults {
+		last := merged[endIndex-1]
+		nextCursor = (&searchCursor{
+			CreatedAt:  last.block.CreatedAt(),
+			Datasource: last.datasource,
+			ID:         last.block.ID(),
+		}).encode()
+	}
+
 	totalPages := params.Page
 	if hasMoreResults {
 		totalPages = params.Page + 1
@@ -286,12 +361,167 @@ func (s *SearchService) executeSearch(params SearchParams) (map[string][]core.Bl
 		totalPages = params.Page
 	}
 
-	return groupedResults, orderedPage, totalResults, hasMoreResults, totalPages, nil
+	searchResults := page(groupedResults, orderedPage, totalResults, hasMoreResults, totalPages, nextCursor)
+	searchResults.Snippets = snippets
+	return searchResults, nil
+}
+
+// TargetDatasources returns the existing datasources a search with params,
+// its operators already applied, applies to.
+func (s *SearchService) TargetDatasources(params SearchParams) []string {
+	return s.targetDatasources(params)
+}
+
+// targetDatasources returns the existing datasources a search or purge with
+// params applies to.
+func (s *SearchService) targetDatasources(params SearchParams) []string {
+	datasources := params.DatasourceFilters
+	if len(datasources) == 0 {
+		datasources = s.manager.SearchAllDatasources()
+	}
+	if len(params.DatasourceTypes) > 0 {
+		datasources = s.manager.filterDatasourcesByType(datasources, params.DatasourceTypes)
+	}
+
+	// Filter to only include datasources that actually exist
+	validDatasources := make([]string, 0, len(datasources))
+	s.manager.mu.RLock()
+	for _, name := range datasources {
+		if _, exists := s.manager.storages[name]; exists {
+			validDatasources = append(validDatasources, name)
+		}
+	}
+	s.manager.mu.RUnlock()
+	return validDatasources
+}
+
+// parseQuery splits field filters (e.g. stars:>100) from the FTS5 query and
+// makes sure the filtered fields exist in at least one of the datasources.
+func (s *SearchService) parseQuery(raw string, datasources []string) (*parsedQuery, error) {
+	query, err := parseSearchQuery(raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(query.filters) > 0 {
+		storages := make([]*GenericStorage, 0, len(datasources))
+		for _, name := range datasources {
+			storage, err := s.manager.GetStorage(name)
+			if err != nil {
+				return nil, fmt.Errorf("getting storage for %s: %w", name, err)
+			}
+			storages = append(storages, storage)
+		}
+		if err := validateFieldFilters(query.filters, storages); err != nil {
+			return nil, err
+		}
+	}
+	return query, nil
+}
+
+// mergedBlock is a search result in the global search order.
+type mergedBlock struct {
+	block      core.Block
+	datasource string
+	createdAt  string // sort key, see createdAtKey
+	snippet    string
+}
+
+// searchDatasources searches every datasource database in parallel and merges
+// the results into the global search order.
+func (s *SearchService) searchDatasources(datasources []string, q storageQuery) ([]mergedBlock, error) {
+	results := s.searchDatasourcesInParallel(datasources, q)
+
+	// Check for any errors first - fail fast like original behavior
+	for _, result := range results {
+		if result.err != nil {
+			return nil, fmt.Errorf("searching %s: %w", result.datasource, result.err)
+		}
+	}
+
+	// Build a global list of all blocks across all datasources and then sort
+	// them strictly by block creation time (descending). This provides a true
+	// firehose ordering (newest first) instead of grouping by datasource.
+	var merged []mergedBlock
+	for _, result := range results {
+		for _, block := range result.blocks {
+			merged = append(merged, mergedBlock{
+				block:      block,
+				datasource: result.datasource,
+				createdAt:  createdAtKey(block.CreatedAt()),
+				snippet:    result.snippets[block.ID()],
+			})
+		}
+	}
+
+	// Global stable sort by CreatedAt (newest first)
+	// Deterministic ordering:
+	// 1. Newest CreatedAt first
+	// 2. If same CreatedAt, order by datasource name (ascending)
+	// 3. If same datasource and CreatedAt, order by block ID (ascending)
+	// This prevents unstable ordering across calls which previously caused
+	// duplicates/omissions when paginating (seen in TestPaginationBehaviorDetailed).
+	// CreatedAt is compared in its stored form so the order matches the per-database
+	// ORDER BY and cursor seeks.
+	sort.SliceStable(merged, func(i, j int) bool {
+		ti := merged[i].createdAt
+		tj := merged[j].createdAt
+		if ti == tj {
+			if merged[i].datasource == merged[j].datasource {
+				return merged[i].block.ID() < merged[j].block.ID()
+			}
+			return merged[i].datasource < merged[j].datasource
+		}
+		return ti > tj
+	})
+	return merged, nil
+}
+
+// storageQuery holds the parameters of a search against a single datasource database.
+type storageQuery struct {
+	query       *parsedQuery
+	limit       int
+	orderByTime bool
+	startDate   *time.Time
+	endDate     *time.Time
+	cursor      *searchCursor // only return blocks after this position
+	snippets    bool          // also return FTS5 snippets keyed by block ID
+}
+
+// conditions returns the SQL conditions (and their arguments) shared by every
+// query against a datasource database: date range, field filters, tags and cursor.
+// Columns are prefixed with alias. ok is false when the field filters can't
+// match any block in this database.
+func (q storageQuery) conditions(storage *GenericStorage, datasource, alias string) (conds []string, args []interface{}, ok bool, err error) {
+	if q.startDate != nil {
+		conds = append(conds, alias+"created_at >= ?")
+		args = append(args, q.startDate.Format(time.RFC3339))
+	}
+	if q.endDate != nil {
+		conds = append(conds, alias+"created_at <= ?")
+		args = append(args, q.endDate.Format(time.RFC3339))
+	}
+
+	fieldConds, fieldArgs, ok, err := fieldConditions(storage, q.query.filters, alias)
+	if err != nil || !ok {
+		return nil, nil, ok, err
+	}
+	conds = append(conds, fieldConds...)
+	args = append(args, fieldArgs...)
+
+	tagConds, tagArgs := tagConditions(q.query.tags, alias)
+	conds = append(conds, tagConds...)
+	args = append(args, tagArgs...)
+
+	if q.cursor != nil {
+		cond, cursorArgs := q.cursor.seekCondition(datasource, alias)
+		conds = append(conds, cond)
+		args = append(args, cursorArgs...)
+	}
+	return conds, args, true, nil
 }
 
-// convertResultsToMap converts search results to a map for sorting
 // searchDatasourcesInParallel executes searches across multiple datasources in parallel.
-func (s *SearchService) searchDatasourcesInParallel(datasources []string, query string, limit int, orderByTime bool, startDate, endDate *time.Time) []searchResult {
+func (s *SearchService) searchDatasourcesInParallel(datasources []string, q storageQuery) []searchResult {
 	resultChan := make(chan searchResult, len(datasources))
 
 	for _, datasource := range datasources {
@@ -302,10 +532,11 @@ func (s *SearchService) searchDatasourcesInParallel(datasources []string, query
 				return
 			}
 
-			blocks, err := s.executeStorageSearch(storage, query, limit, orderByTime, startDate, endDate)
+			blocks, snippets, err := s.executeStorageSearch(storage, ds, q)
 			resultChan <- searchResult{
 				datasource: ds,
 				blocks:     blocks,
+				snippets:   snippets,
 				err:        err,
 			}
 		}(datasource)
@@ -320,70 +551,75 @@ func (s *SearchService) searchDatasourcesInParallel(datasources []string, query
 }
 
 // executeStorageSearch performs the actual database search with all parameters.
-func (s *SearchService) executeStorageSearch(storage *GenericStorage, query string, limit int, orderByTime bool, startDate, endDate *time.Time) ([]core.Block, error) {
+// When a cursor is given, only blocks after it in the global search order are returned.
+// Snippets are only produced for full-text queries.
+func (s *SearchService) executeStorageSearch(storage *GenericStorage, datasource string, q storageQuery) ([]core.Block, map[string]string, error) {
 	var sqlQuery string
 	var args []interface{}
+	query := q.query
+	withSnippets := q.snippets && query.fts != ""
 
-	if query != "" {
-		// Build the date range conditions with table alias
-		var dateConditions []string
-		if startDate != nil {
-			dateConditions = append(dateConditions, "b.created_at >= ?")
-			args = append(args, startDate.Format(time.RFC3339))
+	if query.fts != "" {
+		conditions, condArgs, ok, err := q.conditions(storage, datasource, "b.")
+		if err != nil {
+			return nil, nil, err
 		}
-		if endDate != nil {
-			dateConditions = append(dateConditions, "b.created_at <= ?")
-			args = append(args, endDate.Format(time.RFC3339))
+		if !ok {
+			return nil, nil, nil
 		}
+		args = append(args, condArgs...)
 
 		var whereClause string
-		if len(dateConditions) > 0 {
-			whereClause = " AND " + strings.Join(dateConditions, " AND ")
+		if len(conditions) > 0 {
+			whereClause = " AND " + strings.Join(conditions, " AND ")
 		}
 
 		// Escape FTS5 query for special characters
-		escapedQuery := escapeFTS5Query(query)
-		orderClause := "ORDER BY b.created_at DESC"
-		if !orderByTime {
+		escapedQuery := escapeFTS5Query(query.fts)
+		orderClause := "ORDER BY b.created_at DESC, b.id ASC"
+		if !q.orderByTime {
 			orderClause = "ORDER BY bm25(blocks_fts), b.created_at DESC"
 		}
+		// The snippet is taken from whichever column matched best
+		var snippetColumn string
+		if withSnippets {
+			snippetColumn = fmt.Sprintf(", snippet(blocks_fts, -1, '%s', '%s', '…', %d)", SnippetMatchStart, SnippetMatchEnd, snippetTokens)
+		}
 		sqlQuery = `
-			SELECT b.id, b.text, b.created_at, b.source, b.datasource, b.metadata, b.hostname
+			SELECT b.id, b.text, b.created_at, b.source, b.datasource, b.metadata, b.hostname` + snippetColumn + `
 			FROM blocks b
 			JOIN blocks_fts fts ON b.rowid = fts.rowid
 			WHERE blocks_fts MATCH ?` + whereClause + `
 			` + orderClause + `
 			LIMIT ?`
 		args = append([]interface{}{escapedQuery}, args...)
-		args = append(args, limit)
+		args = append(args, q.limit)
 	} else {
-		// Build the date range conditions without table alias
-		var dateConditions []string
-		if startDate != nil {
-			dateConditions = append(dateConditions, "created_at >= ?")
-			args = append(args, startDate.Format(time.RFC3339))
+		conditions, condArgs, ok, err := q.conditions(storage, datasource, "")
+		if err != nil {
+			return nil, nil, err
 		}
-		if endDate != nil {
-			dateConditions = append(dateConditions, "created_at <= ?")
-			args = append(args, endDate.Format(time.RFC3339))
+		if !ok {
+			return nil, nil, nil
 		}
+		args = append(args, condArgs...)
 
 		var whereClause string
-		if len(dateConditions) > 0 {
-			whereClause = " WHERE " + strings.Join(dateConditions, " AND ")
+		if len(conditions) > 0 {
+			whereClause = " WHERE " + strings.Join(conditions, " AND ")
 		}
 
 		sqlQuery = `
 			SELECT id, text, created_at, source, datasource, metadata, hostname
 			FROM blocks` + whereClause + `
-			ORDER BY created_at DESC
+			ORDER BY created_at DESC, id ASC
 			LIMIT ?`
-		args = append(args, limit)
+		args = append(args, q.limit)
 	}
 
 	rows, err := storage.db.Query(sqlQuery, args...)
 	if err != nil {
-		return nil, fmt.Errorf("querying blocks: %w", err)
+		return nil, nil, fmt.Errorf("querying blocks: %w", err)
 	}
 	defer func() {
 		if err := rows.Close(); err != nil {
@@ -392,19 +628,30 @@ func (s *SearchService) executeStorageSearch(storage *GenericStorage, query stri
 	}()
 
 	var blocks []core.Block
+	var snippets map[string]string
+	if withSnippets {
+		snippets = make(map[string]string)
+	}
 	for rows.Next() {
 		var id, text, source, datasourceType, metadataStr string
-		var hostname sql.NullString
+		var hostname, snippet sql.NullString
 		var createdAt time.Time
 
-		err = rows.Scan(&id, &text, &createdAt, &source, &datasourceType, &metadataStr, &hostname)
+		dest := []any{&id, &text, &createdAt, &source, &datasourceType, &metadataStr, &hostname}
+		if withSnippets {
+			dest = append(dest, &snippet)
+		}
+		err = rows.Scan(dest...)
 		if err != nil {
-			return nil, fmt.Errorf("scanning row: %w", err)
+			return nil, nil, fmt.Errorf("scanning row: %w", err)
 		}
 
 		var metadata map[string]interface{}
 		if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
-			return nil, fmt.Errorf("unmarshaling metadata for block %s: %w", id, err)
+			return nil, nil, fmt.Errorf("unmarshaling metadata for block %s: %w", id, err)
+		}
+		if snippet.Valid {
+			snippets[id] = snippet.String
 		}
 
 		hostnameStr := ""
@@ -416,7 +663,7 @@ func (s *SearchService) executeStorageSearch(storage *GenericStorage, query stri
 		blocks = append(blocks, block)
 	}
 
-	return blocks, rows.Err()
+	return blocks, snippets, rows.Err()
 }
 
 // ParseSearchParams parses HTTP query parameters into a SearchParams struct.
@@ -424,12 +671,14 @@ func (s *SearchService) executeStorageSearch(storage *GenericStorage, query stri
 // for missing or invalid parameters.
 //
 // Supported parameters:
-//   - q: Search query string
+//   - q: Search query string, may include the operators handled by ApplyQueryOperators
 //   - datasource: Datasource filter (can be specified multiple times)
 //   - page: Page number (positive integer, defaults to 1)
 //   - limit: Results per page (positive integer, defaults to 30)
 //   - start_date: Start date filter in YYYY-MM-DD format
 //   - end_date: End date filter in YYYY-MM-DD format (set to end of day)
+//   - cursor: Opaque cursor from a previous page's NextCursor (overrides page)
+//   - interval: Facet histogram interval (day, week or month)
 //
 // Date parsing:
 //   - Invalid date formats return an error
@@ -513,6 +762,25 @@ func ParseSearchParams(queryParams map[string][]string) (SearchParams, error) {
 		}
 	}
 
+	// Extract after:, before:, on:, source: and type: operators from the query
+	if err := ApplyQueryOperators(&params); err != nil {
+		return params, err
+	}
+
+	// Opaque keyset pagination cursor
+	if cursor := queryParams["cursor"]; len(cursor) > 0 && cursor[0] != "" {
+		params.Cursor = cursor[0]
+	}
+
+	// Facet histogram interval
+	if interval := queryParams["interval"]; len(interval) > 0 && interval[0] != "" {
+		parsed, err := ParseFacetInterval(interval[0])
+		if err != nil {
+			return params, err
+		}
+		params.FacetInterval = parsed
+	}
+
 	// Parse high-precision 'since' timestamp (RFC3339)
 	if sinceStr := queryParams["since"]; len(sinceStr) > 0 && sinceStr[0] != "" {
 		if parsed, err := time.Parse(time.RFC3339, sinceStr[0]); err == nil {
@@ -531,6 +799,7 @@ func ParseSearchParams(queryParams map[string][]string) (SearchParams, error) {
 type searchResult struct {
 	datasource string
 	blocks     []core.Block
+	snippets   map[string]string
 	err        error
 }
 
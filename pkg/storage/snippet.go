@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"html"
+	"strings"
+)
+
+// Markers FTS5 wraps around matched terms in search snippets. Control characters
+// are used so they can't clash with block text; use HighlightSnippet or
+// SnippetHTML to turn them into something displayable.
+const (
+	SnippetMatchStart = "\x02"
+	SnippetMatchEnd   = "\x03"
+)
+
+// snippetTokens is the maximum number of tokens in a search snippet.
+const snippetTokens = 24
+
+// snippetKey identifies a block's snippet in SearchResults.
+func snippetKey(datasource, blockID string) string {
+	return datasource + "\x00" + blockID
+}
+
+// Snippet returns the search snippet for a block, with matched terms wrapped in
+// SnippetMatchStart and SnippetMatchEnd. Empty if snippets weren't requested
+// or the search had no full-text query.
+func (r *SearchResults) Snippet(datasource, blockID string) string {
+	return r.Snippets[snippetKey(datasource, blockID)]
+}
+
+// HighlightSnippet replaces the match markers in a snippet with open and close.
+func HighlightSnippet(snippet, open, close string) string {
+	return strings.NewReplacer(SnippetMatchStart, open, SnippetMatchEnd, close).Replace(snippet)
+}
+
+// SnippetHTML returns the snippet as escaped HTML with matches wrapped in <mark>.
+func SnippetHTML(snippet string) string {
+	return HighlightSnippet(html.EscapeString(snippet), "<mark>", "</mark>")
+}
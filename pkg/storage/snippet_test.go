@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestSearchSnippets(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	if err := manager.InitializeDatasourceStorage("subs", map[string]any{"subtitle_text": "TEXT"}); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	st, err := manager.GetStorage("subs")
+	if err != nil {
+		t.Fatalf("GetStorage error: %v", err)
+	}
+	subtitle := strings.Repeat("filler words ", 50) + "the armadillo appears " + strings.Repeat("more filler ", 50)
+	block := core.NewGenericBlock("ep1", "Episode one", "rtve", "subs", time.Now(), map[string]any{"subtitle_text": subtitle})
+	if err := st.StoreBlock(block, "subs"); err != nil {
+		t.Fatalf("StoreBlock error: %v", err)
+	}
+
+	search := manager.GetSearchService()
+	results, err := search.Search(SearchParams{Query: "armadillo", Page: 1, Limit: 10, Snippets: true})
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	snippet := results.Snippet("subs", "ep1")
+	if !strings.Contains(snippet, SnippetMatchStart+"armadillo"+SnippetMatchEnd) {
+		t.Fatalf("expected highlighted match in snippet, got %q", snippet)
+	}
+	if len(snippet) >= len(subtitle) {
+		t.Errorf("expected snippet shorter than the matching column, got %d bytes", len(snippet))
+	}
+	if html := SnippetHTML(snippet); !strings.Contains(html, "<mark>armadillo</mark>") {
+		t.Errorf("expected escaped HTML with <mark>, got %q", html)
+	}
+
+	if got := SnippetHTML("<b>" + SnippetMatchStart + "x" + SnippetMatchEnd); got != "&lt;b&gt;<mark>x</mark>" {
+		t.Errorf("expected block text to be escaped, got %q", got)
+	}
+
+	results, err = search.Search(SearchParams{Query: "armadillo", Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if got := results.Snippet("subs", "ep1"); got != "" {
+		t.Errorf("expected no snippet when not requested, got %q", got)
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultTokenizer is the FTS5 tokenizer of datasources that don't configure one.
+// It stems English words, so "running" matches "run".
+const DefaultTokenizer = "porter"
+
+// tokenizers are the FTS5 tokenizers a datasource can be configured with.
+var tokenizers = map[string]bool{
+	// English stemming
+	"porter": true,
+	// Unicode word splitting, without stemming
+	"unicode61": true,
+	// Unicode word splitting ignoring accents, so "camion" matches "camión"
+	"unicode61 remove_diacritics 2": true,
+	// Substring matching (three characters or more), for code and identifiers
+	"trigram": true,
+}
+
+// NormalizeTokenizer validates a tokenizer name, collapsing repeated spaces.
+// An empty name selects DefaultTokenizer.
+func NormalizeTokenizer(name string) (string, error) {
+	tokenizer := strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	if tokenizer == "" {
+		return DefaultTokenizer, nil
+	}
+	if !tokenizers[tokenizer] {
+		return "", fmt.Errorf("unsupported tokenizer %q: use porter, unicode61, 'unicode61 remove_diacritics 2' or trigram", name)
+	}
+	return tokenizer, nil
+}
+
+var tokenizeOption = regexp.MustCompile(`tokenize\s*=\s*'([^']*)'`)
+
+// FTSTokenizer returns the tokenizer blocks_fts was created with.
+func (s *GenericStorage) FTSTokenizer() (string, error) {
+	var createSQL string
+	err := s.db.QueryRow("SELECT sql FROM sqlite_master WHERE type='table' AND name='blocks_fts'").Scan(&createSQL)
+	if err != nil {
+		return "", fmt.Errorf("reading FTS table definition: %w", err)
+	}
+	match := tokenizeOption.FindStringSubmatch(createSQL)
+	if match == nil {
+		// FTS5's own default
+		return "unicode61", nil
+	}
+	return strings.Join(strings.Fields(match[1]), " "), nil
+}
+
+// ApplyTokenizer makes tokenizer the FTS5 tokenizer of the storage. If blocks_fts
+// was created with a different one, it is recreated and rebuilt from the blocks
+// table in a schema migration. FTSRebuild keeps using the tokenizer afterwards.
+func (s *GenericStorage) ApplyTokenizer(tokenizer string) error {
+	tokenizer, err := NormalizeTokenizer(tokenizer)
+	if err != nil {
+		return err
+	}
+
+	current, err := s.FTSTokenizer()
+	if err != nil {
+		return err
+	}
+	if current != tokenizer {
+		if err := s.runSchemaMigrations([]schemaMigration{tokenizerMigration(tokenizer)}); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.tokenizer = tokenizer
+	s.mu.Unlock()
+	return nil
+}
+
+// tokenizerMigration recreates blocks_fts with the given tokenizer and reindexes
+// every block. The sync triggers are recreated along with the table, as in
+// migrations 003 and 007.
+func tokenizerMigration(tokenizer string) schemaMigration {
+	return schemaMigration{
+		description: fmt.Sprintf("rebuild blocks_fts with tokenizer %q", tokenizer),
+		statements: []string{
+			"DROP TRIGGER IF EXISTS blocks_ai_fts",
+			"DROP TRIGGER IF EXISTS blocks_ad_fts",
+			"DROP TRIGGER IF EXISTS blocks_au_fts",
+			"DROP TABLE IF EXISTS blocks_fts",
+			fmt.Sprintf(`CREATE VIRTUAL TABLE blocks_fts USING fts5(
+				text,
+				source,
+				datasource,
+				metadata,
+				hostname,
+				content='blocks',
+				content_rowid='rowid',
+				tokenize='%s'
+			)`, tokenizer),
+			"INSERT INTO blocks_fts(blocks_fts) VALUES('rebuild')",
+			`CREATE TRIGGER blocks_ai_fts AFTER INSERT ON blocks
+			BEGIN
+				INSERT INTO blocks_fts(rowid, text, source, datasource, metadata, hostname)
+				VALUES (new.rowid, new.text, new.source, new.datasource, new.metadata, new.hostname);
+			END`,
+			`CREATE TRIGGER blocks_ad_fts AFTER DELETE ON blocks
+			BEGIN
+				INSERT INTO blocks_fts(blocks_fts, rowid, text, source, datasource, metadata, hostname)
+				VALUES('delete', old.rowid, old.text, old.source, old.datasource, old.metadata, old.hostname);
+			END`,
+			`CREATE TRIGGER blocks_au_fts AFTER UPDATE ON blocks
+			BEGIN
+				INSERT INTO blocks_fts(blocks_fts, rowid, text, source, datasource, metadata, hostname)
+				VALUES('delete', old.rowid, old.text, old.source, old.datasource, old.metadata, old.hostname);
+				INSERT INTO blocks_fts(rowid, text, source, datasource, metadata, hostname)
+				VALUES (new.rowid, new.text, new.source, new.datasource, new.metadata, new.hostname);
+			END`,
+		},
+	}
+}
+
+// usesDefaultTokenizer reports whether all the datasources are configured with DefaultTokenizer.
+func (m *Manager) usesDefaultTokenizer(datasources []string) bool {
+	for _, ds := range datasources {
+		if m.Tokenizer(ds) != DefaultTokenizer {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestNormalizeTokenizer(t *testing.T) {
+	valid := map[string]string{
+		"":                               "porter",
+		"porter":                         "porter",
+		"Trigram":                        "trigram",
+		"unicode61":                      "unicode61",
+		"unicode61  remove_diacritics 2": "unicode61 remove_diacritics 2",
+	}
+	for name, want := range valid {
+		got, err := NormalizeTokenizer(name)
+		if err != nil || got != want {
+			t.Errorf("NormalizeTokenizer(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"ascii", "porter'); DROP TABLE blocks; --", "unicode61 remove_diacritics 3"} {
+		if _, err := NormalizeTokenizer(name); err == nil {
+			t.Errorf("NormalizeTokenizer(%q) expected error", name)
+		}
+	}
+}
+
+func TestDatasourceTokenizer(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	schema := map[string]any{"title": "TEXT"}
+	if err := manager.InitializeDatasourceStorage("code", schema); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	storage, err := manager.GetStorage("code")
+	if err != nil {
+		t.Fatalf("GetStorage error: %v", err)
+	}
+	if tokenizer, err := storage.FTSTokenizer(); err != nil || tokenizer != DefaultTokenizer {
+		t.Fatalf("FTSTokenizer = %q, %v; want %q", tokenizer, err, DefaultTokenizer)
+	}
+
+	now := time.Now()
+	blocks := []core.Block{
+		core.NewGenericBlock("1", "func parseGolangQuery", "code", "code", now, nil),
+		core.NewGenericBlock("2", "running late", "code", "code", now.Add(-time.Minute), nil),
+	}
+	if err := storage.StoreBlocks(blocks, "code"); err != nil {
+		t.Fatalf("StoreBlocks error: %v", err)
+	}
+	count := func(query string) int {
+		t.Helper()
+		results, err := manager.GetSearchService().Search(SearchParams{Query: query, Page: 1, Limit: 10})
+		if err != nil {
+			t.Fatalf("Search(%q) error: %v", query, err)
+		}
+		return len(results.Ordered)
+	}
+	if count("run") != 1 || count("golang") != 0 {
+		t.Fatal("expected porter to stem words and not match substrings")
+	}
+
+	// Changing the tokenizer rebuilds the index of the existing blocks
+	if err := manager.SetTokenizer("code", "trigram"); err != nil {
+		t.Fatalf("SetTokenizer error: %v", err)
+	}
+	if err := manager.InitializeDatasourceStorage("code", schema); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	if tokenizer, _ := storage.FTSTokenizer(); tokenizer != "trigram" {
+		t.Fatalf("FTSTokenizer = %q, want trigram", tokenizer)
+	}
+	if count("golang") != 1 || count("unn") != 1 {
+		t.Error("expected trigram to match substrings")
+	}
+
+	// The sync triggers survive the rebuild
+	if err := storage.StoreBlock(core.NewGenericBlock("3", "another golang snippet", "code", "code", now, nil), "code"); err != nil {
+		t.Fatalf("StoreBlock error: %v", err)
+	}
+	if got := count("golang"); got != 2 {
+		t.Errorf("expected new blocks to be indexed, got %d matches", got)
+	}
+	if _, err := storage.GetDB().Exec("DELETE FROM blocks WHERE id = '3'"); err != nil {
+		t.Fatalf("deleting block: %v", err)
+	}
+	if err := storage.FTSIntegrityCheck(); err != nil {
+		t.Errorf("FTSIntegrityCheck error: %v", err)
+	}
+
+	// FTSRebuild keeps the configured tokenizer
+	if err := storage.FTSRebuild(); err != nil {
+		t.Fatalf("FTSRebuild error: %v", err)
+	}
+	if tokenizer, _ := storage.FTSTokenizer(); tokenizer != "trigram" || count("golang") != 1 {
+		t.Errorf("expected trigram index after FTSRebuild, got %q", tokenizer)
+	}
+
+	if err := manager.SetTokenizer("code", "unicode61 remove_diacritics 2"); err != nil {
+		t.Fatalf("SetTokenizer error: %v", err)
+	}
+	if err := manager.InitializeDatasourceStorage("code", schema); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	if err := storage.StoreBlock(core.NewGenericBlock("4", "Gasolinera Camión", "code", "code", now, nil), "code"); err != nil {
+		t.Fatalf("StoreBlock error: %v", err)
+	}
+	if count("camion") != 1 || count("run") != 0 {
+		t.Error("expected unicode61 to ignore accents without stemming")
+	}
+
+	if err := manager.SetTokenizer("code", "ascii"); err == nil {
+		t.Error("expected an error for an unsupported tokenizer")
+	}
+}
@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+// WatchedBlock is a block found by a BlockWatcher.
+type WatchedBlock struct {
+	Datasource string
+	Block      core.Block
+}
+
+// BlockWatcher finds the blocks inserted into datasource databases since it
+// last looked, whichever process inserted them, so a process sharing only the
+// storage directory with the warehouse can follow what it stores.
+//
+// Each database is watched through its own read-only connection:
+// PRAGMA data_version on it changes when another connection commits, so
+// idle databases cost one pragma per poll, and changed ones are queried for
+// rows past the highest rowid seen. Blocks updated in place keep their rowid
+// and are not reported again.
+type BlockWatcher struct {
+	storageDir string
+	dbs        map[string]*watchedDB
+	mu         sync.Mutex
+}
+
+type watchedDB struct {
+	db        *sql.DB
+	version   int64
+	lastRowID int64
+}
+
+// NewBlockWatcher returns a watcher for the databases of the given
+// datasources. Blocks stored before the first Poll are not reported.
+func (m *Manager) NewBlockWatcher(datasources []string) (*BlockWatcher, error) {
+	w := &BlockWatcher{storageDir: m.storageDir, dbs: make(map[string]*watchedDB)}
+	for _, name := range datasources {
+		if err := w.open(name); err != nil {
+			_ = w.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// open opens the connection to the database of a datasource and starts
+// watching it from its current last row.
+func (w *BlockWatcher) open(name string) error {
+	db, err := sql.Open("sqlite3", filepath.Join(w.storageDir, fmt.Sprintf("%s.db", name)))
+	if err != nil {
+		return fmt.Errorf("opening database of %s: %w", name, err)
+	}
+	// data_version is per connection, so there must be only one
+	db.SetMaxOpenConns(1)
+	pragmas := []string{
+		"PRAGMA busy_timeout = 30000",
+		"PRAGMA query_only = ON",
+	}
+	for _, pragma := range pragmas {
+		if _, err := db.Exec(pragma); err != nil {
+			_ = db.Close()
+			return fmt.Errorf("applying pragma %q to %s: %w", pragma, name, err)
+		}
+	}
+
+	wdb := &watchedDB{db: db}
+	if err := db.QueryRow("PRAGMA data_version").Scan(&wdb.version); err != nil {
+		_ = db.Close()
+		return fmt.Errorf("reading data version of %s: %w", name, err)
+	}
+	if err := db.QueryRow("SELECT COALESCE(MAX(rowid), 0) FROM blocks").Scan(&wdb.lastRowID); err != nil {
+		_ = db.Close()
+		return fmt.Errorf("reading last block of %s: %w", name, err)
+	}
+	w.dbs[name] = wdb
+	return nil
+}
+
+// Poll returns the blocks inserted since the previous call, oldest first for
+// each datasource, and at most limit per datasource; the rest are returned by
+// the next calls. Datasources failing are reported in the error and retried
+// on the next call.
+func (w *BlockWatcher) Poll(limit int) ([]WatchedBlock, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var (
+		found []WatchedBlock
+		errs  []error
+	)
+	for name, wdb := range w.dbs {
+		blocks, err := wdb.poll(limit)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("watching %s: %w", name, err))
+		}
+		for _, b := range blocks {
+			found = append(found, WatchedBlock{Datasource: name, Block: b})
+		}
+	}
+	return found, errors.Join(errs...)
+}
+
+// poll returns up to limit blocks past lastRowID when the database changed
+func (wdb *watchedDB) poll(limit int) ([]core.Block, error) {
+	var version int64
+	if err := wdb.db.QueryRow("PRAGMA data_version").Scan(&version); err != nil {
+		return nil, fmt.Errorf("reading data version: %w", err)
+	}
+	// A full batch may have left rows behind, so the version is only
+	// remembered once caught up
+	if version == wdb.version {
+		return nil, nil
+	}
+
+	// SQLite hands the rowids of deleted newest blocks out again, so the
+	// cursor follows deletions seen between polls. Blocks reusing a rowid
+	// deleted since the previous poll are missed.
+	var maxRowID int64
+	if err := wdb.db.QueryRow("SELECT COALESCE(MAX(rowid), 0) FROM blocks").Scan(&maxRowID); err != nil {
+		return nil, fmt.Errorf("reading last block: %w", err)
+	}
+	if maxRowID < wdb.lastRowID {
+		wdb.lastRowID = maxRowID
+	}
+
+	rows, err := wdb.db.Query(`
+		SELECT rowid, id, text, created_at, source, datasource, metadata, hostname
+		FROM blocks
+		WHERE rowid > ?
+		ORDER BY rowid
+		LIMIT ?`, wdb.lastRowID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying new blocks: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			fmt.Printf("Warning: failed to close rows: %v\n", err)
+		}
+	}()
+
+	var blocks []core.Block
+	for rows.Next() {
+		var rowID int64
+		var id, text, source, datasourceType, metadataStr string
+		var hostname sql.NullString
+		var createdAt time.Time
+
+		if err := rows.Scan(&rowID, &id, &text, &createdAt, &source, &datasourceType, &metadataStr, &hostname); err != nil {
+			return nil, fmt.Errorf("scanning row: %w", err)
+		}
+		wdb.lastRowID = rowID
+
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
+			return nil, fmt.Errorf("unmarshaling metadata for block %s: %w", id, err)
+		}
+		blocks = append(blocks, core.NewGenericBlockWithHostname(id, text, source, datasourceType, hostname.String, createdAt, metadata))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(blocks) < limit {
+		wdb.version = version
+	}
+	return blocks, nil
+}
+
+// Close closes the watcher's database connections.
+func (w *BlockWatcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var errs []error
+	for name, wdb := range w.dbs {
+		if err := wdb.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("closing database of %s: %w", name, err))
+		}
+		delete(w.dbs, name)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/core"
+)
+
+func TestBlockWatcher(t *testing.T) {
+	manager := NewManagerWithoutMigrationCheck(t.TempDir())
+	defer func() { _ = manager.Close() }()
+
+	if err := manager.InitializeDatasourceStorage("hn", map[string]any{"title": "TEXT"}); err != nil {
+		t.Fatalf("InitializeDatasourceStorage error: %v", err)
+	}
+	hn, _ := manager.GetStorage("hn")
+	now := time.Now()
+	store := func(ids ...string) {
+		t.Helper()
+		var blocks []core.Block
+		for _, id := range ids {
+			blocks = append(blocks, core.NewGenericBlock(id, "text of "+id, "hn", "hackernews", now, map[string]interface{}{"score": 1}))
+		}
+		if err := hn.StoreBlocks(blocks, "hackernews"); err != nil {
+			t.Fatalf("StoreBlocks error: %v", err)
+		}
+	}
+	poll := func(w *BlockWatcher, limit int) []string {
+		t.Helper()
+		found, err := w.Poll(limit)
+		if err != nil {
+			t.Fatalf("Poll error: %v", err)
+		}
+		var ids []string
+		for _, wb := range found {
+			if wb.Datasource != "hn" || wb.Block.Type() != "hackernews" {
+				t.Errorf("unexpected block %s of %s with type %s", wb.Block.ID(), wb.Datasource, wb.Block.Type())
+			}
+			ids = append(ids, wb.Block.ID())
+		}
+		return ids
+	}
+
+	store("old")
+	watcher, err := manager.NewBlockWatcher([]string{"hn"})
+	if err != nil {
+		t.Fatalf("NewBlockWatcher error: %v", err)
+	}
+	defer func() { _ = watcher.Close() }()
+
+	// Blocks stored before the watcher was created are not reported
+	if ids := poll(watcher, 10); len(ids) != 0 {
+		t.Fatalf("expected no blocks, got %v", ids)
+	}
+
+	// New blocks are reported once, in insertion order, limit at a time
+	store("b1", "b2", "b3")
+	if ids := poll(watcher, 2); len(ids) != 2 || ids[0] != "b1" || ids[1] != "b2" {
+		t.Fatalf("expected b1 and b2, got %v", ids)
+	}
+	if ids := poll(watcher, 2); len(ids) != 1 || ids[0] != "b3" {
+		t.Fatalf("expected b3, got %v", ids)
+	}
+
+	// Updated blocks are not reported again
+	store("b1", "b4")
+	if ids := poll(watcher, 10); len(ids) != 1 || ids[0] != "b4" {
+		t.Fatalf("expected b4, got %v", ids)
+	}
+
+	// Rowids of deleted newest blocks are handed out again
+	if _, err := hn.DeleteBlocks([]string{"b3", "b4"}); err != nil {
+		t.Fatalf("DeleteBlocks error: %v", err)
+	}
+	if ids := poll(watcher, 10); len(ids) != 0 {
+		t.Fatalf("expected no blocks after deleting, got %v", ids)
+	}
+	store("b5")
+	if ids := poll(watcher, 10); len(ids) != 1 || ids[0] != "b5" {
+		t.Fatalf("expected b5, got %v", ids)
+	}
+}
@@ -0,0 +1,153 @@
+package warehouse
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rubiojr/ergs/pkg/core"
+	"github.com/rubiojr/ergs/pkg/storage"
+)
+
+// cursorKeyPrefix namespaces datasource cursors inside the fetch_metadata table.
+const cursorKeyPrefix = "cursor:"
+
+// fetchCursors is the core.CursorStore handed to a datasource during a single fetch.
+// Reads fall through to the datasource's fetch_metadata table; writes are buffered
+// and only persisted by commit, after every block from the fetch has been stored.
+type fetchCursors struct {
+	datasource     string
+	storageManager *storage.Manager
+	mu             sync.Mutex
+	pending        map[string]string
+}
+
+func newFetchCursors(datasource string, storageManager *storage.Manager) *fetchCursors {
+	return &fetchCursors{
+		datasource:     datasource,
+		storageManager: storageManager,
+		pending:        make(map[string]string),
+	}
+}
+
+// GetCursor implements core.CursorStore.
+func (c *fetchCursors) GetCursor(key string) (string, bool, error) {
+	c.mu.Lock()
+	value, ok := c.pending[key]
+	c.mu.Unlock()
+	if ok {
+		return value, true, nil
+	}
+
+	st, err := c.storageManager.GetStorage(c.datasource)
+	if err != nil {
+		return "", false, fmt.Errorf("getting storage for datasource %s: %w", c.datasource, err)
+	}
+	return st.GetFetchMetadata(cursorKeyPrefix + key)
+}
+
+// SetCursor implements core.CursorStore.
+func (c *fetchCursors) SetCursor(key, value string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending[key] = value
+	return nil
+}
+
+// commit persists all buffered cursor updates.
+func (c *fetchCursors) commit() error {
+	c.mu.Lock()
+	values := make(map[string]string, len(c.pending))
+	for key, value := range c.pending {
+		values[cursorKeyPrefix+key] = value
+	}
+	c.mu.Unlock()
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	st, err := c.storageManager.GetStorage(c.datasource)
+	if err != nil {
+		return fmt.Errorf("getting storage for datasource %s: %w", c.datasource, err)
+	}
+	if err := st.SetFetchMetadata(values); err != nil {
+		return fmt.Errorf("saving cursors for datasource %s: %w", c.datasource, err)
+	}
+	whLogger.Debugf("Saved %d cursor(s) for datasource %s", len(values), c.datasource)
+	return nil
+}
+
+// fetchRun tracks the datasources of one fetch cycle so cursors are only committed
+// for datasources whose fetch completed and whose blocks were all stored.
+type fetchRun struct {
+	mu        sync.Mutex
+	completed []*fetchCursors
+	failed    map[string]bool
+}
+
+func newFetchRun() *fetchRun {
+	return &fetchRun{failed: make(map[string]bool)}
+}
+
+// fetchDone records the outcome of a datasource's FetchBlocks call.
+func (r *fetchRun) fetchDone(cursors *fetchCursors, err error) {
+	if err != nil {
+		return
+	}
+	r.mu.Lock()
+	r.completed = append(r.completed, cursors)
+	r.mu.Unlock()
+}
+
+// storeFailed marks a datasource as having failed to store at least one block.
+func (r *fetchRun) storeFailed(datasource string) {
+	r.mu.Lock()
+	r.failed[datasource] = true
+	r.mu.Unlock()
+}
+
+// commit persists the cursors of every successfully completed datasource.
+// Must be called once all blocks of the run have been processed.
+func (r *fetchRun) commit() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cursors := range r.completed {
+		if r.failed[cursors.datasource] {
+			whLogger.Warnf("Not saving cursors for datasource %s: some blocks failed to store", cursors.datasource)
+			continue
+		}
+		if err := cursors.commit(); err != nil {
+			whLogger.Warnf("%v", err)
+		}
+	}
+}
+
+// ResetCursors deletes all stored fetch cursors for a datasource so the next
+// fetch starts from scratch. Returns the number of cursors removed.
+func (w *Warehouse) ResetCursors(datasourceName string) (int64, error) {
+	w.mu.RLock()
+	var targetDS core.Datasource
+	for ds, name := range w.datasourceNames {
+		if name == datasourceName {
+			targetDS = ds
+			break
+		}
+	}
+	w.mu.RUnlock()
+
+	if targetDS == nil {
+		return 0, fmt.Errorf("datasource %s not found", datasourceName)
+	}
+
+	// Datasources without a schema have no storage (and therefore no cursors)
+	if len(targetDS.Schema()) == 0 {
+		return 0, nil
+	}
+
+	st, err := w.storageManager.GetStorage(datasourceName)
+	if err != nil {
+		return 0, fmt.Errorf("getting storage for datasource %s: %w", datasourceName, err)
+	}
+	return st.DeleteFetchMetadata(cursorKeyPrefix)
+}
@@ -2,61 +2,199 @@ package warehouse
 
 import (
 	"bufio"
+	"crypto/subtle"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
 
 // eventBridge is a lightweight, in-process publisher that fans out block events
-// to *other processes* (the web/API process) via a Unix domain socket.
+// to *other processes* (the web/API process) via a Unix domain socket and,
+// optionally, a TCP listener for consumers on other hosts.
 // It is intentionally one-way: warehouse -> consumers.
 //
 // Protocol:
 //   - Newline delimited JSON (NDJSON)
 //   - Each line is one JSON object
 //   - Event types:
-//     { "type":"block", "id":"...", "datasource":"...", "ds_type":"...", "created_at":"RFC3339", "text":"...", "metadata":{...} }
-//     { "type":"heartbeat", "ts":"RFC3339Nano" }
+//     { "type":"block", "seq":N, "id":"...", "datasource":"...", "ds_type":"...", "created_at":"RFC3339", "text":"...", "metadata":{...} }
+//     { "type":"delete", "seq":N, "id":"...", "datasource":"...", "ds_type":"..." } (a datasource sent a core.Tombstone)
+//     { "type":"heartbeat", "ts":"RFC3339Nano", "seq":N } (seq of the last event)
+//     { "type":"gap", "from_seq":A, "to_seq":B } (events A..B can't be replayed any more)
 //     { "type":"info", "message":"..." } (occasionally, e.g. when shutting down)
-//     { "type":"error", "message":"...", "detail":"..." } (rare)
+//     { "type":"error", "message":"...", "detail":"..." } (rare, e.g. when authentication fails)
+//
+// TCP listener:
+//   - Enabled by Config.EventListenAddr, with TLS when Config.EventTLSCert and
+//     Config.EventTLSKey are set.
+//   - Consumers must authenticate first, sending { "type":"auth", "token":"..." }
+//     within authWait, with the token in Config.EventToken. Connections that
+//     don't are sent an error event and closed. Unix socket consumers don't
+//     authenticate.
+//
+// Sequence numbers and replay:
+//   - Every block and delete event has a sequence number, increasing by one
+//     with each event. With an event log (Config.EventLogPath) the sequence
+//     survives restarts and the newest events are kept on disk.
+//   - A client may send { "type":"resume", "resume_from":N } right after
+//     connecting, N being the last sequence number it got. The bridge replays
+//     the logged events after N, then streams live events, without gaps or
+//     duplicates. Events no longer in the log are reported with a gap event,
+//     so the client can backfill them over REST.
+//   - Live events are held back until the client sends its resume request,
+//     or for resumeWait if it sends none.
+//   - The resume request may also set "datasources" and "types" (lists of
+//     datasource names and types) to only receive, and replay, the events of
+//     those datasources. resume_from may be 0 to only set filters.
 //
 // Design goals:
 //   - Never block ingestion: writes are best-effort; failures are logged silently here.
 //   - Multiple consumers may connect simultaneously (fan-out writing to each).
-//   - Inbound data from clients is ignored and connections are dropped on read error.
+//   - Inbound data other than the first resume request is ignored and
+//     connections are dropped on read error.
 //   - Heartbeats help consumers detect dead connections quickly.
 //
 // Limits / Non-goals:
-//   - No durability / replay. Consumers must do an initial REST backfill on (re)connect.
+//   - Replay is bounded by the event log size (Config.EventLogSize).
 //   - No per-client backpressure; if a client stalls, its connection eventually errors out.
-//   - No authentication (assumes local, permission-controlled socket).
+//   - No authentication on the Unix socket (assumes local, permission-controlled socket).
 //
 // Security considerations:
 //   - The Unix socket path should reside in a directory with controlled permissions.
 //   - The file is removed and recreated on start, and removed on stop.
+//   - The TCP listener refuses to start without a token. Without TLS, the
+//     token and events travel in clear text.
 //
 // Lifecycle:
-//   - Created when warehouse.Config.EventSocketPath != "".
+//   - Created when warehouse.Config.EventSocketPath or EventListenAddr != "".
 //   - Started in Warehouse.Start().
 //   - Stopped in Warehouse.Stop() or on process termination.
 type eventBridge struct {
 	path      string
 	ln        net.Listener
 	mu        sync.RWMutex
-	conns     map[net.Conn]struct{}
+	conns     map[net.Conn]*bridgeConn
 	stopCh    chan struct{}
 	startOnce sync.Once
 	stopOnce  sync.Once
 	running   bool
+
+	// TCP listener (optional; nil if tcpAddr is empty)
+	tcpAddr string
+	tlsCert string
+	tlsKey  string
+	token   string
+	tcpLn   net.Listener
+
+	// Event log (optional; nil if logPath is empty)
+	logPath string
+	logSize int
+	log     *eventLog
+
+	// Serializes publishing, so events are logged and broadcast in sequence order
+	pubMu sync.Mutex
+	seq   uint64
+}
+
+// bridgeConn is a consumer connection. Until the consumer is live, events
+// are buffered instead of written, so they can follow the replayed ones.
+type bridgeConn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	filter  bridgeFilter
+	live    bool
+	pending []bridgeFrame
+}
+
+// bridgeFrame is an encoded event to be written to connections, with the
+// datasource it's about for filtering
+type bridgeFrame struct {
+	seq        uint64
+	datasource string
+	dsType     string
+	data       []byte
+}
+
+// bridgeFilter selects the events sent to a connection. Empty lists match
+// every datasource.
+type bridgeFilter struct {
+	datasources []string
+	types       []string
+}
+
+// matches reports whether events about a datasource pass the filter. Frames
+// that aren't about a datasource (seq 0) always do.
+func (f bridgeFilter) matches(frame bridgeFrame) bool {
+	if frame.seq == 0 {
+		return true
+	}
+	if len(f.datasources) > 0 && !slices.Contains(f.datasources, frame.datasource) {
+		return false
+	}
+	return len(f.types) == 0 || slices.Contains(f.types, frame.dsType)
+}
+
+// matchesEncoded is matches for an encoded event with sequence number seq
+func (f bridgeFilter) matchesEncoded(seq uint64, data []byte) bool {
+	if len(f.datasources) == 0 && len(f.types) == 0 {
+		return true
+	}
+	var evt struct {
+		Datasource string `json:"datasource"`
+		DSType     string `json:"ds_type"`
+	}
+	if err := json.Unmarshal(data, &evt); err != nil {
+		return false
+	}
+	return f.matches(bridgeFrame{seq: seq, datasource: evt.Datasource, dsType: evt.DSType})
 }
 
+// authRequest is the first line sent by TCP consumers.
+type authRequest struct {
+	Type  string `json:"type"`
+	Token string `json:"token"`
+}
+
+// resumeRequest is the optional first line sent by a consumer (after
+// authenticating, for TCP consumers).
+type resumeRequest struct {
+	Type        string   `json:"type"`
+	ResumeFrom  uint64   `json:"resume_from"`
+	Datasources []string `json:"datasources,omitempty"`
+	Types       []string `json:"types,omitempty"`
+}
+
+// bridgeGapEvent reports events a consumer asked for that can't be replayed.
+type bridgeGapEvent struct {
+	Type    string `json:"type"`
+	FromSeq uint64 `json:"from_seq"`
+	ToSeq   uint64 `json:"to_seq"`
+}
+
+const (
+	// resumeWait is how long live events are held back for a consumer that
+	// sends no resume request
+	resumeWait = 500 * time.Millisecond
+	// authWait is how long TCP consumers have to authenticate, TLS handshake
+	// included
+	authWait = 10 * time.Second
+	// maxPendingFrames is how many live events are buffered for a consumer
+	// while its replay is written before it is disconnected
+	maxPendingFrames = 10000
+)
+
 // bridgeBlockEvent is the JSON structure emitted for each stored block.
 type bridgeBlockEvent struct {
 	Type       string         `json:"type"`
+	Seq        uint64         `json:"seq"`
 	ID         string         `json:"id"`
 	Datasource string         `json:"datasource"`
 	DSType     string         `json:"ds_type,omitempty"`
@@ -65,55 +203,162 @@ type bridgeBlockEvent struct {
 	Metadata   map[string]any `json:"metadata,omitempty"`
 }
 
-// newEventBridge constructs (but does not start) an event bridge.
-// If path is empty, the bridge is effectively disabled.
-func newEventBridge(path string) *eventBridge {
+// bridgeDeleteEvent is the JSON structure emitted for each deleted block.
+type bridgeDeleteEvent struct {
+	Type       string `json:"type"`
+	Seq        uint64 `json:"seq"`
+	ID         string `json:"id"`
+	Datasource string `json:"datasource"`
+	DSType     string `json:"ds_type"`
+}
+
+// newEventBridge constructs (but does not start) an event bridge listening
+// on config.EventSocketPath and config.EventListenAddr. Events are logged to
+// config.EventLogPath for replay; without it sequence numbers restart on
+// every start and nothing can be replayed.
+func newEventBridge(config Config) *eventBridge {
 	return &eventBridge{
-		path:   path,
-		conns:  make(map[net.Conn]struct{}),
-		stopCh: make(chan struct{}),
+		path:    config.EventSocketPath,
+		conns:   make(map[net.Conn]*bridgeConn),
+		stopCh:  make(chan struct{}),
+		tcpAddr: config.EventListenAddr,
+		tlsCert: config.EventTLSCert,
+		tlsKey:  config.EventTLSKey,
+		token:   config.EventToken,
+		logPath: config.EventLogPath,
+		logSize: config.EventLogSize,
 	}
 }
 
-// start initializes the Unix domain socket listener and begins accepting connections.
-// Safe to call multiple times; subsequent calls are ignored.
+// String describes where the bridge listens
+func (b *eventBridge) String() string {
+	var addrs []string
+	if b.path != "" {
+		addrs = append(addrs, b.path)
+	}
+	if b.tcpAddr != "" {
+		scheme := "tcp"
+		if b.tlsCert != "" {
+			scheme = "tls"
+		}
+		addrs = append(addrs, scheme+"://"+b.tcpAddr)
+	}
+	return strings.Join(addrs, " and ")
+}
+
+// start initializes the Unix domain socket and TCP listeners and begins
+// accepting connections. Safe to call multiple times; subsequent calls are ignored.
 func (b *eventBridge) start() error {
 	var err error
 	b.startOnce.Do(func() {
-		if b.path == "" {
-			err = errors.New("event bridge path is empty")
+		if b.path == "" && b.tcpAddr == "" {
+			err = errors.New("event bridge path and listen address are empty")
 			return
 		}
+		var tlsConfig *tls.Config
+		if b.tcpAddr != "" {
+			if b.token == "" {
+				err = errors.New("a token is required to listen on TCP")
+				return
+			}
+			if (b.tlsCert == "") != (b.tlsKey == "") {
+				err = errors.New("both a TLS certificate and key are required")
+				return
+			}
+			if b.tlsCert != "" {
+				cert, certErr := tls.LoadX509KeyPair(b.tlsCert, b.tlsKey)
+				if certErr != nil {
+					err = fmt.Errorf("loading TLS certificate: %w", certErr)
+					return
+				}
+				tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+			}
+		}
 
-		// Remove stale socket file if it exists
-		if st, statErr := os.Stat(b.path); statErr == nil && !st.IsDir() {
-			_ = os.Remove(b.path)
+		if b.logPath != "" {
+			if mkErr := os.MkdirAll(filepath.Dir(b.logPath), 0755); mkErr != nil {
+				err = fmt.Errorf("creating event log directory: %w", mkErr)
+				return
+			}
+			l, logErr := openEventLog(b.logPath, b.logSize)
+			if logErr != nil {
+				err = logErr
+				return
+			}
+			_, newest, boundsErr := l.bounds()
+			if boundsErr != nil {
+				_ = l.close()
+				err = boundsErr
+				return
+			}
+			b.log = l
+			b.seq = newest
 		}
 
-		ln, listenErr := net.Listen("unix", b.path)
-		if listenErr != nil {
-			err = fmt.Errorf("listen on unix socket %s: %w", b.path, listenErr)
-			return
+		cleanup := func() {
+			if b.ln != nil {
+				_ = b.ln.Close()
+				b.ln = nil
+			}
+			if b.log != nil {
+				_ = b.log.close()
+				b.log = nil
+			}
 		}
 
-		// Set more restrictive permissions (owner RWX, group RWX, others none) for the socket path's parent dir if possible.
-		// The socket itself typically inherits process umask; users can manage directory perms externally.
-		// Best effort; ignore errors.
-		_ = os.Chmod(b.path, 0660)
+		if b.path != "" {
+			// Remove stale socket file if it exists
+			if st, statErr := os.Stat(b.path); statErr == nil && !st.IsDir() {
+				_ = os.Remove(b.path)
+			}
+
+			ln, listenErr := net.Listen("unix", b.path)
+			if listenErr != nil {
+				cleanup()
+				err = fmt.Errorf("listen on unix socket %s: %w", b.path, listenErr)
+				return
+			}
+
+			// Set more restrictive permissions (owner RWX, group RWX, others none) for the socket path's parent dir if possible.
+			// The socket itself typically inherits process umask; users can manage directory perms externally.
+			// Best effort; ignore errors.
+			_ = os.Chmod(b.path, 0660)
+			b.ln = ln
+		}
+
+		if b.tcpAddr != "" {
+			ln, listenErr := net.Listen("tcp", b.tcpAddr)
+			if listenErr != nil {
+				cleanup()
+				err = fmt.Errorf("listen on %s: %w", b.tcpAddr, listenErr)
+				return
+			}
+			if tlsConfig != nil {
+				ln = tls.NewListener(ln, tlsConfig)
+			} else {
+				whLogger.Warnf("Event bridge: listening on %s without TLS, the token and events are sent in clear text", b.tcpAddr)
+			}
+			b.tcpLn = ln
+		}
 
-		b.ln = ln
 		b.running = true
 
-		go b.acceptLoop()
+		if b.ln != nil {
+			go b.acceptLoop(b.ln, false)
+		}
+		if b.tcpLn != nil {
+			go b.acceptLoop(b.tcpLn, true)
+		}
 		go b.heartbeatLoop()
 	})
 	return err
 }
 
 // acceptLoop continuously accepts new client connections until stopped.
-func (b *eventBridge) acceptLoop() {
+// Clients of listeners with auth set must authenticate.
+func (b *eventBridge) acceptLoop(ln net.Listener, auth bool) {
 	for {
-		conn, err := b.ln.Accept()
+		conn, err := ln.Accept()
 		if err != nil {
 			// Check if we are shutting down
 			select {
@@ -132,26 +377,166 @@ func (b *eventBridge) acceptLoop() {
 			return
 		}
 
-		b.mu.Lock()
-		b.conns[conn] = struct{}{}
-		b.mu.Unlock()
+		bc := &bridgeConn{conn: conn, reader: bufio.NewReader(conn)}
+		if auth {
+			go func() {
+				if b.authenticate(bc) {
+					b.register(bc)
+				} else {
+					_ = conn.Close()
+				}
+			}()
+			continue
+		}
+		b.register(bc)
+	}
+}
+
+// authenticate reads the auth request of a TCP client and checks its token.
+// Clients failing to authenticate are sent an error event.
+func (b *eventBridge) authenticate(bc *bridgeConn) bool {
+	c := bc.conn
+	_ = c.SetDeadline(time.Now().Add(authWait))
+	defer func() { _ = c.SetDeadline(time.Time{}) }()
 
-		go b.drain(conn)
+	line, err := bc.reader.ReadBytes('\n')
+	if err != nil {
+		return false
+	}
+	var req authRequest
+	if err := json.Unmarshal(line, &req); err != nil || req.Type != "auth" || subtle.ConstantTimeCompare([]byte(req.Token), []byte(b.token)) != 1 {
+		whLogger.Warnf("Event bridge: authentication failed for %s", c.RemoteAddr())
+		_ = b.writeTo(c, map[string]any{"type": "error", "message": "authentication failed"})
+		return false
+	}
+	return true
+}
+
+// register adds a client connection, buffering events for it until serve
+// makes it live.
+func (b *eventBridge) register(bc *bridgeConn) {
+	b.mu.Lock()
+	select {
+	case <-b.stopCh:
+		// Stopped while authenticating
+		b.mu.Unlock()
+		_ = bc.conn.Close()
+		return
+	default:
 	}
+	b.conns[bc.conn] = bc
+	b.mu.Unlock()
+
+	go b.serve(bc)
 }
 
-// drain consumes (and ignores) any inbound data from a client.
+// serve waits for the client's resume request, replays the events it missed
+// and makes it live, then consumes (and ignores) any further inbound data.
 // When the client disconnects or an error occurs, the connection is removed.
-func (b *eventBridge) drain(c net.Conn) {
-	sc := bufio.NewScanner(c)
-	for sc.Scan() {
-		// Ignore inbound lines (protocol is one-way).
+func (b *eventBridge) serve(bc *bridgeConn) {
+	c := bc.conn
+	defer func() {
+		b.mu.Lock()
+		delete(b.conns, c)
+		b.mu.Unlock()
+		_ = c.Close()
+	}()
+
+	reader := bc.reader
+	_ = c.SetReadDeadline(time.Now().Add(resumeWait))
+	line, err := reader.ReadBytes('\n')
+	_ = c.SetReadDeadline(time.Time{})
+	var req resumeRequest
+	if err == nil {
+		if jsonErr := json.Unmarshal(line, &req); jsonErr != nil || req.Type != "resume" {
+			req = resumeRequest{}
+		}
+	} else if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		return
 	}
-	// Remove connection
+
 	b.mu.Lock()
-	delete(b.conns, c)
+	bc.filter = bridgeFilter{datasources: req.Datasources, types: req.Types}
 	b.mu.Unlock()
-	_ = c.Close()
+
+	if err := b.resume(bc, req.ResumeFrom); err != nil {
+		return
+	}
+
+	for {
+		// Ignore inbound lines (protocol is one-way after the resume request).
+		if _, err := reader.ReadBytes('\n'); err != nil {
+			return
+		}
+	}
+}
+
+// resume replays the logged events after seq (none when seq is 0) to a
+// connection that isn't live yet, then writes the events buffered meanwhile
+// and makes it live.
+func (b *eventBridge) resume(bc *bridgeConn, seq uint64) error {
+	replayed := seq
+	if seq > 0 {
+		b.pubMu.Lock()
+		current, events := b.seq, b.log
+		b.pubMu.Unlock()
+
+		oldest := current + 1
+		if events != nil {
+			first, _, err := events.bounds()
+			if err != nil {
+				whLogger.Warnf("Event bridge: %v", err)
+			} else if first > 0 {
+				oldest = first
+			}
+		}
+
+		switch {
+		case seq > current:
+			// The client saw events this log never had, e.g. the log was removed
+			if err := b.writeTo(bc.conn, map[string]any{
+				"type":    "info",
+				"message": fmt.Sprintf("resume_from %d is ahead of the last event %d, the event log was reset", seq, current),
+			}); err != nil {
+				return err
+			}
+			replayed = current
+		case seq+1 < oldest:
+			if err := b.writeTo(bc.conn, bridgeGapEvent{Type: "gap", FromSeq: seq + 1, ToSeq: oldest - 1}); err != nil {
+				return err
+			}
+		}
+
+		if events != nil && seq < current {
+			// The connection isn't live, so nothing else writes to it
+			err := events.since(seq, current, func(s uint64, data []byte) error {
+				replayed = s
+				if !bc.filter.matchesEncoded(s, data) {
+					return nil
+				}
+				return b.write(bc.conn, data)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if replayed < current {
+			replayed = current
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, frame := range bc.pending {
+		if frame.seq > replayed && bc.filter.matches(frame) {
+			if err := b.write(bc.conn, frame.data); err != nil {
+				return err
+			}
+		}
+	}
+	bc.pending = nil
+	bc.live = true
+	return nil
 }
 
 // heartbeatLoop emits periodic heartbeat frames so consumers can detect stale connections.
@@ -163,9 +548,13 @@ func (b *eventBridge) heartbeatLoop() {
 		case <-b.stopCh:
 			return
 		case now := <-ticker.C:
-			_ = b.broadcast(map[string]any{
+			b.pubMu.Lock()
+			seq := b.seq
+			b.pubMu.Unlock()
+			_ = b.broadcast(0, map[string]any{
 				"type": "heartbeat",
 				"ts":   now.UTC().Format(time.RFC3339Nano),
+				"seq":  seq,
 			})
 		}
 	}
@@ -177,21 +566,57 @@ func (b *eventBridge) publishBlock(id, datasource, dsType string, created time.T
 	if !b.running {
 		return
 	}
-	evt := bridgeBlockEvent{
-		Type:       "block",
-		ID:         id,
-		Datasource: datasource,
-		DSType:     dsType,
-		CreatedAt:  created,
-		Text:       text,
-		Metadata:   metadata,
+	b.publish(datasource, dsType, func(seq uint64) any {
+		return bridgeBlockEvent{
+			Type:       "block",
+			Seq:        seq,
+			ID:         id,
+			Datasource: datasource,
+			DSType:     dsType,
+			CreatedAt:  created,
+			Text:       text,
+			Metadata:   metadata,
+		}
+	})
+}
+
+// publishDelete sends a best-effort delete event to all connected consumers.
+func (b *eventBridge) publishDelete(id, datasource, dsType string) {
+	if !b.running {
+		return
+	}
+	b.publish(datasource, dsType, func(seq uint64) any {
+		return bridgeDeleteEvent{Type: "delete", Seq: seq, ID: id, Datasource: datasource, DSType: dsType}
+	})
+}
+
+// publish gives the event about datasource built by build the next sequence
+// number, logs it and broadcasts it. Logging failures are logged and don't
+// stop the broadcast.
+func (b *eventBridge) publish(datasource, dsType string, build func(seq uint64) any) {
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+
+	seq := b.seq + 1
+	data, err := json.Marshal(build(seq))
+	if err != nil {
+		return
+	}
+	b.seq = seq
+	data = append(data, '\n')
+
+	if b.log != nil {
+		if err := b.log.append(seq, data); err != nil {
+			whLogger.Warnf("Event bridge: %v", err)
+		}
 	}
-	_ = b.broadcast(evt) // Best effort
+	b.broadcastFrame(bridgeFrame{seq: seq, datasource: datasource, dsType: dsType, data: data})
 }
 
 // broadcast marshals v to JSON, appends newline, and writes to every connection.
-// Dead or slow connections are closed and removed.
-func (b *eventBridge) broadcast(v any) error {
+// seq is the sequence number of the event, 0 for frames that aren't replayed
+// (heartbeats).
+func (b *eventBridge) broadcast(seq uint64, v any) error {
 	if !b.running {
 		return nil
 	}
@@ -201,42 +626,89 @@ func (b *eventBridge) broadcast(v any) error {
 		return err
 	}
 	data = append(data, '\n')
+	b.broadcastFrame(bridgeFrame{seq: seq, data: data})
+	return nil
+}
 
+// broadcastFrame writes an encoded event to every live connection and buffers
+// it for the others. Dead or slow connections, and connections buffering too
+// many events, are closed and removed.
+func (b *eventBridge) broadcastFrame(frame bridgeFrame) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	for c := range b.conns {
-		// Small write deadline to avoid blocking ingestion
-		_ = c.SetWriteDeadline(time.Now().Add(2 * time.Second))
-		if _, werr := c.Write(data); werr != nil {
+	for c, bc := range b.conns {
+		if !bc.filter.matches(frame) {
+			continue
+		}
+		if !bc.live {
+			if frame.seq == 0 {
+				continue
+			}
+			if len(bc.pending) >= maxPendingFrames {
+				_ = c.Close()
+				delete(b.conns, c)
+				continue
+			}
+			bc.pending = append(bc.pending, frame)
+			continue
+		}
+		if err := b.write(c, frame.data); err != nil {
 			// If write error, close and remove connection
 			_ = c.Close()
 			delete(b.conns, c)
-		} else {
-			// Clear deadline
-			_ = c.SetWriteDeadline(time.Time{})
 		}
 	}
-	return nil
+}
+
+// write writes an encoded event to a connection
+func (b *eventBridge) write(c net.Conn, data []byte) error {
+	// Small write deadline to avoid blocking ingestion
+	_ = c.SetWriteDeadline(time.Now().Add(2 * time.Second))
+	_, err := c.Write(data)
+	// Clear deadline
+	_ = c.SetWriteDeadline(time.Time{})
+	return err
+}
+
+// writeTo marshals v to JSON and writes it to a single connection
+func (b *eventBridge) writeTo(c net.Conn, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.write(c, append(data, '\n'))
 }
 
 // stop shuts down the bridge, closes all connections, and removes the socket file.
 // Safe to call multiple times.
 func (b *eventBridge) stop() {
 	b.stopOnce.Do(func() {
+		b.mu.Lock()
 		close(b.stopCh)
+		b.mu.Unlock()
 
 		if b.ln != nil {
 			_ = b.ln.Close()
 		}
+		if b.tcpLn != nil {
+			_ = b.tcpLn.Close()
+		}
 
 		b.mu.Lock()
 		for c := range b.conns {
 			_ = c.Close()
 		}
-		b.conns = make(map[net.Conn]struct{})
+		b.conns = make(map[net.Conn]*bridgeConn)
 		b.mu.Unlock()
 
+		b.pubMu.Lock()
+		if b.log != nil {
+			_ = b.log.close()
+			b.log = nil
+		}
+		b.pubMu.Unlock()
+
 		// Attempt to remove the socket file
 		if b.path != "" {
 			_ = os.Remove(b.path)
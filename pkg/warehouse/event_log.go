@@ -0,0 +1,134 @@
+package warehouse
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// EventLogFile is the path of the event bridge log, relative to the storage directory.
+const EventLogFile = "internal/events.db"
+
+// DefaultEventLogSize is the number of recent events kept in the event log
+// when Config.EventLogSize is not set.
+const DefaultEventLogSize = 10000
+
+// eventLogTrimEvery is how many appends happen between trims of the log
+const eventLogTrimEvery = 100
+
+const eventLogSchema = `
+CREATE TABLE IF NOT EXISTS events (
+    seq INTEGER PRIMARY KEY,
+    data TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+`
+
+// eventLog is the bounded on-disk log of recent event bridge events, keyed by
+// sequence number, so consumers can replay what they missed while
+// disconnected. Only the newest size events are kept.
+type eventLog struct {
+	db      *sql.DB
+	size    uint64
+	appends int
+}
+
+// openEventLog opens (creating it if needed) the event log database at dbPath.
+func openEventLog(dbPath string, size int) (*eventLog, error) {
+	if size <= 0 {
+		size = DefaultEventLogSize
+	}
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening event log: %w", err)
+	}
+
+	pragmas := []string{
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA synchronous = NORMAL",
+		"PRAGMA busy_timeout = 30000",
+	}
+	for _, pragma := range pragmas {
+		if _, err := db.Exec(pragma); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("applying pragma %q: %w", pragma, err)
+		}
+	}
+
+	if _, err := db.Exec(eventLogSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("creating event log schema: %w", err)
+	}
+	return &eventLog{db: db, size: uint64(size)}, nil
+}
+
+// close closes the event log database.
+func (l *eventLog) close() error {
+	return l.db.Close()
+}
+
+// bounds returns the oldest and newest sequence numbers in the log, both 0
+// when it is empty.
+func (l *eventLog) bounds() (oldest, newest uint64, err error) {
+	var minSeq, maxSeq sql.NullInt64
+	if err := l.db.QueryRow("SELECT MIN(seq), MAX(seq) FROM events").Scan(&minSeq, &maxSeq); err != nil {
+		return 0, 0, fmt.Errorf("reading event log bounds: %w", err)
+	}
+	return uint64(minSeq.Int64), uint64(maxSeq.Int64), nil
+}
+
+// append records the encoded event with sequence number seq, trimming the
+// log to its size every eventLogTrimEvery appends.
+func (l *eventLog) append(seq uint64, data []byte) error {
+	if _, err := l.db.Exec("INSERT OR REPLACE INTO events (seq, data, created_at) VALUES (?, ?, ?)", int64(seq), string(data), time.Now().Unix()); err != nil {
+		return fmt.Errorf("appending event %d: %w", seq, err)
+	}
+	l.appends++
+	if l.appends%eventLogTrimEvery == 0 && seq > l.size {
+		if _, err := l.db.Exec("DELETE FROM events WHERE seq <= ?", int64(seq-l.size)); err != nil {
+			return fmt.Errorf("trimming event log: %w", err)
+		}
+	}
+	return nil
+}
+
+// since calls fn with the encoded events after seq, up to and including
+// until, in order. It stops at the first error returned by fn.
+func (l *eventLog) since(seq, until uint64, fn func(seq uint64, data []byte) error) error {
+	const batch = 500
+	for seq < until {
+		rows, err := l.db.Query("SELECT seq, data FROM events WHERE seq > ? AND seq <= ? ORDER BY seq LIMIT ?", int64(seq), int64(until), batch)
+		if err != nil {
+			return fmt.Errorf("reading event log: %w", err)
+		}
+		type entry struct {
+			seq  uint64
+			data string
+		}
+		var entries []entry
+		for rows.Next() {
+			var e entry
+			var s int64
+			if err := rows.Scan(&s, &e.data); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("scanning event: %w", err)
+			}
+			e.seq = uint64(s)
+			entries = append(entries, e)
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		// Callbacks write to network connections, so rows are released first
+		for _, e := range entries {
+			if err := fn(e.seq, []byte(e.data)); err != nil {
+				return err
+			}
+			seq = e.seq
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package warehouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownDatasource is returned for datasources not added to the warehouse.
+	ErrUnknownDatasource = errors.New("unknown datasource")
+	// ErrFetchInProgress is returned by FetchNow while the datasource is being fetched.
+	ErrFetchInProgress = errors.New("fetch already in progress")
+	// ErrNotRunning is returned by FetchNow before Start and after Stop.
+	ErrNotRunning = errors.New("warehouse is not running")
+)
+
+// DatasourceStatus is the scheduling state of a datasource, see
+// Warehouse.DatasourceStatuses.
+type DatasourceStatus struct {
+	Name string
+	Type string
+	// Interval between scheduled fetches; 0 for schema-only datasources
+	Interval time.Duration
+	// Paused datasources skip their scheduled fetches
+	Paused bool
+	// Fetching is true while the datasource is being fetched
+	Fetching bool
+	// NextRun is when the next scheduled fetch is due; zero when the
+	// datasource is not scheduled
+	NextRun time.Time
+	// LastRun is when the last fetch since the warehouse started finished,
+	// zero if there was none, and LastError how it failed
+	LastRun   time.Time
+	LastError string
+}
+
+// datasourceRuns is the scheduling state of a datasource
+type datasourceRuns struct {
+	nextRun   time.Time
+	fetching  bool
+	lastRun   time.Time
+	lastError string
+	paused    bool
+}
+
+// schedule tracks the fetches of every datasource. It has its own lock, as
+// it is updated from the fetch goroutines Stop waits for while holding
+// Warehouse.mu.
+type schedule struct {
+	mu   sync.Mutex
+	runs map[string]*datasourceRuns
+}
+
+func newSchedule() *schedule {
+	return &schedule{runs: make(map[string]*datasourceRuns)}
+}
+
+// get returns the state of a datasource, creating it. Must be called with mu held.
+func (s *schedule) get(name string) *datasourceRuns {
+	r, ok := s.runs[name]
+	if !ok {
+		r = &datasourceRuns{}
+		s.runs[name] = r
+	}
+	return r
+}
+
+// scheduled records when the next scheduled fetch of a datasource is due.
+func (s *schedule) scheduled(name string, next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.get(name).nextRun = next
+}
+
+// start marks a datasource as being fetched. Returns false if it already was.
+func (s *schedule) start(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.get(name)
+	if r.fetching {
+		return false
+	}
+	r.fetching = true
+	return true
+}
+
+// finish marks the fetch started by start as done.
+func (s *schedule) finish(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.get(name).fetching = false
+}
+
+// fetched records the outcome of a datasource's FetchBlocks call. Fetches
+// cancelled by the warehouse stopping are not recorded.
+func (s *schedule) fetched(name string, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.get(name)
+	r.lastRun = time.Now()
+	r.lastError = ""
+	if err != nil {
+		r.lastError = err.Error()
+	}
+}
+
+// isPaused returns whether the scheduled fetches of a datasource are paused.
+func (s *schedule) isPaused(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(name).paused
+}
+
+// setPaused pauses or resumes the scheduled fetches of a datasource.
+func (s *schedule) setPaused(name string, paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.get(name).paused = paused
+}
+
+// forget drops the state of a removed datasource but its pause, so pauses
+// survive configuration reloads.
+func (s *schedule) forget(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.runs[name]; ok {
+		*r = datasourceRuns{paused: r.paused}
+	}
+}
+
+// DatasourceStatuses returns the scheduling state of every datasource,
+// sorted by name.
+func (w *Warehouse) DatasourceStatuses() []DatasourceStatus {
+	w.mu.RLock()
+	statuses := make([]DatasourceStatus, 0, len(w.datasourceNames))
+	for ds, name := range w.datasourceNames {
+		statuses = append(statuses, DatasourceStatus{
+			Name:     name,
+			Type:     ds.Type(),
+			Interval: w.datasourceIntervals[name],
+		})
+	}
+	w.mu.RUnlock()
+
+	w.schedule.mu.Lock()
+	for i := range statuses {
+		r := w.schedule.get(statuses[i].Name)
+		statuses[i].Paused = r.paused
+		statuses[i].Fetching = r.fetching
+		statuses[i].NextRun = r.nextRun
+		statuses[i].LastRun = r.lastRun
+		statuses[i].LastError = r.lastError
+	}
+	w.schedule.mu.Unlock()
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// FetchNow starts fetching a datasource in the background, whether it is
+// paused or not. The scheduled fetches keep their schedule.
+func (w *Warehouse) FetchNow(name string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if _, ok := w.datasourceIntervals[name]; !ok {
+		return fmt.Errorf("%w %s", ErrUnknownDatasource, name)
+	}
+	if !w.running {
+		return ErrNotRunning
+	}
+	if !w.schedule.start(name) {
+		return fmt.Errorf("%s: %w", name, ErrFetchInProgress)
+	}
+
+	// Added with mu held, so Stop can't be waiting already
+	w.wg.Add(1)
+	go func(ctx context.Context) {
+		defer w.wg.Done()
+		defer w.schedule.finish(name)
+		whLogger.Debugf("Running triggered fetch for datasource: %s", name)
+		if err := w.fetchFromDatasourceByName(ctx, name); err != nil {
+			whLogger.Warnf("Triggered fetch failed for datasource %s: %v", name, err)
+		}
+	}(w.ctx)
+	return nil
+}
+
+// PauseDatasource stops the scheduled fetches of a datasource until
+// ResumeDatasource is called. Pauses last until the warehouse process exits,
+// across configuration reloads.
+func (w *Warehouse) PauseDatasource(name string) error {
+	return w.setPaused(name, true)
+}
+
+// ResumeDatasource resumes the scheduled fetches of a paused datasource.
+func (w *Warehouse) ResumeDatasource(name string) error {
+	return w.setPaused(name, false)
+}
+
+func (w *Warehouse) setPaused(name string, paused bool) error {
+	if !w.isDatasourceConfigured(name) {
+		return fmt.Errorf("%w %s", ErrUnknownDatasource, name)
+	}
+	w.schedule.setPaused(name, paused)
+	return nil
+}
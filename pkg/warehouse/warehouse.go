@@ -8,13 +8,23 @@ import (
 
 	"github.com/rubiojr/ergs/pkg/log"
 
+	"github.com/rubiojr/ergs/pkg/alerts"
 	"github.com/rubiojr/ergs/pkg/core"
+	"github.com/rubiojr/ergs/pkg/realtime"
 	"github.com/rubiojr/ergs/pkg/storage"
+	"github.com/rubiojr/ergs/pkg/webhooks"
 )
 
 type Config struct {
 	OptimizeInterval time.Duration
 	EventSocketPath  string // Optional Unix domain socket path for realtime warehouse->web events
+	EventListenAddr  string // Optional TCP address the event bridge also listens on, for consumers on other hosts
+	EventTLSCert     string // Certificate file; with EventTLSKey, the TCP listener uses TLS
+	EventTLSKey      string // Private key file of EventTLSCert
+	EventToken       string // Shared token TCP consumers authenticate with; required with EventListenAddr
+	EventLogPath     string // Optional log of recent bridge events, replayed to consumers that resume
+	EventLogSize     int    // Events kept in the event log; DefaultEventLogSize if 0
+	WebhookLogPath   string // Delivery log of outbound webhooks; webhooks can't be set if empty
 }
 
 type Warehouse struct {
@@ -24,34 +34,62 @@ type Warehouse struct {
 	datasourceNames     map[core.Datasource]string
 	datasourceIntervals map[string]time.Duration
 	datasourceTickers   map[string]*time.Ticker
-	optimizeTicker      *time.Ticker
-	stopCh              chan struct{}
-	ctx                 context.Context
-	ctxCancel           context.CancelFunc
-	mu                  sync.RWMutex
-	wg                  sync.WaitGroup
-	running             bool
-
-	// Realtime event bridge (optional; nil if EventSocketPath is empty)
+	datasourceRetention map[string]storage.RetentionPolicy
+	// Blocks matching these rules are dropped instead of stored
+	datasourceExclusions map[string]*storage.ExclusionRules
+	// Processors run on every block before it is stored
+	datasourceProcessors map[string]core.Pipeline
+	optimizeTicker       *time.Ticker
+	stopCh               chan struct{}
+	ctx                  context.Context
+	ctxCancel            context.CancelFunc
+	mu                   sync.RWMutex
+	wg                   sync.WaitGroup
+	running              bool
+
+	// Next runs, fetches in progress and pauses of the datasources
+	schedule *schedule
+
+	// Realtime event bridge (optional; nil if EventSocketPath and EventListenAddr are empty)
 	eventBridge *eventBridge
+
+	// In-process firehose hub getting the same block and delete events as
+	// the event bridge (optional; set when the web server runs in the
+	// warehouse process)
+	firehoseHub *realtime.FirehoseHub
+
+	// New blocks matching these searches are sent to their sinks (the
+	// dispatcher is nil until saved searches are set)
+	savedSearches   []alerts.SavedSearch
+	alertDispatcher *alerts.Dispatcher
+
+	// Outbound webhooks (optional; nil if WebhookLogPath is empty)
+	webhookSender *webhooks.Sender
 }
 
 var whLogger = log.ForService("warehouse")
 
 func NewWarehouse(config Config, storageManager *storage.Manager) *Warehouse {
 	w := &Warehouse{
-		config:              config,
-		storageManager:      storageManager,
-		datasources:         make([]core.Datasource, 0),
-		datasourceNames:     make(map[core.Datasource]string),
-		datasourceIntervals: make(map[string]time.Duration),
-		datasourceTickers:   make(map[string]*time.Ticker),
-		stopCh:              make(chan struct{}),
+		config:               config,
+		storageManager:       storageManager,
+		datasources:          make([]core.Datasource, 0),
+		datasourceNames:      make(map[core.Datasource]string),
+		datasourceIntervals:  make(map[string]time.Duration),
+		datasourceTickers:    make(map[string]*time.Ticker),
+		datasourceRetention:  make(map[string]storage.RetentionPolicy),
+		datasourceExclusions: make(map[string]*storage.ExclusionRules),
+		datasourceProcessors: make(map[string]core.Pipeline),
+		schedule:             newSchedule(),
+		stopCh:               make(chan struct{}),
 	}
 
 	// Initialize event bridge if configured
-	if config.EventSocketPath != "" {
-		w.eventBridge = newEventBridge(config.EventSocketPath)
+	if config.EventSocketPath != "" || config.EventListenAddr != "" {
+		w.eventBridge = newEventBridge(config)
+	}
+	if config.WebhookLogPath != "" {
+		w.webhookSender = webhooks.NewSender(config.WebhookLogPath)
 	}
 
 	return w
@@ -89,7 +127,7 @@ func (w *Warehouse) AddDatasourceWithInterval(name string, ds core.Datasource, i
 		ticker := time.NewTicker(interval)
 		w.datasourceTickers[name] = ticker
 		w.wg.Add(1)
-		go w.runDatasource(w.ctx, name, ticker)
+		go w.runDatasource(w.ctx, name, ticker, interval)
 		whLogger.Debugf("Started scheduler for new datasource %s with interval %v", name, interval)
 	} else if interval == 0 {
 		whLogger.Debugf("Datasource %s configured with interval 0 (schema-only, no automatic fetching)", name)
@@ -137,13 +175,103 @@ func (w *Warehouse) RemoveDatasource(name string) error {
 		}
 	}
 
-	// Remove from intervals map
+	// Remove from intervals and retention maps
 	delete(w.datasourceIntervals, name)
+	delete(w.datasourceRetention, name)
+	delete(w.datasourceExclusions, name)
+	delete(w.datasourceProcessors, name)
+	w.schedule.forget(name)
 
 	whLogger.Debugf("Removed datasource: %s", name)
 	return nil
 }
 
+// SetFirehoseHub makes the warehouse broadcast block and delete events to an
+// in-process hub, for the web server running in the same process. Must be
+// called before Start.
+func (w *Warehouse) SetFirehoseHub(hub *realtime.FirehoseHub) {
+	w.firehoseHub = hub
+}
+
+// SetRetentionPolicy sets the retention policy of a datasource. Blocks not kept by
+// the policy are pruned on the optimization ticker (see Config.OptimizeInterval).
+// A zero policy keeps every block.
+func (w *Warehouse) SetRetentionPolicy(name string, policy storage.RetentionPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("retention policy for datasource %s: %w", name, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if policy.IsZero() {
+		delete(w.datasourceRetention, name)
+	} else {
+		w.datasourceRetention[name] = policy
+	}
+	return nil
+}
+
+// SetExclusionRules sets the exclusion rules of a datasource. Blocks matching
+// them are dropped instead of stored. Nil or empty rules store every block.
+func (w *Warehouse) SetExclusionRules(name string, rules *storage.ExclusionRules) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if rules.IsZero() {
+		delete(w.datasourceExclusions, name)
+	} else {
+		w.datasourceExclusions[name] = rules
+	}
+}
+
+// SetProcessors sets the processor pipeline of a datasource, run on every block
+// before it is stored. An empty pipeline stores blocks as fetched.
+func (w *Warehouse) SetProcessors(name string, pipeline core.Pipeline) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(pipeline) == 0 {
+		delete(w.datasourceProcessors, name)
+	} else {
+		w.datasourceProcessors[name] = pipeline
+	}
+}
+
+// SetSavedSearches sets the saved searches evaluated against every new block,
+// replacing the previous ones. Blocks stored for the first time that match a
+// search are sent to its sinks in the background; updates to stored blocks
+// never alert.
+func (w *Warehouse) SetSavedSearches(searches []alerts.SavedSearch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.savedSearches = searches
+	if len(searches) > 0 && w.alertDispatcher == nil {
+		w.alertDispatcher = alerts.NewDispatcher(alertQueueSize)
+	}
+}
+
+// SetWebhooks sets the webhooks new blocks are POSTed to, replacing the
+// previous ones. Deliveries are recorded in the delivery log at
+// Config.WebhookLogPath, delivered while the warehouse runs and retried with
+// backoff; Close attempts the deliveries still due.
+func (w *Warehouse) SetWebhooks(hooks []webhooks.Webhook) error {
+	if w.webhookSender == nil {
+		if len(hooks) == 0 {
+			return nil
+		}
+		return fmt.Errorf("webhooks need a delivery log path")
+	}
+	return w.webhookSender.SetWebhooks(hooks)
+}
+
+// webhookFlushTimeout bounds the webhook deliveries attempted on Close
+const webhookFlushTimeout = 30 * time.Second
+
+// alertFlushTimeout bounds the delivery of the queued alerts on Close
+const alertFlushTimeout = 30 * time.Second
+
+// alertQueueSize is the number of alerts waiting for delivery before new
+// ones are dropped
+const alertQueueSize = 1000
+
 func (w *Warehouse) Start(ctx context.Context) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -163,12 +291,21 @@ func (w *Warehouse) Start(ctx context.Context) error {
 	// Start event bridge if configured
 	if w.eventBridge != nil {
 		if err := w.eventBridge.start(); err != nil {
-			whLogger.Warnf("failed to start event bridge on %s: %v", w.config.EventSocketPath, err)
+			whLogger.Warnf("failed to start event bridge on %s: %v", w.eventBridge, err)
 		} else {
-			whLogger.Debugf("Event bridge started on %s", w.config.EventSocketPath)
+			whLogger.Debugf("Event bridge started on %s", w.eventBridge)
 		}
 	}
 
+	// Deliver webhooks until the warehouse stops
+	if w.webhookSender != nil {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.webhookSender.Run(w.ctx)
+		}()
+	}
+
 	// Log all configured datasources and their intervals
 	whLogger.Debugf("Starting warehouse with %d datasources:", len(w.datasources))
 	for name, interval := range w.datasourceIntervals {
@@ -188,7 +325,7 @@ func (w *Warehouse) Start(ctx context.Context) error {
 		ticker := time.NewTicker(interval)
 		w.datasourceTickers[name] = ticker
 		w.wg.Add(1)
-		go w.runDatasource(w.ctx, name, ticker)
+		go w.runDatasource(w.ctx, name, ticker, interval)
 		whLogger.Debugf("Started scheduler for datasource %s with interval %v", name, interval)
 	}
 
@@ -212,10 +349,11 @@ func (w *Warehouse) Start(ctx context.Context) error {
 	return nil
 }
 
-func (w *Warehouse) runDatasource(ctx context.Context, datasourceName string, ticker *time.Ticker) {
+func (w *Warehouse) runDatasource(ctx context.Context, datasourceName string, ticker *time.Ticker, interval time.Duration) {
 	defer w.wg.Done()
 	defer ticker.Stop()
 
+	w.schedule.scheduled(datasourceName, time.Now().Add(interval))
 	for {
 		select {
 		case <-ctx.Done():
@@ -224,11 +362,21 @@ func (w *Warehouse) runDatasource(ctx context.Context, datasourceName string, ti
 		case <-w.stopCh:
 			whLogger.Debugf("Datasource %s stop signal received", datasourceName)
 			return
-		case <-ticker.C:
+		case tick := <-ticker.C:
+			w.schedule.scheduled(datasourceName, tick.Add(interval))
+			if w.schedule.isPaused(datasourceName) {
+				whLogger.Debugf("Skipping scheduled fetch for paused datasource: %s", datasourceName)
+				continue
+			}
+			if !w.schedule.start(datasourceName) {
+				whLogger.Debugf("Skipping scheduled fetch for datasource %s: already being fetched", datasourceName)
+				continue
+			}
 			whLogger.Debugf("Running scheduled fetch for datasource: %s", datasourceName)
 			if err := w.fetchFromDatasourceByName(ctx, datasourceName); err != nil {
 				whLogger.Warnf("Scheduled fetch failed for datasource %s: %v", datasourceName, err)
 			}
+			w.schedule.finish(datasourceName)
 		}
 	}
 }
@@ -246,6 +394,7 @@ func (w *Warehouse) runOptimization(ctx context.Context) {
 			whLogger.Debugf("Optimization stop signal received")
 			return
 		case <-w.optimizeTicker.C:
+			w.enforceRetention(time.Now())
 			whLogger.Debugf("Running database optimization")
 			if err := w.storageManager.OptimizeAll(); err != nil {
 				whLogger.Warnf("Database optimization failed: %v", err)
@@ -254,6 +403,46 @@ func (w *Warehouse) runOptimization(ctx context.Context) {
 	}
 }
 
+// enforceRetention prunes the blocks of every datasource with a retention policy.
+// Pruned blocks are also removed from the global index.
+func (w *Warehouse) enforceRetention(now time.Time) {
+	w.mu.RLock()
+	policies := make(map[string]storage.RetentionPolicy, len(w.datasourceRetention))
+	for name, policy := range w.datasourceRetention {
+		policies[name] = policy
+	}
+	w.mu.RUnlock()
+
+	pruned := false
+	for name, policy := range policies {
+		st, err := w.storageManager.GetStorage(name)
+		if err != nil {
+			whLogger.Warnf("Retention for datasource %s failed: %v", name, err)
+			continue
+		}
+		result, err := st.Prune(policy, now)
+		if err != nil {
+			whLogger.Warnf("Retention for datasource %s failed: %v", name, err)
+		}
+		if result.Total() == 0 {
+			continue
+		}
+		pruned = true
+		whLogger.Infof("Pruned %d blocks from %s (%d expired, %d downsampled, %d over limit)",
+			result.Total(), name, result.Expired, result.Downsampled, result.OverLimit)
+
+		if index := w.storageManager.GlobalIndex(); index != nil {
+			if err := index.RemoveBlocks(name, result.IDs); err != nil {
+				whLogger.Warnf("Failed to remove pruned blocks of %s from the global index: %v", name, err)
+			}
+		}
+	}
+
+	if pruned {
+		w.storageManager.InvalidateStatsCache()
+	}
+}
+
 func (w *Warehouse) fetchAll(ctx context.Context) error {
 	w.mu.RLock()
 	// Only fetch from datasources with interval > 0
@@ -271,6 +460,7 @@ func (w *Warehouse) fetchAll(ctx context.Context) error {
 	// Use a separate WaitGroup for the initial fetch to avoid interfering with main scheduler
 	var fetchWg sync.WaitGroup
 	var processorWg sync.WaitGroup
+	run := newFetchRun()
 
 	// Start block processor
 	processorWg.Add(1)
@@ -286,6 +476,7 @@ func (w *Warehouse) fetchAll(ctx context.Context) error {
 				}
 				if err := w.storeBlock(block); err != nil {
 					whLogger.Warnf("Error storing block %s: %v", block.ID(), err)
+					run.storeFailed(block.Source())
 				}
 			}
 		}
@@ -306,11 +497,20 @@ func (w *Warehouse) fetchAll(ctx context.Context) error {
 			name := w.datasourceNames[ds]
 			w.mu.RUnlock()
 
+			if !w.schedule.start(name) {
+				whLogger.Debugf("Skipping datasource %s: already being fetched", name)
+				return
+			}
+			defer w.schedule.finish(name)
+
 			whLogger.Debugf("Starting to fetch blocks from datasource: %s", name)
-			err := ds.FetchBlocks(ctx, blockCh)
+			cursors := newFetchCursors(name, w.storageManager)
+			err := ds.FetchBlocks(core.WithCursorStore(ctx, cursors), blockCh)
 			if err != nil && err != context.Canceled {
 				whLogger.Warnf("Error fetching blocks from datasource %s: %v", name, err)
 			}
+			w.schedule.fetched(name, err)
+			run.fetchDone(cursors, err)
 			whLogger.Debugf("Finished fetching blocks from datasource: %s", name)
 		}(ds)
 	}
@@ -326,6 +526,11 @@ func (w *Warehouse) fetchAll(ctx context.Context) error {
 	// Wait for the block processor to finish
 	processorWg.Wait()
 
+	// Persist cursors only once every block from this cycle has been stored
+	if ctx.Err() == nil {
+		run.commit()
+	}
+
 	return nil
 }
 
@@ -348,6 +553,7 @@ func (w *Warehouse) fetchFromDatasourceByName(ctx context.Context, datasourceNam
 	blockCh := make(chan core.Block, 1000)
 	var fetchWg sync.WaitGroup
 	var processorWg sync.WaitGroup
+	run := newFetchRun()
 
 	// Start block processor
 	processorWg.Add(1)
@@ -363,6 +569,7 @@ func (w *Warehouse) fetchFromDatasourceByName(ctx context.Context, datasourceNam
 				}
 				if err := w.storeBlock(block); err != nil {
 					whLogger.Warnf("Error storing block %s: %v", block.ID(), err)
+					run.storeFailed(block.Source())
 				}
 			}
 		}
@@ -373,10 +580,13 @@ func (w *Warehouse) fetchFromDatasourceByName(ctx context.Context, datasourceNam
 	go func() {
 		defer fetchWg.Done()
 		whLogger.Debugf("Starting to fetch blocks from datasource: %s", datasourceName)
-		err := targetDS.FetchBlocks(ctx, blockCh)
+		cursors := newFetchCursors(datasourceName, w.storageManager)
+		err := targetDS.FetchBlocks(core.WithCursorStore(ctx, cursors), blockCh)
 		if err != nil && err != context.Canceled {
 			whLogger.Warnf("Error fetching blocks from datasource %s: %v", datasourceName, err)
 		}
+		w.schedule.fetched(datasourceName, err)
+		run.fetchDone(cursors, err)
 		whLogger.Debugf("Finished fetching blocks from datasource: %s", datasourceName)
 	}()
 
@@ -389,10 +599,34 @@ func (w *Warehouse) fetchFromDatasourceByName(ctx context.Context, datasourceNam
 	// Wait for the block processor to finish
 	processorWg.Wait()
 
+	// Persist cursors only once every block from this cycle has been stored
+	if ctx.Err() == nil {
+		run.commit()
+	}
+
 	return nil
 }
 
+// StoreBlock stores a block pushed to the warehouse instead of fetched, e.g.
+// by the importer API running in the warehouse process. It goes through the
+// same exclusion rules, processors, saved searches, webhooks and realtime
+// events as fetched blocks. Blocks of datasources not added to the warehouse
+// are rejected.
+func (w *Warehouse) StoreBlock(block core.Block) error {
+	if !w.isDatasourceConfigured(block.Source()) {
+		return fmt.Errorf("unknown datasource %s", block.Source())
+	}
+	return w.storeBlock(block)
+}
+
 func (w *Warehouse) storeBlock(block core.Block) error {
+	return w.storeAndStream(block, nil)
+}
+
+// storeAndStream stores a block like storeBlock and passes it to onStored,
+// if not nil, as stored: after the processors, and not at all when it is
+// excluded, dropped or a tombstone.
+func (w *Warehouse) storeAndStream(block core.Block, onStored func(core.Block)) error {
 	// Fast check via helper to see if datasource was explicitly configured.
 	if !w.isDatasourceConfigured(block.Source()) {
 		whLogger.Warnf("Dropping block %s: unknown / disabled datasource %s", block.ID(), block.Source())
@@ -413,14 +647,62 @@ func (w *Warehouse) storeBlock(block core.Block) error {
 			break
 		}
 	}
+	exclusions := w.datasourceExclusions[block.Source()]
+	processors := w.datasourceProcessors[block.Source()]
+	searches := w.savedSearches
+	sender := w.webhookSender
 	w.mu.RUnlock()
 	if datasourceType == "" {
 		datasourceType = "unknown"
 	}
 
+	if core.IsTombstone(block) {
+		return w.deleteBlock(storage, block, datasourceType)
+	}
+
+	if exclusions.Match(block) {
+		whLogger.Debugf("Dropping block %s from %s: matches exclusion rules", block.ID(), block.Source())
+		return nil
+	}
+
+	if len(processors) > 0 {
+		processed, err := processors.Process(block)
+		if err != nil {
+			return fmt.Errorf("processing block %s: %w", block.ID(), err)
+		}
+		if processed == nil {
+			whLogger.Debugf("Dropping block %s from %s: dropped by a processor", block.ID(), block.Source())
+			return nil
+		}
+		block = processed
+	}
+
+	// Only blocks stored for the first time are checked against saved searches
+	// and sent to webhooks
+	sendWebhooks := sender != nil && sender.Active()
+	isNew := false
+	if len(searches) > 0 || sendWebhooks {
+		exists, err := storage.BlockExists(block.ID())
+		if err != nil {
+			return err
+		}
+		isNew = !exists
+	}
+
 	if err := storage.StoreBlock(block, datasourceType); err != nil {
 		return fmt.Errorf("storing block %s: %w", block.ID(), err)
 	}
+	if onStored != nil {
+		onStored(block)
+	}
+
+	// A block missing from the global index is only found again after
+	// `ergs optimize index` rebuilds it, so indexing errors don't fail ingestion.
+	if index := w.storageManager.GlobalIndex(); index != nil {
+		if err := index.IndexBlocks(block.Source(), datasourceType, []core.Block{block}); err != nil {
+			whLogger.Warnf("Failed to add block %s to the global index: %v", block.ID(), err)
+		}
+	}
 
 	// Broadcast realtime event after successful persistence.
 	if w.eventBridge != nil {
@@ -433,6 +715,94 @@ func (w *Warehouse) storeBlock(block core.Block) error {
 			block.Metadata(),
 		)
 	}
+	if w.firehoseHub != nil {
+		w.firehoseHub.Broadcast(realtime.WrapBlock(realtime.NewBlockEvent(
+			block.ID(),
+			block.Source(),
+			datasourceType,
+			block.CreatedAt(),
+			block.Text(),
+			block.Metadata(),
+		)))
+	}
+
+	if isNew && len(searches) > 0 {
+		w.alertSavedSearches(block, datasourceType, searches)
+	}
+
+	// Deliveries are recorded before they are sent, so a block only misses
+	// its webhooks if the delivery log can't be written.
+	if isNew && sendWebhooks {
+		_, err := sender.Publish(webhooks.Event{
+			Type:       "block",
+			ID:         block.ID(),
+			Datasource: block.Source(),
+			DSType:     datasourceType,
+			CreatedAt:  block.CreatedAt(),
+			Text:       block.Text(),
+			Metadata:   block.Metadata(),
+		})
+		if err != nil {
+			whLogger.Warnf("Failed to record webhook deliveries of block %s: %v", block.ID(), err)
+		}
+	}
+	return nil
+}
+
+// alertSavedSearches sends a new block to the sinks of the saved searches it
+// matches. Matching errors are logged and don't fail ingestion.
+func (w *Warehouse) alertSavedSearches(block core.Block, datasourceType string, searches []alerts.SavedSearch) {
+	for _, search := range searches {
+		matched, err := w.storageManager.GetSearchService().MatchBlocks(storage.SearchParams{Query: search.Query}, block.Source(), []string{block.ID()})
+		if err != nil {
+			whLogger.Warnf("Failed to match block %s against saved search %s: %v", block.ID(), search.Name, err)
+			continue
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		whLogger.Debugf("Block %s from %s matches saved search %s", block.ID(), block.Source(), search.Name)
+		w.alertDispatcher.Send(alerts.Alert{
+			Search:     search.Name,
+			ID:         block.ID(),
+			Datasource: block.Source(),
+			DSType:     datasourceType,
+			CreatedAt:  block.CreatedAt(),
+			Text:       block.Text(),
+			Metadata:   block.Metadata(),
+		}, search.Sinks)
+	}
+}
+
+// deleteBlock removes the block marked as deleted by a tombstone from storage
+// and the global index, and broadcasts a delete event. Tombstones for blocks
+// that were never stored are ignored.
+func (w *Warehouse) deleteBlock(st *storage.GenericStorage, tombstone core.Block, datasourceType string) error {
+	deleted, err := st.DeleteBlocks([]string{tombstone.ID()})
+	if err != nil {
+		return fmt.Errorf("deleting block %s: %w", tombstone.ID(), err)
+	}
+	if deleted == 0 {
+		return nil
+	}
+	whLogger.Debugf("Deleted block %s from %s", tombstone.ID(), tombstone.Source())
+
+	if index := w.storageManager.GlobalIndex(); index != nil {
+		if err := index.RemoveBlocks(tombstone.Source(), []string{tombstone.ID()}); err != nil {
+			whLogger.Warnf("Failed to remove block %s from the global index: %v", tombstone.ID(), err)
+		}
+	}
+	w.storageManager.InvalidateStatsCache()
+
+	if w.eventBridge != nil {
+		w.eventBridge.publishDelete(tombstone.ID(), tombstone.Source(), datasourceType)
+	}
+	if w.firehoseHub != nil {
+		w.firehoseHub.Broadcast(realtime.InternalEvent{
+			Type:  "delete",
+			Block: realtime.BlockEvent{ID: tombstone.ID(), Datasource: tombstone.Source(), DSType: datasourceType},
+		})
+	}
 	return nil
 }
 
@@ -508,6 +878,7 @@ func (w *Warehouse) FetchOnce(ctx context.Context, options ...FetchOption) error
 	blockCh := make(chan core.Block, 1000)
 	var fetchWg sync.WaitGroup
 	var processorWg sync.WaitGroup
+	run := newFetchRun()
 
 	// Start block processor with streaming callback
 	processorWg.Add(1)
@@ -521,13 +892,10 @@ func (w *Warehouse) FetchOnce(ctx context.Context, options ...FetchOption) error
 				if !ok {
 					return
 				}
-				// Call the streaming callback first
-				if opts.onBlock != nil {
-					opts.onBlock(block)
-				}
-				// Then store the block
-				if err := w.storeBlock(block); err != nil {
+				// Only stored blocks are streamed, so excluded ones aren't shown
+				if err := w.storeAndStream(block, opts.onBlock); err != nil {
 					whLogger.Warnf("Error storing block %s: %v", block.ID(), err)
+					run.storeFailed(block.Source())
 				}
 			}
 		}
@@ -540,10 +908,12 @@ func (w *Warehouse) FetchOnce(ctx context.Context, options ...FetchOption) error
 			defer fetchWg.Done()
 			name := w.datasourceNames[ds]
 			whLogger.Debugf("Starting to fetch blocks from datasource: %s", name)
-			err := ds.FetchBlocks(ctx, blockCh)
+			cursors := newFetchCursors(name, w.storageManager)
+			err := ds.FetchBlocks(core.WithCursorStore(ctx, cursors), blockCh)
 			if err != nil && err != context.Canceled {
 				whLogger.Warnf("Error fetching blocks from datasource %s: %v", name, err)
 			}
+			run.fetchDone(cursors, err)
 			whLogger.Debugf("Finished fetching blocks from datasource: %s", name)
 		}(ds)
 	}
@@ -557,6 +927,11 @@ func (w *Warehouse) FetchOnce(ctx context.Context, options ...FetchOption) error
 	// Wait for the block processor to finish
 	processorWg.Wait()
 
+	// Persist cursors only once every block from this cycle has been stored
+	if ctx.Err() == nil {
+		run.commit()
+	}
+
 	whLogger.Debugf("One-time fetch completed from %d datasources", len(datasources))
 	return nil
 }
@@ -568,7 +943,8 @@ type fetchOptions struct {
 	onBlock func(core.Block)
 }
 
-// WithStreaming enables streaming blocks to a callback function
+// WithStreaming enables streaming blocks to a callback function, as they are
+// stored: blocks dropped by exclusion rules or processors are not streamed.
 func WithStreaming(callback func(core.Block)) FetchOption {
 	return func(opts *fetchOptions) {
 		opts.onBlock = callback
@@ -585,6 +961,26 @@ func (w *Warehouse) Close() error {
 		}
 	}
 
+	// Deliver the alerts still queued, dropping the ones left at the deadline
+	if w.alertDispatcher != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), alertFlushTimeout)
+		w.alertDispatcher.Close(ctx)
+		cancel()
+	}
+
+	// Attempt the webhook deliveries still due; the ones failing are retried
+	// by the next run
+	if w.webhookSender != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookFlushTimeout)
+		if _, err := w.webhookSender.Flush(ctx); err != nil {
+			whLogger.Warnf("Failed to deliver webhooks: %v", err)
+		}
+		cancel()
+		if err := w.webhookSender.Close(); err != nil {
+			whLogger.Warnf("Error closing webhook delivery log: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -1,14 +1,34 @@
 package warehouse
 
 import (
+	"bufio"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/rubiojr/ergs/pkg/alerts"
 	"github.com/rubiojr/ergs/pkg/core"
+	"github.com/rubiojr/ergs/pkg/realtime"
 	"github.com/rubiojr/ergs/pkg/storage"
+	"github.com/rubiojr/ergs/pkg/webhooks"
 )
 
 type mockDatasource struct {
@@ -432,3 +452,885 @@ func TestIsDatasourceConfiguredAndDropUnknown(t *testing.T) {
 		t.Fatalf("Expected to find stored block k1 for datasource %s", dsName)
 	}
 }
+
+// cursorDatasource emits one block per fetch, numbered after the stored cursor.
+type cursorDatasource struct {
+	mockDatasource
+	seen []string
+}
+
+func (c *cursorDatasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block) error {
+	cursors := core.CursorStoreFromContext(ctx)
+	last, _, err := cursors.GetCursor("last_id")
+	if err != nil {
+		return err
+	}
+	c.seen = append(c.seen, last)
+
+	next := len(c.seen)
+	block := &mockBlock{
+		id:        "cursor-" + strconv.Itoa(next),
+		text:      "cursor block",
+		createdAt: time.Now(),
+		source:    c.name,
+		metadata:  map[string]interface{}{},
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case blockCh <- block:
+	}
+	return cursors.SetCursor("last_id", strconv.Itoa(next))
+}
+
+func TestFetchCursorsPersistAndReset(t *testing.T) {
+	storageManager, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			t.Logf("Warning: failed to close storage manager: %v", err)
+		}
+	}()
+
+	wh := NewWarehouse(Config{}, storageManager)
+	ds := &cursorDatasource{mockDatasource: mockDatasource{name: "cursor-ds"}}
+	if err := wh.AddDatasource("cursor-ds", ds); err != nil {
+		t.Fatalf("Failed to add datasource: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		if err := wh.FetchOnce(ctx); err != nil {
+			t.Fatalf("FetchOnce %d failed: %v", i, err)
+		}
+	}
+
+	if len(ds.seen) != 2 || ds.seen[0] != "" || ds.seen[1] != "1" {
+		t.Fatalf("Expected cursors [\"\" \"1\"], got %q", ds.seen)
+	}
+
+	removed, err := wh.ResetCursors("cursor-ds")
+	if err != nil {
+		t.Fatalf("ResetCursors failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 cursor removed, got %d", removed)
+	}
+
+	if err := wh.FetchOnce(ctx); err != nil {
+		t.Fatalf("FetchOnce after reset failed: %v", err)
+	}
+	if ds.seen[2] != "" {
+		t.Errorf("Expected empty cursor after reset, got %q", ds.seen[2])
+	}
+
+	if _, err := wh.ResetCursors("missing"); err == nil {
+		t.Error("Expected error resetting cursors for unknown datasource")
+	}
+}
+
+func TestEnforceRetention(t *testing.T) {
+	storageManager, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			t.Logf("Warning: failed to close storage manager: %v", err)
+		}
+	}()
+	if err := storageManager.OpenGlobalIndex(); err != nil {
+		t.Fatalf("OpenGlobalIndex failed: %v", err)
+	}
+
+	now := time.Now()
+	ds := &mockDatasource{name: "weather"}
+	for i := 0; i < 5; i++ {
+		ds.blocks = append(ds.blocks, core.NewGenericBlock(strconv.Itoa(i), "retention sample", "weather", "mock", now.Add(-time.Duration(i)*24*time.Hour), nil))
+	}
+
+	wh := NewWarehouse(Config{}, storageManager)
+	if err := wh.AddDatasource("weather", ds); err != nil {
+		t.Fatalf("Failed to add datasource: %v", err)
+	}
+	if err := wh.SetRetentionPolicy("weather", storage.RetentionPolicy{MaxAge: -time.Hour}); err == nil {
+		t.Error("Expected error for a negative max age")
+	}
+	if err := wh.SetRetentionPolicy("weather", storage.RetentionPolicy{MaxAge: 36 * time.Hour}); err != nil {
+		t.Fatalf("SetRetentionPolicy failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := wh.FetchOnce(ctx); err != nil {
+		t.Fatalf("FetchOnce failed: %v", err)
+	}
+	if err := storageManager.GlobalIndex().Rebuild(storageManager, []string{"weather"}, nil); err != nil {
+		t.Fatalf("Rebuild failed: %v", err)
+	}
+
+	wh.enforceRetention(now)
+
+	blocks, err := storageManager.SearchBlocks("weather", "retention", 10)
+	if err != nil {
+		t.Fatalf("SearchBlocks failed: %v", err)
+	}
+	if len(blocks) != 2 {
+		t.Errorf("Expected 2 blocks after pruning, got %d", len(blocks))
+	}
+	stats, err := storageManager.GetStats()
+	if err != nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if pruned := stats["weather"].(map[string]interface{})["pruned_blocks"]; pruned != 3 {
+		t.Errorf("Expected 3 pruned blocks in stats, got %v", pruned)
+	}
+
+	// Searches served by the global index agree
+	results, err := storageManager.GetSearchService().Search(storage.SearchParams{Query: "retention", Page: 1, Limit: 10})
+	if err != nil || results.TotalCount != 2 || results.HasMore {
+		t.Errorf("Expected 2 global index results, got %v (err %v)", results, err)
+	}
+}
+
+func TestTombstoneDeletesBlock(t *testing.T) {
+	storageManager, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			t.Logf("Warning: failed to close storage manager: %v", err)
+		}
+	}()
+	if err := storageManager.OpenGlobalIndex(); err != nil {
+		t.Fatalf("OpenGlobalIndex failed: %v", err)
+	}
+
+	now := time.Now()
+	ds := &mockDatasource{name: "zed", blocks: []core.Block{
+		core.NewGenericBlock("thread-1", "tombstone sample one", "zed", "mock", now, nil),
+		core.NewGenericBlock("thread-2", "tombstone sample two", "zed", "mock", now, nil),
+	}}
+
+	socketPath := filepath.Join(t.TempDir(), "bridge.sock")
+	wh := NewWarehouse(Config{EventSocketPath: socketPath}, storageManager)
+	if err := wh.AddDatasource("zed", ds); err != nil {
+		t.Fatalf("Failed to add datasource: %v", err)
+	}
+	if err := wh.eventBridge.start(); err != nil {
+		t.Fatalf("Failed to start event bridge: %v", err)
+	}
+	defer wh.eventBridge.stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := wh.FetchOnce(ctx); err != nil {
+		t.Fatalf("FetchOnce failed: %v", err)
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to connect to event bridge: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	// Wait for the bridge to register the connection
+	for i := 0; i < 100; i++ {
+		wh.eventBridge.mu.RLock()
+		n := len(wh.eventBridge.conns)
+		wh.eventBridge.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The datasource reports thread-1 and an unknown block as deleted
+	ds.blocks = []core.Block{core.NewTombstone("thread-1", "zed"), core.NewTombstone("missing", "zed")}
+	if err := wh.FetchOnce(ctx); err != nil {
+		t.Fatalf("FetchOnce failed: %v", err)
+	}
+
+	blocks, err := storageManager.SearchBlocks("zed", "tombstone", 10)
+	if err != nil {
+		t.Fatalf("SearchBlocks failed: %v", err)
+	}
+	if len(blocks) != 1 || blocks[0].ID() != "thread-2" {
+		t.Errorf("Expected only thread-2 to be left, got %v", blocks)
+	}
+	results, err := storageManager.GetSearchService().Search(storage.SearchParams{Query: "tombstone", Page: 1, Limit: 10})
+	if err != nil || results.TotalCount != 1 {
+		t.Errorf("Expected 1 global index result, got %v (err %v)", results, err)
+	}
+
+	// Only the stored block produces a delete event
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("Failed to read event: %v", err)
+	}
+	var evt bridgeDeleteEvent
+	if err := json.Unmarshal(line, &evt); err != nil {
+		t.Fatalf("Failed to decode event %s: %v", line, err)
+	}
+	// Sequence numbers 1 and 2 are the stored blocks
+	if evt != (bridgeDeleteEvent{Type: "delete", Seq: 3, ID: "thread-1", Datasource: "zed", DSType: "mock"}) {
+		t.Errorf("Unexpected event: %s", line)
+	}
+}
+
+func TestEventBridgeResume(t *testing.T) {
+	dir := t.TempDir()
+	socketPath := filepath.Join(dir, "bridge.sock")
+	logPath := filepath.Join(dir, "internal", "events.db")
+	now := time.Now().UTC()
+
+	bridge := newEventBridge(Config{EventSocketPath: socketPath, EventLogPath: logPath, EventLogSize: 10})
+	if err := bridge.start(); err != nil {
+		t.Fatalf("Failed to start event bridge: %v", err)
+	}
+	for i := 1; i <= 120; i++ {
+		bridge.publishBlock("block-"+strconv.Itoa(i), "ds", "mock", now, "text", nil)
+	}
+	bridge.stop()
+
+	// Sequence numbers continue after a restart
+	bridge = newEventBridge(Config{EventSocketPath: socketPath, EventLogPath: logPath, EventLogSize: 10})
+	if err := bridge.start(); err != nil {
+		t.Fatalf("Failed to restart event bridge: %v", err)
+	}
+	defer bridge.stop()
+	bridge.publishDelete("block-1", "ds", "mock")
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to connect to event bridge: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write([]byte(`{"type":"resume","resume_from":5}` + "\n")); err != nil {
+		t.Fatalf("Failed to send resume request: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	next := func() map[string]any {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		var evt map[string]any
+		if err := json.Unmarshal(line, &evt); err != nil {
+			t.Fatalf("Failed to decode event %s: %v", line, err)
+		}
+		return evt
+	}
+
+	// The log was trimmed to the newest events after 100 appends
+	if evt := next(); evt["type"] != "gap" || evt["from_seq"] != float64(6) || evt["to_seq"] != float64(90) {
+		t.Fatalf("Expected a gap from 6 to 90, got %v", evt)
+	}
+	for seq := 91; seq <= 120; seq++ {
+		evt := next()
+		if evt["type"] != "block" || evt["seq"] != float64(seq) || evt["id"] != "block-"+strconv.Itoa(seq) {
+			t.Fatalf("Expected block event %d, got %v", seq, evt)
+		}
+	}
+	if evt := next(); evt["type"] != "delete" || evt["seq"] != float64(121) {
+		t.Fatalf("Expected delete event 121, got %v", evt)
+	}
+
+	// Live events follow the replayed ones
+	bridge.publishBlock("block-122", "ds", "mock", now, "text", nil)
+	if evt := next(); evt["type"] != "block" || evt["seq"] != float64(122) {
+		t.Fatalf("Expected live block event 122, got %v", evt)
+	}
+}
+
+func TestEventBridgeFilter(t *testing.T) {
+	dir := t.TempDir()
+	socketPath := filepath.Join(dir, "bridge.sock")
+	bridge := newEventBridge(Config{EventSocketPath: socketPath, EventLogPath: filepath.Join(dir, "events.db")})
+	if err := bridge.start(); err != nil {
+		t.Fatalf("Failed to start event bridge: %v", err)
+	}
+	defer bridge.stop()
+
+	now := time.Now().UTC()
+	bridge.publishBlock("a-1", "a", "github", now, "text", nil)
+	bridge.publishBlock("b-1", "b", "rss", now, "text", nil)
+	bridge.publishBlock("c-1", "c", "github", now, "text", nil)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to connect to event bridge: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Write([]byte(`{"type":"resume","resume_from":1,"types":["github"]}` + "\n")); err != nil {
+		t.Fatalf("Failed to send resume request: %v", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	next := func() bridgeBlockEvent {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		var evt bridgeBlockEvent
+		if err := json.Unmarshal(line, &evt); err != nil {
+			t.Fatalf("Failed to decode event %s: %v", line, err)
+		}
+		return evt
+	}
+
+	// Replayed and live events of other types are skipped
+	if evt := next(); evt.ID != "c-1" || evt.Seq != 3 {
+		t.Fatalf("Expected replayed event c-1, got %+v", evt)
+	}
+	bridge.publishBlock("b-2", "b", "rss", now, "text", nil)
+	bridge.publishBlock("a-2", "a", "github", now, "text", nil)
+	if evt := next(); evt.ID != "a-2" || evt.Seq != 5 {
+		t.Fatalf("Expected live event a-2, got %+v", evt)
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 and its key
+func writeTestCertificate(t *testing.T, dir string) (certPath, keyPath string, pool *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ergs test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	certPath = filepath.Join(dir, "bridge.crt")
+	keyPath = filepath.Join(dir, "bridge.key")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(certPath, certPEM, 0600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	pool = x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	return certPath, keyPath, pool
+}
+
+func TestEventBridgeTCPAuth(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath, pool := writeTestCertificate(t, dir)
+
+	if err := newEventBridge(Config{EventListenAddr: "127.0.0.1:0"}).start(); err == nil {
+		t.Error("Expected an error listening on TCP without a token")
+	}
+
+	bridge := newEventBridge(Config{EventListenAddr: "127.0.0.1:0", EventTLSCert: certPath, EventTLSKey: keyPath, EventToken: "s3cret"})
+	if err := bridge.start(); err != nil {
+		t.Fatalf("Failed to start event bridge: %v", err)
+	}
+	defer bridge.stop()
+	addr := bridge.tcpLn.Addr().String()
+
+	connect := func(token string) (*tls.Conn, *bufio.Reader) {
+		t.Helper()
+		conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: pool})
+		if err != nil {
+			t.Fatalf("Failed to connect to event bridge: %v", err)
+		}
+		if _, err := conn.Write([]byte(`{"type":"auth","token":"` + token + `"}` + "\n")); err != nil {
+			t.Fatalf("Failed to authenticate: %v", err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		return conn, bufio.NewReader(conn)
+	}
+
+	// A wrong token gets an error and the connection closed
+	conn, reader := connect("wrong")
+	defer func() { _ = conn.Close() }()
+	line, err := reader.ReadBytes('\n')
+	if err != nil || !strings.Contains(string(line), `"type":"error"`) {
+		t.Fatalf("Expected an error event, got %s (err %v)", line, err)
+	}
+	if _, err := reader.ReadBytes('\n'); err == nil {
+		t.Error("Expected the connection to be closed")
+	}
+
+	conn, reader = connect("s3cret")
+	defer func() { _ = conn.Close() }()
+	// Wait for the bridge to register the connection
+	for i := 0; i < 100; i++ {
+		bridge.mu.RLock()
+		n := len(bridge.conns)
+		bridge.mu.RUnlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	bridge.publishDelete("block-1", "ds", "mock")
+	line, err = reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("Failed to read event: %v", err)
+	}
+	var evt bridgeDeleteEvent
+	if err := json.Unmarshal(line, &evt); err != nil || evt != (bridgeDeleteEvent{Type: "delete", Seq: 1, ID: "block-1", Datasource: "ds", DSType: "mock"}) {
+		t.Errorf("Unexpected event %s (err %v)", line, err)
+	}
+}
+
+func TestEventLog(t *testing.T) {
+	l, err := openEventLog(filepath.Join(t.TempDir(), "events.db"), 150)
+	if err != nil {
+		t.Fatalf("openEventLog failed: %v", err)
+	}
+	defer func() { _ = l.close() }()
+
+	if oldest, newest, err := l.bounds(); err != nil || oldest != 0 || newest != 0 {
+		t.Fatalf("Expected an empty log, got %d-%d (err %v)", oldest, newest, err)
+	}
+	for seq := uint64(1); seq <= 200; seq++ {
+		if err := l.append(seq, []byte(strconv.FormatUint(seq, 10))); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+	if oldest, newest, err := l.bounds(); err != nil || oldest != 51 || newest != 200 {
+		t.Fatalf("Expected events 51-200, got %d-%d (err %v)", oldest, newest, err)
+	}
+
+	var got []uint64
+	err = l.since(60, 700, func(seq uint64, data []byte) error {
+		if string(data) != strconv.FormatUint(seq, 10) {
+			t.Errorf("Unexpected data %q for event %d", data, seq)
+		}
+		got = append(got, seq)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("since failed: %v", err)
+	}
+	if len(got) != 140 || got[0] != 61 || got[len(got)-1] != 200 {
+		t.Errorf("Expected events 61-200, got %d events", len(got))
+	}
+}
+
+func TestExclusionRulesDropBlocks(t *testing.T) {
+	storageManager, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			t.Logf("Warning: failed to close storage manager: %v", err)
+		}
+	}()
+
+	now := time.Now()
+	ds := &mockDatasource{name: "firefox", blocks: []core.Block{
+		core.NewGenericBlock("visit-1", "online banking", "firefox", "mock", now, map[string]interface{}{"url": "https://login.mybank.com/home"}),
+		core.NewGenericBlock("visit-2", "weather forecast", "firefox", "mock", now, map[string]interface{}{"url": "https://weather.example.com"}),
+	}}
+
+	wh := NewWarehouse(Config{}, storageManager)
+	if err := wh.AddDatasource("firefox", ds); err != nil {
+		t.Fatalf("Failed to add datasource: %v", err)
+	}
+	rules, err := storage.NewExclusionRules([]string{"mybank.com"}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewExclusionRules failed: %v", err)
+	}
+	wh.SetExclusionRules("firefox", rules)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// ergs fetch --stream only shows the blocks kept
+	var streamed []string
+	if err := wh.FetchOnce(ctx, WithStreaming(func(block core.Block) {
+		streamed = append(streamed, block.ID())
+	})); err != nil {
+		t.Fatalf("FetchOnce failed: %v", err)
+	}
+	if len(streamed) != 1 || streamed[0] != "visit-2" {
+		t.Errorf("Expected only visit-2 to be streamed, got %v", streamed)
+	}
+
+	s, err := storageManager.GetStorage("firefox")
+	if err != nil {
+		t.Fatalf("GetStorage failed: %v", err)
+	}
+	blocks, err := s.GetBlocksByID([]string{"visit-1", "visit-2"})
+	if err != nil {
+		t.Fatalf("GetBlocksByID failed: %v", err)
+	}
+	if _, ok := blocks["visit-2"]; len(blocks) != 1 || !ok {
+		t.Errorf("Expected only visit-2 to be stored, got %v", blocks)
+	}
+}
+
+func TestStoreBlockFeedsFirehoseHub(t *testing.T) {
+	storageManager, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			t.Logf("Warning: failed to close storage manager: %v", err)
+		}
+	}()
+
+	wh := NewWarehouse(Config{}, storageManager)
+	if err := wh.AddDatasourceWithInterval("notes", &mockDatasource{name: "notes"}, 0); err != nil {
+		t.Fatalf("Failed to add datasource: %v", err)
+	}
+	hub := realtime.NewFirehoseHub(8)
+	wh.SetFirehoseHub(hub)
+	_, events := hub.Register()
+
+	now := time.Now().UTC()
+	if err := wh.StoreBlock(core.NewGenericBlock("note-1", "imported note", "notes", "mock", now, nil)); err != nil {
+		t.Fatalf("StoreBlock failed: %v", err)
+	}
+	if err := wh.StoreBlock(core.NewGenericBlock("note-2", "stray note", "elsewhere", "mock", now, nil)); err == nil {
+		t.Error("Expected StoreBlock to reject a block of an unknown datasource")
+	}
+	if err := wh.StoreBlock(core.NewTombstone("note-1", "notes")); err != nil {
+		t.Fatalf("StoreBlock of a tombstone failed: %v", err)
+	}
+
+	for _, want := range []string{"block", "delete"} {
+		select {
+		case evt := <-events:
+			if evt.Type != want || evt.Block.ID != "note-1" || evt.Block.Datasource != "notes" || evt.Block.DSType != "mock" {
+				t.Errorf("Expected %s event of note-1, got %+v", want, evt)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a %s event", want)
+		}
+	}
+}
+
+// gatedDatasource blocks every fetch until it is released, returning the
+// error it is released with.
+type gatedDatasource struct {
+	mockDatasource
+	started chan struct{}
+	release chan error
+}
+
+func (g *gatedDatasource) FetchBlocks(ctx context.Context, blockCh chan<- core.Block) error {
+	g.started <- struct{}{}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-g.release:
+		return err
+	}
+}
+
+func TestDatasourceSchedule(t *testing.T) {
+	storageManager, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			t.Logf("Warning: failed to close storage manager: %v", err)
+		}
+	}()
+
+	wh := NewWarehouse(Config{}, storageManager)
+	gated := &gatedDatasource{
+		mockDatasource: mockDatasource{name: "gated"},
+		started:        make(chan struct{}, 1),
+		release:        make(chan error),
+	}
+	if err := wh.AddDatasourceWithInterval("gated", gated, time.Hour); err != nil {
+		t.Fatalf("Failed to add datasource: %v", err)
+	}
+
+	if err := wh.FetchNow("gated"); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Expected ErrNotRunning before Start, got %v", err)
+	}
+	if err := wh.FetchNow("missing"); !errors.Is(err, ErrUnknownDatasource) {
+		t.Errorf("Expected ErrUnknownDatasource fetching an unknown datasource, got %v", err)
+	}
+	if err := wh.PauseDatasource("missing"); !errors.Is(err, ErrUnknownDatasource) {
+		t.Errorf("Expected ErrUnknownDatasource pausing an unknown datasource, got %v", err)
+	}
+
+	status := func() DatasourceStatus {
+		t.Helper()
+		statuses := wh.DatasourceStatuses()
+		if len(statuses) != 1 || statuses[0].Name != "gated" || statuses[0].Type != "mock" {
+			t.Fatalf("Unexpected statuses: %+v", statuses)
+		}
+		return statuses[0]
+	}
+	waitIdle := func() DatasourceStatus {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if st := status(); !st.Fetching {
+				return st
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("Datasource still fetching")
+		return DatasourceStatus{}
+	}
+
+	start := time.Now()
+	if err := wh.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start warehouse: %v", err)
+	}
+	defer wh.Stop()
+
+	// The initial fetch is running
+	<-gated.started
+	st := status()
+	if !st.Fetching || st.Interval != time.Hour {
+		t.Errorf("Expected the initial fetch running, got %+v", st)
+	}
+	if st.NextRun.Before(start.Add(time.Hour)) || st.NextRun.After(time.Now().Add(time.Hour)) {
+		t.Errorf("Expected the next run an interval after starting, got %v", st.NextRun)
+	}
+	if err := wh.FetchNow("gated"); !errors.Is(err, ErrFetchInProgress) {
+		t.Errorf("Expected ErrFetchInProgress while fetching, got %v", err)
+	}
+	gated.release <- errors.New("boom")
+	if st := waitIdle(); st.LastError != "boom" || st.LastRun.IsZero() {
+		t.Errorf("Expected the failed fetch recorded, got %+v", st)
+	}
+
+	// Paused datasources can still be fetched on demand
+	if err := wh.PauseDatasource("gated"); err != nil {
+		t.Fatalf("PauseDatasource failed: %v", err)
+	}
+	if !status().Paused {
+		t.Error("Expected the datasource paused")
+	}
+	if err := wh.FetchNow("gated"); err != nil {
+		t.Fatalf("FetchNow failed: %v", err)
+	}
+	<-gated.started
+	gated.release <- nil
+	if st := waitIdle(); st.LastError != "" {
+		t.Errorf("Expected the last error cleared, got %+v", st)
+	}
+
+	// Pauses survive removing and adding the datasource back
+	if err := wh.RemoveDatasource("gated"); err != nil {
+		t.Fatalf("RemoveDatasource failed: %v", err)
+	}
+	if err := wh.AddDatasourceWithInterval("gated", gated, time.Hour); err != nil {
+		t.Fatalf("Failed to add datasource back: %v", err)
+	}
+	if st := status(); !st.Paused || !st.LastRun.IsZero() {
+		t.Errorf("Expected only the pause kept, got %+v", st)
+	}
+	if err := wh.ResumeDatasource("gated"); err != nil {
+		t.Fatalf("ResumeDatasource failed: %v", err)
+	}
+	if status().Paused {
+		t.Error("Expected the datasource resumed")
+	}
+}
+
+// prefixProcessor prefixes the block text, dropping blocks containing "drop"
+type prefixProcessor struct{ prefix string }
+
+func (p prefixProcessor) Process(block core.Block) (core.Block, error) {
+	if strings.Contains(block.Text(), "drop") {
+		return nil, nil
+	}
+	return core.RewriteBlock(block, p.prefix+block.Text(), block.Metadata()), nil
+}
+
+func TestProcessorsRunBeforeStoring(t *testing.T) {
+	storageManager, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			t.Logf("Warning: failed to close storage manager: %v", err)
+		}
+	}()
+
+	now := time.Now()
+	ds := &mockDatasource{name: "notes", blocks: []core.Block{
+		core.NewGenericBlock("note-1", "keep me", "notes", "mock", now, nil),
+		core.NewGenericBlock("note-2", "drop me", "notes", "mock", now, nil),
+	}}
+
+	wh := NewWarehouse(Config{}, storageManager)
+	if err := wh.AddDatasource("notes", ds); err != nil {
+		t.Fatalf("Failed to add datasource: %v", err)
+	}
+	wh.SetProcessors("notes", core.Pipeline{prefixProcessor{prefix: "a: "}, prefixProcessor{prefix: "b: "}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := wh.FetchOnce(ctx); err != nil {
+		t.Fatalf("FetchOnce failed: %v", err)
+	}
+
+	s, err := storageManager.GetStorage("notes")
+	if err != nil {
+		t.Fatalf("GetStorage failed: %v", err)
+	}
+	blocks, err := s.GetBlocksByID([]string{"note-1", "note-2"})
+	if err != nil {
+		t.Fatalf("GetBlocksByID failed: %v", err)
+	}
+	if len(blocks) != 1 || blocks["note-1"] == nil || blocks["note-1"].Text() != "b: a: keep me" {
+		t.Errorf("Expected only the processed note-1 to be stored, got %v", blocks)
+	}
+}
+
+// recordingSink records the alerts it receives
+type recordingSink struct {
+	mu     sync.Mutex
+	alerts []alerts.Alert
+}
+
+func (s *recordingSink) Send(ctx context.Context, alert alerts.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+func (s *recordingSink) String() string { return "recording" }
+
+func TestSavedSearchesAlertNewBlocks(t *testing.T) {
+	storageManager, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			t.Logf("Warning: failed to close storage manager: %v", err)
+		}
+	}()
+
+	now := time.Now()
+	ds := &mockDatasource{name: "hn", blocks: []core.Block{
+		core.NewGenericBlock("story-1", "Show HN: ergs", "hn", "mock", now, nil),
+		core.NewGenericBlock("story-2", "Something else", "hn", "mock", now, nil),
+	}}
+
+	wh := NewWarehouse(Config{}, storageManager)
+	if err := wh.AddDatasource("hn", ds); err != nil {
+		t.Fatalf("Failed to add datasource: %v", err)
+	}
+	sink := &recordingSink{}
+	wh.SetSavedSearches([]alerts.SavedSearch{
+		{Name: "mentions", Query: "ergs", Sinks: []alerts.Sink{sink}},
+		{Name: "rss", Query: "ergs type:rss", Sinks: []alerts.Sink{sink}},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Blocks fetched again are not new and don't alert twice
+	for range 2 {
+		if err := wh.FetchOnce(ctx); err != nil {
+			t.Fatalf("FetchOnce failed: %v", err)
+		}
+	}
+	if err := wh.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if len(sink.alerts) != 1 {
+		t.Fatalf("Expected 1 alert, got %+v", sink.alerts)
+	}
+	if alert := sink.alerts[0]; alert.Search != "mentions" || alert.ID != "story-1" || alert.Datasource != "hn" || alert.DSType != "mock" {
+		t.Errorf("Unexpected alert: %+v", alert)
+	}
+}
+
+func TestWebhooksReceiveNewBlocks(t *testing.T) {
+	storageManager, err := storage.NewManager(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage manager: %v", err)
+	}
+	defer func() {
+		if err := storageManager.Close(); err != nil {
+			t.Logf("Warning: failed to close storage manager: %v", err)
+		}
+	}()
+
+	var mu sync.Mutex
+	var received []webhooks.Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event webhooks.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, event)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	ds := &mockDatasource{name: "hn", blocks: []core.Block{
+		core.NewGenericBlock("story-1", "Show HN: ergs", "hn", "mock", time.Now(), nil),
+	}}
+
+	if err := NewWarehouse(Config{}, storageManager).SetWebhooks([]webhooks.Webhook{{Name: "hook", URL: server.URL}}); err == nil {
+		t.Error("Expected an error setting webhooks without a delivery log")
+	}
+
+	wh := NewWarehouse(Config{WebhookLogPath: filepath.Join(t.TempDir(), "webhooks.db")}, storageManager)
+	if err := wh.AddDatasource("hn", ds); err != nil {
+		t.Fatalf("Failed to add datasource: %v", err)
+	}
+	if err := wh.SetWebhooks([]webhooks.Webhook{
+		{Name: "all", URL: server.URL},
+		{Name: "rss", URL: server.URL, Types: []string{"rss"}},
+	}); err != nil {
+		t.Fatalf("SetWebhooks failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	// Blocks fetched again are not new and aren't sent twice
+	for range 2 {
+		if err := wh.FetchOnce(ctx); err != nil {
+			t.Fatalf("FetchOnce failed: %v", err)
+		}
+	}
+	// Close delivers what is due
+	if err := wh.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 || received[0].ID != "story-1" || received[0].Type != "block" || received[0].DSType != "mock" {
+		t.Errorf("Unexpected deliveries: %+v", received)
+	}
+}
@@ -0,0 +1,271 @@
+package webhooks
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+)
+
+// LogFile is the path of the webhook delivery log, relative to the storage directory.
+const LogFile = "internal/webhooks.db"
+
+// Times are stored as Unix seconds, so due deliveries can be compared in SQL.
+const logSchema = `
+CREATE TABLE IF NOT EXISTS deliveries (
+    id INTEGER PRIMARY KEY,
+    webhook TEXT NOT NULL,
+    event TEXT NOT NULL,
+    block_id TEXT NOT NULL,
+    datasource TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT 'pending' CHECK (state IN ('pending', 'delivered', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at INTEGER NOT NULL,
+    last_status INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_due ON deliveries(state, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_deliveries_webhook ON deliveries(webhook, id);
+`
+
+// DeliveryState is the state of a webhook delivery.
+type DeliveryState string
+
+const (
+	// StatePending deliveries are waiting for their next attempt
+	StatePending DeliveryState = "pending"
+	// StateDelivered deliveries got a 2xx response
+	StateDelivered DeliveryState = "delivered"
+	// StateFailed deliveries ran out of attempts
+	StateFailed DeliveryState = "failed"
+)
+
+// ParseDeliveryState parses a delivery state name, an empty name matching any state.
+func ParseDeliveryState(name string) (DeliveryState, error) {
+	switch state := DeliveryState(strings.ToLower(strings.TrimSpace(name))); state {
+	case "", StatePending, StateDelivered, StateFailed:
+		return state, nil
+	}
+	return "", fmt.Errorf("invalid delivery state %q: use pending, delivered or failed", name)
+}
+
+// Delivery is an event sent (or to be sent) to a webhook.
+type Delivery struct {
+	ID            int64
+	Webhook       string
+	Event         string
+	BlockID       string
+	Datasource    string
+	Payload       []byte
+	State         DeliveryState
+	Attempts      int
+	NextAttemptAt time.Time
+	LastStatus    int // HTTP status of the last attempt, 0 when it got no response
+	LastError     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Log is the webhook delivery log, a SQLite database shared by every webhook.
+// It doubles as the outbox: events are recorded as pending deliveries before
+// they are sent, so deliveries survive restarts and are retried until they
+// succeed or run out of attempts.
+type Log struct {
+	db *sql.DB
+}
+
+// OpenLog opens (creating it if needed) the delivery log database at dbPath.
+func OpenLog(dbPath string) (*Log, error) {
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening webhook delivery log: %w", err)
+	}
+
+	pragmas := []string{
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA synchronous = NORMAL",
+		"PRAGMA busy_timeout = 30000",
+	}
+	for _, pragma := range pragmas {
+		if _, err := db.Exec(pragma); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("applying pragma %q: %w", pragma, err)
+		}
+	}
+
+	if _, err := db.Exec(logSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("creating webhook delivery log schema: %w", err)
+	}
+
+	return &Log{db: db}, nil
+}
+
+// Close closes the delivery log database.
+func (l *Log) Close() error {
+	return l.db.Close()
+}
+
+// enqueue records a pending delivery of payload to webhook, due now.
+func (l *Log) enqueue(webhook, event, blockID, datasource string, payload []byte, now time.Time) (int64, error) {
+	result, err := l.db.Exec(`
+		INSERT INTO deliveries (webhook, event, block_id, datasource, payload, next_attempt_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		webhook, event, blockID, datasource, string(payload), now.Unix(), now.Unix(), now.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("recording delivery to %s: %w", webhook, err)
+	}
+	return result.LastInsertId()
+}
+
+// due returns up to limit pending deliveries to the given webhooks whose next
+// attempt is due at now, oldest first.
+func (l *Log) due(webhooks []string, now time.Time, limit int) ([]Delivery, error) {
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{string(StatePending), now.Unix()}
+	for _, name := range webhooks {
+		args = append(args, name)
+	}
+	args = append(args, limit)
+	return l.query(`
+		WHERE state = ? AND next_attempt_at <= ?
+		AND webhook IN (`+strings.TrimSuffix(strings.Repeat("?,", len(webhooks)), ",")+`)
+		ORDER BY id
+		LIMIT ?`, args...)
+}
+
+// recordAttempt records the outcome of a delivery attempt. A nil next marks
+// the delivery delivered when err is nil and failed otherwise; a non-nil next
+// keeps it pending until then.
+func (l *Log) recordAttempt(id int64, status int, attemptErr error, next *time.Time, now time.Time) error {
+	state, nextAttempt, lastError := StateDelivered, now.Unix(), ""
+	if attemptErr != nil {
+		lastError = attemptErr.Error()
+		state = StateFailed
+		if next != nil {
+			state, nextAttempt = StatePending, next.Unix()
+		}
+	}
+	_, err := l.db.Exec(`
+		UPDATE deliveries
+		SET state = ?, attempts = attempts + 1, next_attempt_at = ?, last_status = ?, last_error = ?, updated_at = ?
+		WHERE id = ?`,
+		string(state), nextAttempt, status, lastError, now.Unix(), id)
+	if err != nil {
+		return fmt.Errorf("recording attempt of delivery %d: %w", id, err)
+	}
+	return nil
+}
+
+// Deliveries returns up to limit deliveries, newest first, optionally only
+// those to webhook and in state.
+func (l *Log) Deliveries(webhook string, state DeliveryState, limit int) ([]Delivery, error) {
+	var conds []string
+	var args []interface{}
+	if webhook != "" {
+		conds = append(conds, "webhook = ?")
+		args = append(args, webhook)
+	}
+	if state != "" {
+		conds = append(conds, "state = ?")
+		args = append(args, string(state))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	return l.query(where+" ORDER BY id DESC LIMIT ?", append(args, limit)...)
+}
+
+// Counts returns how many deliveries each webhook has in each state.
+func (l *Log) Counts() (map[string]map[DeliveryState]int, error) {
+	rows, err := l.db.Query("SELECT webhook, state, COUNT(*) FROM deliveries GROUP BY webhook, state")
+	if err != nil {
+		return nil, fmt.Errorf("counting deliveries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[string]map[DeliveryState]int)
+	for rows.Next() {
+		var webhook, state string
+		var count int
+		if err := rows.Scan(&webhook, &state, &count); err != nil {
+			return nil, fmt.Errorf("scanning count: %w", err)
+		}
+		if counts[webhook] == nil {
+			counts[webhook] = make(map[DeliveryState]int)
+		}
+		counts[webhook][DeliveryState(state)] = count
+	}
+	return counts, rows.Err()
+}
+
+// Retry makes failed deliveries pending again, due now, with a fresh set of
+// attempts. It returns how many were failed; other IDs are ignored.
+func (l *Log) Retry(ids []int64) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	now := time.Now().Unix()
+	args := []interface{}{string(StatePending), now, now, string(StateFailed)}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	result, err := l.db.Exec(`
+		UPDATE deliveries SET state = ?, attempts = 0, next_attempt_at = ?, updated_at = ?
+		WHERE state = ? AND id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")+`)`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("retrying deliveries: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// prune deletes the deliveries delivered before before and returns how many.
+func (l *Log) prune(before time.Time) (int, error) {
+	result, err := l.db.Exec("DELETE FROM deliveries WHERE state = ? AND updated_at < ?", string(StateDelivered), before.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("pruning deliveries: %w", err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// query returns the deliveries selected by the SQL after the FROM clause.
+func (l *Log) query(clauses string, args ...interface{}) ([]Delivery, error) {
+	rows, err := l.db.Query(`
+		SELECT id, webhook, event, block_id, datasource, payload, state, attempts,
+			next_attempt_at, last_status, last_error, created_at, updated_at
+		FROM deliveries `+clauses, args...)
+	if err != nil {
+		return nil, fmt.Errorf("querying deliveries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var d Delivery
+		var payload, state string
+		var nextAttemptAt, createdAt, updatedAt int64
+		if err := rows.Scan(&d.ID, &d.Webhook, &d.Event, &d.BlockID, &d.Datasource, &payload, &state, &d.Attempts,
+			&nextAttemptAt, &d.LastStatus, &d.LastError, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("scanning delivery: %w", err)
+		}
+		d.Payload = []byte(payload)
+		d.State = DeliveryState(state)
+		d.NextAttemptAt = time.Unix(nextAttemptAt, 0)
+		d.CreatedAt = time.Unix(createdAt, 0)
+		d.UpdatedAt = time.Unix(updatedAt, 0)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
@@ -0,0 +1,415 @@
+// Package webhooks POSTs the blocks stored by the warehouse to external URLs.
+// Webhooks are configured with [[webhooks]] tables:
+//
+//	[[webhooks]]
+//	name = 'automation'
+//	url = 'https://automation.example.com/ergs'
+//	secret = 'shared-secret'
+//	types = ['hackernews', 'rss']
+//
+// Every new block matching a webhook's filters is recorded as a pending
+// delivery in the delivery log (see Log) and then POSTed as JSON, signed with
+// HMAC-SHA256 when the webhook has a secret. Failed deliveries are retried
+// with exponential backoff until they run out of attempts.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rubiojr/ergs/pkg/log"
+)
+
+var webhooksLogger = log.ForService("webhooks")
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the request
+	// body, keyed with the webhook secret. Only set when there is a secret.
+	SignatureHeader = "X-Ergs-Signature-256"
+	// EventHeader carries the event type (block)
+	EventHeader = "X-Ergs-Event"
+	// DeliveryHeader carries the delivery ID, the same across retries
+	DeliveryHeader = "X-Ergs-Delivery"
+)
+
+// DefaultMaxAttempts is the number of attempts of a delivery when the webhook
+// doesn't set one. With the retry backoff, the last attempt happens about an
+// hour after the first.
+const DefaultMaxAttempts = 8
+
+const (
+	// requestTimeout bounds each delivery attempt
+	requestTimeout = 10 * time.Second
+	// pollInterval is how often the log is checked for retries that became due
+	pollInterval = 10 * time.Second
+	// deliveredRetention is how long delivered deliveries stay in the log
+	deliveredRetention = 7 * 24 * time.Hour
+	// dueBatchSize is the number of due deliveries loaded at once
+	dueBatchSize = 100
+)
+
+// Webhook is an external URL receiving new blocks.
+type Webhook struct {
+	Name        string
+	URL         string
+	Secret      string   // Signs deliveries when set
+	Datasources []string // Only send blocks of these datasources (all when empty)
+	Types       []string // Only send blocks of these datasource types (all when empty)
+	MaxAttempts int      // Defaults to DefaultMaxAttempts
+}
+
+// Matches reports whether the webhook wants blocks of the given datasource
+// and datasource type.
+func (h *Webhook) Matches(datasource, datasourceType string) bool {
+	if len(h.Datasources) > 0 && !slices.Contains(h.Datasources, datasource) {
+		return false
+	}
+	return len(h.Types) == 0 || slices.Contains(h.Types, datasourceType)
+}
+
+func (h *Webhook) maxAttempts() int {
+	if h.MaxAttempts > 0 {
+		return h.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
+
+// Event is the JSON body of a delivery, the same as the event bridge block event.
+type Event struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id"`
+	Datasource string         `json:"datasource"`
+	DSType     string         `json:"ds_type,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	Text       string         `json:"text"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+}
+
+// Sign returns the SignatureHeader value of body for secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay is the wait before the attempt following the given number of
+// failed attempts: 30s, 1m, 2m... capped at an hour.
+func retryDelay(attempts int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, time.Hour)
+}
+
+// Sender records events for the configured webhooks in the delivery log and
+// delivers them. The log is opened when webhooks are first set, so nothing is
+// created on disk while no webhook is configured.
+type Sender struct {
+	logPath string
+	client  *http.Client
+	wake    chan struct{}
+	now     func() time.Time
+
+	mu    sync.RWMutex
+	log   *Log
+	hooks map[string]Webhook
+
+	// Serialize the delivery runs of each webhook, by name
+	deliverMu  sync.Mutex
+	delivering map[string]*sync.Mutex
+}
+
+// NewSender creates a sender keeping its delivery log at logPath.
+func NewSender(logPath string) *Sender {
+	return &Sender{
+		logPath:    logPath,
+		client:     &http.Client{Timeout: requestTimeout},
+		wake:       make(chan struct{}, 1),
+		now:        time.Now,
+		hooks:      make(map[string]Webhook),
+		delivering: make(map[string]*sync.Mutex),
+	}
+}
+
+// SetWebhooks replaces the webhooks, opening the delivery log if needed.
+// Pending deliveries to webhooks no longer configured are kept, and resume if
+// a webhook with the same name comes back.
+func (s *Sender) SetWebhooks(hooks []Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(hooks) > 0 && s.log == nil {
+		if err := os.MkdirAll(filepath.Dir(s.logPath), 0755); err != nil {
+			return fmt.Errorf("creating webhook delivery log directory: %w", err)
+		}
+		l, err := OpenLog(s.logPath)
+		if err != nil {
+			return err
+		}
+		s.log = l
+	}
+	s.hooks = make(map[string]Webhook, len(hooks))
+	for _, hook := range hooks {
+		s.hooks[hook.Name] = hook
+	}
+	s.signal()
+	return nil
+}
+
+// Active reports whether any webhook is configured.
+func (s *Sender) Active() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.hooks) > 0
+}
+
+// Publish records a pending delivery of event to every webhook matching it
+// and wakes up Run. It returns how many deliveries were recorded.
+func (s *Sender) Publish(event Event) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.log == nil {
+		return 0, nil
+	}
+
+	var payload []byte
+	recorded := 0
+	for name, hook := range s.hooks {
+		if !hook.Matches(event.Datasource, event.DSType) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(event); err != nil {
+				return recorded, fmt.Errorf("encoding event: %w", err)
+			}
+		}
+		if _, err := s.log.enqueue(name, event.Type, event.ID, event.Datasource, payload, s.now()); err != nil {
+			return recorded, err
+		}
+		recorded++
+	}
+	if recorded > 0 {
+		s.signal()
+	}
+	return recorded, nil
+}
+
+// signal wakes up Run without blocking
+func (s *Sender) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers pending deliveries as they are published or their retries
+// become due, until ctx is done. Each webhook is delivered in its own
+// goroutine, so a slow or dead endpoint only delays its own deliveries.
+// Delivered deliveries older than a week are pruned from the log.
+func (s *Sender) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	lastPrune := time.Time{}
+	for {
+		for _, hook := range s.webhooks() {
+			// Webhooks still being delivered pick up due deliveries once done
+			mu := s.deliveryLock(hook.Name)
+			if !mu.TryLock() {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer mu.Unlock()
+				if _, err := s.flushWebhook(ctx, &hook); err != nil && ctx.Err() == nil {
+					webhooksLogger.Warnf("Failed to deliver to webhook %s: %v", hook.Name, err)
+				}
+			}()
+		}
+		if now := s.now(); now.Sub(lastPrune) > time.Hour {
+			lastPrune = now
+			s.prune(now)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush attempts every delivery that is due now, once, and returns how many
+// were attempted. Webhooks are delivered concurrently. Failed attempts are
+// scheduled for a retry by Run.
+func (s *Sender) Flush(ctx context.Context) (int, error) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	attempted := 0
+	var firstErr error
+	for _, hook := range s.webhooks() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock := s.deliveryLock(hook.Name)
+			lock.Lock()
+			n, err := s.flushWebhook(ctx, &hook)
+			lock.Unlock()
+
+			mu.Lock()
+			defer mu.Unlock()
+			attempted += n
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}()
+	}
+	wg.Wait()
+	return attempted, firstErr
+}
+
+// webhooks returns the configured webhooks, none while the log isn't open
+func (s *Sender) webhooks() []Webhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.log == nil {
+		return nil
+	}
+	hooks := make([]Webhook, 0, len(s.hooks))
+	for _, hook := range s.hooks {
+		hooks = append(hooks, hook)
+	}
+	return hooks
+}
+
+// deliveryLock returns the mutex serializing the deliveries to a webhook
+func (s *Sender) deliveryLock(name string) *sync.Mutex {
+	s.deliverMu.Lock()
+	defer s.deliverMu.Unlock()
+	mu, ok := s.delivering[name]
+	if !ok {
+		mu = &sync.Mutex{}
+		s.delivering[name] = mu
+	}
+	return mu
+}
+
+// flushWebhook attempts every delivery to hook that is due now, once, oldest
+// first, and returns how many were attempted. The caller holds its delivery lock.
+func (s *Sender) flushWebhook(ctx context.Context, hook *Webhook) (int, error) {
+	attempted := 0
+	// Deliveries retried during this flush are due later, so each is only
+	// attempted once.
+	now := s.now()
+	for ctx.Err() == nil {
+		s.mu.RLock()
+		l := s.log
+		s.mu.RUnlock()
+		if l == nil {
+			return attempted, nil
+		}
+
+		due, err := l.due([]string{hook.Name}, now, dueBatchSize)
+		if err != nil {
+			return attempted, err
+		}
+		if len(due) == 0 {
+			return attempted, nil
+		}
+		for _, d := range due {
+			if ctx.Err() != nil {
+				break
+			}
+			status, sendErr := s.send(ctx, hook, &d)
+			attempted++
+
+			var next *time.Time
+			if sendErr != nil {
+				if d.Attempts+1 < hook.maxAttempts() {
+					at := s.now().Add(retryDelay(d.Attempts + 1))
+					next = &at
+					webhooksLogger.Debugf("Delivery %d to webhook %s failed, retrying at %s: %v", d.ID, d.Webhook, at.Format(time.RFC3339), sendErr)
+				} else {
+					webhooksLogger.Warnf("Delivery %d to webhook %s failed after %d attempts: %v", d.ID, d.Webhook, d.Attempts+1, sendErr)
+				}
+			} else {
+				webhooksLogger.Debugf("Delivered block %s of %s to webhook %s", d.BlockID, d.Datasource, d.Webhook)
+			}
+			if err := l.recordAttempt(d.ID, status, sendErr, next, s.now()); err != nil {
+				return attempted, err
+			}
+		}
+	}
+	return attempted, ctx.Err()
+}
+
+// send POSTs a delivery to its webhook and returns the response status.
+func (s *Sender) send(ctx context.Context, hook *Webhook, d *Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ergs-webhooks")
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(d.ID, 10))
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, d.Payload))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// prune removes old delivered deliveries from the log
+func (s *Sender) prune(now time.Time) {
+	s.mu.RLock()
+	l := s.log
+	s.mu.RUnlock()
+	if l == nil {
+		return
+	}
+	n, err := l.prune(now.Add(-deliveredRetention))
+	if err != nil {
+		webhooksLogger.Warnf("Failed to prune webhook deliveries: %v", err)
+	} else if n > 0 {
+		webhooksLogger.Debugf("Pruned %d delivered webhook deliveries", n)
+	}
+}
+
+// Close closes the delivery log.
+func (s *Sender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return nil
+	}
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
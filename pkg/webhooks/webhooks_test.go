@@ -0,0 +1,190 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSenderDeliversSignedEvents(t *testing.T) {
+	var mu sync.Mutex
+	var requests []*http.Request
+	var bodies [][]byte
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		if fail && r.URL.Path == "/flaky" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	sender := NewSender(filepath.Join(t.TempDir(), "internal", "webhooks.db"))
+	sender.now = func() time.Time { return now }
+	defer func() { _ = sender.Close() }()
+
+	if sender.Active() {
+		t.Error("expected no webhooks before SetWebhooks")
+	}
+	err := sender.SetWebhooks([]Webhook{
+		{Name: "all", URL: server.URL + "/all", Secret: "s3cret"},
+		{Name: "flaky", URL: server.URL + "/flaky", Types: []string{"hackernews"}, MaxAttempts: 2},
+		{Name: "rss", URL: server.URL + "/rss", Datasources: []string{"rss"}},
+	})
+	if err != nil {
+		t.Fatalf("SetWebhooks error: %v", err)
+	}
+
+	event := Event{Type: "block", ID: "story-1", Datasource: "hn", DSType: "hackernews", CreatedAt: now, Text: "Show HN", Metadata: map[string]any{"score": float64(10)}}
+	n, err := sender.Publish(event)
+	if err != nil || n != 2 {
+		t.Fatalf("Publish = %d, %v, want 2 deliveries", n, err)
+	}
+
+	ctx := context.Background()
+	if attempted, err := sender.Flush(ctx); err != nil || attempted != 2 {
+		t.Fatalf("Flush = %d, %v", attempted, err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	for i, r := range requests {
+		if r.URL.Path != "/all" {
+			continue
+		}
+		if r.Header.Get(SignatureHeader) != Sign("s3cret", bodies[i]) || r.Header.Get(EventHeader) != "block" || r.Header.Get(DeliveryHeader) == "" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		var got Event
+		if err := json.Unmarshal(bodies[i], &got); err != nil || got.ID != "story-1" || got.DSType != "hackernews" || !got.CreatedAt.Equal(now) {
+			t.Errorf("unexpected body %s: %v", bodies[i], err)
+		}
+	}
+
+	log := sender.log
+	pending, _ := log.Deliveries("flaky", StatePending, 10)
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastStatus != http.StatusServiceUnavailable || !pending[0].NextAttemptAt.Equal(now.Add(30*time.Second)) {
+		t.Fatalf("unexpected pending deliveries: %+v", pending)
+	}
+
+	// Not due yet
+	if attempted, _ := sender.Flush(ctx); attempted != 0 {
+		t.Errorf("expected no attempts before the retry is due, got %d", attempted)
+	}
+	// The second and last attempt fails too
+	now = now.Add(time.Minute)
+	if attempted, _ := sender.Flush(ctx); attempted != 1 {
+		t.Errorf("expected the retry to be attempted, got %d", attempted)
+	}
+	failed, _ := log.Deliveries("flaky", StateFailed, 10)
+	if len(failed) != 1 || failed[0].Attempts != 2 || failed[0].LastError == "" {
+		t.Fatalf("unexpected failed deliveries: %+v", failed)
+	}
+
+	// A manual retry delivers it once the endpoint is back
+	fail = false
+	if n, err := log.Retry([]int64{failed[0].ID, 999}); err != nil || n != 1 {
+		t.Fatalf("Retry = %d, %v", n, err)
+	}
+	sender.now = time.Now
+	if attempted, _ := sender.Flush(ctx); attempted != 1 {
+		t.Errorf("expected the retried delivery to be attempted, got %d", attempted)
+	}
+	counts, err := log.Counts()
+	if err != nil {
+		t.Fatalf("Counts error: %v", err)
+	}
+	if counts["all"][StateDelivered] != 1 || counts["flaky"][StateDelivered] != 1 || len(counts["rss"]) != 0 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+
+	if n, err := log.prune(time.Now().Add(time.Minute)); err != nil || n != 2 {
+		t.Errorf("prune = %d, %v", n, err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute}
+	for i, d := range want {
+		if got := retryDelay(i + 1); got != d {
+			t.Errorf("retryDelay(%d) = %v, want %v", i+1, got, d)
+		}
+	}
+	if got := retryDelay(20); got != time.Hour {
+		t.Errorf("retryDelay(20) = %v, want 1h", got)
+	}
+}
+
+func TestSenderDeadWebhookDoesNotDelayOthers(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dead" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	sender := NewSender(filepath.Join(t.TempDir(), "internal", "webhooks.db"))
+	defer func() { _ = sender.Close() }()
+	err := sender.SetWebhooks([]Webhook{
+		{Name: "dead", URL: server.URL + "/dead"},
+		{Name: "ok", URL: server.URL + "/ok"},
+	})
+	if err != nil {
+		t.Fatalf("SetWebhooks error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sender.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	for i := range 3 {
+		event := Event{Type: "block", ID: fmt.Sprintf("story-%d", i), Datasource: "hn", DSType: "hackernews", CreatedAt: time.Now()}
+		if _, err := sender.Publish(event); err != nil {
+			t.Fatalf("Publish error: %v", err)
+		}
+	}
+
+	// The deliveries to ok go through while the first one to dead hangs
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		counts, err := sender.log.Counts()
+		if err != nil {
+			t.Fatalf("Counts error: %v", err)
+		}
+		if counts["ok"][StateDelivered] == 3 {
+			if counts["dead"][StatePending] != 3 {
+				t.Fatalf("expected the deliveries to dead pending, got %v", counts)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the deliveries to ok delivered, got %v", counts)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}